@@ -3,24 +3,40 @@ package main
 import (
 	"fmt"
 	"log"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/spf13/viper"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
 	"monai-auth/internal/auth"
+	"monai-auth/internal/directory"
+	"monai-auth/internal/domain"
+	"monai-auth/internal/eventbus"
+	"monai-auth/internal/federation"
+	"monai-auth/internal/mail"
 	userrepo "monai-auth/internal/repository/mysql" // 明确地使用别名 userrepo
+	"monai-auth/internal/saml"
+	"monai-auth/internal/sms"
 	httptransport "monai-auth/internal/transport/http"
 )
 
 // ClientConfig 子应用（客户端）配置
 type ClientConfig struct {
-	ClientID             string   `mapstructure:"client_id"`
-	ClientSecret         string   `mapstructure:"client_secret"`
-	AllowedRedirectURIs  []string `mapstructure:"allowed_redirect_uris"`
+	ClientID            string   `mapstructure:"client_id"`
+	ClientSecret        string   `mapstructure:"client_secret"`
+	AllowedRedirectURIs []string `mapstructure:"allowed_redirect_uris"`
 }
 
 // Config 结构体映射 config.yaml
@@ -35,14 +51,340 @@ type Config struct {
 		LoginPagePath       string         `mapstructure:"login_page_path"`
 		AllowedRedirectURIs []string       `mapstructure:"allowed_redirect_uris"`
 		Clients             []ClientConfig `mapstructure:"clients"`
+		// 忘记密码：重置页路径（与 auth_base_url 拼接）及令牌有效期
+		PasswordResetPath       string `mapstructure:"password_reset_path"`
+		PasswordResetTTLMinutes int    `mapstructure:"password_reset_ttl_minutes"`
+		// 注册邮箱验证：开启后新用户为 pending 状态，需点击验证邮件中的链接激活
+		RequireEmailVerification   bool   `mapstructure:"require_email_verification"`
+		VerifyEmailPath            string `mapstructure:"verify_email_path"`
+		VerifyEmailTTLHours        int    `mapstructure:"verify_email_ttl_hours"`
+		VerifyEmailResendIntervalS int    `mapstructure:"verify_email_resend_interval_seconds"`
+		// TrustProxyHeaders 部署在反向代理之后时为 true，从 X-Forwarded-For 最右一项（代理追加）/ X-Real-IP 取客户端 IP
+		TrustProxyHeaders bool `mapstructure:"trust_proxy_headers"`
+		// CSRFProtection 通过 auth_token Cookie 鉴权的修改类请求需携带 X-CSRF-Token（GET /api/v1/auth/csrf 获取）
+		CSRFProtection bool `mapstructure:"csrf_protection"`
+		// Cookie 属性：跨子域 SSO 时设置 cookie_domain；cookie_same_site 为 lax / strict / none；
+		// cookie_host_prefix 为 true 时 Cookie 名加 __Host- 前缀（需 cookie_secure 且不能设置 cookie_domain）
+		CookieDomain     string `mapstructure:"cookie_domain"`
+		CookieSameSite   string `mapstructure:"cookie_same_site"`
+		CookieHostPrefix bool   `mapstructure:"cookie_host_prefix"`
 	} `mapstructure:"server"`
-	Database struct {
-		Host     string `mapstructure:"host"`
-		Port     string `mapstructure:"port"`
-		User     string `mapstructure:"user"`
-		Password string `mapstructure:"password"`
-		DBName   string `mapstructure:"dbname"`
-	} `mapstructure:"database"`
+	Database DatabaseConfig `mapstructure:"database"`
+	Mail     MailConfig     `mapstructure:"mail"`
+	// SMS 短信发送：file 写入 dir 目录，log 仅打印日志（本地开发默认）；生产环境需接入短信服务商的 sms.Sender 实现
+	SMS struct {
+		Driver string `mapstructure:"driver"`
+		Dir    string `mapstructure:"dir"`
+	} `mapstructure:"sms"`
+	// PhoneOTP 短信验证码登录与手机号验证
+	PhoneOTP struct {
+		Enabled               bool `mapstructure:"enabled"`
+		CodeTTLMinutes        int  `mapstructure:"code_ttl_minutes"`
+		MaxAttempts           int  `mapstructure:"max_attempts"`
+		ResendIntervalSeconds int  `mapstructure:"resend_interval_seconds"`
+	} `mapstructure:"phone_otp"`
+	MFA struct {
+		Enabled bool   `mapstructure:"enabled"`
+		Issuer  string `mapstructure:"issuer"`
+		// EncryptionKey 加密库中 TOTP 密钥的主密钥，上线后不可更换（否则已绑定的 TOTP 无法解密）
+		EncryptionKey string `mapstructure:"encryption_key"`
+	} `mapstructure:"mfa"`
+	WebAuthn struct {
+		Enabled bool `mapstructure:"enabled"`
+		// RPID 依赖方 ID，为认证中心域名（不含协议与端口），Passkey 与该域名绑定
+		RPID          string `mapstructure:"rp_id"`
+		RPDisplayName string `mapstructure:"rp_display_name"`
+		// RPOrigins 允许发起 WebAuthn 的登录页 Origin，如 https://auth.example.com
+		RPOrigins []string `mapstructure:"rp_origins"`
+	} `mapstructure:"webauthn"`
+	PasswordHash struct {
+		// Algorithm 新密码使用的哈希算法：bcrypt（默认）或 argon2id；切换后旧哈希在用户下次登录时自动升级
+		Algorithm         string `mapstructure:"algorithm"`
+		BcryptCost        int    `mapstructure:"bcrypt_cost"`
+		Argon2MemoryKiB   uint32 `mapstructure:"argon2_memory_kib"`
+		Argon2Iterations  uint32 `mapstructure:"argon2_iterations"`
+		Argon2Parallelism uint8  `mapstructure:"argon2_parallelism"`
+	} `mapstructure:"password_hash"`
+	PasswordPolicy struct {
+		MinLength        int  `mapstructure:"min_length"`
+		MaxBytes         int  `mapstructure:"max_bytes"`
+		MinCharClasses   int  `mapstructure:"min_char_classes"`
+		RequireUpper     bool `mapstructure:"require_upper"`
+		RequireLower     bool `mapstructure:"require_lower"`
+		RequireDigit     bool `mapstructure:"require_digit"`
+		RequireSymbol    bool `mapstructure:"require_symbol"`
+		DisallowUserInfo bool `mapstructure:"disallow_user_info"`
+		HistorySize      int  `mapstructure:"history_size"`
+		// BreachedDir 本地泄露密码库目录（k-anonymity 前缀文件格式），为空表示不检查
+		BreachedDir      string `mapstructure:"breached_dir"`
+		BreachedMinCount int    `mapstructure:"breached_min_count"`
+	} `mapstructure:"password_policy"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Lockout   struct {
+		Enabled          bool `mapstructure:"enabled"`
+		DelayAfter       int  `mapstructure:"delay_after"`
+		BaseDelaySeconds int  `mapstructure:"base_delay_seconds"`
+		MaxDelaySeconds  int  `mapstructure:"max_delay_seconds"`
+		MaxFailures      int  `mapstructure:"max_failures"`
+		DurationMinutes  int  `mapstructure:"duration_minutes"`
+	} `mapstructure:"lockout"`
+	Audit struct {
+		Enabled bool `mapstructure:"enabled"`
+		// RetentionDays 审计事件保留天数，0 表示永久保留
+		RetentionDays int `mapstructure:"retention_days"`
+	} `mapstructure:"audit"`
+	LoginRisk struct {
+		Enabled bool `mapstructure:"enabled"`
+		// GeoIPDB 本地 MaxMind 数据库（GeoLite2-Country / GeoLite2-City .mmdb），为空时只检测新设备；
+		// 不可能旅行检测需要 City 库提供经纬度
+		GeoIPDB             string  `mapstructure:"geoip_db"`
+		MaxTravelSpeedKmh   float64 `mapstructure:"max_travel_speed_kmh"`
+		MinTravelDistanceKm float64 `mapstructure:"min_travel_distance_km"`
+		// Notify 异常登录成功后邮件通知用户
+		Notify bool `mapstructure:"notify"`
+		// StepUpMFA 已启用 MFA 的用户仅在异常登录时需要第二步验证；false 时始终需要
+		StepUpMFA bool `mapstructure:"step_up_mfa"`
+	} `mapstructure:"login_risk"`
+	// MagicLink 邮件链接无密码登录
+	MagicLink struct {
+		Enabled bool `mapstructure:"enabled"`
+		// Path 前端落地页路径（与 auth_base_url 拼接，令牌以 ?token= 追加）
+		Path       string `mapstructure:"path"`
+		TTLMinutes int    `mapstructure:"ttl_minutes"`
+	} `mapstructure:"magic_link"`
+	// Federation 第三方登录（GitHub / Google / 任意 OIDC 提供方）
+	Federation struct {
+		Enabled bool `mapstructure:"enabled"`
+		// CallbackBaseURL 回调地址前缀，缺省为 <auth_base_url>/api/v1/auth/federation；
+		// 实际回调地址为 <前缀>/<name>/callback，需在提供方后台登记
+		CallbackBaseURL string `mapstructure:"callback_base_url"`
+		// LandingPath 回调后重定向的前端落地页路径（与 auth_base_url 拼接，附带 ?ticket= 或 ?error=）
+		LandingPath string `mapstructure:"landing_path"`
+		// AutoRegister 已验证邮箱没有对应账号时自动创建用户
+		AutoRegister bool                       `mapstructure:"auto_register"`
+		Providers    []FederationProviderConfig `mapstructure:"providers"`
+	} `mapstructure:"federation"`
+	// LDAP 企业目录（LDAP / Active Directory）账号登录
+	LDAP struct {
+		Enabled bool `mapstructure:"enabled"`
+		// Mode: first（先查目录，目录中没有该账号或目录不可用时回退到本地密码，默认）、only（仅目录）
+		Mode string `mapstructure:"mode"`
+		// URL ldap://host:389 或 ldaps://host:636
+		URL                string `mapstructure:"url"`
+		StartTLS           bool   `mapstructure:"start_tls"`
+		InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+		CACertFile         string `mapstructure:"ca_cert_file"`
+		ServerName         string `mapstructure:"server_name"`
+		TimeoutSeconds     int    `mapstructure:"timeout_seconds"`
+		// BindDN / BindPassword 查找用户使用的服务账号，BindDN 为空时匿名查找
+		BindDN       string `mapstructure:"bind_dn"`
+		BindPassword string `mapstructure:"bind_password"`
+		BaseDN       string `mapstructure:"base_dn"`
+		// UserFilter 用户过滤器，{identifier} 替换为转义后的登录标识
+		UserFilter         string `mapstructure:"user_filter"`
+		IDAttribute        string `mapstructure:"id_attribute"`
+		UsernameAttribute  string `mapstructure:"username_attribute"`
+		EmailAttribute     string `mapstructure:"email_attribute"`
+		FirstNameAttribute string `mapstructure:"first_name_attribute"`
+		LastNameAttribute  string `mapstructure:"last_name_attribute"`
+		MemberOfAttribute  string `mapstructure:"member_of_attribute"`
+		// GroupBaseDN / GroupFilter 非空时另外搜索用户所属组，{dn} 替换为用户 DN
+		GroupBaseDN string `mapstructure:"group_base_dn"`
+		GroupFilter string `mapstructure:"group_filter"`
+		// AllowedGroups 非空时仅这些组的成员可登录（组 DN 或组名）
+		AllowedGroups []string `mapstructure:"allowed_groups"`
+		// GroupRoles 按顺序匹配，第一个命中的组决定本地角色；为空时不同步角色
+		GroupRoles  []LDAPGroupRoleConfig `mapstructure:"group_roles"`
+		DefaultRole string                `mapstructure:"default_role"`
+	} `mapstructure:"ldap"`
+	// SAML 作为 SAML 2.0 IdP，供只支持 SAML 的 SaaS 系统通过认证中心登录
+	SAML struct {
+		Enabled bool `mapstructure:"enabled"`
+		// EntityID IdP 实体标识，缺省为 <auth_base_url>/api/v1/auth/saml/metadata
+		EntityID string `mapstructure:"entity_id"`
+		// CertificateFile / PrivateKeyFile PEM 格式的签名证书与 RSA 私钥；均为空时启动时生成临时密钥（仅用于开发）
+		CertificateFile string `mapstructure:"certificate_file"`
+		PrivateKeyFile  string `mapstructure:"private_key_file"`
+		// AssertionTTLSeconds 断言有效期，缺省 300 秒
+		AssertionTTLSeconds int `mapstructure:"assertion_ttl_seconds"`
+		// PersistentIDSecret 派生 persistent NameID 的密钥，有 SP 使用 persistent 时必填，更换后 SP 侧的用户标识会全部变化
+		PersistentIDSecret string                      `mapstructure:"persistent_id_secret"`
+		ServiceProviders   []SAMLServiceProviderConfig `mapstructure:"service_providers"`
+	} `mapstructure:"saml"`
+	// SCIM SCIM 2.0 用户与组同步接口（/scim/v2），供 HR 系统或 IdP 自动开通、停用账号
+	SCIM struct {
+		Enabled bool `mapstructure:"enabled"`
+		// Clients 同步客户端，请求以 Authorization: Bearer <token> 鉴权
+		Clients []SCIMClientConfig `mapstructure:"clients"`
+	} `mapstructure:"scim"`
+	// Webhooks 将用户生命周期事件（注册、邮箱变更、停用 / 启用、删除）推送给下游应用
+	Webhooks struct {
+		Enabled     bool                      `mapstructure:"enabled"`
+		Subscribers []WebhookSubscriberConfig `mapstructure:"subscribers"`
+		// MaxAttempts 每条投递最多尝试次数（含首次），缺省 10
+		MaxAttempts int `mapstructure:"max_attempts"`
+		// TimeoutSeconds 单次请求超时，缺省 10 秒
+		TimeoutSeconds int `mapstructure:"timeout_seconds"`
+		// RetentionDays 已结束（delivered / failed）投递记录的保留天数，0 表示永久保留
+		RetentionDays int `mapstructure:"retention_days"`
+	} `mapstructure:"webhooks"`
+	// Events 领域事件发件箱：事件与引起它的状态变更在同一事务中写入 outbox_events 表，由后台协程投递到日志、消息总线与 Webhook
+	Events struct {
+		Enabled bool `mapstructure:"enabled"`
+		// PollIntervalSeconds 扫描到期事件（含失败重试）的间隔，缺省 2 秒
+		PollIntervalSeconds int `mapstructure:"poll_interval_seconds"`
+		// RetentionDays 已投递事件的保留天数，0 表示永久保留
+		RetentionDays int `mapstructure:"retention_days"`
+		// MaxAttempts 每个事件最多投递次数（含首次），用尽后标记为失败；缺省 20
+		MaxAttempts int `mapstructure:"max_attempts"`
+		// Log 为 true 时每个事件输出一行日志
+		Log bool `mapstructure:"log"`
+		// Broker 消息总线：memory 为进程内实现（供进程内订阅方使用），为空表示不发布到消息总线
+		Broker struct {
+			Driver string `mapstructure:"driver"`
+			// SubjectPrefix 主题前缀，主题为 <prefix><事件类型>，缺省 identity.
+			SubjectPrefix string `mapstructure:"subject_prefix"`
+		} `mapstructure:"broker"`
+	} `mapstructure:"events"`
+	// SecurityHeaders 所有响应附带的安全响应头，字符串为空表示不发送
+	SecurityHeaders struct {
+		HSTSMaxAgeSeconds     int    `mapstructure:"hsts_max_age_seconds"`
+		HSTSIncludeSubdomains bool   `mapstructure:"hsts_include_subdomains"`
+		HSTSPreload           bool   `mapstructure:"hsts_preload"`
+		ContentSecurityPolicy string `mapstructure:"content_security_policy"`
+		ReferrerPolicy        string `mapstructure:"referrer_policy"`
+		FrameOptions          string `mapstructure:"frame_options"`
+	} `mapstructure:"security_headers"`
+}
+
+// RateLimitRule 单条限流规则：window_seconds 内最多 limit 次，limit 为 0 表示不限
+type RateLimitRule struct {
+	Limit         int `mapstructure:"limit"`
+	WindowSeconds int `mapstructure:"window_seconds"`
+}
+
+// RateLimitConfig 登录、注册、换 token、重置密码接口的限流配置
+type RateLimitConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Store: memory（单实例令牌桶，默认）、mysql（多实例共享计数）
+	Store      string        `mapstructure:"store"`
+	LoginIP    RateLimitRule `mapstructure:"login_ip"`
+	LoginEmail RateLimitRule `mapstructure:"login_email"`
+	RegisterIP RateLimitRule `mapstructure:"register_ip"`
+	TokenIP    RateLimitRule `mapstructure:"token_ip"`
+	ResetIP    RateLimitRule `mapstructure:"reset_ip"`
+	ResetEmail RateLimitRule `mapstructure:"reset_email"`
+	// MagicLinkIP / MagicLinkEmail 申请邮件登录链接
+	MagicLinkIP    RateLimitRule `mapstructure:"magic_link_ip"`
+	MagicLinkEmail RateLimitRule `mapstructure:"magic_link_email"`
+	// SMSIP / SMSPhone 发送短信验证码（登录与绑定手机号）
+	SMSIP    RateLimitRule `mapstructure:"sms_ip"`
+	SMSPhone RateLimitRule `mapstructure:"sms_phone"`
+	// VerifyEmailIP 验证邮箱与重发验证邮件
+	VerifyEmailIP RateLimitRule `mapstructure:"verify_email_ip"`
+}
+
+// FederationProviderConfig 第三方登录提供方配置
+type FederationProviderConfig struct {
+	// Name 提供方标识（小写字母、数字、- 与 _），出现在登录与回调地址中
+	Name         string   `mapstructure:"name"`
+	Type         string   `mapstructure:"type"` // oidc 或 github
+	DisplayName  string   `mapstructure:"display_name"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	Scopes       []string `mapstructure:"scopes"`
+	// Issuer OIDC 提供方标识，端点通过 <issuer>/.well-known/openid-configuration 发现
+	Issuer string `mapstructure:"issuer"`
+	// 可选：覆盖发现结果或 GitHub 默认端点（如 GitHub Enterprise）
+	AuthorizationURL string `mapstructure:"authorization_url"`
+	TokenURL         string `mapstructure:"token_url"`
+	UserInfoURL      string `mapstructure:"userinfo_url"`
+	JWKSURL          string `mapstructure:"jwks_url"`
+	// TokenAuthMethod client_secret_basic 或 client_secret_post，为空按提供方类型选择
+	TokenAuthMethod string `mapstructure:"token_auth_method"`
+}
+
+// SAMLServiceProviderConfig 登记的 SAML SP
+type SAMLServiceProviderConfig struct {
+	// MetadataFile SP 元数据文件，提供 entity_id 与 HTTP-POST 断言接收地址；与下面两项同时配置时以下面为准
+	MetadataFile string `mapstructure:"metadata_file"`
+	EntityID     string `mapstructure:"entity_id"`
+	// ACSURLs 允许的断言接收地址，第一个为默认地址
+	ACSURLs []string `mapstructure:"acs_urls"`
+	// NameIDFormat email（默认）、persistent（按 SP 派生的不透明标识）、unspecified（用户名）
+	NameIDFormat string `mapstructure:"name_id_format"`
+	// Attributes 断言属性映射，为空时下发 email、username、first_name、last_name、role
+	Attributes []SAMLAttributeConfig `mapstructure:"attributes"`
+	// SignResponse 除断言外同时对外层 Response 签名
+	SignResponse bool `mapstructure:"sign_response"`
+}
+
+// SAMLAttributeConfig 断言属性名到用户字段的映射
+type SAMLAttributeConfig struct {
+	Name string `mapstructure:"name"`
+	// Source: id、username、email、first_name、last_name、display_name、role、phone_number（仅已验证）
+	Source string `mapstructure:"source"`
+}
+
+// SCIMClientConfig SCIM 同步客户端；name 记录在审计事件的 client_id 中
+type SCIMClientConfig struct {
+	Name string `mapstructure:"name"`
+	// Token 至少 32 个字符的随机字符串
+	Token string `mapstructure:"token"`
+}
+
+// DatabaseConfig 数据库连接
+type DatabaseConfig struct {
+	// Driver mysql（默认）或 postgres，两者共用同一套 GORM 仓库（用户名、邮箱比较与唯一约束冲突按方言区分）；
+	// postgres 的建表脚本见 scripts/postgres/，且 rate_limit.store 不能为 mysql
+	Driver   string `mapstructure:"driver"`
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+	DBName   string `mapstructure:"dbname"`
+	// SSLMode 仅 postgres：disable / require / verify-ca / verify-full，缺省 disable
+	SSLMode string `mapstructure:"sslmode"`
+}
+
+// WebhookSubscriberConfig Webhook 订阅方
+type WebhookSubscriberConfig struct {
+	// Name 订阅方标识，记录在投递日志中；修改后尚未完成的投递不再重试
+	Name string `mapstructure:"name"`
+	URL  string `mapstructure:"url"`
+	// Secret 签名密钥，至少 32 个字符
+	Secret string `mapstructure:"secret"`
+	// Events 订阅的事件类型，为空表示全部
+	Events []string `mapstructure:"events"`
+}
+
+// LDAPGroupRoleConfig 目录组到本地角色的映射
+type LDAPGroupRoleConfig struct {
+	// Group 组的完整 DN 或组名（如 cn 的值），不区分大小写
+	Group string `mapstructure:"group"`
+	Role  string `mapstructure:"role"` // standard 或 admin
+}
+
+// federationProviderName 提供方名称格式
+var federationProviderName = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// minSCIMTokenLength SCIM 客户端 token 最小长度
+const minSCIMTokenLength = 32
+
+// minWebhookSecretLength Webhook 签名密钥最小长度
+const minWebhookSecretLength = 32
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	// Driver: smtp（生产）、file（写入 Dir 目录的 .eml 文件）、log（仅打印日志，默认）
+	Driver      string `mapstructure:"driver"`
+	From        string `mapstructure:"from"`
+	Host        string `mapstructure:"host"`
+	Port        string `mapstructure:"port"`
+	Username    string `mapstructure:"username"`
+	Password    string `mapstructure:"password"`
+	ImplicitTLS bool   `mapstructure:"implicit_tls"`
+	Dir         string `mapstructure:"dir"`
 }
 
 // validateConfig 校验必填配置项
@@ -56,16 +398,370 @@ func validateConfig(cfg Config) error {
 	if cfg.Server.JWTExpirationHours < 1 || cfg.Server.JWTExpirationHours > 720 {
 		return fmt.Errorf("server.jwt_expiration_hours must be between 1 and 720")
 	}
+	if _, err := cookieOptions(cfg); err != nil {
+		return fmt.Errorf("server cookie options: %w", err)
+	}
 	if cfg.Database.Host == "" || cfg.Database.Port == "" || cfg.Database.User == "" || cfg.Database.DBName == "" {
 		return fmt.Errorf("database host, port, user, dbname are required")
 	}
+	if cfg.MFA.Enabled && cfg.MFA.EncryptionKey == "" {
+		return fmt.Errorf("mfa.encryption_key is required when mfa.enabled is true")
+	}
+	switch cfg.PasswordHash.Algorithm {
+	case "", auth.HashAlgorithmBcrypt:
+		if cfg.PasswordPolicy.MaxBytes > domain.BcryptMaxPasswordBytes {
+			return fmt.Errorf("password_policy.max_bytes must not exceed %d when using bcrypt", domain.BcryptMaxPasswordBytes)
+		}
+	case auth.HashAlgorithmArgon2id:
+	default:
+		return fmt.Errorf("password_hash.algorithm must be bcrypt or argon2id")
+	}
+	if cfg.WebAuthn.Enabled && (cfg.WebAuthn.RPID == "" || len(cfg.WebAuthn.RPOrigins) == 0) {
+		return fmt.Errorf("webauthn.rp_id and webauthn.rp_origins are required when webauthn.enabled is true")
+	}
+	if cfg.Federation.Enabled {
+		if len(cfg.Federation.Providers) == 0 {
+			return fmt.Errorf("federation.providers is required when federation.enabled is true")
+		}
+		seen := make(map[string]bool, len(cfg.Federation.Providers))
+		for _, p := range cfg.Federation.Providers {
+			if !federationProviderName.MatchString(p.Name) {
+				return fmt.Errorf("federation provider name %q must match %s", p.Name, federationProviderName)
+			}
+			if seen[p.Name] {
+				return fmt.Errorf("federation provider name %q is duplicated", p.Name)
+			}
+			seen[p.Name] = true
+			if cfg.LDAP.Enabled && p.Name == auth.LDAPIdentityProvider {
+				return fmt.Errorf("federation provider name %q is reserved for ldap", p.Name)
+			}
+			if cfg.SCIM.Enabled && p.Name == auth.SCIMIdentityProvider {
+				return fmt.Errorf("federation provider name %q is reserved for scim", p.Name)
+			}
+			if p.ClientID == "" || p.ClientSecret == "" {
+				return fmt.Errorf("federation provider %s: client_id and client_secret are required", p.Name)
+			}
+			switch p.Type {
+			case federation.TypeOIDC:
+				if p.Issuer == "" {
+					return fmt.Errorf("federation provider %s: issuer is required for oidc providers", p.Name)
+				}
+			case federation.TypeGitHub:
+			default:
+				return fmt.Errorf("federation provider %s: type must be oidc or github", p.Name)
+			}
+		}
+	}
+	if cfg.LDAP.Enabled {
+		switch cfg.LDAP.Mode {
+		case "", auth.DirectoryModeFirst, auth.DirectoryModeOnly:
+		default:
+			return fmt.Errorf("ldap.mode must be first or only")
+		}
+		if cfg.LDAP.URL == "" || cfg.LDAP.BaseDN == "" || cfg.LDAP.UserFilter == "" {
+			return fmt.Errorf("ldap.url, ldap.base_dn and ldap.user_filter are required when ldap.enabled is true")
+		}
+		for _, m := range cfg.LDAP.GroupRoles {
+			if m.Group == "" || (m.Role != domain.RoleStandard && m.Role != domain.RoleAdmin) {
+				return fmt.Errorf("ldap.group_roles entries need a group and a role of standard or admin")
+			}
+		}
+		if r := cfg.LDAP.DefaultRole; r != "" && r != domain.RoleStandard && r != domain.RoleAdmin {
+			return fmt.Errorf("ldap.default_role must be standard or admin")
+		}
+	}
+	if cfg.SAML.Enabled {
+		if len(cfg.SAML.ServiceProviders) == 0 {
+			return fmt.Errorf("saml.service_providers is required when saml.enabled is true")
+		}
+		if (cfg.SAML.CertificateFile == "") != (cfg.SAML.PrivateKeyFile == "") {
+			return fmt.Errorf("saml.certificate_file and saml.private_key_file must be set together")
+		}
+		for i, sp := range cfg.SAML.ServiceProviders {
+			if sp.MetadataFile == "" && (sp.EntityID == "" || len(sp.ACSURLs) == 0) {
+				return fmt.Errorf("saml.service_providers[%d]: metadata_file or entity_id and acs_urls are required", i)
+			}
+			if sp.NameIDFormat == auth.SAMLNameIDPersistent && cfg.SAML.PersistentIDSecret == "" {
+				return fmt.Errorf("saml.persistent_id_secret is required when a service provider uses name_id_format persistent")
+			}
+		}
+	}
+	if cfg.SCIM.Enabled {
+		if len(cfg.SCIM.Clients) == 0 {
+			return fmt.Errorf("scim.clients is required when scim.enabled is true")
+		}
+		names := make(map[string]bool)
+		for i, c := range cfg.SCIM.Clients {
+			if c.Name == "" || names[c.Name] {
+				return fmt.Errorf("scim.clients[%d]: name is required and must be unique", i)
+			}
+			names[c.Name] = true
+			if len(c.Token) < minSCIMTokenLength {
+				return fmt.Errorf("scim.clients[%d]: token must be at least %d characters", i, minSCIMTokenLength)
+			}
+		}
+	}
+	if cfg.Webhooks.Enabled {
+		if len(cfg.Webhooks.Subscribers) == 0 {
+			return fmt.Errorf("webhooks.subscribers is required when webhooks.enabled is true")
+		}
+		names := make(map[string]bool)
+		for i, s := range cfg.Webhooks.Subscribers {
+			if !federationProviderName.MatchString(s.Name) || names[s.Name] {
+				return fmt.Errorf("webhooks.subscribers[%d]: name is required, must be unique and match %s", i, federationProviderName)
+			}
+			names[s.Name] = true
+			if u, err := url.Parse(s.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return fmt.Errorf("webhooks.subscribers[%d]: url must be an absolute http(s) URL", i)
+			}
+			if len(s.Secret) < minWebhookSecretLength {
+				return fmt.Errorf("webhooks.subscribers[%d]: secret must be at least %d characters", i, minWebhookSecretLength)
+			}
+			for _, e := range s.Events {
+				if !slices.Contains(domain.WebhookEventTypes, e) {
+					return fmt.Errorf("webhooks.subscribers[%d]: unknown event %q, expected one of %s", i, e, strings.Join(domain.WebhookEventTypes, ", "))
+				}
+			}
+		}
+	}
+	if cfg.Events.PollIntervalSeconds < 0 || cfg.Events.RetentionDays < 0 {
+		return fmt.Errorf("events.poll_interval_seconds and events.retention_days must not be negative")
+	}
+	switch cfg.Events.Broker.Driver {
+	case "", "memory":
+	default:
+		return fmt.Errorf("unsupported events.broker.driver %q, expected memory or empty", cfg.Events.Broker.Driver)
+	}
+	if cfg.LoginRisk.StepUpMFA && !cfg.LoginRisk.Enabled {
+		return fmt.Errorf("login_risk.enabled must be true when login_risk.step_up_mfa is true")
+	}
+	switch cfg.RateLimit.Store {
+	case "", "memory", "mysql":
+	default:
+		return fmt.Errorf("rate_limit.store must be memory or mysql")
+	}
+	switch cfg.Database.Driver {
+	case "", "mysql":
+	case "postgres":
+		if cfg.RateLimit.Enabled && cfg.RateLimit.Store == "mysql" {
+			return fmt.Errorf("rate_limit.store mysql requires database.driver mysql")
+		}
+	default:
+		return fmt.Errorf("unsupported database.driver %q, expected mysql or postgres", cfg.Database.Driver)
+	}
+	switch cfg.SMS.Driver {
+	case "", "log", "file":
+	default:
+		return fmt.Errorf("sms.driver must be one of file, log")
+	}
+	switch cfg.Mail.Driver {
+	case "", "log", "file":
+	case "smtp":
+		if cfg.Mail.Host == "" || cfg.Mail.Port == "" || cfg.Mail.From == "" {
+			return fmt.Errorf("mail host, port, from are required when mail.driver is smtp")
+		}
+	default:
+		return fmt.Errorf("mail.driver must be one of smtp, file, log")
+	}
 	return nil
 }
 
-// staticCORSHandler 为 /static/ 响应加 CORS 头，跨域加载时浏览器才能按 Cache-Control 正常缓存
-func staticCORSHandler(next http.Handler) http.Handler {
+// cookieOptions 根据 server 配置生成 Cookie 属性
+func cookieOptions(cfg Config) (httptransport.CookieOptions, error) {
+	sameSite, err := httptransport.ParseSameSite(cfg.Server.CookieSameSite)
+	if err != nil {
+		return httptransport.CookieOptions{}, err
+	}
+	opts := httptransport.CookieOptions{
+		Secure:     cfg.Server.CookieSecure,
+		Domain:     strings.TrimSpace(cfg.Server.CookieDomain),
+		SameSite:   sameSite,
+		HostPrefix: cfg.Server.CookieHostPrefix,
+	}
+	return opts, opts.Validate()
+}
+
+// initSAMLService 加载 IdP 签名密钥与 SP 登记信息（含元数据文件），创建 SAML IdP 服务
+func initSAMLService(cfg Config, authBaseURL string, users domain.UserRepository) (auth.SAMLService, error) {
+	base := strings.TrimSuffix(authBaseURL, "/")
+	idp := &saml.IdentityProvider{
+		EntityID:     cfg.SAML.EntityID,
+		SSOURL:       base + "/api/v1/auth/saml/sso",
+		AssertionTTL: time.Duration(cfg.SAML.AssertionTTLSeconds) * time.Second,
+	}
+	if idp.EntityID == "" {
+		idp.EntityID = base + "/api/v1/auth/saml/metadata"
+	}
+	var err error
+	if cfg.SAML.CertificateFile != "" {
+		idp.Key, idp.Certificate, err = saml.LoadKeyPair(cfg.SAML.CertificateFile, cfg.SAML.PrivateKeyFile)
+	} else {
+		log.Printf("[WARN] saml.certificate_file not set, using an ephemeral signing key; service providers must re-import metadata after every restart")
+		idp.Key, idp.Certificate, err = saml.GenerateKeyPair(idp.EntityID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("saml signing key: %w", err)
+	}
+
+	providers := make([]auth.SAMLServiceProvider, 0, len(cfg.SAML.ServiceProviders))
+	seen := make(map[string]bool, len(cfg.SAML.ServiceProviders))
+	for i, c := range cfg.SAML.ServiceProviders {
+		sp := auth.SAMLServiceProvider{
+			EntityID:     c.EntityID,
+			ACSURLs:      c.ACSURLs,
+			NameIDFormat: c.NameIDFormat,
+			SignResponse: c.SignResponse,
+		}
+		if c.MetadataFile != "" {
+			data, err := os.ReadFile(c.MetadataFile)
+			if err != nil {
+				return nil, fmt.Errorf("saml.service_providers[%d]: %w", i, err)
+			}
+			md, err := saml.ParseServiceProviderMetadata(data)
+			if err != nil {
+				return nil, fmt.Errorf("saml.service_providers[%d]: %w", i, err)
+			}
+			if sp.EntityID == "" {
+				sp.EntityID = md.EntityID
+			}
+			if len(sp.ACSURLs) == 0 {
+				sp.ACSURLs = md.ACSURLs
+			}
+		}
+		for _, a := range c.Attributes {
+			sp.Attributes = append(sp.Attributes, auth.SAMLAttributeMapping{Name: a.Name, Source: a.Source})
+		}
+		if err := auth.ValidateSAMLServiceProvider(sp); err != nil {
+			return nil, fmt.Errorf("saml.service_providers[%d]: %w", i, err)
+		}
+		for _, acs := range sp.ACSURLs {
+			if u, err := url.Parse(acs); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return nil, fmt.Errorf("saml service provider %s: acs url %q must be an absolute http(s) url", sp.EntityID, acs)
+			}
+		}
+		if seen[sp.EntityID] {
+			return nil, fmt.Errorf("saml service provider %s is duplicated", sp.EntityID)
+		}
+		seen[sp.EntityID] = true
+		providers = append(providers, sp)
+	}
+	return auth.NewSAMLService(idp, users, auth.NewMemorySAMLRequestStore(10*time.Minute), auth.SAMLConfig{
+		ServiceProviders:   providers,
+		PersistentIDSecret: cfg.SAML.PersistentIDSecret,
+	}), nil
+}
+
+// initMailer 根据配置创建邮件发送器
+func initMailer(cfg MailConfig) mail.Mailer {
+	from := cfg.From
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+	switch cfg.Driver {
+	case "smtp":
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:        cfg.Host,
+			Port:        cfg.Port,
+			Username:    cfg.Username,
+			Password:    cfg.Password,
+			From:        from,
+			ImplicitTLS: cfg.ImplicitTLS,
+		})
+	case "file":
+		dir := cfg.Dir
+		if dir == "" {
+			dir = "mails"
+		}
+		m, err := mail.NewFileMailer(dir, from)
+		if err != nil {
+			log.Fatalf("Failed to init file mailer: %v", err)
+		}
+		return m
+	default:
+		return mail.NewLogMailer()
+	}
+}
+
+// initSMSSender 根据配置创建短信发送器
+func initSMSSender(driver, dir string) sms.Sender {
+	switch driver {
+	case "file":
+		if dir == "" {
+			dir = "sms"
+		}
+		s, err := sms.NewFileSender(dir)
+		if err != nil {
+			log.Fatalf("Failed to init file sms sender: %v", err)
+		}
+		return s
+	default:
+		return sms.NewLogSender()
+	}
+}
+
+// initEventOutbox 根据配置组装事件 Sink 并创建发件箱；webhooks 非 nil 时作为其中一个 Sink
+func initEventOutbox(cfg Config, db *gorm.DB, webhooks auth.WebhookService) auth.EventOutbox {
+	var sinks []eventbus.Sink
+	if cfg.Events.Log {
+		sinks = append(sinks, eventbus.NewLogSink())
+	}
+	if cfg.Events.Broker.Driver == "memory" {
+		prefix := cfg.Events.Broker.SubjectPrefix
+		if prefix == "" {
+			prefix = "identity."
+		}
+		sinks = append(sinks, eventbus.NewBrokerSink("memory", eventbus.NewMemoryBroker(), prefix))
+	}
+	if webhooks != nil {
+		sinks = append(sinks, webhooks)
+	}
+	return auth.NewEventOutbox(userrepo.NewGORMTransactor(db), userrepo.NewGORMOutboxRepository(db), sinks, auth.OutboxConfig{
+		PollInterval: time.Duration(cfg.Events.PollIntervalSeconds) * time.Second,
+		Retention:    time.Duration(cfg.Events.RetentionDays) * 24 * time.Hour,
+		MaxAttempts:  cfg.Events.MaxAttempts,
+	})
+}
+
+// newRateLimitFactory 返回按规则创建限流中间件的函数；未启用限流或规则 limit 为 0 时返回直通中间件
+func newRateLimitFactory(cfg RateLimitConfig, repo domain.RateLimitRepository) func(scope string, rule RateLimitRule, key func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(scope string, rule RateLimitRule, key func(*http.Request) string) func(http.Handler) http.Handler {
+		if !cfg.Enabled || rule.Limit <= 0 {
+			return func(next http.Handler) http.Handler { return next }
+		}
+		window := time.Duration(rule.WindowSeconds) * time.Second
+		var limiter auth.RateLimiter
+		if cfg.Store == "mysql" {
+			limiter = auth.NewStoreRateLimiter(repo, rule.Limit, window)
+		} else {
+			limiter = auth.NewMemoryRateLimiter(rule.Limit, window)
+		}
+		return httptransport.RateLimitMiddleware(limiter, scope, key)
+	}
+}
+
+// inlineStaticExts 可在浏览器内直接展示的上传文件类型，其余类型一律按附件下载（含 SVG、HTML 等可执行脚本的类型）
+var inlineStaticExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".avif": true, ".bmp": true, ".ico": true,
+}
+
+// staticSecurityHandler 加固上传文件的访问：禁止目录列表、禁止 MIME 嗅探、以沙箱 CSP 返回，
+// 非图片文件以 Content-Disposition: attachment 下载，避免用户上传的 HTML 在认证中心域名下执行。
+// 跨域访问由全局 CORSMiddleware 按 allowed_origins 放行
+func staticSecurityHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if r.URL.Path == "" || strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("Content-Security-Policy", "default-src 'none'; sandbox")
+		name := path.Base(r.URL.Path)
+		disposition := "attachment"
+		if inlineStaticExts[strings.ToLower(path.Ext(name))] {
+			disposition = "inline"
+		}
+		h.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": name}))
 		next.ServeHTTP(w, r)
 	})
 }
@@ -100,18 +796,41 @@ func (w *cacheControlResponseWriter) Write(p []byte) (int, error) {
 	return w.ResponseWriter.Write(p)
 }
 
-// initDB 初始化并返回 GORM 数据库连接
-func initDB(cfg Config) *gorm.DB {
-	dsn := fmt.Sprintf(
+// databaseDSN 按驱动拼接连接串：MySQL 为 go-sql-driver 格式，PostgreSQL 为 postgres:// URL（用户名、密码等经转义）
+func databaseDSN(cfg DatabaseConfig) string {
+	if cfg.Driver == "postgres" {
+		sslMode := cfg.SSLMode
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(cfg.User, cfg.Password),
+			Host:     net.JoinHostPort(cfg.Host, cfg.Port),
+			Path:     "/" + cfg.DBName,
+			RawQuery: url.Values{"sslmode": {sslMode}}.Encode(),
+		}
+		return u.String()
+	}
+	return fmt.Sprintf(
 		"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.DBName,
+		cfg.User,
+		cfg.Password,
+		cfg.Host,
+		cfg.Port,
+		cfg.DBName,
 	)
+}
+
+// initDB 初始化并返回 GORM 数据库连接
+func initDB(cfg Config) *gorm.DB {
+	dsn := databaseDSN(cfg.Database)
+	dialector := mysql.Open(dsn)
+	if cfg.Database.Driver == "postgres" {
+		dialector = postgres.Open(dsn)
+	}
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -140,17 +859,19 @@ func main() {
 	// 2. 初始化数据库和依赖注入 (DI)
 	gormDB := initDB(cfg)
 
-	// 仓库层 (Repository)
-	userRepo := userrepo.NewGORMUserRepository(gormDB)
-	userAssetRepo := userrepo.NewGORMUserAssetRepository(gormDB)
+	// 仓库层 (Repository)，MySQL 与 PostgreSQL 共用同一套 GORM 实现
+	var userRepo domain.UserRepository = userrepo.NewGORMUserRepository(gormDB)
+	var userAssetRepo domain.UserAssetRepository = userrepo.NewGORMUserAssetRepository(gormDB)
+	resetTokenRepo := userrepo.NewGORMPasswordResetTokenRepository(gormDB)
+	verifyTokenRepo := userrepo.NewGORMEmailVerificationTokenRepository(gormDB)
+	mfaRepo := userrepo.NewGORMMFARepository(gormDB)
+	passwordHistoryRepo := userrepo.NewGORMPasswordHistoryRepository(gormDB)
+	webAuthnRepo := userrepo.NewGORMWebAuthnCredentialRepository(gormDB)
 
 	// Token 服务
 	expiry := time.Duration(cfg.Server.JWTExpirationHours) * time.Hour
 	tokenService := auth.NewJWTService(cfg.Server.JWTSecret, expiry)
 
-	// 核心鉴权服务 (Service)
-	authService := auth.NewAuthService(userRepo, tokenService)
-
 	// SSO state 与 授权码 存储
 	stateStore := auth.NewMemoryStateStore(10 * time.Minute)
 	codeStore := auth.NewMemoryCodeStore(5 * time.Minute)
@@ -163,7 +884,7 @@ func main() {
 	for _, c := range cfg.Server.Clients {
 		clients = append(clients, httptransport.Client{
 			ClientID:            c.ClientID,
-			ClientSecret:       c.ClientSecret,
+			ClientSecret:        c.ClientSecret,
 			AllowedRedirectURIs: c.AllowedRedirectURIs,
 		})
 	}
@@ -172,38 +893,458 @@ func main() {
 	if authBaseURL == "" {
 		authBaseURL = "http://localhost:" + cfg.Server.Port
 	}
+	mailer := initMailer(cfg.Mail)
+
+	// 注册邮箱验证（可选）
+	var emailVerificationService auth.EmailVerificationService
+	if cfg.Server.RequireEmailVerification {
+		verifyPath := cfg.Server.VerifyEmailPath
+		if verifyPath == "" {
+			verifyPath = "/verify-email"
+		}
+		emailVerificationService = auth.NewEmailVerificationService(userRepo, verifyTokenRepo, resetTokenRepo, mailer, auth.EmailVerificationConfig{
+			TTL:            time.Duration(cfg.Server.VerifyEmailTTLHours) * time.Hour,
+			ResendInterval: time.Duration(cfg.Server.VerifyEmailResendIntervalS) * time.Second,
+			VerifyURL:      strings.TrimSuffix(authBaseURL, "/") + "/" + strings.TrimPrefix(verifyPath, "/"),
+			SetPasswordTTL: time.Duration(cfg.Server.PasswordResetTTLMinutes) * time.Minute,
+		})
+	}
+
+	// TOTP 多因素认证（可选）
+	var mfaService auth.MFAService
+	if cfg.MFA.Enabled {
+		var err error
+		mfaService, err = auth.NewMFAService(mfaRepo, auth.MFAConfig{
+			Issuer:        cfg.MFA.Issuer,
+			EncryptionKey: cfg.MFA.EncryptionKey,
+		})
+		if err != nil {
+			log.Fatalf("Failed to init MFA service: %v", err)
+		}
+	}
+
+	// WebAuthn / Passkey 无密码登录（可选）
+	var passkeyService auth.PasskeyService
+	if cfg.WebAuthn.Enabled {
+		var err error
+		passkeyService, err = auth.NewPasskeyService(userRepo, webAuthnRepo, auth.NewMemoryWebAuthnSessionStore(5*time.Minute), auth.PasskeyConfig{
+			RPID:          cfg.WebAuthn.RPID,
+			RPDisplayName: cfg.WebAuthn.RPDisplayName,
+			RPOrigins:     cfg.WebAuthn.RPOrigins,
+		})
+		if err != nil {
+			log.Fatalf("Failed to init WebAuthn service: %v", err)
+		}
+	}
+
+	// 密码哈希（bcrypt / argon2id）
+	passwordHasher, err := auth.NewPasswordHasher(auth.PasswordHasherConfig{
+		Algorithm:  cfg.PasswordHash.Algorithm,
+		BcryptCost: cfg.PasswordHash.BcryptCost,
+		Argon2id: auth.Argon2idParams{
+			MemoryKiB:   cfg.PasswordHash.Argon2MemoryKiB,
+			Iterations:  cfg.PasswordHash.Argon2Iterations,
+			Parallelism: cfg.PasswordHash.Argon2Parallelism,
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to init password hasher: %v", err)
+	}
+
+	// 密码策略与本地泄露密码库
+	policy := domain.DefaultPasswordPolicy()
+	if cfg.PasswordPolicy.MinLength > 0 {
+		policy.MinLength = cfg.PasswordPolicy.MinLength
+	}
+	if cfg.PasswordPolicy.MaxBytes > 0 {
+		policy.MaxBytes = cfg.PasswordPolicy.MaxBytes
+	}
+	policy.MinCharClasses = cfg.PasswordPolicy.MinCharClasses
+	policy.RequireUpper = cfg.PasswordPolicy.RequireUpper
+	policy.RequireLower = cfg.PasswordPolicy.RequireLower
+	policy.RequireDigit = cfg.PasswordPolicy.RequireDigit
+	policy.RequireSymbol = cfg.PasswordPolicy.RequireSymbol
+	policy.DisallowUserInfo = cfg.PasswordPolicy.DisallowUserInfo
+	policy.HistorySize = cfg.PasswordPolicy.HistorySize
+	var breachedChecker auth.BreachedPasswordChecker
+	if cfg.PasswordPolicy.BreachedDir != "" {
+		checker, err := auth.NewFileBreachedPasswordChecker(cfg.PasswordPolicy.BreachedDir, cfg.PasswordPolicy.BreachedMinCount)
+		if err != nil {
+			log.Fatalf("Failed to load breached password list: %v", err)
+		}
+		breachedChecker = checker
+	}
+	passwordChecker := auth.NewPasswordChecker(policy, passwordHasher, breachedChecker, passwordHistoryRepo)
+
+	// 连续登录失败锁定（可选）
+	var lockout *auth.LockoutPolicy
+	if cfg.Lockout.Enabled {
+		policy := auth.DefaultLockoutPolicy()
+		if cfg.Lockout.DelayAfter > 0 {
+			policy.DelayAfter = cfg.Lockout.DelayAfter
+		}
+		if cfg.Lockout.BaseDelaySeconds > 0 {
+			policy.BaseDelay = time.Duration(cfg.Lockout.BaseDelaySeconds) * time.Second
+		}
+		if cfg.Lockout.MaxDelaySeconds > 0 {
+			policy.MaxDelay = time.Duration(cfg.Lockout.MaxDelaySeconds) * time.Second
+		}
+		if cfg.Lockout.MaxFailures > 0 {
+			policy.MaxFailures = cfg.Lockout.MaxFailures
+		}
+		if cfg.Lockout.DurationMinutes > 0 {
+			policy.LockoutDuration = time.Duration(cfg.Lockout.DurationMinutes) * time.Minute
+		}
+		lockout = &policy
+	}
+
+	// 安全审计（可选）
+	var auditService auth.AuditService
+	if cfg.Audit.Enabled {
+		auditService = auth.NewAuditService(userrepo.NewGORMAuditEventRepository(gormDB), userRepo, auth.AuditConfig{
+			Retention: time.Duration(cfg.Audit.RetentionDays) * 24 * time.Hour,
+		})
+	}
+
+	// 用户生命周期 Webhook（可选）
+	var webhookService auth.WebhookService
+	if cfg.Webhooks.Enabled {
+		subscribers := make([]auth.WebhookSubscriber, 0, len(cfg.Webhooks.Subscribers))
+		for _, s := range cfg.Webhooks.Subscribers {
+			subscribers = append(subscribers, auth.WebhookSubscriber{Name: s.Name, URL: s.URL, Secret: s.Secret, Events: s.Events})
+		}
+		webhookService = auth.NewWebhookService(userrepo.NewGORMWebhookDeliveryRepository(gormDB), auth.WebhookConfig{
+			Subscribers: subscribers,
+			MaxAttempts: cfg.Webhooks.MaxAttempts,
+			Timeout:     time.Duration(cfg.Webhooks.TimeoutSeconds) * time.Second,
+			Retention:   time.Duration(cfg.Webhooks.RetentionDays) * 24 * time.Hour,
+		})
+	}
+
+	// 领域事件发件箱（可选）：启用后 Webhook 也经由发件箱发布
+	var outbox auth.EventOutbox
+	if cfg.Events.Enabled {
+		outbox = initEventOutbox(cfg, gormDB, webhookService)
+	}
+
+	// 新设备 / 新国家 / 不可能旅行检测（可选）
+	loginHistoryRepo := userrepo.NewGORMLoginHistoryRepository(gormDB)
+	var loginRisk auth.LoginRiskService
+	if cfg.LoginRisk.Enabled {
+		var geoip auth.GeoIPResolver
+		if cfg.LoginRisk.GeoIPDB != "" {
+			db, err := auth.NewMaxMindGeoIP(cfg.LoginRisk.GeoIPDB)
+			if err != nil {
+				log.Fatalf("Failed to load GeoIP database: %v", err)
+			}
+			geoip = db
+		}
+		loginRisk = auth.NewLoginRiskService(userrepo.NewGORMKnownDeviceRepository(gormDB), loginHistoryRepo, geoip, mailer, auditService, auth.LoginRiskConfig{
+			MaxTravelSpeedKmh:   cfg.LoginRisk.MaxTravelSpeedKmh,
+			MinTravelDistanceKm: cfg.LoginRisk.MinTravelDistanceKm,
+			Notify:              cfg.LoginRisk.Notify,
+		})
+	}
+
+	// 企业目录登录（可选）
+	identityRepo := userrepo.NewGORMUserIdentityRepository(gormDB)
+	var directoryAuth auth.DirectoryAuthenticator
+	if cfg.LDAP.Enabled {
+		client, err := directory.New(directory.Config{
+			URL:                cfg.LDAP.URL,
+			StartTLS:           cfg.LDAP.StartTLS,
+			InsecureSkipVerify: cfg.LDAP.InsecureSkipVerify,
+			CACertFile:         cfg.LDAP.CACertFile,
+			ServerName:         cfg.LDAP.ServerName,
+			Timeout:            time.Duration(cfg.LDAP.TimeoutSeconds) * time.Second,
+			BindDN:             cfg.LDAP.BindDN,
+			BindPassword:       cfg.LDAP.BindPassword,
+			BaseDN:             cfg.LDAP.BaseDN,
+			UserFilter:         cfg.LDAP.UserFilter,
+			IDAttribute:        cfg.LDAP.IDAttribute,
+			UsernameAttribute:  cfg.LDAP.UsernameAttribute,
+			EmailAttribute:     cfg.LDAP.EmailAttribute,
+			FirstNameAttribute: cfg.LDAP.FirstNameAttribute,
+			LastNameAttribute:  cfg.LDAP.LastNameAttribute,
+			MemberOfAttribute:  cfg.LDAP.MemberOfAttribute,
+			GroupBaseDN:        cfg.LDAP.GroupBaseDN,
+			GroupFilter:        cfg.LDAP.GroupFilter,
+		})
+		if err != nil {
+			log.Fatalf("Failed to init ldap client: %v", err)
+		}
+		roles := make([]auth.DirectoryRoleMapping, 0, len(cfg.LDAP.GroupRoles))
+		for _, m := range cfg.LDAP.GroupRoles {
+			roles = append(roles, auth.DirectoryRoleMapping{Group: m.Group, Role: m.Role})
+		}
+		directoryAuth = auth.NewLDAPAuthenticator(client, userRepo, identityRepo, auth.LDAPConfig{
+			AllowedGroups: cfg.LDAP.AllowedGroups,
+			GroupRoles:    roles,
+			DefaultRole:   cfg.LDAP.DefaultRole,
+			Webhooks:      webhookService,
+			Outbox:        outbox,
+		})
+	}
+
+	// 核心鉴权服务 (Service)
+	authService := auth.NewAuthService(userRepo, tokenService, &auth.AuthServiceOpts{
+		EmailVerification: emailVerificationService,
+		MFA:               mfaService,
+		MFAChallenges:     auth.NewMemoryMFAChallengeStore(5*time.Minute, 5),
+		Lockout:           lockout,
+		Passwords:         passwordChecker,
+		Hasher:            passwordHasher,
+		LoginRisk:         loginRisk,
+		StepUpMFA:         cfg.LoginRisk.StepUpMFA,
+		Directory:         directoryAuth,
+		DirectoryMode:     cfg.LDAP.Mode,
+		Webhooks:          webhookService,
+		Outbox:            outbox,
+	})
+
+	// 忘记密码 / 重置密码
+	resetPath := cfg.Server.PasswordResetPath
+	if resetPath == "" {
+		resetPath = "/reset-password"
+	}
+	passwordResetService := auth.NewPasswordResetService(userRepo, resetTokenRepo, mailer, passwordHasher, passwordChecker, auth.PasswordResetConfig{
+		TTL:      time.Duration(cfg.Server.PasswordResetTTLMinutes) * time.Minute,
+		ResetURL: strings.TrimSuffix(authBaseURL, "/") + "/" + strings.TrimPrefix(resetPath, "/"),
+	})
+
+	// 邮件链接登录（可选）
+	var magicLinkService auth.MagicLinkService
+	if cfg.MagicLink.Enabled {
+		magicPath := cfg.MagicLink.Path
+		if magicPath == "" {
+			magicPath = "/magic-link"
+		}
+		ttl := time.Duration(cfg.MagicLink.TTLMinutes) * time.Minute
+		magicLinkService = auth.NewMagicLinkService(userRepo, auth.NewMemoryMagicLinkStore(ttl), mailer, auth.MagicLinkConfig{
+			TTL:     ttl,
+			LinkURL: strings.TrimSuffix(authBaseURL, "/") + "/" + strings.TrimPrefix(magicPath, "/"),
+			Secret:  cfg.Server.JWTSecret,
+		})
+	}
+
+	// 短信验证码登录 / 手机号验证（可选）
+	var phoneOTPService auth.PhoneOTPService
+	if cfg.PhoneOTP.Enabled {
+		ttl := time.Duration(cfg.PhoneOTP.CodeTTLMinutes) * time.Minute
+		otpStore := auth.NewMemoryOTPStore(ttl, cfg.PhoneOTP.MaxAttempts, time.Duration(cfg.PhoneOTP.ResendIntervalSeconds)*time.Second)
+		phoneOTPService = auth.NewPhoneOTPService(userRepo, otpStore, initSMSSender(cfg.SMS.Driver, cfg.SMS.Dir), auth.PhoneOTPConfig{
+			TTL:     ttl,
+			AppName: cfg.MFA.Issuer,
+		})
+	}
+
+	// 第三方登录（可选）
+	var federationService auth.FederationService
+	federationLanding := ""
+	if cfg.Federation.Enabled {
+		providers := make([]federation.Provider, 0, len(cfg.Federation.Providers))
+		for _, p := range cfg.Federation.Providers {
+			provider, err := federation.New(federation.ProviderConfig{
+				Name:             p.Name,
+				Type:             p.Type,
+				DisplayName:      p.DisplayName,
+				ClientID:         p.ClientID,
+				ClientSecret:     p.ClientSecret,
+				Scopes:           p.Scopes,
+				Issuer:           p.Issuer,
+				AuthorizationURL: p.AuthorizationURL,
+				TokenURL:         p.TokenURL,
+				UserInfoURL:      p.UserInfoURL,
+				JWKSURL:          p.JWKSURL,
+				TokenAuthMethod:  p.TokenAuthMethod,
+			}, nil)
+			if err != nil {
+				log.Fatalf("Failed to init federation provider: %v", err)
+			}
+			providers = append(providers, provider)
+		}
+		callbackBase := cfg.Federation.CallbackBaseURL
+		if callbackBase == "" {
+			callbackBase = strings.TrimSuffix(authBaseURL, "/") + "/api/v1/auth/federation"
+		}
+		landingPath := cfg.Federation.LandingPath
+		if landingPath == "" {
+			landingPath = "/federation/callback"
+		}
+		federationLanding = strings.TrimSuffix(authBaseURL, "/") + "/" + strings.TrimPrefix(landingPath, "/")
+		federationService = auth.NewFederationService(userRepo, identityRepo,
+			auth.NewMemoryFederationStore(10*time.Minute, 2*time.Minute), providers, auth.FederationConfig{
+				CallbackBaseURL: callbackBase,
+				AutoRegister:    cfg.Federation.AutoRegister,
+				Webhooks:        webhookService,
+				Outbox:          outbox,
+			})
+	}
+
+	// SAML 2.0 IdP（可选）
+	var samlService auth.SAMLService
+	if cfg.SAML.Enabled {
+		samlService, err = initSAMLService(cfg, authBaseURL, userRepo)
+		if err != nil {
+			log.Fatalf("Failed to init saml: %v", err)
+		}
+	}
+
+	// SCIM 2.0 同步（可选）
+	var scimService auth.SCIMService
+	var scimClients []httptransport.SCIMClient
+	if cfg.SCIM.Enabled {
+		scimService = auth.NewSCIMService(userRepo, userrepo.NewGORMGroupRepository(gormDB), identityRepo, passwordChecker, passwordHasher, auth.SCIMConfig{
+			BaseURL:  strings.TrimSuffix(authBaseURL, "/") + "/scim/v2",
+			Webhooks: webhookService,
+			Outbox:   outbox,
+		})
+		for _, c := range cfg.SCIM.Clients {
+			scimClients = append(scimClients, httptransport.SCIMClient{Name: c.Name, Token: c.Token})
+		}
+	}
+
 	// 传输层 (Handler)
+	cookies, _ := cookieOptions(cfg) // 已在 validateConfig 中校验
 	httpHandler := httptransport.NewHandler(authService, &httptransport.HandlerOpts{
-		StateStore:           stateStore,
-		CodeStore:            codeStore,
-		UserAssetRepository:  userAssetRepo,
-		CookieSecure:         cfg.Server.CookieSecure,
-		LoginPagePath:        loginPagePath,
-		AuthBaseURL:          authBaseURL,
-		AllowedRedirectURIs:  cfg.Server.AllowedRedirectURIs,
-		Clients:              clients,
-		AccessTokenExpireSec: cfg.Server.JWTExpirationHours * 3600,
+		StateStore:               stateStore,
+		CodeStore:                codeStore,
+		UserAssetRepository:      userAssetRepo,
+		Cookies:                  cookies,
+		LoginPagePath:            loginPagePath,
+		AuthBaseURL:              authBaseURL,
+		AllowedRedirectURIs:      cfg.Server.AllowedRedirectURIs,
+		Clients:                  clients,
+		AccessTokenExpireSec:     cfg.Server.JWTExpirationHours * 3600,
+		PasswordResetService:     passwordResetService,
+		EmailVerificationService: emailVerificationService,
+		MFAService:               mfaService,
+		PasskeyService:           passkeyService,
+		AuditService:             auditService,
+		LoginHistoryService:      auth.NewLoginHistoryService(userRepo, loginHistoryRepo, outbox),
+		TrustProxyHeaders:        cfg.Server.TrustProxyHeaders,
+		MagicLinkService:         magicLinkService,
+		PhoneOTPService:          phoneOTPService,
+		FederationService:        federationService,
+		FederationLandingURL:     federationLanding,
+		SAMLService:              samlService,
+		SCIMService:              scimService,
+		WebhookService:           webhookService,
 	})
 
 	// 3. 配置 HTTP 路由
 	r := chi.NewRouter()
 	r.Use(httptransport.LoggerMiddleware)
+	r.Use(httptransport.SecurityHeadersMiddleware(httptransport.SecurityHeadersConfig{
+		HSTSMaxAge:            cfg.SecurityHeaders.HSTSMaxAgeSeconds,
+		HSTSIncludeSubdomains: cfg.SecurityHeaders.HSTSIncludeSubdomains,
+		HSTSPreload:           cfg.SecurityHeaders.HSTSPreload,
+		ContentSecurityPolicy: cfg.SecurityHeaders.ContentSecurityPolicy,
+		ReferrerPolicy:        cfg.SecurityHeaders.ReferrerPolicy,
+		FrameOptions:          cfg.SecurityHeaders.FrameOptions,
+	}))
 	if len(cfg.Server.AllowedOrigins) > 0 {
 		r.Use(httptransport.CORSMiddleware(cfg.Server.AllowedOrigins))
 	}
+	// 按 IP / 邮箱限流
+	rl := newRateLimitFactory(cfg.RateLimit, userrepo.NewGORMRateLimitRepository(gormDB))
+	byIP := httptransport.ClientIPKey(cfg.Server.TrustProxyHeaders)
+	byEmail := httptransport.JSONFieldKey("email")
+	// 登录按提交的登录标识（邮箱 / 用户名 / 手机号）限流，沿用 login_email 规则
+	byLoginIdentifier := httptransport.LoginIdentifierKey()
+	loginLimits := []func(http.Handler) http.Handler{rl("login_ip", cfg.RateLimit.LoginIP, byIP), rl("login_email", cfg.RateLimit.LoginEmail, byLoginIdentifier)}
+	registerLimits := []func(http.Handler) http.Handler{rl("register_ip", cfg.RateLimit.RegisterIP, byIP)}
+	tokenLimits := []func(http.Handler) http.Handler{rl("token_ip", cfg.RateLimit.TokenIP, byIP)}
+	resetLimits := []func(http.Handler) http.Handler{rl("reset_ip", cfg.RateLimit.ResetIP, byIP), rl("reset_email", cfg.RateLimit.ResetEmail, byEmail)}
+	// 短信验证码登录按 IP 限流沿用 login_ip 规则；验证码本身另有失败次数上限
+	phoneLoginLimits := []func(http.Handler) http.Handler{rl("login_ip", cfg.RateLimit.LoginIP, byIP)}
+	// 第三方登录发起与回调同样按 IP 沿用 login_ip 规则
+	federationLimits := []func(http.Handler) http.Handler{rl("login_ip", cfg.RateLimit.LoginIP, byIP)}
+	smsLimits := []func(http.Handler) http.Handler{rl("sms_ip", cfg.RateLimit.SMSIP, byIP), rl("sms_phone", cfg.RateLimit.SMSPhone, httptransport.JSONFieldKey("phone_number"))}
+	magicLinkLimits := []func(http.Handler) http.Handler{rl("magic_link_ip", cfg.RateLimit.MagicLinkIP, byIP), rl("magic_link_email", cfg.RateLimit.MagicLinkEmail, byEmail)}
+	verifyEmailLimits := []func(http.Handler) http.Handler{rl("verify_email_ip", cfg.RateLimit.VerifyEmailIP, byIP)}
+
+	// 通过 auth_token Cookie 鉴权的修改类接口：开启 CSRF 防护时需携带 X-CSRF-Token
+	var csrfGuard []func(http.Handler) http.Handler
+	if cfg.Server.CSRFProtection {
+		csrf := httptransport.NewCSRFProtection(cfg.Server.JWTSecret, cookies)
+		csrfGuard = append(csrfGuard, csrf.Middleware)
+		r.Get("/api/v1/auth/csrf", csrf.TokenHandler)
+	}
+
 	r.Get("/api/v1/auth/request-login", httpHandler.SSORequestLoginHandler)
-	r.Post("/api/v1/auth/login", httpHandler.LoginHandler)
-	r.Post("/api/v1/auth/logout", httpHandler.LogoutHandler)
+	r.With(loginLimits...).Post("/api/v1/auth/login", httpHandler.LoginHandler)
+	r.Post("/api/v1/auth/login/mfa", httpHandler.LoginMFAHandler)
+	r.With(csrfGuard...).Post("/api/v1/auth/logout", httpHandler.LogoutHandler)
 	r.Get("/api/v1/auth/validate", httpHandler.ValidateHandler)
 	r.Get("/api/v1/auth/me", httpHandler.MeHandler)
-	r.Post("/api/v1/auth/upload", httpHandler.UploadHandler)
-	r.Post("/api/v1/auth/token", httpHandler.TokenHandler)
-	r.Post("/api/v1/auth/token-by-code", httpHandler.TokenByCodeHandler)
-	r.Post("/api/v1/auth/register", httpHandler.RegisterHandler)
+	r.Get("/api/v1/auth/me/activity", httpHandler.MyActivityHandler)
+	r.Get("/api/v1/auth/me/logins", httpHandler.MyLoginsHandler)
+	r.With(csrfGuard...).Patch("/api/v1/auth/me", httpHandler.UpdateMeHandler)
+	r.With(csrfGuard...).Post("/api/v1/auth/password", httpHandler.ChangePasswordHandler)
+	r.With(resetLimits...).Post("/api/v1/auth/password/forgot", httpHandler.ForgotPasswordHandler)
+	r.With(resetLimits...).Post("/api/v1/auth/password/reset", httpHandler.ResetPasswordHandler)
+	r.Get("/api/v1/auth/mfa", httpHandler.MFAStatusHandler)
+	r.With(csrfGuard...).Post("/api/v1/auth/mfa/totp/enroll", httpHandler.EnrollTOTPHandler)
+	r.With(csrfGuard...).Post("/api/v1/auth/mfa/totp/confirm", httpHandler.ConfirmTOTPHandler)
+	r.With(csrfGuard...).Post("/api/v1/auth/mfa/totp/disable", httpHandler.DisableTOTPHandler)
+	r.With(csrfGuard...).Post("/api/v1/auth/mfa/recovery-codes", httpHandler.RegenerateRecoveryCodesHandler)
+	r.With(csrfGuard...).Post("/api/v1/auth/webauthn/register/begin", httpHandler.WebAuthnBeginRegistrationHandler)
+	r.With(csrfGuard...).Post("/api/v1/auth/webauthn/register/finish", httpHandler.WebAuthnFinishRegistrationHandler)
+	r.Get("/api/v1/auth/webauthn/credentials", httpHandler.WebAuthnListCredentialsHandler)
+	r.With(csrfGuard...).Delete("/api/v1/auth/webauthn/credentials/{id}", httpHandler.WebAuthnDeleteCredentialHandler)
+	r.Post("/api/v1/auth/webauthn/login/begin", httpHandler.WebAuthnBeginLoginHandler)
+	r.Post("/api/v1/auth/webauthn/login/finish", httpHandler.WebAuthnFinishLoginHandler)
+	r.With(magicLinkLimits...).Post("/api/v1/auth/magic-link", httpHandler.MagicLinkRequestHandler)
+	r.With(tokenLimits...).Post("/api/v1/auth/magic-link/consume", httpHandler.MagicLinkConsumeHandler)
+	r.With(smsLimits...).Post("/api/v1/auth/phone/login/send", httpHandler.PhoneLoginSendHandler)
+	r.With(phoneLoginLimits...).Post("/api/v1/auth/phone/login", httpHandler.PhoneLoginHandler)
+	r.With(slices.Concat(csrfGuard, smsLimits)...).Post("/api/v1/auth/me/phone/send", httpHandler.SendPhoneVerificationHandler)
+	r.With(csrfGuard...).Post("/api/v1/auth/me/phone/verify", httpHandler.VerifyPhoneHandler)
+	r.With(csrfGuard...).Delete("/api/v1/auth/me/phone", httpHandler.RemovePhoneHandler)
+	r.Get("/api/v1/auth/federation/providers", httpHandler.FederationProvidersHandler)
+	r.With(federationLimits...).Get("/api/v1/auth/federation/{provider}/login", httpHandler.FederationLoginHandler)
+	r.With(federationLimits...).Get("/api/v1/auth/federation/{provider}/callback", httpHandler.FederationCallbackHandler)
+	r.With(tokenLimits...).Post("/api/v1/auth/federation/consume", httpHandler.FederationConsumeHandler)
+	r.Get("/api/v1/auth/me/identities", httpHandler.MyIdentitiesHandler)
+	r.With(csrfGuard...).Delete("/api/v1/auth/me/identities/{provider}", httpHandler.UnlinkIdentityHandler)
+	// SAML SP 以跨站表单 POST 投递 AuthnRequest，不经过 CSRF 校验；请求只登记待完成登录，不改变会话
+	r.Get("/api/v1/auth/saml/metadata", httpHandler.SAMLMetadataHandler)
+	r.With(federationLimits...).Get("/api/v1/auth/saml/sso", httpHandler.SAMLSSOHandler)
+	r.With(federationLimits...).Post("/api/v1/auth/saml/sso", httpHandler.SAMLSSOHandler)
+	r.Get("/api/v1/auth/saml/continue", httpHandler.SAMLContinueHandler)
+	// SCIM 同步接口以 bearer token 鉴权（不读取 Cookie），无需 CSRF 校验；未启用时没有客户端，请求一律 401
+	r.Route("/scim/v2", func(r chi.Router) {
+		r.Use(httptransport.NewSCIMAuth(scimClients).Middleware)
+		r.Get("/ServiceProviderConfig", httpHandler.SCIMServiceProviderConfigHandler)
+		r.Get("/Users", httpHandler.SCIMListUsersHandler)
+		r.Post("/Users", httpHandler.SCIMCreateUserHandler)
+		r.Get("/Users/{id}", httpHandler.SCIMGetUserHandler)
+		r.Put("/Users/{id}", httpHandler.SCIMReplaceUserHandler)
+		r.Patch("/Users/{id}", httpHandler.SCIMPatchUserHandler)
+		r.Delete("/Users/{id}", httpHandler.SCIMDeleteUserHandler)
+		r.Get("/Groups", httpHandler.SCIMListGroupsHandler)
+		r.Post("/Groups", httpHandler.SCIMCreateGroupHandler)
+		r.Get("/Groups/{id}", httpHandler.SCIMGetGroupHandler)
+		r.Put("/Groups/{id}", httpHandler.SCIMReplaceGroupHandler)
+		r.Patch("/Groups/{id}", httpHandler.SCIMPatchGroupHandler)
+		r.Delete("/Groups/{id}", httpHandler.SCIMDeleteGroupHandler)
+	})
+	r.With(csrfGuard...).Post("/api/v1/auth/upload", httpHandler.UploadHandler)
+	r.With(tokenLimits...).Post("/api/v1/auth/token", httpHandler.TokenHandler)
+	r.With(tokenLimits...).Post("/api/v1/auth/token-by-code", httpHandler.TokenByCodeHandler)
+	r.With(registerLimits...).Post("/api/v1/auth/register", httpHandler.RegisterHandler)
+	r.With(verifyEmailLimits...).Post("/api/v1/auth/verify-email", httpHandler.VerifyEmailHandler)
+	r.With(verifyEmailLimits...).Post("/api/v1/auth/verify-email/resend", httpHandler.ResendVerificationHandler)
+	r.With(csrfGuard...).Post("/api/v1/admin/users/{id}/unlock", httpHandler.AdminUnlockUserHandler)
+	r.Get("/api/v1/admin/audit-events", httpHandler.AdminAuditEventsHandler)
+	r.Get("/api/v1/admin/webhooks/deliveries", httpHandler.AdminWebhookDeliveriesHandler)
+	r.With(csrfGuard...).Post("/api/v1/admin/webhooks/deliveries/{id}/replay", httpHandler.AdminReplayWebhookDeliveryHandler)
 	// 上传文件的访问路径（跨域可访问 + 3 天缓存，便于前端另一域名下走缓存）
 	const staticCacheMaxAge = 3 * 24 * 3600 // 3 天
-	staticHandler := http.StripPrefix("/static", cacheControlHandler(http.FileServer(http.Dir(".")), staticCacheMaxAge))
-	r.Handle("/static/*", staticCORSHandler(staticHandler))
+	// 仅暴露 uploads 目录，工作目录下的配置文件等不可通过 /static 访问
+	staticHandler := http.StripPrefix("/static/uploads", cacheControlHandler(http.FileServer(http.Dir("uploads")), staticCacheMaxAge))
+	r.Handle("/static/uploads/*", staticSecurityHandler(staticHandler))
 
 	// 4. 启动服务
 	addr := fmt.Sprintf(":%s", cfg.Server.Port)
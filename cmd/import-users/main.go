@@ -0,0 +1,357 @@
+// import-users 从旧系统批量导入用户（保留原密码哈希，用户首次登录时自动升级为当前哈希算法）
+//
+// 在项目根目录执行:
+//
+//	go run ./cmd/import-users -file users.csv [-format csv|jsonl] [-dry-run] [-report conflicts.csv]
+//
+// CSV 首行为表头，JSONL 每行一个 JSON 对象，字段名相同：
+// email（必填）、password_hash（必填）、username、first_name、last_name、phone_number、avatar_url、status、role。
+// 支持的哈希格式见 auth.IdentifyHashFormat（bcrypt、argon2id、Django PBKDF2、phpass、加盐 SHA、LDAP {SSHA}）。
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"monai-auth/internal/auth"
+	"monai-auth/internal/domain"
+	userrepo "monai-auth/internal/repository/mysql"
+)
+
+// importRecord 单条待导入用户
+type importRecord struct {
+	Email        string `json:"email"`
+	PasswordHash string `json:"password_hash"`
+	Username     string `json:"username"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	PhoneNumber  string `json:"phone_number"`
+	AvatarURL    string `json:"avatar_url"`
+	Status       string `json:"status"`
+	Role         string `json:"role"`
+}
+
+// recordSource 逐条读取导入文件，line 为源文件行号（用于报告）
+type recordSource interface {
+	Next() (rec importRecord, line int, err error)
+}
+
+type csvSource struct {
+	r      *csv.Reader
+	header []string
+}
+
+func newCSVSource(r io.Reader) (*csvSource, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")))
+	}
+	return &csvSource{r: cr, header: header}, nil
+}
+
+func (s *csvSource) Next() (importRecord, int, error) {
+	row, err := s.r.Read()
+	line, _ := s.r.FieldPos(0)
+	if err != nil {
+		return importRecord{}, line, err
+	}
+	m := make(map[string]string, len(s.header))
+	for i, name := range s.header {
+		if i < len(row) {
+			m[name] = strings.TrimSpace(row[i])
+		}
+	}
+	return importRecord{
+		Email:        m["email"],
+		PasswordHash: m["password_hash"],
+		Username:     m["username"],
+		FirstName:    m["first_name"],
+		LastName:     m["last_name"],
+		PhoneNumber:  m["phone_number"],
+		AvatarURL:    m["avatar_url"],
+		Status:       m["status"],
+		Role:         m["role"],
+	}, line, nil
+}
+
+type jsonlSource struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+func newJSONLSource(r io.Reader) *jsonlSource {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	return &jsonlSource{sc: sc}
+}
+
+func (s *jsonlSource) Next() (importRecord, int, error) {
+	for s.sc.Scan() {
+		s.line++
+		text := strings.TrimSpace(s.sc.Text())
+		if text == "" {
+			continue
+		}
+		var rec importRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return importRecord{}, s.line, fmt.Errorf("invalid json: %w", err)
+		}
+		return rec, s.line, nil
+	}
+	if err := s.sc.Err(); err != nil {
+		return importRecord{}, s.line, err
+	}
+	return importRecord{}, s.line, io.EOF
+}
+
+// toUser 校验并转换为 domain.User，错误信息写入报告
+func toUser(rec importRecord) (*domain.User, error) {
+	email := domain.NormalizeEmail(rec.Email)
+	if err := domain.ValidateRegisterRequest(domain.RegisterRequest{Email: email}); err != nil {
+		return nil, err
+	}
+	hash := strings.TrimSpace(rec.PasswordHash)
+	if auth.IdentifyHashFormat(hash) == "" {
+		return nil, auth.ErrUnknownHashFormat
+	}
+	profile := domain.UpdateProfileRequest{
+		FirstName: &rec.FirstName,
+		LastName:  &rec.LastName,
+		AvatarURL: &rec.AvatarURL,
+	}
+	profile.Normalize()
+	if err := domain.ValidateUpdateProfileRequest(profile); err != nil {
+		return nil, err
+	}
+	// 导入的手机号视为未验证，用户需通过短信验证后才能用于登录
+	phone := strings.TrimSpace(rec.PhoneNumber)
+	if phone != "" {
+		var err error
+		if phone, err = domain.NormalizePhoneNumber(phone); err != nil {
+			return nil, err
+		}
+	}
+	// 用户名按注册规则校验：含 @ 时必须与该用户的邮箱一致
+	if username := domain.NormalizeUsername(rec.Username); username != "" {
+		if err := domain.ValidateRegisterRequest(domain.RegisterRequest{Email: email, Username: username}); err != nil {
+			return nil, err
+		}
+		profile.Username = &username
+	}
+	status := strings.ToLower(strings.TrimSpace(rec.Status))
+	switch status {
+	case "":
+		status = domain.UserStatusActive
+	case domain.UserStatusActive, domain.UserStatusInactive, domain.UserStatusSuspended, domain.UserStatusPending:
+	default:
+		return nil, fmt.Errorf("invalid status %q", rec.Status)
+	}
+	role := strings.ToLower(strings.TrimSpace(rec.Role))
+	switch role {
+	case "":
+		role = domain.RoleStandard
+	case domain.RoleStandard, domain.RoleAdmin:
+	default:
+		return nil, fmt.Errorf("invalid role %q", rec.Role)
+	}
+	user := &domain.User{Email: email, PasswordHash: hash, Status: status, Role: role, PhoneNumber: phone}
+	profile.Apply(user)
+	return user, nil
+}
+
+// conflictReason 将仓库错误映射为报告中的原因
+func conflictReason(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrEmailExists):
+		return "email_exists"
+	case errors.Is(err, domain.ErrUserExists):
+		return "username_exists"
+	case errors.Is(err, domain.ErrPhoneExists):
+		return "phone_exists"
+	default:
+		return ""
+	}
+}
+
+// openUserRepository 按 database.driver 连接 MySQL 或 PostgreSQL 并返回对应的用户仓库
+func openUserRepository(configDir string) domain.UserRepository {
+	viper.SetConfigName("config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath(configDir)
+	if err := viper.ReadInConfig(); err != nil {
+		log.Fatalf("读取配置失败: %v", err)
+	}
+	cfg := struct {
+		Driver  string `mapstructure:"driver"`
+		SSLMode string `mapstructure:"sslmode"`
+		Host    string `mapstructure:"host"`
+		Port    string `mapstructure:"port"`
+		User    string `mapstructure:"user"`
+		Pass    string `mapstructure:"password"`
+		DB      string `mapstructure:"dbname"`
+	}{}
+	if err := viper.UnmarshalKey("database", &cfg); err != nil {
+		log.Fatalf("解析配置失败: %v", err)
+	}
+	gormCfg := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+	switch cfg.Driver {
+	case "", "mysql":
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			cfg.User, cfg.Pass, cfg.Host, cfg.Port, cfg.DB)
+		db, err := gorm.Open(mysql.Open(dsn), gormCfg)
+		if err != nil {
+			log.Fatalf("连接数据库失败: %v", err)
+		}
+		return userrepo.NewGORMUserRepository(db)
+	case "postgres":
+		sslMode := cfg.SSLMode
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		dsn := (&url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(cfg.User, cfg.Pass),
+			Host:     net.JoinHostPort(cfg.Host, cfg.Port),
+			Path:     "/" + cfg.DB,
+			RawQuery: url.Values{"sslmode": {sslMode}}.Encode(),
+		}).String()
+		db, err := gorm.Open(postgres.Open(dsn), gormCfg)
+		if err != nil {
+			log.Fatalf("连接数据库失败: %v", err)
+		}
+		return userrepo.NewGORMUserRepository(db)
+	default:
+		log.Fatalf("不支持的 database.driver %q，请使用 mysql 或 postgres", cfg.Driver)
+		return nil
+	}
+}
+
+func main() {
+	file := flag.String("file", "", "导入文件路径（.csv 或 .jsonl）")
+	format := flag.String("format", "", "文件格式 csv | jsonl，默认按扩展名判断")
+	configDir := flag.String("config", "./configs", "config.yaml 所在目录")
+	dryRun := flag.Bool("dry-run", false, "只校验并检查邮箱冲突，不写入数据库")
+	reportPath := flag.String("report", "", "将被拒绝的行（冲突或不合法）写入该 CSV 文件")
+	flag.Parse()
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), ".")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("打开导入文件失败: %v", err)
+	}
+	defer f.Close()
+	var src recordSource
+	switch *format {
+	case "csv":
+		if src, err = newCSVSource(f); err != nil {
+			log.Fatal(err)
+		}
+	case "jsonl", "ndjson":
+		src = newJSONLSource(f)
+	default:
+		log.Fatalf("不支持的文件格式 %q，请使用 -format csv 或 jsonl", *format)
+	}
+
+	var report *csv.Writer
+	if *reportPath != "" {
+		rf, err := os.Create(*reportPath)
+		if err != nil {
+			log.Fatalf("创建报告文件失败: %v", err)
+		}
+		defer rf.Close()
+		report = csv.NewWriter(rf)
+		_ = report.Write([]string{"line", "email", "result", "detail"})
+		defer report.Flush()
+	}
+	reject := func(line int, email, result, detail string) {
+		log.Printf("line %d email=%s %s: %s", line, email, result, detail)
+		if report != nil {
+			_ = report.Write([]string{fmt.Sprint(line), email, result, detail})
+		}
+	}
+
+	ctx := context.Background()
+	repo := openUserRepository(*configDir)
+	var imported, conflicts, invalid int
+	for {
+		rec, line, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			invalid++
+			reject(line, "", "invalid", err.Error())
+			continue
+		}
+		user, err := toUser(rec)
+		if err != nil {
+			invalid++
+			reject(line, rec.Email, "invalid", err.Error())
+			continue
+		}
+		if *dryRun {
+			exists, err := repo.ExistsByEmail(ctx, user.Email)
+			if err != nil {
+				log.Fatalf("查询邮箱失败: %v", err)
+			}
+			if exists {
+				conflicts++
+				reject(line, user.Email, "conflict", "email_exists")
+				continue
+			}
+			imported++
+			continue
+		}
+		if err := repo.CreateUser(ctx, user); err != nil {
+			if reason := conflictReason(err); reason != "" {
+				conflicts++
+				reject(line, user.Email, "conflict", reason)
+				continue
+			}
+			log.Fatalf("line %d 写入失败: %v", line, err)
+		}
+		imported++
+	}
+
+	verb := "imported"
+	if *dryRun {
+		verb = "importable"
+	}
+	log.Printf("done: %s=%d conflicts=%d invalid=%d", verb, imported, conflicts, invalid)
+	if conflicts+invalid > 0 {
+		if report != nil {
+			report.Flush()
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,139 @@
+// mock-ldap 本地开发与联调用的最小 LDAP 服务器，用于在不接入真实 OpenLDAP / Active Directory 的情况下测试目录登录。
+//
+// 在项目根目录执行:
+//
+//	go run ./cmd/mock-ldap [-addr 127.0.0.1:3890] [-base dc=example,dc=com] [-bind-password admin]
+//	                       [-users alice:secret:alice@example.com:admins,bob:secret:bob@example.com]
+//
+// 对应 config.yaml:
+//
+//	ldap:
+//	  enabled: true
+//	  url: "ldap://127.0.0.1:3890"
+//	  bind_dn: "cn=admin,dc=example,dc=com"
+//	  bind_password: "admin"
+//	  base_dn: "ou=people,dc=example,dc=com"
+//	  user_filter: "(&(objectClass=inetOrgPerson)(|(uid={identifier})(mail={identifier})))"
+//	  member_of_attribute: "memberOf"
+//	  group_roles:
+//	    - group: "admins"
+//	      role: "admin"
+//
+// 目录结构：服务账号 cn=admin,<base>；用户 uid=<name>,ou=people,<base>（inetOrgPerson，带 entryUUID 与 memberOf）；
+// 组 cn=<group>,ou=groups,<base>（groupOfNames，member 为用户 DN）。所有数据仅保存在内存中。
+package main
+
+import (
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"monai-auth/internal/directory/ldaptest"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:3890", "listen address")
+	base := flag.String("base", "dc=example,dc=com", "base DN")
+	bindPassword := flag.String("bind-password", "admin", "password of the service account cn=admin,<base>")
+	users := flag.String("users", "alice:secret:alice@example.com:admins,bob:secret:bob@example.com",
+		"comma separated users as name:password:email[:group1;group2]")
+	flag.Parse()
+
+	entries, err := buildEntries(*base, *bindPassword, *users)
+	if err != nil {
+		log.Fatalf("invalid -users: %v", err)
+	}
+	srv, err := ldaptest.Start(*addr, entries...)
+	if err != nil {
+		log.Fatalf("listen failed: %v", err)
+	}
+	log.Printf("mock ldap listening on %s base=%s bind_dn=cn=admin,%s", srv.URL, *base, *base)
+	for _, e := range entries {
+		log.Printf("  %s", e.DN)
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	srv.Close()
+}
+
+// buildEntries 按参数构造目录条目：组织单位、服务账号、用户与组
+func buildEntries(base, bindPassword, spec string) ([]ldaptest.Entry, error) {
+	peopleDN := "ou=people," + base
+	groupsDN := "ou=groups," + base
+	entries := []ldaptest.Entry{
+		{DN: base, Attributes: map[string][]string{"objectClass": {"top", "domain"}}},
+		{DN: peopleDN, Attributes: map[string][]string{"objectClass": {"organizationalUnit"}, "ou": {"people"}}},
+		{DN: groupsDN, Attributes: map[string][]string{"objectClass": {"organizationalUnit"}, "ou": {"groups"}}},
+		{DN: "cn=admin," + base, Attributes: map[string][]string{
+			"objectClass":  {"organizationalRole"},
+			"cn":           {"admin"},
+			"userPassword": {bindPassword},
+		}},
+	}
+
+	members := make(map[string][]string) // 组名 -> 成员 DN
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		if len(parts) < 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("user %q must be name:password:email[:groups]", item)
+		}
+		name, password, email := parts[0], parts[1], parts[2]
+		dn := "uid=" + name + "," + peopleDN
+		attrs := map[string][]string{
+			"objectClass":  {"inetOrgPerson"},
+			"uid":          {name},
+			"cn":           {name},
+			"givenName":    {strings.ToUpper(name[:1]) + name[1:]},
+			"sn":           {"Example"},
+			"entryUUID":    {entryUUID(dn)},
+			"userPassword": {password},
+		}
+		if email != "" {
+			attrs["mail"] = []string{email}
+		}
+		if len(parts) > 3 {
+			for _, g := range strings.Split(parts[3], ";") {
+				if g = strings.TrimSpace(g); g != "" {
+					members[g] = append(members[g], dn)
+					attrs["memberOf"] = append(attrs["memberOf"], "cn="+g+","+groupsDN)
+				}
+			}
+		}
+		entries = append(entries, ldaptest.Entry{DN: dn, Attributes: attrs})
+	}
+
+	groups := make([]string, 0, len(members))
+	for g := range members {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	for _, g := range groups {
+		entries = append(entries, ldaptest.Entry{DN: "cn=" + g + "," + groupsDN, Attributes: map[string][]string{
+			"objectClass": {"groupOfNames"},
+			"cn":          {g},
+			"member":      members[g],
+		}})
+	}
+	return entries, nil
+}
+
+// entryUUID 由 DN 派生固定的 UUID，模拟 OpenLDAP 的 entryUUID；重启后保持不变，已绑定的本地账号仍能对应
+func entryUUID(dn string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(dn)))
+	b := sum[:16]
+	b[6] = b[6]&0x0f | 0x50
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,354 @@
+// mock-oidc 本地开发与联调用的最小 OIDC 提供方，用于在不接入真实 GitHub / Google 的情况下测试第三方登录。
+//
+// 在项目根目录执行:
+//
+//	go run ./cmd/mock-oidc [-addr :9999] [-issuer http://localhost:9999] [-client-id mock-client] [-client-secret mock-secret]
+//	                       [-email alice@example.com] [-email-verified=true] [-auto-approve]
+//
+// 对应 config.yaml:
+//
+//	federation:
+//	  enabled: true
+//	  providers:
+//	    - name: "mock"
+//	      type: "oidc"
+//	      issuer: "http://localhost:9999"
+//	      client_id: "mock-client"
+//	      client_secret: "mock-secret"
+//
+// 支持发现文档、JWKS、授权码 + PKCE（S256）、ID Token（RS256，含 nonce）与 UserInfo。授权页可修改本次登录的 sub 与邮箱；
+// -auto-approve 时直接使用命令行参数中的用户跳回，便于脚本化测试。所有状态仅保存在内存中。
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockKeyID JWKS 中唯一签名密钥的 kid
+const mockKeyID = "mock-oidc"
+
+// mockUser 本次授权对应的上游用户
+type mockUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// authCode 授权码绑定的参数
+type authCode struct {
+	user          mockUser
+	clientID      string
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	expiresAt     time.Time
+}
+
+type mockProvider struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	defaultUser  mockUser
+	autoApprove  bool
+	key          *rsa.PrivateKey
+
+	mu     sync.Mutex
+	codes  map[string]*authCode
+	tokens map[string]mockUser // access_token -> 用户
+}
+
+func main() {
+	addr := flag.String("addr", ":9999", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9999", "issuer URL (must match federation.providers[].issuer)")
+	clientID := flag.String("client-id", "mock-client", "accepted client_id")
+	clientSecret := flag.String("client-secret", "mock-secret", "accepted client_secret")
+	sub := flag.String("sub", "mock-user-1", "default subject")
+	email := flag.String("email", "alice@example.com", "default email")
+	emailVerified := flag.Bool("email-verified", true, "default email_verified claim")
+	name := flag.String("name", "Alice Mock", "default name")
+	autoApprove := flag.Bool("auto-approve", false, "redirect back immediately without showing the consent page")
+	flag.Parse()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("generate signing key: %v", err)
+	}
+	p := &mockProvider{
+		issuer:       strings.TrimSuffix(*issuer, "/"),
+		clientID:     *clientID,
+		clientSecret: *clientSecret,
+		defaultUser:  mockUser{Subject: *sub, Email: *email, EmailVerified: *emailVerified, Name: *name},
+		autoApprove:  *autoApprove,
+		key:          key,
+		codes:        make(map[string]*authCode),
+		tokens:       make(map[string]mockUser),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("GET /authorize", p.authorize)
+	mux.HandleFunc("POST /authorize", p.approve)
+	mux.HandleFunc("POST /token", p.token)
+	mux.HandleFunc("GET /userinfo", p.userinfo)
+
+	log.Printf("Mock OIDC provider issuer=%s client_id=%s listening on %s", p.issuer, p.clientID, *addr)
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		log.Fatalf("Could not start server: %v", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// oauthError 令牌端点错误响应（RFC 6749 5.2）
+func oauthError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func (p *mockProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.issuer,
+		"authorization_endpoint":                p.issuer + "/authorize",
+		"token_endpoint":                        p.issuer + "/token",
+		"userinfo_endpoint":                     p.issuer + "/userinfo",
+		"jwks_uri":                              p.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+		"scopes_supported":                      []string{"openid", "email", "profile"},
+	})
+}
+
+func (p *mockProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": mockKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+var consentPage = template.Must(template.New("consent").Parse(`<!doctype html>
+<html><head><meta charset="utf-8"><title>Mock OIDC sign-in</title></head>
+<body style="font-family: sans-serif; max-width: 28rem; margin: 3rem auto">
+<h2>Mock OIDC provider</h2>
+<p>Client <code>{{.ClientID}}</code> requests sign-in.</p>
+<form method="post" action="/authorize?{{.Query}}">
+<p><label>Subject<br><input name="sub" value="{{.User.Subject}}" size="40"></label></p>
+<p><label>Email<br><input name="email" value="{{.User.Email}}" size="40"></label></p>
+<p><label><input type="checkbox" name="email_verified" value="true" {{if .User.EmailVerified}}checked{{end}}> Email verified</label></p>
+<p><label>Name<br><input name="name" value="{{.User.Name}}" size="40"></label></p>
+<p><button name="action" value="approve">Sign in</button> <button name="action" value="deny">Deny</button></p>
+</form>
+</body></html>`))
+
+// validateAuthorize 校验授权请求参数，返回错误描述
+func (p *mockProvider) validateAuthorize(q url.Values) string {
+	switch {
+	case q.Get("response_type") != "code":
+		return "response_type must be code"
+	case q.Get("client_id") != p.clientID:
+		return "unknown client_id"
+	case q.Get("redirect_uri") == "":
+		return "redirect_uri is required"
+	case q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256":
+		return "PKCE with S256 is required"
+	case !strings.Contains(" "+q.Get("scope")+" ", " openid "):
+		return "scope must include openid"
+	}
+	return ""
+}
+
+func (p *mockProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if msg := p.validateAuthorize(q); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if p.autoApprove {
+		p.redirectWithCode(w, r, q, p.defaultUser)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = consentPage.Execute(w, map[string]interface{}{
+		"ClientID": q.Get("client_id"),
+		"Query":    template.URL(q.Encode()),
+		"User":     p.defaultUser,
+	})
+}
+
+func (p *mockProvider) approve(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if msg := p.validateAuthorize(q); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	if r.PostForm.Get("action") == "deny" {
+		u, _ := url.Parse(q.Get("redirect_uri"))
+		rq := u.Query()
+		rq.Set("error", "access_denied")
+		rq.Set("state", q.Get("state"))
+		u.RawQuery = rq.Encode()
+		http.Redirect(w, r, u.String(), http.StatusFound)
+		return
+	}
+	p.redirectWithCode(w, r, q, mockUser{
+		Subject:       r.PostForm.Get("sub"),
+		Email:         r.PostForm.Get("email"),
+		EmailVerified: r.PostForm.Get("email_verified") == "true",
+		Name:          r.PostForm.Get("name"),
+	})
+}
+
+func (p *mockProvider) redirectWithCode(w http.ResponseWriter, r *http.Request, q url.Values, user mockUser) {
+	u, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	code := randomString(16)
+	p.mu.Lock()
+	p.codes[code] = &authCode{
+		user:          user,
+		clientID:      q.Get("client_id"),
+		redirectURI:   q.Get("redirect_uri"),
+		codeChallenge: q.Get("code_challenge"),
+		nonce:         q.Get("nonce"),
+		expiresAt:     time.Now().Add(time.Minute),
+	}
+	p.mu.Unlock()
+	rq := u.Query()
+	rq.Set("code", code)
+	rq.Set("state", q.Get("state"))
+	u.RawQuery = rq.Encode()
+	log.Printf("[MOCK-OIDC] authorized sub=%s email=%s verified=%t", user.Subject, user.Email, user.EmailVerified)
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// clientCredentials 读取 client_secret_basic 或 client_secret_post 凭据
+func clientCredentials(r *http.Request) (string, string) {
+	if id, secret, ok := r.BasicAuth(); ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+		return id, secret
+	}
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+}
+
+func (p *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		oauthError(w, http.StatusBadRequest, "invalid_request", "invalid form")
+		return
+	}
+	id, secret := clientCredentials(r)
+	if id != p.clientID || subtle.ConstantTimeCompare([]byte(secret), []byte(p.clientSecret)) != 1 {
+		oauthError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		oauthError(w, http.StatusBadRequest, "unsupported_grant_type", "only authorization_code is supported")
+		return
+	}
+	p.mu.Lock()
+	code, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+	if !ok || time.Now().After(code.expiresAt) || code.clientID != id || code.redirectURI != r.PostForm.Get("redirect_uri") {
+		oauthError(w, http.StatusBadRequest, "invalid_grant", "invalid, expired or mismatched code")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != code.codeChallenge {
+		oauthError(w, http.StatusBadRequest, "invalid_grant", "PKCE verification failed")
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            p.issuer,
+		"sub":            code.user.Subject,
+		"aud":            id,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          code.nonce,
+		"email":          code.user.Email,
+		"email_verified": code.user.EmailVerified,
+		"name":           code.user.Name,
+	})
+	idToken.Header["kid"] = mockKeyID
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		oauthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	accessToken := randomString(24)
+	p.mu.Lock()
+	p.tokens[accessToken] = code.user
+	p.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func (p *mockProvider) userinfo(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	p.mu.Lock()
+	user, found := p.tokens[token]
+	p.mu.Unlock()
+	if !ok || !found {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sub":            user.Subject,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"name":           user.Name,
+	})
+}
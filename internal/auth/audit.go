@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+// 审计查询单页条数
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 200
+)
+
+// AuditService 记录与查询安全审计事件
+type AuditService interface {
+	// Record 记录一条事件；写入失败只记日志，不影响业务流程。
+	// 未指定 UserID 但带有 Email 时按邮箱补全所属用户，使失败的登录尝试也出现在用户的近期活动中
+	Record(ctx context.Context, event *domain.AuditEvent)
+	// List 按条件查询事件，Limit 超出范围时按默认值 / 上限处理
+	List(ctx context.Context, filter domain.AuditEventFilter) ([]*domain.AuditEvent, error)
+}
+
+// AuditConfig 审计配置
+type AuditConfig struct {
+	// Retention 事件保留时长，0 表示永久保留
+	Retention time.Duration
+}
+
+type auditService struct {
+	repo  domain.AuditEventRepository
+	users domain.UserRepository
+	cfg   AuditConfig
+}
+
+// NewAuditService 创建审计服务；Retention > 0 时后台定期清理过期事件
+func NewAuditService(repo domain.AuditEventRepository, users domain.UserRepository, cfg AuditConfig) AuditService {
+	s := &auditService{repo: repo, users: users, cfg: cfg}
+	if cfg.Retention > 0 {
+		go s.cleanup()
+	}
+	return s
+}
+
+func (s *auditService) Record(ctx context.Context, event *domain.AuditEvent) {
+	// 请求结束（如客户端断开）不应导致审计记录丢失
+	ctx = context.WithoutCancel(ctx)
+	event.Email = truncate(strings.ToLower(strings.TrimSpace(event.Email)), 255)
+	event.UserAgent = truncate(event.UserAgent, 512)
+	event.Reason = truncate(event.Reason, 255)
+	if event.UserID == 0 && event.Email != "" && s.users != nil {
+		// Email 为登录标识，可能是邮箱或用户名（手机号需已验证才能登录，不在此反查）
+		find := s.users.FindByUsername
+		if strings.Contains(event.Email, "@") {
+			find = s.users.FindByEmail
+		}
+		if user, err := find(ctx, event.Email); err == nil {
+			event.UserID = user.ID
+		}
+	}
+	if err := s.repo.Create(ctx, event); err != nil {
+		log.Printf("[AUDIT] record failed type=%s outcome=%s user_id=%d err=%v", event.Type, event.Outcome, event.UserID, err)
+	}
+}
+
+func (s *auditService) List(ctx context.Context, filter domain.AuditEventFilter) ([]*domain.AuditEvent, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAuditPageSize
+	}
+	if filter.Limit > MaxAuditPageSize {
+		filter.Limit = MaxAuditPageSize
+	}
+	return s.repo.List(ctx, filter)
+}
+
+func (s *auditService) cleanup() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := s.repo.DeleteBefore(context.Background(), time.Now().Add(-s.cfg.Retention)); err != nil {
+			log.Printf("[AUDIT] cleanup failed err=%v", err)
+		}
+	}
+}
+
+// truncate 按字节截断字符串并保证不截断多字节字符
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
@@ -7,17 +7,24 @@ import (
 	"time"
 )
 
-// CodeStore 授权码存储：code -> (userID, clientID, redirectURI)，一次性使用，短 TTL（如 5 分钟）
+// CodeGrant 授权码绑定的信息
+type CodeGrant struct {
+	UserID      int64
+	ClientID    string
+	RedirectURI string
+	// AMR 用户登录时使用的认证方式（如 pwd、otp、mfa），兑换 token 时写入 amr 声明
+	AMR []string
+}
+
+// CodeStore 授权码存储：code -> CodeGrant，一次性使用，短 TTL（如 5 分钟）
 type CodeStore interface {
-	Save(userID int64, clientID, redirectURI string) (code string, err error)
-	GetAndConsume(code string) (userID int64, clientID, redirectURI string, ok bool)
+	Save(grant CodeGrant) (code string, err error)
+	GetAndConsume(code string) (grant CodeGrant, ok bool)
 }
 
 type codeEntry struct {
-	userID      int64
-	clientID    string
-	redirectURI string
-	expiresAt   time.Time
+	grant     CodeGrant
+	expiresAt time.Time
 }
 
 // NewMemoryCodeStore 授权码内存存储，默认 TTL 5 分钟
@@ -37,7 +44,7 @@ type MemoryCodeStore struct {
 	store map[string]*codeEntry
 }
 
-func (c *MemoryCodeStore) Save(userID int64, clientID, redirectURI string) (string, error) {
+func (c *MemoryCodeStore) Save(grant CodeGrant) (string, error) {
 	b := make([]byte, 24)
 	if _, err := rand.Read(b); err != nil {
 		return "", err
@@ -45,19 +52,19 @@ func (c *MemoryCodeStore) Save(userID int64, clientID, redirectURI string) (stri
 	code := hex.EncodeToString(b)
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.store[code] = &codeEntry{userID: userID, clientID: clientID, redirectURI: redirectURI, expiresAt: time.Now().Add(c.ttl)}
+	c.store[code] = &codeEntry{grant: grant, expiresAt: time.Now().Add(c.ttl)}
 	return code, nil
 }
 
-func (c *MemoryCodeStore) GetAndConsume(code string) (userID int64, clientID, redirectURI string, ok bool) {
+func (c *MemoryCodeStore) GetAndConsume(code string) (CodeGrant, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	e, ok := c.store[code]
 	if !ok || e == nil || time.Now().After(e.expiresAt) {
-		return 0, "", "", false
+		return CodeGrant{}, false
 	}
 	delete(c.store, code)
-	return e.userID, e.clientID, e.redirectURI, true
+	return e.grant, true
 }
 
 func (c *MemoryCodeStore) cleanup() {
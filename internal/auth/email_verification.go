@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/mail"
+)
+
+// EmailVerificationService 注册邮箱验证流程：pending 用户通过邮件中的链接激活
+type EmailVerificationService interface {
+	// SendVerification 为用户生成验证令牌并发送验证邮件
+	SendVerification(ctx context.Context, user *domain.User) error
+	// Verify 校验令牌（一次性）并将 pending 用户激活为 active，同时作废注册时设置的密码，
+	// 返回用于设置密码的重置令牌（交给 ConfirmReset）；用户已不是 pending 时返回空字符串
+	Verify(ctx context.Context, token string) (string, error)
+	// Resend 为 pending 用户重新发送验证邮件；邮箱不存在、已激活或处于冷却期时静默忽略，避免枚举用户
+	Resend(ctx context.Context, email string) error
+}
+
+// EmailVerificationConfig 邮箱验证配置
+type EmailVerificationConfig struct {
+	// TTL 验证链接有效期，默认 24 小时
+	TTL time.Duration
+	// ResendInterval 同一用户两次发送的最小间隔，默认 1 分钟
+	ResendInterval time.Duration
+	// VerifyURL 验证页面完整地址，令牌以 ?token= 追加
+	VerifyURL string
+	// SetPasswordTTL 验证通过后签发的设置密码令牌有效期，默认 30 分钟
+	SetPasswordTTL time.Duration
+}
+
+type emailVerificationService struct {
+	users       domain.UserRepository
+	tokens      domain.EmailVerificationTokenRepository
+	resetTokens domain.PasswordResetTokenRepository
+	mailer      mail.Mailer
+	cfg         EmailVerificationConfig
+}
+
+// NewEmailVerificationService 创建邮箱验证服务；resetTokens 用于验证通过后签发设置密码的令牌
+func NewEmailVerificationService(users domain.UserRepository, tokens domain.EmailVerificationTokenRepository, resetTokens domain.PasswordResetTokenRepository, mailer mail.Mailer, cfg EmailVerificationConfig) EmailVerificationService {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if cfg.ResendInterval <= 0 {
+		cfg.ResendInterval = time.Minute
+	}
+	if cfg.SetPasswordTTL <= 0 {
+		cfg.SetPasswordTTL = 30 * time.Minute
+	}
+	return &emailVerificationService{users: users, tokens: tokens, resetTokens: resetTokens, mailer: mailer, cfg: cfg}
+}
+
+func (s *emailVerificationService) SendVerification(ctx context.Context, user *domain.User) error {
+	token, err := newRandomToken(32)
+	if err != nil {
+		return err
+	}
+	if err := s.tokens.Create(ctx, user.ID, hashToken(token), time.Now().Add(s.cfg.TTL)); err != nil {
+		return err
+	}
+	return s.sendMail(ctx, user, token)
+}
+
+// sendMail 发送包含验证链接的邮件
+func (s *emailVerificationService) sendMail(ctx context.Context, user *domain.User, token string) error {
+	link, err := appendQuery(s.cfg.VerifyURL, "token", token)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Hello %s,\n\n"+
+		"Thanks for signing up. Please confirm your email address by opening the link below within %d hours; "+
+		"you will then choose the password for your account:\n\n%s\n\n"+
+		"If you did not create an account, you can safely ignore this email.\n",
+		user.Username, int(s.cfg.TTL.Hours()), link)
+	if err := s.mailer.Send(ctx, mail.Message{To: user.Email, Subject: "Verify your email address", Body: body}); err != nil {
+		return fmt.Errorf("send verification mail: %w", err)
+	}
+	return nil
+}
+
+func (s *emailVerificationService) Verify(ctx context.Context, token string) (string, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", domain.ErrInvalidVerifyToken
+	}
+	userID, err := s.tokens.Consume(ctx, hashToken(token))
+	if err != nil {
+		return "", err
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	// 仅激活待验证用户，避免通过旧链接解封被停用的账号
+	if user.Status != domain.UserStatusPending {
+		return "", nil
+	}
+	// 注册者未必是邮箱所有者：保留注册时的密码会让抢注者在所有者点击验证链接后用自己的密码登录，
+	// 因此激活时清空密码，由点击链接（证明了邮箱归属）的人重新设置
+	if err := s.users.ActivatePending(ctx, userID); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			// 并发期间账号已被删除或状态已变更
+			return "", nil
+		}
+		return "", err
+	}
+	resetToken, err := newRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	if err := s.resetTokens.Create(ctx, userID, hashToken(resetToken), time.Now().Add(s.cfg.SetPasswordTTL)); err != nil {
+		// 账号已激活，用户仍可通过忘记密码设置密码
+		return "", fmt.Errorf("create set-password token: %w", err)
+	}
+	log.Printf("[AUTH] email verified user_id=%d", userID)
+	return resetToken, nil
+}
+
+func (s *emailVerificationService) Resend(ctx context.Context, email string) error {
+	email = domain.NormalizeEmail(email)
+	if email == "" {
+		return nil
+	}
+	user, err := s.users.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil
+		}
+		return fmt.Errorf("repository lookup error: %w", err)
+	}
+	if user.Status != domain.UserStatusPending {
+		return nil
+	}
+	token, err := newRandomToken(32)
+	if err != nil {
+		return err
+	}
+	// 冷却期检查与写入令牌原子完成，并发的重发请求只有一个会发信
+	now := time.Now()
+	created, err := s.tokens.CreateIfIdle(ctx, user.ID, hashToken(token), now.Add(s.cfg.TTL), now.Add(-s.cfg.ResendInterval))
+	if err != nil {
+		return err
+	}
+	if !created {
+		log.Printf("[AUTH] resend verification throttled user_id=%d", user.ID)
+		return nil
+	}
+	return s.sendMail(ctx, user, token)
+}
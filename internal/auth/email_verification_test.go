@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/mail"
+	"monai-auth/internal/repository/inmemory"
+)
+
+// recordingMailer 记录发出的邮件
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []mail.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func (m *recordingMailer) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sent)
+}
+
+func TestResendVerificationCooldown(t *testing.T) {
+	users := inmemory.NewInMemoryUserRepo()
+	mailer := &recordingMailer{}
+	svc := NewEmailVerificationService(users, inmemory.NewInMemoryEmailVerificationTokenRepo(), inmemory.NewInMemoryPasswordResetTokenRepo(), mailer,
+		EmailVerificationConfig{ResendInterval: 50 * time.Millisecond, VerifyURL: "https://auth.example.com/verify"})
+	ctx := context.Background()
+	user := &domain.User{Username: "newuser", Email: "newuser@example.com", Role: domain.RoleStandard, Status: domain.UserStatusPending}
+	if err := users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	// 并发重发只有一个请求能发信
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := svc.Resend(ctx, "NewUser@example.com"); err != nil {
+				t.Errorf("Resend: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := mailer.count(); got != 1 {
+		t.Fatalf("sent %d mails during cooldown, want 1", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := svc.Resend(ctx, "newuser@example.com"); err != nil {
+		t.Fatalf("Resend: %v", err)
+	}
+	if got := mailer.count(); got != 2 {
+		t.Fatalf("sent %d mails after cooldown, want 2", got)
+	}
+
+	// 未注册或已激活的邮箱静默忽略
+	for _, email := range []string{"unknown@example.com", "test@example.com", ""} {
+		if err := svc.Resend(ctx, email); err != nil {
+			t.Fatalf("Resend(%q): %v", email, err)
+		}
+	}
+	if got := mailer.count(); got != 2 {
+		t.Fatalf("sent %d mails, want 2", got)
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	users := inmemory.NewInMemoryUserRepo()
+	resetTokens := inmemory.NewInMemoryPasswordResetTokenRepo()
+	mailer := &recordingMailer{}
+	svc := NewEmailVerificationService(users, inmemory.NewInMemoryEmailVerificationTokenRepo(), resetTokens, mailer,
+		EmailVerificationConfig{VerifyURL: "https://auth.example.com/verify"})
+	ctx := context.Background()
+	// 抢注者以受害者邮箱注册并设置了自己的密码
+	user := &domain.User{Username: "newuser", Email: "newuser@example.com", PasswordHash: "squatter-hash", Role: domain.RoleStandard, Status: domain.UserStatusPending}
+	if err := users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	version := user.TokenVersion
+	if err := svc.SendVerification(ctx, user); err != nil {
+		t.Fatalf("SendVerification: %v", err)
+	}
+	_, token, ok := strings.Cut(mailer.sent[0].Body, "?token=")
+	if !ok {
+		t.Fatalf("mail has no verification link: %q", mailer.sent[0].Body)
+	}
+	token, _, _ = strings.Cut(token, "\n")
+
+	resetToken, err := svc.Verify(ctx, token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	stored, _ := users.FindByID(ctx, user.ID)
+	if stored.Status != domain.UserStatusActive {
+		t.Fatalf("status = %s, want active", stored.Status)
+	}
+	// 注册时的密码作废、已签发 token 失效，由邮箱所有者用返回的令牌设置密码
+	if stored.PasswordHash != "" || stored.TokenVersion != version+1 {
+		t.Fatalf("password_hash = %q token_version = %d, want cleared and %d", stored.PasswordHash, stored.TokenVersion, version+1)
+	}
+	if owner, err := resetTokens.Lookup(ctx, hashToken(resetToken)); err != nil || owner != user.ID {
+		t.Fatalf("reset token lookup = %d, %v", owner, err)
+	}
+	if _, err := svc.Verify(ctx, token); !errors.Is(err, domain.ErrInvalidVerifyToken) {
+		t.Fatalf("reused token: got %v, want ErrInvalidVerifyToken", err)
+	}
+}
+
+func TestVerifyEmailSkipsNonPendingUser(t *testing.T) {
+	users := inmemory.NewInMemoryUserRepo()
+	verifyTokens := inmemory.NewInMemoryEmailVerificationTokenRepo()
+	svc := NewEmailVerificationService(users, verifyTokens, inmemory.NewInMemoryPasswordResetTokenRepo(), &recordingMailer{},
+		EmailVerificationConfig{VerifyURL: "https://auth.example.com/verify"})
+	ctx := context.Background()
+	user := &domain.User{Username: "suspended", Email: "suspended@example.com", PasswordHash: "hash", Role: domain.RoleStandard, Status: domain.UserStatusSuspended}
+	if err := users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := verifyTokens.Create(ctx, user.ID, hashToken("old-link"), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// 旧验证链接不能解封被停用的账号，也不签发设置密码令牌
+	resetToken, err := svc.Verify(ctx, "old-link")
+	if err != nil || resetToken != "" {
+		t.Fatalf("Verify = %q, %v", resetToken, err)
+	}
+	if stored, _ := users.FindByID(ctx, user.ID); stored.Status != domain.UserStatusSuspended || stored.PasswordHash != "hash" {
+		t.Fatalf("suspended user modified: status=%s", stored.Status)
+	}
+}
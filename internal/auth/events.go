@@ -0,0 +1,265 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"maps"
+	"math/rand/v2"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/eventbus"
+)
+
+const (
+	outboxBatchSize = 100
+	outboxRetryBase = 5 * time.Second
+	outboxRetryMax  = 5 * time.Minute
+)
+
+// EventOutbox 领域事件发件箱：事件与引起它的状态变更在同一事务中写入 MySQL，由后台协程投递到各 Sink。
+// 投递保证至少一次：任一 Sink 失败时稍后对全部 Sink 重新投递，消费方应按事件 ID 去重。
+// 不保证投递顺序：失败事件重试期间同一用户的后续事件照常投递，多实例也会并发投递，消费方应以 OccurredAt 为准
+type EventOutbox interface {
+	// Run 在事务中执行状态变更 fn，并在同一事务中写入 fn 返回的事件；任一步失败整体回滚
+	Run(ctx context.Context, fn func(ctx context.Context) ([]domain.Event, error)) error
+	// Record 写入不伴随数据库状态变更的事件（如签发 token）；写入失败只记日志，不影响业务流程
+	Record(ctx context.Context, events ...domain.Event)
+}
+
+// OutboxConfig 发件箱配置
+type OutboxConfig struct {
+	// PollInterval 扫描到期事件（含失败重试）的间隔，缺省 2 秒；事件提交后立即投递，不等待扫描
+	PollInterval time.Duration
+	// Retention 已投递事件的保留时长，0 表示永久保留
+	Retention time.Duration
+	// MaxAttempts 每个事件最多投递次数（含首次），用尽后标记为失败不再重试；缺省 20（约 1 小时内按指数退避重试）
+	MaxAttempts int
+}
+
+type eventOutbox struct {
+	tx    domain.Transactor
+	repo  domain.OutboxRepository
+	sinks []eventbus.Sink
+	cfg   OutboxConfig
+	wake  chan struct{}
+}
+
+// NewEventOutbox 创建发件箱并在后台投递到 sinks；多实例部署时各实例共同投递，同一事件不会被并发领取
+func NewEventOutbox(tx domain.Transactor, repo domain.OutboxRepository, sinks []eventbus.Sink, cfg OutboxConfig) EventOutbox {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 2 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 20
+	}
+	o := &eventOutbox{tx: tx, repo: repo, sinks: sinks, cfg: cfg, wake: make(chan struct{}, 1)}
+	go o.run()
+	if cfg.Retention > 0 {
+		go o.cleanup()
+	}
+	return o
+}
+
+func (o *eventOutbox) Run(ctx context.Context, fn func(ctx context.Context) ([]domain.Event, error)) error {
+	err := o.tx.WithinTx(ctx, func(ctx context.Context) error {
+		events, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+		return o.write(ctx, events)
+	})
+	if err != nil {
+		return err
+	}
+	o.signal()
+	return nil
+}
+
+func (o *eventOutbox) Record(ctx context.Context, events ...domain.Event) {
+	if err := o.write(context.WithoutCancel(ctx), events); err != nil {
+		for _, e := range events {
+			log.Printf("[EVENT] record failed id=%s type=%s user_id=%d err=%v", e.ID, e.Type, e.UserID, err)
+		}
+		return
+	}
+	o.signal()
+}
+
+func (o *eventOutbox) write(ctx context.Context, events []domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	records := make([]*domain.OutboxRecord, 0, len(events))
+	for _, e := range events {
+		payload, err := eventbus.Encode(e)
+		if err != nil {
+			return err
+		}
+		records = append(records, &domain.OutboxRecord{
+			EventID:       e.ID,
+			EventType:     e.Type,
+			UserID:        e.UserID,
+			Payload:       payload,
+			NextAttemptAt: e.OccurredAt,
+			CreatedAt:     e.OccurredAt,
+		})
+	}
+	return o.repo.Create(ctx, records)
+}
+
+// signal 唤醒投递协程；已有未处理的唤醒时忽略
+func (o *eventOutbox) signal() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (o *eventOutbox) run() {
+	ticker := time.NewTicker(o.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-o.wake:
+		}
+		o.relay(context.Background())
+	}
+}
+
+// relay 逐条投递到期事件，批满时继续领取下一批
+func (o *eventOutbox) relay(ctx context.Context) {
+	for {
+		// 租约需覆盖一批事件的投递耗时，进程在投递中退出时事件于租约到期后由其他实例重新投递
+		batch, err := o.repo.ClaimDue(ctx, time.Now(), 5*time.Minute, outboxBatchSize)
+		if err != nil {
+			log.Printf("[EVENT] claim outbox events failed err=%v", err)
+			return
+		}
+		for _, rec := range batch {
+			o.publish(ctx, rec)
+		}
+		if len(batch) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+func (o *eventOutbox) publish(ctx context.Context, rec *domain.OutboxRecord) {
+	event, err := eventbus.Decode(rec.Payload)
+	if err == nil {
+		for _, sink := range o.sinks {
+			if err = sink.Publish(ctx, event); err != nil {
+				log.Printf("[EVENT] publish failed id=%s type=%s sink=%s attempts=%d err=%v", rec.EventID, rec.EventType, sink.Name(), rec.Attempts+1, err)
+				break
+			}
+		}
+	}
+	if err != nil {
+		attempts := rec.Attempts + 1
+		if attempts >= o.cfg.MaxAttempts {
+			log.Printf("[EVENT] giving up id=%s type=%s attempts=%d err=%v", rec.EventID, rec.EventType, attempts, err)
+			if err := o.repo.MarkFailed(ctx, rec.ID, attempts, time.Now(), truncate(err.Error(), 512)); err != nil {
+				log.Printf("[EVENT] mark failed error id=%s err=%v", rec.EventID, err)
+			}
+			return
+		}
+		if err := o.repo.Reschedule(ctx, rec.ID, attempts, time.Now().Add(retryDelay(attempts, outboxRetryBase, outboxRetryMax)), truncate(err.Error(), 512)); err != nil {
+			log.Printf("[EVENT] reschedule failed id=%s err=%v", rec.EventID, err)
+		}
+		return
+	}
+	if err := o.repo.MarkPublished(ctx, rec.ID, time.Now()); err != nil {
+		log.Printf("[EVENT] mark published failed id=%s err=%v", rec.EventID, err)
+	}
+}
+
+func (o *eventOutbox) cleanup() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := o.repo.DeletePublishedBefore(context.Background(), time.Now().Add(-o.cfg.Retention)); err != nil {
+			log.Printf("[EVENT] cleanup failed err=%v", err)
+		}
+	}
+}
+
+// eventEmitter 发出领域事件：启用发件箱时事件与状态变更在同一事务中写入，由发件箱投递到各 Sink（含 Webhook）；
+// 未启用时在状态变更成功后直接发布到 Webhook。两者均为 nil 时只执行状态变更
+type eventEmitter struct {
+	outbox   EventOutbox
+	webhooks WebhookPublisher
+}
+
+// run 执行状态变更 fn 并发出其返回的事件
+func (e eventEmitter) run(ctx context.Context, fn func(ctx context.Context) ([]domain.Event, error)) error {
+	if e.outbox != nil {
+		return e.outbox.Run(ctx, fn)
+	}
+	events, err := fn(ctx)
+	if err != nil {
+		return err
+	}
+	e.emit(ctx, events...)
+	return nil
+}
+
+// emit 发出不伴随状态变更的事件
+func (e eventEmitter) emit(ctx context.Context, events ...domain.Event) {
+	if e.outbox != nil {
+		e.outbox.Record(ctx, events...)
+		return
+	}
+	if e.webhooks != nil {
+		for _, event := range events {
+			_ = e.webhooks.Publish(ctx, event)
+		}
+	}
+}
+
+// newEvent 构造领域事件并生成事件 ID
+func newEvent(eventType string, userID int64, data map[string]any) domain.Event {
+	// crypto/rand 读取不会失败
+	id, _ := newRandomToken(16)
+	return domain.Event{ID: "evt_" + id, Type: eventType, UserID: userID, Data: data, OccurredAt: time.Now()}
+}
+
+// userEvent 构造用户生命周期事件：data 包含用户基本信息，extra 中的字段合并到 data
+func userEvent(eventType string, user *domain.User, extra map[string]any) domain.Event {
+	data := map[string]any{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"email":    user.Email,
+		"status":   user.Status,
+		"role":     user.Role,
+	}
+	maps.Copy(data, extra)
+	return newEvent(eventType, user.ID, data)
+}
+
+// statusChangeEvents 账号由 active 变为停用时返回 user.suspended，停用账号恢复为 active 时返回 user.reactivated；
+// pending 账号完成激活不属于二者
+func statusChangeEvents(user *domain.User, previous string) []domain.Event {
+	extra := map[string]any{"previous_status": previous}
+	switch {
+	case previous == domain.UserStatusActive && user.Status != domain.UserStatusActive:
+		return []domain.Event{userEvent(domain.EventUserSuspended, user, extra)}
+	case (previous == domain.UserStatusInactive || previous == domain.UserStatusSuspended) && user.Status == domain.UserStatusActive:
+		return []domain.Event{userEvent(domain.EventUserReactivated, user, extra)}
+	}
+	return nil
+}
+
+// retryDelay 第 attempts 次失败后的等待时间：从 base 起翻倍，不超过 max，加 ±20% 抖动避免集中重试
+func retryDelay(attempts int, base, max time.Duration) time.Duration {
+	delay := max
+	if attempts <= 30 {
+		delay = min(base<<(attempts-1), max)
+	}
+	if delay <= 0 {
+		delay = max
+	}
+	jitter := time.Duration(rand.Int64N(int64(delay)*2/5+1)) - delay/5
+	return delay + jitter
+}
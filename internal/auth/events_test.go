@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/eventbus"
+	"monai-auth/internal/repository/inmemory"
+)
+
+// flakySink 前 failures 次投递返回错误
+type flakySink struct {
+	failures  int
+	calls     int
+	published []string
+}
+
+func (s *flakySink) Name() string { return "flaky" }
+
+func (s *flakySink) Publish(ctx context.Context, event domain.Event) error {
+	s.calls++
+	if s.calls <= s.failures {
+		return errors.New("broker unavailable")
+	}
+	s.published = append(s.published, event.ID)
+	return nil
+}
+
+// relayOnce 以 at 为当前时间领取一批到期事件并投递，返回领取到的事件
+func relayOnce(t *testing.T, o *eventOutbox, at time.Time) []*domain.OutboxRecord {
+	t.Helper()
+	ctx := context.Background()
+	batch, err := o.repo.ClaimDue(ctx, at, time.Minute, outboxBatchSize)
+	if err != nil {
+		t.Fatalf("ClaimDue: %v", err)
+	}
+	for _, rec := range batch {
+		o.publish(ctx, rec)
+	}
+	return batch
+}
+
+func TestOutboxGivesUpAfterMaxAttempts(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int
+		wantPublished bool
+	}{
+		{"succeeds on last attempt", 2, true},
+		{"exhausts attempts", 100, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &flakySink{failures: tt.failures}
+			o := &eventOutbox{repo: inmemory.NewInMemoryOutboxRepo(), sinks: []eventbus.Sink{sink}, cfg: OutboxConfig{MaxAttempts: 3}}
+			event := newEvent(domain.EventUserRegistered, 42, nil)
+			if err := o.write(context.Background(), []domain.Event{event}); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+
+			// 每轮把时间推后一小时，越过重试退避
+			at := time.Now()
+			for round := 0; round < 3; round++ {
+				batch := relayOnce(t, o, at)
+				if len(batch) != 1 {
+					t.Fatalf("round %d: claimed %d records, want 1", round, len(batch))
+				}
+				if batch[0].Attempts != round {
+					t.Fatalf("round %d: attempts = %d", round, batch[0].Attempts)
+				}
+				at = at.Add(time.Hour)
+			}
+			if got := len(sink.published) == 1; got != tt.wantPublished {
+				t.Fatalf("published = %v, want %v", sink.published, tt.wantPublished)
+			}
+			// 已投递或已标记失败的事件都不再领取
+			if batch := relayOnce(t, o, at.Add(24*time.Hour)); len(batch) != 0 {
+				t.Fatalf("claimed %d records after final attempt", len(batch))
+			}
+			if sink.calls != 3 {
+				t.Fatalf("sink called %d times, want 3", sink.calls)
+			}
+		})
+	}
+}
+
+func TestOutboxFailedEventDoesNotBlockOthers(t *testing.T) {
+	sink := &flakySink{failures: 1}
+	o := &eventOutbox{repo: inmemory.NewInMemoryOutboxRepo(), sinks: []eventbus.Sink{sink}, cfg: OutboxConfig{MaxAttempts: 3}}
+	first := newEvent(domain.EventUserRegistered, 42, nil)
+	second := newEvent(domain.EventUserEmailChanged, 42, nil)
+	if err := o.write(context.Background(), []domain.Event{first, second}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	// 不保证顺序：第一个事件重试期间，同一用户的后续事件照常投递
+	relayOnce(t, o, time.Now())
+	if len(sink.published) != 1 || sink.published[0] != second.ID {
+		t.Fatalf("published = %v, want [%s]", sink.published, second.ID)
+	}
+	relayOnce(t, o, time.Now().Add(time.Hour))
+	if len(sink.published) != 2 || sink.published[1] != first.ID {
+		t.Fatalf("published = %v, want retried %s", sink.published, first.ID)
+	}
+}
@@ -0,0 +1,382 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/federation"
+)
+
+// AMRFederated 通过上游身份提供方完成的认证（RFC 8176 未定义，沿用常见取值）
+const AMRFederated = "fed"
+
+// FederationFlow 发起第三方登录时保存的一次性参数，回调时按 state 取回
+type FederationFlow struct {
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	// ServerState 发起时登录页持有的 server_state，为空表示非 SSO 登录
+	ServerState string
+}
+
+// FederationGrant 回调成功后签发的一次性登录票据绑定的信息
+type FederationGrant struct {
+	UserID      int64
+	Provider    string
+	ServerState string
+}
+
+// FederationStore 第三方登录的流程参数（state -> FederationFlow）与登录票据（ticket -> FederationGrant）存储，均一次性使用
+type FederationStore interface {
+	SaveFlow(flow FederationFlow) (state string, err error)
+	ConsumeFlow(state string) (FederationFlow, bool)
+	SaveGrant(grant FederationGrant) (ticket string, err error)
+	ConsumeGrant(ticket string) (FederationGrant, bool)
+}
+
+type federationEntry[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+// MemoryFederationStore 实现 FederationStore
+type MemoryFederationStore struct {
+	flowTTL  time.Duration
+	grantTTL time.Duration
+	mu       sync.Mutex
+	flows    map[string]*federationEntry[FederationFlow]
+	grants   map[string]*federationEntry[FederationGrant]
+}
+
+// NewMemoryFederationStore 流程参数默认 10 分钟有效（用户在提供方登录页的时间），登录票据默认 2 分钟有效
+func NewMemoryFederationStore(flowTTL, grantTTL time.Duration) *MemoryFederationStore {
+	if flowTTL <= 0 {
+		flowTTL = 10 * time.Minute
+	}
+	if grantTTL <= 0 {
+		grantTTL = 2 * time.Minute
+	}
+	s := &MemoryFederationStore{
+		flowTTL:  flowTTL,
+		grantTTL: grantTTL,
+		flows:    make(map[string]*federationEntry[FederationFlow]),
+		grants:   make(map[string]*federationEntry[FederationGrant]),
+	}
+	go s.cleanup()
+	return s
+}
+
+func (s *MemoryFederationStore) SaveFlow(flow FederationFlow) (string, error) {
+	state, err := newRandomToken(24)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flows[state] = &federationEntry[FederationFlow]{value: flow, expiresAt: time.Now().Add(s.flowTTL)}
+	return state, nil
+}
+
+func (s *MemoryFederationStore) ConsumeFlow(state string) (FederationFlow, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.flows[state]
+	if !ok || e == nil || time.Now().After(e.expiresAt) {
+		return FederationFlow{}, false
+	}
+	delete(s.flows, state)
+	return e.value, true
+}
+
+func (s *MemoryFederationStore) SaveGrant(grant FederationGrant) (string, error) {
+	ticket, err := newRandomToken(24)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grants[ticket] = &federationEntry[FederationGrant]{value: grant, expiresAt: time.Now().Add(s.grantTTL)}
+	return ticket, nil
+}
+
+func (s *MemoryFederationStore) ConsumeGrant(ticket string) (FederationGrant, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.grants[ticket]
+	if !ok || e == nil || time.Now().After(e.expiresAt) {
+		return FederationGrant{}, false
+	}
+	delete(s.grants, ticket)
+	return e.value, true
+}
+
+func (s *MemoryFederationStore) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mu.Lock()
+		now := time.Now()
+		for k, e := range s.flows {
+			if e != nil && now.After(e.expiresAt) {
+				delete(s.flows, k)
+			}
+		}
+		for k, e := range s.grants {
+			if e != nil && now.After(e.expiresAt) {
+				delete(s.grants, k)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// FederationProviderInfo 登录页展示的提供方
+type FederationProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// FederationService 通过上游身份提供方（GitHub、Google、OIDC）登录
+type FederationService interface {
+	// Providers 返回已配置的提供方（按名称排序）
+	Providers() []FederationProviderInfo
+	// Begin 生成 state、PKCE 与 nonce 并返回提供方授权地址；state 需由调用方绑定到浏览器（Cookie）
+	Begin(ctx context.Context, provider, serverState string) (authURL, state string, err error)
+	// Callback 校验 state、用授权码换取上游身份并解析本地用户（已绑定 / 按已验证邮箱绑定 / 自动注册），
+	// 返回一次性登录票据
+	Callback(ctx context.Context, provider, state, code string) (ticket string, err error)
+	// Consume 消费登录票据，返回用户与 server_state
+	Consume(ctx context.Context, ticket string) (FederationGrant, error)
+	// ListIdentities 列出用户已绑定的第三方账号
+	ListIdentities(ctx context.Context, userID int64) ([]*domain.UserIdentity, error)
+	// Unlink 解除用户在指定提供方的绑定
+	Unlink(ctx context.Context, userID int64, provider string) error
+}
+
+// FederationConfig 第三方登录配置
+type FederationConfig struct {
+	// CallbackBaseURL 回调地址前缀，实际回调地址为 <CallbackBaseURL>/<provider>/callback，需在提供方后台登记
+	CallbackBaseURL string
+	// AutoRegister 已验证邮箱不存在对应账号时自动创建用户；关闭时返回 domain.ErrFederatedAccountNotFound
+	AutoRegister bool
+	// Webhooks 非 nil 且未设置 Outbox 时自动创建用户后直接发布 user.registered 事件
+	Webhooks WebhookPublisher
+	// Outbox 非 nil 时 user.registered 与自动创建用户在同一事务中写入发件箱
+	Outbox EventOutbox
+}
+
+type federationService struct {
+	users      domain.UserRepository
+	identities domain.UserIdentityRepository
+	store      FederationStore
+	providers  map[string]federation.Provider
+	cfg        FederationConfig
+}
+
+// NewFederationService 创建第三方登录服务
+func NewFederationService(users domain.UserRepository, identities domain.UserIdentityRepository, store FederationStore, providers []federation.Provider, cfg FederationConfig) FederationService {
+	m := make(map[string]federation.Provider, len(providers))
+	for _, p := range providers {
+		m[p.Name()] = p
+	}
+	cfg.CallbackBaseURL = strings.TrimSuffix(cfg.CallbackBaseURL, "/")
+	return &federationService{users: users, identities: identities, store: store, providers: m, cfg: cfg}
+}
+
+func (s *federationService) Providers() []FederationProviderInfo {
+	out := make([]FederationProviderInfo, 0, len(s.providers))
+	for _, p := range s.providers {
+		out = append(out, FederationProviderInfo{Name: p.Name(), DisplayName: p.DisplayName()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (s *federationService) callbackURL(provider string) string {
+	return s.cfg.CallbackBaseURL + "/" + provider + "/callback"
+}
+
+func (s *federationService) Begin(ctx context.Context, provider, serverState string) (string, string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", domain.ErrUnknownProvider
+	}
+	verifier, err := newRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := newRandomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	state, err := s.store.SaveFlow(FederationFlow{
+		Provider:     provider,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ServerState:  strings.TrimSpace(serverState),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("save federation flow: %w", err)
+	}
+	authURL, err := p.AuthCodeURL(ctx, federation.AuthRequest{
+		State:         state,
+		Nonce:         nonce,
+		CodeChallenge: federation.CodeChallengeS256(verifier),
+		RedirectURI:   s.callbackURL(provider),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", domain.ErrFederationFailed, err)
+	}
+	return authURL, state, nil
+}
+
+func (s *federationService) Callback(ctx context.Context, provider, state, code string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", domain.ErrUnknownProvider
+	}
+	flow, ok := s.store.ConsumeFlow(state)
+	if !ok || flow.Provider != provider || code == "" {
+		return "", domain.ErrInvalidFederationState
+	}
+	identity, err := p.Exchange(ctx, federation.Exchange{
+		Code:         code,
+		CodeVerifier: flow.CodeVerifier,
+		RedirectURI:  s.callbackURL(provider),
+		Nonce:        flow.Nonce,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrFederationFailed, err)
+	}
+	user, err := s.resolveUser(ctx, provider, identity)
+	if err != nil {
+		return "", err
+	}
+	ticket, err := s.store.SaveGrant(FederationGrant{UserID: user.ID, Provider: provider, ServerState: flow.ServerState})
+	if err != nil {
+		return "", fmt.Errorf("save federation grant: %w", err)
+	}
+	return ticket, nil
+}
+
+// claimPendingUser 外部身份证明邮箱归属后激活 pending 账号，并清空本地密码、使已签发的 token 失效。
+// pending 账号可能是他人用该邮箱抢注的：保留注册时设置的密码会让抢注者在邮箱所有者激活账号后用自己的密码登录
+func claimPendingUser(ctx context.Context, users domain.UserRepository, user *domain.User) error {
+	if err := users.ActivatePending(ctx, user.ID); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			// 并发期间账号已被删除或状态已变更
+			return domain.ErrInvalidCredentials
+		}
+		return err
+	}
+	log.Printf("[AUTH] pending account claimed by external identity user_id=%d", user.ID)
+	user.Status = domain.UserStatusActive
+	user.PasswordHash = ""
+	user.TokenVersion++
+	return nil
+}
+
+// resolveUser 按 (provider, subject) 查找已绑定用户；未绑定时按已验证邮箱匹配本地账号并绑定，
+// 无匹配账号且开启自动注册时创建新用户
+func (s *federationService) resolveUser(ctx context.Context, provider string, identity *federation.Identity) (*domain.User, error) {
+	email := domain.NormalizeEmail(identity.Email)
+	now := time.Now()
+	linked, err := s.identities.FindByProviderSubject(ctx, provider, identity.Subject)
+	switch {
+	case err == nil:
+		user, err := s.users.FindByID(ctx, linked.UserID)
+		if err != nil {
+			if errors.Is(err, domain.ErrUserNotFound) {
+				return nil, domain.ErrInvalidCredentials
+			}
+			return nil, fmt.Errorf("repository lookup error: %w", err)
+		}
+		if user.Status != domain.UserStatusActive {
+			return nil, domain.ErrInvalidCredentials
+		}
+		if err := s.identities.UpdateLogin(ctx, linked.ID, email, now); err != nil {
+			log.Printf("[AUTH] update federated identity failed user_id=%d provider=%s err=%v", user.ID, provider, err)
+		}
+		return user, nil
+	case !errors.Is(err, domain.ErrIdentityNotFound):
+		return nil, fmt.Errorf("identity lookup error: %w", err)
+	}
+
+	// 未绑定：只信任提供方确认过的邮箱，否则任何人都能在提供方填写他人邮箱接管本地账号
+	if email == "" || !identity.EmailVerified {
+		return nil, domain.ErrFederatedEmailUnverified
+	}
+	user, err := s.users.FindByEmail(ctx, email)
+	switch {
+	case err == nil:
+		switch user.Status {
+		case domain.UserStatusActive:
+		case domain.UserStatusPending:
+			// 提供方已证明邮箱归属，视同完成邮箱验证；注册时设置的密码同时作废，防止他人抢注邮箱后坐等认领
+			if err := claimPendingUser(ctx, s.users, user); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, domain.ErrInvalidCredentials
+		}
+	case errors.Is(err, domain.ErrUserNotFound):
+		if !s.cfg.AutoRegister {
+			return nil, domain.ErrFederatedAccountNotFound
+		}
+		// 不设置密码：用户可通过忘记密码流程设置后再使用密码登录
+		user = &domain.User{
+			Username: email,
+			Email:    email,
+			Role:     domain.RoleStandard,
+			Status:   domain.UserStatusActive,
+		}
+		events := eventEmitter{outbox: s.cfg.Outbox, webhooks: s.cfg.Webhooks}
+		err := events.run(ctx, func(ctx context.Context) ([]domain.Event, error) {
+			if err := s.users.CreateUser(ctx, user); err != nil {
+				return nil, err
+			}
+			return []domain.Event{userEvent(domain.EventUserRegistered, user, map[string]any{"source": "federation", "provider": provider})}, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("[AUTH] federated user registered user_id=%d provider=%s", user.ID, provider)
+	default:
+		return nil, fmt.Errorf("repository lookup error: %w", err)
+	}
+
+	if err := s.identities.Create(ctx, &domain.UserIdentity{
+		UserID:      user.ID,
+		Provider:    provider,
+		Subject:     identity.Subject,
+		Email:       email,
+		LastLoginAt: &now,
+	}); err != nil {
+		// ErrIdentityExists：该用户已绑定同一提供方的另一个账号，不自动替换
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *federationService) Consume(ctx context.Context, ticket string) (FederationGrant, error) {
+	grant, ok := s.store.ConsumeGrant(strings.TrimSpace(ticket))
+	if !ok {
+		return FederationGrant{}, domain.ErrInvalidFederationTicket
+	}
+	return grant, nil
+}
+
+func (s *federationService) ListIdentities(ctx context.Context, userID int64) ([]*domain.UserIdentity, error) {
+	return s.identities.ListByUser(ctx, userID)
+}
+
+func (s *federationService) Unlink(ctx context.Context, userID int64, provider string) error {
+	return s.identities.Delete(ctx, userID, provider)
+}
@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/federation"
+	"monai-auth/internal/repository/inmemory"
+)
+
+// stubProvider 模拟上游提供方：按授权码返回预设身份，并校验 PKCE 与 nonce 与发起时一致
+type stubProvider struct {
+	identities map[string]*federation.Identity
+	challenge  string
+	nonce      string
+}
+
+func (p *stubProvider) Name() string        { return "corp" }
+func (p *stubProvider) DisplayName() string { return "Corp SSO" }
+
+func (p *stubProvider) AuthCodeURL(ctx context.Context, req federation.AuthRequest) (string, error) {
+	p.challenge, p.nonce = req.CodeChallenge, req.Nonce
+	return "https://idp.example.com/authorize?" + url.Values{"state": {req.State}}.Encode(), nil
+}
+
+func (p *stubProvider) Exchange(ctx context.Context, req federation.Exchange) (*federation.Identity, error) {
+	if federation.CodeChallengeS256(req.CodeVerifier) != p.challenge {
+		return nil, errors.New("code_verifier does not match code_challenge")
+	}
+	if req.Nonce != p.nonce {
+		return nil, errors.New("nonce mismatch")
+	}
+	identity, ok := p.identities[req.Code]
+	if !ok {
+		return nil, errors.New("invalid_grant")
+	}
+	return identity, nil
+}
+
+type federationFixture struct {
+	svc        FederationService
+	provider   *stubProvider
+	users      *inmemory.InMemoryUserRepo
+	identities *inmemory.InMemoryUserIdentityRepo
+}
+
+func newFederationFixture(autoRegister bool) *federationFixture {
+	f := &federationFixture{
+		provider:   &stubProvider{identities: map[string]*federation.Identity{}},
+		users:      inmemory.NewInMemoryUserRepo(),
+		identities: inmemory.NewInMemoryUserIdentityRepo(),
+	}
+	f.svc = NewFederationService(f.users, f.identities, NewMemoryFederationStore(0, 0),
+		[]federation.Provider{f.provider}, FederationConfig{CallbackBaseURL: "https://auth.example.com/federation", AutoRegister: autoRegister})
+	return f
+}
+
+// login 走完整的 Begin -> Callback -> Consume 流程
+func (f *federationFixture) login(t *testing.T, identity *federation.Identity) (FederationGrant, error) {
+	t.Helper()
+	_, state, err := f.svc.Begin(context.Background(), "corp", "")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	f.provider.identities["code-"+identity.Subject] = identity
+	ticket, err := f.svc.Callback(context.Background(), "corp", state, "code-"+identity.Subject)
+	if err != nil {
+		return FederationGrant{}, err
+	}
+	return f.svc.Consume(context.Background(), ticket)
+}
+
+func (f *federationFixture) createUser(t *testing.T, email, status string) *domain.User {
+	t.Helper()
+	user := &domain.User{Username: email, Email: email, PasswordHash: "registrant-hash", Role: domain.RoleStandard, Status: status}
+	if err := f.users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return user
+}
+
+func TestFederationCallbackState(t *testing.T) {
+	f := newFederationFixture(true)
+	ctx := context.Background()
+	f.provider.identities["code"] = &federation.Identity{Subject: "s1", Email: "a@example.com", EmailVerified: true}
+
+	if _, err := f.svc.Callback(ctx, "corp", "unknown-state", "code"); !errors.Is(err, domain.ErrInvalidFederationState) {
+		t.Fatalf("unknown state: got %v, want ErrInvalidFederationState", err)
+	}
+	if _, err := f.svc.Callback(ctx, "other", "any", "code"); !errors.Is(err, domain.ErrUnknownProvider) {
+		t.Fatalf("unknown provider: got %v, want ErrUnknownProvider", err)
+	}
+
+	_, state, err := f.svc.Begin(ctx, "corp", "")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err := f.svc.Callback(ctx, "corp", state, ""); !errors.Is(err, domain.ErrInvalidFederationState) {
+		t.Fatalf("empty code: got %v, want ErrInvalidFederationState", err)
+	}
+	// state 为一次性：上面的失败请求已消费
+	if _, err := f.svc.Callback(ctx, "corp", state, "code"); !errors.Is(err, domain.ErrInvalidFederationState) {
+		t.Fatalf("reused state: got %v, want ErrInvalidFederationState", err)
+	}
+
+	_, state, _ = f.svc.Begin(ctx, "corp", "")
+	ticket, err := f.svc.Callback(ctx, "corp", state, "code")
+	if err != nil {
+		t.Fatalf("Callback: %v", err)
+	}
+	if _, err := f.svc.Consume(ctx, ticket); err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if _, err := f.svc.Consume(ctx, ticket); !errors.Is(err, domain.ErrInvalidFederationTicket) {
+		t.Fatalf("reused ticket: got %v, want ErrInvalidFederationTicket", err)
+	}
+}
+
+func TestFederationCallbackPKCE(t *testing.T) {
+	f := newFederationFixture(true)
+	ctx := context.Background()
+	f.provider.identities["code"] = &federation.Identity{Subject: "s1", Email: "a@example.com", EmailVerified: true}
+
+	_, state, _ := f.svc.Begin(ctx, "corp", "")
+	// 第二次发起覆盖了提供方记录的 challenge 与 nonce，第一次的 state 携带的 verifier 不再匹配
+	if _, _, err := f.svc.Begin(ctx, "corp", ""); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err := f.svc.Callback(ctx, "corp", state, "code"); !errors.Is(err, domain.ErrFederationFailed) {
+		t.Fatalf("mismatched verifier: got %v, want ErrFederationFailed", err)
+	}
+}
+
+func TestFederationResolveUser(t *testing.T) {
+	tests := []struct {
+		name         string
+		autoRegister bool
+		existing     string // 同邮箱本地账号的状态，空表示不存在
+		identity     federation.Identity
+		wantErr      error
+	}{
+		{"unverified email", true, domain.UserStatusActive, federation.Identity{Subject: "s1", Email: "u@example.com"}, domain.ErrFederatedEmailUnverified},
+		{"empty email", true, "", federation.Identity{Subject: "s1", EmailVerified: true}, domain.ErrFederatedEmailUnverified},
+		{"link active", false, domain.UserStatusActive, federation.Identity{Subject: "s1", Email: "U@Example.com", EmailVerified: true}, nil},
+		{"suspended", true, domain.UserStatusSuspended, federation.Identity{Subject: "s1", Email: "u@example.com", EmailVerified: true}, domain.ErrInvalidCredentials},
+		{"no account", false, "", federation.Identity{Subject: "s1", Email: "u@example.com", EmailVerified: true}, domain.ErrFederatedAccountNotFound},
+		{"auto register", true, "", federation.Identity{Subject: "s1", Email: "u@example.com", EmailVerified: true}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFederationFixture(tt.autoRegister)
+			var existing *domain.User
+			if tt.existing != "" {
+				existing = f.createUser(t, "u@example.com", tt.existing)
+			}
+			grant, err := f.login(t, &tt.identity)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if _, err := f.identities.FindByProviderSubject(context.Background(), "corp", tt.identity.Subject); !errors.Is(err, domain.ErrIdentityNotFound) {
+					t.Fatalf("identity linked after failed login: %v", err)
+				}
+				return
+			}
+			if existing != nil && grant.UserID != existing.ID {
+				t.Fatalf("grant user = %d, want %d", grant.UserID, existing.ID)
+			}
+			linked, err := f.identities.FindByProviderSubject(context.Background(), "corp", tt.identity.Subject)
+			if err != nil || linked.UserID != grant.UserID {
+				t.Fatalf("identity not linked to user %d: %+v %v", grant.UserID, linked, err)
+			}
+		})
+	}
+}
+
+func TestFederationClaimsPendingAccount(t *testing.T) {
+	f := newFederationFixture(false)
+	ctx := context.Background()
+	pending := f.createUser(t, "victim@example.com", domain.UserStatusPending)
+	version := pending.TokenVersion
+
+	grant, err := f.login(t, &federation.Identity{Subject: "s1", Email: "victim@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if grant.UserID != pending.ID {
+		t.Fatalf("grant user = %d, want %d", grant.UserID, pending.ID)
+	}
+	user, _ := f.users.FindByID(ctx, pending.ID)
+	if user.Status != domain.UserStatusActive {
+		t.Fatalf("status = %s, want active", user.Status)
+	}
+	// 注册时设置的密码可能属于抢注者，认领后必须作废
+	if user.PasswordHash != "" {
+		t.Fatalf("registrant password kept after claim")
+	}
+	if user.TokenVersion != version+1 {
+		t.Fatalf("token_version = %d, want %d", user.TokenVersion, version+1)
+	}
+}
+
+func TestFederationLinkedSubject(t *testing.T) {
+	f := newFederationFixture(true)
+	ctx := context.Background()
+	identity := &federation.Identity{Subject: "s1", Email: "u@example.com", EmailVerified: true}
+	first, err := f.login(t, identity)
+	if err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+
+	// 已绑定的 subject 不再要求邮箱已验证，也不随提供方邮箱变化而改绑
+	again, err := f.login(t, &federation.Identity{Subject: "s1", Email: "changed@example.com"})
+	if err != nil || again.UserID != first.UserID {
+		t.Fatalf("repeat login: user=%d err=%v, want user %d", again.UserID, err, first.UserID)
+	}
+
+	if err := f.users.UpdateStatus(ctx, first.UserID, domain.UserStatusSuspended); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+	if _, err := f.login(t, identity); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("suspended linked user: got %v, want ErrInvalidCredentials", err)
+	}
+}
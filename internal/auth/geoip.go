@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"fmt"
+	"math"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// GeoLocation IP 对应的地理位置
+type GeoLocation struct {
+	// Country ISO 3166-1 两位国家代码，无法解析时为空
+	Country string
+	// Latitude / Longitude 仅 City 级数据库提供
+	Latitude  *float64
+	Longitude *float64
+}
+
+// hasCoordinates 是否带经纬度
+func (l *GeoLocation) hasCoordinates() bool {
+	return l != nil && l.Latitude != nil && l.Longitude != nil
+}
+
+// GeoIPResolver 按 IP 解析地理位置
+type GeoIPResolver interface {
+	// Lookup 返回 ip 的地理位置；内网地址或库中无记录时返回空 Country
+	Lookup(ip net.IP) (*GeoLocation, error)
+}
+
+// MaxMindGeoIP 读取本地 MaxMind 格式数据库（GeoLite2-Country / GeoLite2-City 的 .mmdb 文件）
+type MaxMindGeoIP struct {
+	db *maxminddb.Reader
+}
+
+// NewMaxMindGeoIP 打开本地 .mmdb 文件
+func NewMaxMindGeoIP(path string) (*MaxMindGeoIP, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open geoip database: %w", err)
+	}
+	return &MaxMindGeoIP{db: db}, nil
+}
+
+// Close 关闭数据库文件
+func (g *MaxMindGeoIP) Close() error {
+	return g.db.Close()
+}
+
+func (g *MaxMindGeoIP) Lookup(ip net.IP) (*GeoLocation, error) {
+	var record struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+		Location struct {
+			Latitude  *float64 `maxminddb:"latitude"`
+			Longitude *float64 `maxminddb:"longitude"`
+		} `maxminddb:"location"`
+	}
+	if err := g.db.Lookup(ip, &record); err != nil {
+		return nil, fmt.Errorf("geoip lookup: %w", err)
+	}
+	return &GeoLocation{
+		Country:   record.Country.ISOCode,
+		Latitude:  record.Location.Latitude,
+		Longitude: record.Location.Longitude,
+	}, nil
+}
+
+// distanceKm 两点间的大圆距离（haversine）
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	rad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := rad(lat2 - lat1)
+	dLon := rad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
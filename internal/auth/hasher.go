@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 密码哈希算法
+const (
+	HashAlgorithmBcrypt   = "bcrypt"
+	HashAlgorithmArgon2id = "argon2id"
+)
+
+// ErrUnknownHashFormat 无法识别的密码哈希格式
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// PasswordHasher 密码哈希：新密码使用首选算法，校验时兼容所有已支持的格式
+type PasswordHasher interface {
+	// Hash 使用首选算法与参数生成自描述的编码哈希
+	Hash(password string) (string, error)
+	// Verify 校验密码；needsRehash 为 true 表示哈希的算法或参数已过时，应在校验通过后重新哈希保存
+	Verify(encoded, password string) (ok bool, needsRehash bool, err error)
+}
+
+// Argon2idParams argon2id 参数，编码在哈希中：$argon2id$v=19$m=<KiB>,t=<迭代>,p=<并行度>$<salt>$<hash>
+type Argon2idParams struct {
+	MemoryKiB   uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams 默认参数：64 MiB、3 次迭代、并行度 2（约 50ms）
+func DefaultArgon2idParams() Argon2idParams {
+	return Argon2idParams{MemoryKiB: 64 * 1024, Iterations: 3, Parallelism: 2, SaltLength: 16, KeyLength: 32}
+}
+
+// PasswordHasherConfig 密码哈希配置
+type PasswordHasherConfig struct {
+	// Algorithm 首选算法：bcrypt（默认）或 argon2id
+	Algorithm  string
+	BcryptCost int
+	Argon2id   Argon2idParams
+}
+
+type passwordHasher struct {
+	cfg PasswordHasherConfig
+}
+
+// NewPasswordHasher 创建密码哈希器，未设置的参数使用默认值
+func NewPasswordHasher(cfg PasswordHasherConfig) (PasswordHasher, error) {
+	switch cfg.Algorithm {
+	case "":
+		cfg.Algorithm = HashAlgorithmBcrypt
+	case HashAlgorithmBcrypt, HashAlgorithmArgon2id:
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", cfg.Algorithm)
+	}
+	if cfg.BcryptCost == 0 {
+		cfg.BcryptCost = bcrypt.DefaultCost
+	}
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	def := DefaultArgon2idParams()
+	if cfg.Argon2id.MemoryKiB == 0 {
+		cfg.Argon2id.MemoryKiB = def.MemoryKiB
+	}
+	if cfg.Argon2id.Iterations == 0 {
+		cfg.Argon2id.Iterations = def.Iterations
+	}
+	if cfg.Argon2id.Parallelism == 0 {
+		cfg.Argon2id.Parallelism = def.Parallelism
+	}
+	if cfg.Argon2id.SaltLength == 0 {
+		cfg.Argon2id.SaltLength = def.SaltLength
+	}
+	if cfg.Argon2id.KeyLength == 0 {
+		cfg.Argon2id.KeyLength = def.KeyLength
+	}
+	return &passwordHasher{cfg: cfg}, nil
+}
+
+// DefaultPasswordHasher bcrypt、默认 cost，与引入可配置哈希前的行为一致
+func DefaultPasswordHasher() PasswordHasher {
+	return &passwordHasher{cfg: PasswordHasherConfig{
+		Algorithm:  HashAlgorithmBcrypt,
+		BcryptCost: bcrypt.DefaultCost,
+		Argon2id:   DefaultArgon2idParams(),
+	}}
+}
+
+func (h *passwordHasher) Hash(password string) (string, error) {
+	if h.cfg.Algorithm == HashAlgorithmArgon2id {
+		return hashArgon2id(password, h.cfg.Argon2id)
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.cfg.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+func (h *passwordHasher) Verify(encoded, password string) (bool, bool, error) {
+	switch {
+	case isBcryptHash(encoded):
+		if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, false, nil
+			}
+			return false, false, err
+		}
+		if h.cfg.Algorithm != HashAlgorithmBcrypt {
+			return true, true, nil
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return true, err != nil || cost != h.cfg.BcryptCost, nil
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		params, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, false, err
+		}
+		other := argon2.IDKey([]byte(password), salt, params.Iterations, params.MemoryKiB, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(key, other) != 1 {
+			return false, false, nil
+		}
+		want := h.cfg.Argon2id
+		outdated := h.cfg.Algorithm != HashAlgorithmArgon2id ||
+			params.MemoryKiB != want.MemoryKiB || params.Iterations != want.Iterations ||
+			params.Parallelism != want.Parallelism || uint32(len(key)) != want.KeyLength
+		return true, outdated, nil
+	default:
+		// 迁移导入的旧系统哈希：校验通过后一律需要升级为当前算法
+		ok, err := verifyLegacyHash(encoded, password)
+		return ok, ok, err
+	}
+}
+
+func isBcryptHash(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func hashArgon2id(password string, p Argon2idParams) (string, error) {
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.MemoryKiB, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.MemoryKiB, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	var p Argon2idParams
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.MemoryKiB, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2 params: %w", err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2 salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2 hash: %w", err)
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"monai-auth/internal/directory"
+	"monai-auth/internal/domain"
+)
+
+// LDAPIdentityProvider 目录账号在 user_identities.provider 中的名称，subject 为目录条目的唯一标识（entryUUID / objectGUID）
+const LDAPIdentityProvider = "ldap"
+
+// 目录登录模式
+const (
+	// DirectoryModeFirst 先查目录：目录中不存在该账号或目录不可用时回退到本地密码校验
+	DirectoryModeFirst = "first"
+	// DirectoryModeOnly 仅使用目录，不再校验本地密码
+	DirectoryModeOnly = "only"
+)
+
+// ErrDirectoryUserNotFound 目录中没有该账号，由调用方决定是否回退到本地密码校验
+var ErrDirectoryUserNotFound = errors.New("directory user not found")
+
+// DirectoryAuthenticator 外部账号目录（LDAP / Active Directory）认证
+type DirectoryAuthenticator interface {
+	// Authenticate 在目录中校验账号密码，通过后返回对应的本地用户（首次登录时创建并绑定）。
+	// 密码错误或不允许登录返回 domain.ErrInvalidCredentials，目录中不存在返回 ErrDirectoryUserNotFound，
+	// 目录不可用返回包装 domain.ErrDirectoryUnavailable 的错误
+	Authenticate(ctx context.Context, identifier, password string) (*domain.User, error)
+}
+
+// DirectoryRoleMapping 目录组到本地角色的映射；Group 为组的完整 DN 或组名（DN 第一个 RDN 的值，如 cn）
+type DirectoryRoleMapping struct {
+	Group string
+	Role  string
+}
+
+// LDAPConfig 目录登录的本地策略
+type LDAPConfig struct {
+	// AllowedGroups 非空时仅这些组的成员可登录
+	AllowedGroups []string
+	// GroupRoles 按顺序匹配，第一个命中的映射决定角色，每次登录同步到本地用户；为空时不同步角色（新用户为 standard）
+	GroupRoles []DirectoryRoleMapping
+	// DefaultRole 配置了 GroupRoles 但没有命中时的角色，为空时为 standard
+	DefaultRole string
+	// Webhooks 非 nil 且未设置 Outbox 时首次登录创建用户后直接发布 user.registered 事件
+	Webhooks WebhookPublisher
+	// Outbox 非 nil 时 user.registered 与创建用户在同一事务中写入发件箱
+	Outbox EventOutbox
+}
+
+type ldapAuthenticator struct {
+	client     *directory.Client
+	users      domain.UserRepository
+	identities domain.UserIdentityRepository
+	cfg        LDAPConfig
+}
+
+// NewLDAPAuthenticator 创建目录认证器
+func NewLDAPAuthenticator(client *directory.Client, users domain.UserRepository, identities domain.UserIdentityRepository, cfg LDAPConfig) DirectoryAuthenticator {
+	if cfg.DefaultRole == "" {
+		cfg.DefaultRole = domain.RoleStandard
+	}
+	return &ldapAuthenticator{client: client, users: users, identities: identities, cfg: cfg}
+}
+
+func (a *ldapAuthenticator) Authenticate(ctx context.Context, identifier, password string) (*domain.User, error) {
+	entry, err := a.client.Authenticate(ctx, strings.TrimSpace(identifier), password)
+	switch {
+	case errors.Is(err, directory.ErrInvalidCredentials):
+		return nil, domain.ErrInvalidCredentials
+	case errors.Is(err, directory.ErrUserNotFound):
+		return nil, ErrDirectoryUserNotFound
+	case errors.Is(err, directory.ErrUnavailable):
+		return nil, fmt.Errorf("%w: %v", domain.ErrDirectoryUnavailable, err)
+	case err != nil:
+		return nil, err
+	}
+	if len(a.cfg.AllowedGroups) > 0 && !a.inAnyGroup(entry, a.cfg.AllowedGroups) {
+		log.Printf("[AUTH] ldap user not in allowed groups dn=%s", entry.DN)
+		return nil, domain.ErrInvalidCredentials
+	}
+	user, err := a.resolveUser(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	if len(a.cfg.GroupRoles) > 0 {
+		if role := a.role(entry); role != user.Role {
+			if err := a.users.UpdateRole(ctx, user.ID, role); err != nil {
+				return nil, fmt.Errorf("update role: %w", err)
+			}
+			log.Printf("[AUTH] ldap role synced user_id=%d role=%s->%s", user.ID, user.Role, role)
+			user.Role = role
+		}
+	}
+	return user, nil
+}
+
+// resolveUser 按目录条目唯一标识查找已绑定用户；未绑定时按目录邮箱匹配本地账号并绑定，无匹配账号时创建用户
+func (a *ldapAuthenticator) resolveUser(ctx context.Context, entry *directory.Entry) (*domain.User, error) {
+	email := domain.NormalizeEmail(entry.Email)
+	now := time.Now()
+	linked, err := a.identities.FindByProviderSubject(ctx, LDAPIdentityProvider, entry.ID)
+	switch {
+	case err == nil:
+		user, err := a.users.FindByID(ctx, linked.UserID)
+		if err != nil {
+			if errors.Is(err, domain.ErrUserNotFound) {
+				return nil, domain.ErrInvalidCredentials
+			}
+			return nil, fmt.Errorf("repository lookup error: %w", err)
+		}
+		if err := a.activate(ctx, user); err != nil {
+			return nil, err
+		}
+		if err := a.identities.UpdateLogin(ctx, linked.ID, email, now); err != nil {
+			log.Printf("[AUTH] update ldap identity failed user_id=%d err=%v", user.ID, err)
+		}
+		return user, nil
+	case !errors.Is(err, domain.ErrIdentityNotFound):
+		return nil, fmt.Errorf("identity lookup error: %w", err)
+	}
+
+	// 目录中的邮箱由企业管理员维护，视为已验证；没有邮箱的条目无法对应本地账号
+	if email == "" || domain.ValidateRegisterRequest(domain.RegisterRequest{Email: email}) != nil {
+		log.Printf("[AUTH] ldap entry has no valid email dn=%s", entry.DN)
+		return nil, domain.ErrInvalidCredentials
+	}
+	user, err := a.users.FindByEmail(ctx, email)
+	switch {
+	case err == nil:
+		if err := a.activate(ctx, user); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, domain.ErrUserNotFound):
+		if user, err = a.provision(ctx, entry, email); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("repository lookup error: %w", err)
+	}
+
+	if err := a.identities.Create(ctx, &domain.UserIdentity{
+		UserID:      user.ID,
+		Provider:    LDAPIdentityProvider,
+		Subject:     entry.ID,
+		Email:       email,
+		LastLoginAt: &now,
+	}); err != nil {
+		// ErrIdentityExists：该用户已绑定另一个目录条目，不自动替换
+		return nil, err
+	}
+	return user, nil
+}
+
+// provision 首次登录时按目录条目创建本地用户：优先使用目录用户名，格式不符或已被占用时使用邮箱；不设置本地密码
+func (a *ldapAuthenticator) provision(ctx context.Context, entry *directory.Entry, email string) (*domain.User, error) {
+	username := domain.NormalizeUsername(entry.Username)
+	if username == "" || domain.ValidateRegisterRequest(domain.RegisterRequest{Email: email, Username: username}) != nil {
+		username = email
+	} else if _, err := a.users.FindByUsername(ctx, username); err == nil {
+		username = email
+	} else if !errors.Is(err, domain.ErrUserNotFound) {
+		return nil, fmt.Errorf("repository lookup error: %w", err)
+	}
+	user := &domain.User{
+		Username:  username,
+		Email:     email,
+		FirstName: truncateRunes(entry.FirstName, domain.MaxNameLength),
+		LastName:  truncateRunes(entry.LastName, domain.MaxNameLength),
+		Role:      domain.RoleStandard,
+		Status:    domain.UserStatusActive,
+	}
+	if len(a.cfg.GroupRoles) > 0 {
+		user.Role = a.role(entry)
+	}
+	events := eventEmitter{outbox: a.cfg.Outbox, webhooks: a.cfg.Webhooks}
+	err := events.run(ctx, func(ctx context.Context) ([]domain.Event, error) {
+		if err := a.users.CreateUser(ctx, user); err != nil {
+			return nil, err
+		}
+		return []domain.Event{userEvent(domain.EventUserRegistered, user, map[string]any{"source": "ldap"})}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[AUTH] ldap user provisioned user_id=%d dn=%s", user.ID, entry.DN)
+	return user, nil
+}
+
+// activate 目录已证明账号归属：pending 用户视同完成邮箱验证，注册时设置的本地密码同时作废；停用或封禁的本地账号不允许登录
+func (a *ldapAuthenticator) activate(ctx context.Context, user *domain.User) error {
+	switch user.Status {
+	case domain.UserStatusActive:
+		return nil
+	case domain.UserStatusPending:
+		return claimPendingUser(ctx, a.users, user)
+	default:
+		return domain.ErrInvalidCredentials
+	}
+}
+
+// role 返回第一个命中的组映射的角色，没有命中时为 DefaultRole
+func (a *ldapAuthenticator) role(entry *directory.Entry) string {
+	for _, m := range a.cfg.GroupRoles {
+		if directory.GroupMatches(entry.Groups, m.Group) {
+			return m.Role
+		}
+	}
+	return a.cfg.DefaultRole
+}
+
+func (a *ldapAuthenticator) inAnyGroup(entry *directory.Entry, groups []string) bool {
+	for _, g := range groups {
+		if directory.GroupMatches(entry.Groups, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateRunes 截断到最多 n 个字符，避免目录中的超长姓名写入失败
+func truncateRunes(s string, n int) string {
+	r := []rune(strings.TrimSpace(s))
+	if len(r) > n {
+		return string(r[:n])
+	}
+	return string(r)
+}
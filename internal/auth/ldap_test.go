@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"monai-auth/internal/directory"
+	"monai-auth/internal/directory/ldaptest"
+	"monai-auth/internal/domain"
+	"monai-auth/internal/repository/inmemory"
+)
+
+const ldapTestBaseDN = "dc=example,dc=com"
+
+type ldapFixture struct {
+	auth       DirectoryAuthenticator
+	users      *inmemory.InMemoryUserRepo
+	identities *inmemory.InMemoryUserIdentityRepo
+}
+
+func ldapTestUser(uid, password, email string, groups ...string) ldaptest.Entry {
+	attrs := map[string][]string{
+		"objectClass":  {"inetOrgPerson"},
+		"uid":          {uid},
+		"givenName":    {uid},
+		"sn":           {"Example"},
+		"entryUUID":    {"uuid-" + uid},
+		"userPassword": {password},
+	}
+	if email != "" {
+		attrs["mail"] = []string{email}
+	}
+	for _, g := range groups {
+		attrs["memberOf"] = append(attrs["memberOf"], "cn="+g+",ou=groups,"+ldapTestBaseDN)
+	}
+	return ldaptest.Entry{DN: "uid=" + uid + ",ou=people," + ldapTestBaseDN, Attributes: attrs}
+}
+
+func newLDAPFixture(t *testing.T, cfg LDAPConfig) *ldapFixture {
+	t.Helper()
+	srv := ldaptest.NewServer(
+		ldaptest.Entry{DN: "ou=people," + ldapTestBaseDN, Attributes: map[string][]string{"objectClass": {"organizationalUnit"}}},
+		ldapTestUser("alice", "alice-pass", "alice@example.com", "admins"),
+		ldapTestUser("bob", "bob-pass", "bob@example.com", "developers"),
+		ldapTestUser("carol", "carol-pass", "carol@example.com"),
+		ldapTestUser("nomail", "nomail-pass", ""),
+	)
+	t.Cleanup(srv.Close)
+	client, err := directory.New(directory.Config{
+		URL:               srv.URL,
+		BaseDN:            "ou=people," + ldapTestBaseDN,
+		UserFilter:        "(|(uid={identifier})(mail={identifier}))",
+		MemberOfAttribute: "memberOf",
+	})
+	if err != nil {
+		t.Fatalf("directory.New: %v", err)
+	}
+	f := &ldapFixture{users: inmemory.NewInMemoryUserRepo(), identities: inmemory.NewInMemoryUserIdentityRepo()}
+	f.auth = NewLDAPAuthenticator(client, f.users, f.identities, cfg)
+	return f
+}
+
+func (f *ldapFixture) createUser(t *testing.T, username, email, status string) *domain.User {
+	t.Helper()
+	user := &domain.User{Username: username, Email: email, PasswordHash: "registrant-hash", Role: domain.RoleStandard, Status: status}
+	if err := f.users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return user
+}
+
+func TestLDAPAuthenticateCredentials(t *testing.T) {
+	f := newLDAPFixture(t, LDAPConfig{})
+	tests := []struct {
+		name       string
+		identifier string
+		password   string
+		wantErr    error
+	}{
+		{"success", "alice", "alice-pass", nil},
+		{"wrong password", "alice", "bob-pass", domain.ErrInvalidCredentials},
+		{"empty password", "alice", "", domain.ErrInvalidCredentials},
+		{"not in directory", "dave", "dave-pass", ErrDirectoryUserNotFound},
+		{"wildcard identifier", "ali*", "alice-pass", ErrDirectoryUserNotFound},
+		{"no email", "nomail", "nomail-pass", domain.ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.auth.Authenticate(context.Background(), tt.identifier, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLDAPFirstLoginProvisionsUser(t *testing.T) {
+	f := newLDAPFixture(t, LDAPConfig{GroupRoles: []DirectoryRoleMapping{{Group: "admins", Role: domain.RoleAdmin}}})
+	ctx := context.Background()
+
+	user, err := f.auth.Authenticate(ctx, "alice", "alice-pass")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.Username != "alice" || user.Email != "alice@example.com" || user.Role != domain.RoleAdmin ||
+		user.Status != domain.UserStatusActive || user.PasswordHash != "" {
+		t.Fatalf("unexpected provisioned user %+v", user)
+	}
+	linked, err := f.identities.FindByProviderSubject(ctx, LDAPIdentityProvider, "uuid-alice")
+	if err != nil || linked.UserID != user.ID {
+		t.Fatalf("identity not linked: %+v %v", linked, err)
+	}
+
+	// 再次登录按目录条目标识找到同一用户，不重复创建
+	again, err := f.auth.Authenticate(ctx, "alice@example.com", "alice-pass")
+	if err != nil || again.ID != user.ID {
+		t.Fatalf("second login: user=%+v err=%v, want user %d", again, err, user.ID)
+	}
+}
+
+func TestLDAPProvisionUsernameTaken(t *testing.T) {
+	f := newLDAPFixture(t, LDAPConfig{})
+	f.createUser(t, "bob", "someone-else@example.com", domain.UserStatusActive)
+
+	user, err := f.auth.Authenticate(context.Background(), "bob", "bob-pass")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	// 目录用户名已被其他本地账号占用时使用邮箱作为用户名
+	if user.Username != "bob@example.com" {
+		t.Fatalf("username = %q, want bob@example.com", user.Username)
+	}
+}
+
+func TestLDAPLinkExistingAccount(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		wantErr error
+	}{
+		{"active", domain.UserStatusActive, nil},
+		{"pending", domain.UserStatusPending, nil},
+		{"suspended", domain.UserStatusSuspended, domain.ErrInvalidCredentials},
+		{"inactive", domain.UserStatusInactive, domain.ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newLDAPFixture(t, LDAPConfig{})
+			ctx := context.Background()
+			existing := f.createUser(t, "carol-local", "carol@example.com", tt.status)
+			version := existing.TokenVersion
+
+			user, err := f.auth.Authenticate(ctx, "carol", "carol-pass")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			_, linkErr := f.identities.FindByProviderSubject(ctx, LDAPIdentityProvider, "uuid-carol")
+			if err != nil {
+				if !errors.Is(linkErr, domain.ErrIdentityNotFound) {
+					t.Fatalf("identity linked after failed login: %v", linkErr)
+				}
+				return
+			}
+			if user.ID != existing.ID || linkErr != nil {
+				t.Fatalf("user = %d link err = %v, want existing user %d linked", user.ID, linkErr, existing.ID)
+			}
+			stored, _ := f.users.FindByID(ctx, existing.ID)
+			if stored.Status != domain.UserStatusActive {
+				t.Fatalf("status = %s, want active", stored.Status)
+			}
+			if tt.status == domain.UserStatusPending {
+				// pending 账号可能是他人用该邮箱抢注的，认领后注册时的密码与已签发 token 必须作废
+				if stored.PasswordHash != "" || stored.TokenVersion != version+1 {
+					t.Fatalf("pending claim kept credentials: hash=%q token_version=%d", stored.PasswordHash, stored.TokenVersion)
+				}
+			} else if stored.PasswordHash != "registrant-hash" {
+				t.Fatalf("active account password changed")
+			}
+		})
+	}
+}
+
+func TestLDAPGroupPolicy(t *testing.T) {
+	f := newLDAPFixture(t, LDAPConfig{
+		AllowedGroups: []string{"admins", "developers"},
+		GroupRoles:    []DirectoryRoleMapping{{Group: "admins", Role: domain.RoleAdmin}},
+	})
+	ctx := context.Background()
+	if _, err := f.auth.Authenticate(ctx, "carol", "carol-pass"); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("user outside allowed groups: got %v, want ErrInvalidCredentials", err)
+	}
+	user, err := f.auth.Authenticate(ctx, "bob", "bob-pass")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.Role != domain.RoleStandard {
+		t.Fatalf("role = %s, want default role", user.Role)
+	}
+
+	// 本地角色被修改后，下次登录按目录组重新同步
+	if err := f.users.UpdateRole(ctx, user.ID, domain.RoleAdmin); err != nil {
+		t.Fatalf("UpdateRole: %v", err)
+	}
+	user, err = f.auth.Authenticate(ctx, "bob", "bob-pass")
+	if err != nil || user.Role != domain.RoleStandard {
+		t.Fatalf("role sync: role=%v err=%v", user, err)
+	}
+}
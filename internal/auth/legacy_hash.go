@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"crypto/md5"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// 从其他系统迁移过来的密码哈希格式，仅用于校验；校验通过后由登录流程按当前配置重新哈希
+const (
+	HashFormatBcrypt       = "bcrypt"
+	HashFormatArgon2id     = "argon2id"
+	HashFormatDjangoPBKDF2 = "django_pbkdf2"   // pbkdf2_sha256$<iterations>$<salt>$<base64>，亦支持 pbkdf2_sha1
+	HashFormatPHPass       = "phpass"          // $P$ / $H$（WordPress、phpBB 等 PHP 应用）
+	HashFormatSaltedSHA    = "salted_sha"      // <sha1|sha256|sha512|md5>$<salt>$<hex>，摘要为 hex(H(salt + password))
+	HashFormatLDAPSSHA     = "ldap_salted_sha" // {SSHA} / {SSHA256} / {SSHA512}，base64(H(password + salt) + salt)
+)
+
+// IdentifyHashFormat 识别编码哈希的格式，不支持时返回空字符串（用于导入前校验）
+func IdentifyHashFormat(encoded string) string {
+	switch {
+	case isBcryptHash(encoded):
+		return HashFormatBcrypt
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return HashFormatArgon2id
+	case strings.HasPrefix(encoded, "pbkdf2_sha256$"), strings.HasPrefix(encoded, "pbkdf2_sha1$"):
+		return HashFormatDjangoPBKDF2
+	case (strings.HasPrefix(encoded, "$P$") || strings.HasPrefix(encoded, "$H$")) && len(encoded) == 34:
+		return HashFormatPHPass
+	case strings.HasPrefix(encoded, "{SSHA}"), strings.HasPrefix(encoded, "{SSHA256}"), strings.HasPrefix(encoded, "{SSHA512}"):
+		return HashFormatLDAPSSHA
+	}
+	if algo, _, ok := strings.Cut(encoded, "$"); ok && saltedDigest(algo) != nil && strings.Count(encoded, "$") == 2 {
+		return HashFormatSaltedSHA
+	}
+	return ""
+}
+
+// verifyLegacyHash 校验迁移格式的哈希，格式不支持时返回 ErrUnknownHashFormat
+func verifyLegacyHash(encoded, password string) (bool, error) {
+	switch IdentifyHashFormat(encoded) {
+	case HashFormatDjangoPBKDF2:
+		return verifyDjangoPBKDF2(encoded, password)
+	case HashFormatPHPass:
+		return verifyPHPass(encoded, password)
+	case HashFormatSaltedSHA:
+		return verifySaltedSHA(encoded, password)
+	case HashFormatLDAPSSHA:
+		return verifyLDAPSSHA(encoded, password)
+	default:
+		return false, ErrUnknownHashFormat
+	}
+}
+
+func saltedDigest(algo string) func() hash.Hash {
+	switch algo {
+	case "md5":
+		return md5.New
+	case "sha1":
+		return sha1.New
+	case "sha256":
+		return sha256.New
+	case "sha512":
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+// verifyDjangoPBKDF2 Django 默认格式：algorithm$iterations$salt$base64(hash)
+func verifyDjangoPBKDF2(encoded, password string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 {
+		return false, ErrUnknownHashFormat
+	}
+	newHash := sha256.New
+	if parts[0] == "pbkdf2_sha1" {
+		newHash = sha1.New
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false, fmt.Errorf("invalid pbkdf2 iterations %q", parts[1])
+	}
+	want, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false, fmt.Errorf("invalid pbkdf2 hash: %w", err)
+	}
+	got, err := pbkdf2.Key(newHash, password, []byte(parts[2]), iterations, len(want))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+
+const phpassItoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// verifyPHPass Portable PHP password hashing framework（WordPress / phpBB）
+func verifyPHPass(encoded, password string) (bool, error) {
+	countLog2 := strings.IndexByte(phpassItoa64, encoded[3])
+	if countLog2 < 7 || countLog2 > 30 {
+		return false, fmt.Errorf("invalid phpass iteration count")
+	}
+	salt := encoded[4:12]
+	sum := md5.Sum([]byte(salt + password))
+	digest := sum[:]
+	for i := 0; i < 1<<countLog2; i++ {
+		next := md5.Sum(append(digest, password...))
+		digest = next[:]
+	}
+	got := encoded[:12] + phpassEncode64(digest)
+	return subtle.ConstantTimeCompare([]byte(got), []byte(encoded)) == 1, nil
+}
+
+func phpassEncode64(input []byte) string {
+	var out strings.Builder
+	count := len(input)
+	for i := 0; i < count; {
+		value := int(input[i])
+		i++
+		out.WriteByte(phpassItoa64[value&0x3f])
+		if i < count {
+			value |= int(input[i]) << 8
+		}
+		out.WriteByte(phpassItoa64[(value>>6)&0x3f])
+		if i >= count {
+			break
+		}
+		i++
+		if i < count {
+			value |= int(input[i]) << 16
+		}
+		out.WriteByte(phpassItoa64[(value>>12)&0x3f])
+		if i >= count {
+			break
+		}
+		i++
+		out.WriteByte(phpassItoa64[(value>>18)&0x3f])
+	}
+	return out.String()
+}
+
+// verifySaltedSHA 旧版 Django / 自研 PHP 常见格式：algo$salt$hex(H(salt + password))
+func verifySaltedSHA(encoded, password string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	newHash := saltedDigest(parts[0])
+	want, err := hex.DecodeString(strings.ToLower(parts[2]))
+	if err != nil {
+		return false, fmt.Errorf("invalid salted sha hash: %w", err)
+	}
+	h := newHash()
+	h.Write([]byte(parts[1] + password))
+	return subtle.ConstantTimeCompare(h.Sum(nil), want) == 1, nil
+}
+
+// verifyLDAPSSHA LDAP / OpenLDAP 格式：{SSHA}base64(H(password + salt) + salt)
+func verifyLDAPSSHA(encoded, password string) (bool, error) {
+	scheme, payload, _ := strings.Cut(strings.TrimPrefix(encoded, "{"), "}")
+	var newHash func() hash.Hash
+	switch scheme {
+	case "SSHA":
+		newHash = sha1.New
+	case "SSHA256":
+		newHash = sha256.New
+	case "SSHA512":
+		newHash = sha512.New
+	}
+	raw, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s hash: %w", scheme, err)
+	}
+	h := newHash()
+	size := h.Size()
+	if len(raw) <= size {
+		return false, fmt.Errorf("invalid %s hash: missing salt", scheme)
+	}
+	digest, salt := raw[:size], raw[size:]
+	h.Write([]byte(password))
+	h.Write(salt)
+	return subtle.ConstantTimeCompare(h.Sum(nil), digest) == 1, nil
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerifyLegacyHash(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoded  string
+		password string
+		want     bool
+	}{
+		// RFC 6070 PBKDF2-HMAC-SHA1 测试向量（P="password", S="salt"）按 Django 格式编码
+		{"django pbkdf2_sha1 c=1", "pbkdf2_sha1$1$salt$DGDID5YfDnHzqbUkr2ASBi/gN6Y=", "password", true},
+		{"django pbkdf2_sha1 c=2", "pbkdf2_sha1$2$salt$6mwBTcctb4zNHtkqzh1B8NjeiVc=", "password", true},
+		{"django pbkdf2_sha1 c=4096", "pbkdf2_sha1$4096$salt$SwB5AbdlSJq+rUnZJvch0GWkKcE=", "password", true},
+		{"django pbkdf2_sha1 wrong password", "pbkdf2_sha1$4096$salt$SwB5AbdlSJq+rUnZJvch0GWkKcE=", "passwort", false},
+		// Python hashlib.pbkdf2_hmac 生成（与 Django PBKDF2PasswordHasher 相同算法）
+		{"django pbkdf2_sha256", "pbkdf2_sha256$260000$seasalt$YlZ2Vggtqdc61YjArZuoApoBh9JNGYoDRBUGu6tcJQo=", "lètmein", true},
+		{"django pbkdf2_sha256 wrong password", "pbkdf2_sha256$260000$seasalt$YlZ2Vggtqdc61YjArZuoApoBh9JNGYoDRBUGu6tcJQo=", "letmein", false},
+		// phpass 官方 test.php 中 "test12345" 的 portable hash
+		{"phpass", "$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0", "test12345", true},
+		// phpBB 仅把前缀换成 $H$，算法相同
+		{"phpass phpBB prefix", "$H$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0", "test12345", true},
+		{"phpass wrong password", "$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0", "test12346", false},
+		// 盐为 8f1e02a7，摘要为 H("secret" + salt)
+		{"ldap ssha", "{SSHA}BYTBb98SQvZPe4S5PVkLD9JGSQiPHgKn", "secret", true},
+		{"ldap ssha256", "{SSHA256}bApYLPoB9QbJIBZzuMsD0ZWr+Gj2315A1qTDBoVAAS6PHgKn", "secret", true},
+		{"ldap ssha512", "{SSHA512}tV/noG7IOT8nUY5QwbAKyGqmMFc9KgW4DWnI9rnQ2MOBJl2OYV5eAA7oo5u0NOsq4DQ4SYWuk7+dP+qUd86bgo8eAqc=", "secret", true},
+		{"ldap ssha wrong password", "{SSHA}BYTBb98SQvZPe4S5PVkLD9JGSQiPHgKn", "Secret", false},
+		// 摘要为 hex(H("a1b2c3" + "hunter2"))
+		{"salted md5", "md5$a1b2c3$e22c439f8a353c8246047729692721af", "hunter2", true},
+		{"salted sha1", "sha1$a1b2c3$dce1a7d9a19788ffb9e57c24cf609d35a421ad96", "hunter2", true},
+		{"salted sha1 upper-case hex", "sha1$a1b2c3$DCE1A7D9A19788FFB9E57C24CF609D35A421AD96", "hunter2", true},
+		{"salted sha256", "sha256$a1b2c3$3a1e99564e6f1d56edffa78f8fecf7bcf263458aed81a1c26c522c62a9de700a", "hunter2", true},
+		{"salted sha256 wrong password", "sha256$a1b2c3$3a1e99564e6f1d56edffa78f8fecf7bcf263458aed81a1c26c522c62a9de700a", "hunter3", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifyLegacyHash(tt.encoded, tt.password)
+			if err != nil {
+				t.Fatalf("verifyLegacyHash: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyLegacyHashMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+		unknown bool
+	}{
+		{"empty", "", true},
+		{"plain text", "hunter2", true},
+		{"unknown salted algo", "sha384$a1b2c3$abcd", true},
+		{"phpass wrong length", "$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r", true},
+		{"django missing field", "pbkdf2_sha256$260000$seasalt", true},
+		{"django bad iterations", "pbkdf2_sha256$0$seasalt$YlZ2Vggtqdc61YjArZuoApoBh9JNGYoDRBUGu6tcJQo=", false},
+		{"django bad base64", "pbkdf2_sha256$1000$seasalt$!!!", false},
+		{"phpass bad iteration count", "$P$.IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0", false},
+		{"ldap missing salt", "{SSHA}AAAAAAAAAAAAAAAAAAAAAAAAAAA=", false},
+		{"salted bad hex", "sha1$a1b2c3$zz", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := verifyLegacyHash(tt.encoded, "hunter2")
+			if ok || err == nil {
+				t.Fatalf("got ok=%v err=%v, want error", ok, err)
+			}
+			if errors.Is(err, ErrUnknownHashFormat) != tt.unknown {
+				t.Fatalf("err = %v, want unknown format %v", err, tt.unknown)
+			}
+		})
+	}
+}
+
+func TestIdentifyHashFormat(t *testing.T) {
+	tests := []struct {
+		encoded string
+		want    string
+	}{
+		{"$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", HashFormatBcrypt},
+		{"$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$aGFzaA", HashFormatArgon2id},
+		{"pbkdf2_sha256$260000$seasalt$YlZ2Vggtqdc61YjArZuoApoBh9JNGYoDRBUGu6tcJQo=", HashFormatDjangoPBKDF2},
+		{"pbkdf2_sha1$1$salt$DGDID5YfDnHzqbUkr2ASBi/gN6Y=", HashFormatDjangoPBKDF2},
+		{"$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0", HashFormatPHPass},
+		{"$H$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0", HashFormatPHPass},
+		{"{SSHA}BYTBb98SQvZPe4S5PVkLD9JGSQiPHgKn", HashFormatLDAPSSHA},
+		{"sha1$a1b2c3$dce1a7d9a19788ffb9e57c24cf609d35a421ad96", HashFormatSaltedSHA},
+		// 无盐摘要与明文不支持导入
+		{"{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=", ""},
+		{"5f4dcc3b5aa765d61d8327deb882cf99", ""},
+		{"sha1$a1b2c3$dce1$extra", ""},
+	}
+	for _, tt := range tests {
+		if got := IdentifyHashFormat(tt.encoded); got != tt.want {
+			t.Errorf("IdentifyHashFormat(%q) = %q, want %q", tt.encoded, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package auth
+
+import "time"
+
+// LockoutPolicy 连续登录失败的渐进延迟与临时锁定策略
+//
+// 连续失败次数超过 DelayAfter 后，每次失败锁定 BaseDelay、2×BaseDelay、4×BaseDelay…（不超过 MaxDelay）；
+// 达到 MaxFailures 后锁定 LockoutDuration。锁定期间不校验密码，直接返回 AccountLockedError。
+type LockoutPolicy struct {
+	DelayAfter      int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	MaxFailures     int
+	LockoutDuration time.Duration
+}
+
+// DefaultLockoutPolicy 默认策略：3 次后开始 1s 起的渐进延迟（最长 30s），10 次后锁定 15 分钟
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		DelayAfter:      3,
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+		MaxFailures:     10,
+		LockoutDuration: 15 * time.Minute,
+	}
+}
+
+// lockDuration 根据连续失败次数计算本次失败后的锁定时长，0 表示不锁定
+func (p LockoutPolicy) lockDuration(failures int) time.Duration {
+	if p.MaxFailures > 0 && failures >= p.MaxFailures {
+		return p.LockoutDuration
+	}
+	if p.BaseDelay <= 0 || failures <= p.DelayAfter {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := p.DelayAfter + 1; i < failures; i++ {
+		d *= 2
+		if p.MaxDelay > 0 && d >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return d
+}
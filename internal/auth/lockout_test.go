@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockDuration(t *testing.T) {
+	policy := DefaultLockoutPolicy()
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{6, 4 * time.Second},
+		{8, 16 * time.Second},
+		{9, 30 * time.Second},
+		{10, 15 * time.Minute},
+		{50, 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.lockDuration(tt.failures); got != tt.want {
+			t.Errorf("lockDuration(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLockDurationPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   LockoutPolicy
+		failures int
+		want     time.Duration
+	}{
+		{"delay disabled", LockoutPolicy{DelayAfter: 3, MaxFailures: 5, LockoutDuration: time.Hour}, 4, 0},
+		{"lockout without delay", LockoutPolicy{DelayAfter: 3, MaxFailures: 5, LockoutDuration: time.Hour}, 5, time.Hour},
+		{"lockout disabled", LockoutPolicy{DelayAfter: 0, BaseDelay: time.Second, MaxDelay: time.Minute}, 1000, time.Minute},
+		{"no max delay", LockoutPolicy{DelayAfter: 0, BaseDelay: time.Second}, 11, 1024 * time.Second},
+		{"delay from first failure", LockoutPolicy{DelayAfter: 0, BaseDelay: time.Second, MaxDelay: time.Minute}, 1, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.lockDuration(tt.failures); got != tt.want {
+				t.Fatalf("lockDuration(%d) = %s, want %s", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+// 登录方式，写入 login_history.method
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodPasskey   = "passkey"
+	LoginMethodMagicLink = "magic_link"
+	LoginMethodSMS       = "sms"
+	LoginMethodFederated = "federated"
+)
+
+// LoginMethod 根据 amr 推断登录方式：主因素加第二因素，如 password、password+totp、magic_link+recovery_code、sms、federated、passkey
+func LoginMethod(amr []string) string {
+	var primary string
+	switch {
+	case slices.Contains(amr, AMRHardwareKey):
+		return LoginMethodPasskey
+	case slices.Contains(amr, AMRPassword):
+		primary = LoginMethodPassword
+	case slices.Contains(amr, AMREmail):
+		primary = LoginMethodMagicLink
+	case slices.Contains(amr, AMRSMS):
+		primary = LoginMethodSMS
+	case slices.Contains(amr, AMRFederated):
+		primary = LoginMethodFederated
+	default:
+		return "unknown"
+	}
+	switch {
+	case slices.Contains(amr, AMROTP):
+		return primary + "+totp"
+	case slices.Contains(amr, AMRMFA):
+		return primary + "+recovery_code"
+	default:
+		return primary
+	}
+}
+
+// LoginHistoryService 记录登录成功（更新 last_login_at 并写入登录历史）与查询
+type LoginHistoryService interface {
+	// Record 记录一次登录成功；写入失败只记日志，不影响登录
+	Record(ctx context.Context, record *domain.LoginRecord)
+	// List 按时间倒序返回用户的登录记录
+	List(ctx context.Context, userID int64, beforeID int64, limit int) ([]*domain.LoginRecord, error)
+}
+
+type loginHistoryService struct {
+	users   domain.UserRepository
+	history domain.LoginHistoryRepository
+	outbox  EventOutbox
+}
+
+// NewLoginHistoryService 创建登录历史服务；outbox 非 nil 时 user.logged_in 事件与登录历史在同一事务中写入发件箱
+func NewLoginHistoryService(users domain.UserRepository, history domain.LoginHistoryRepository, outbox EventOutbox) LoginHistoryService {
+	return &loginHistoryService{users: users, history: history, outbox: outbox}
+}
+
+func (s *loginHistoryService) Record(ctx context.Context, record *domain.LoginRecord) {
+	ctx = context.WithoutCancel(ctx)
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	record.UserAgent = truncate(record.UserAgent, 512)
+	if s.outbox == nil {
+		s.save(ctx, record)
+		return
+	}
+	err := s.outbox.Run(ctx, func(ctx context.Context) ([]domain.Event, error) {
+		if err := s.users.UpdateLastLogin(ctx, record.UserID, record.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := s.history.Create(ctx, record); err != nil {
+			return nil, err
+		}
+		return []domain.Event{newEvent(domain.EventUserLoggedIn, record.UserID, map[string]any{
+			"method":    record.Method,
+			"client_id": record.ClientID,
+			"ip":        record.IP,
+			"country":   record.Country,
+		})}, nil
+	})
+	if err != nil {
+		log.Printf("[AUTH] save login history failed user_id=%d err=%v", record.UserID, err)
+	}
+}
+
+// save 未启用发件箱时分别更新 last_login_at 与写入登录历史，一项失败不影响另一项
+func (s *loginHistoryService) save(ctx context.Context, record *domain.LoginRecord) {
+	if err := s.users.UpdateLastLogin(ctx, record.UserID, record.CreatedAt); err != nil {
+		log.Printf("[AUTH] update last login failed user_id=%d err=%v", record.UserID, err)
+	}
+	if err := s.history.Create(ctx, record); err != nil {
+		log.Printf("[AUTH] save login history failed user_id=%d err=%v", record.UserID, err)
+	}
+}
+
+func (s *loginHistoryService) List(ctx context.Context, userID int64, beforeID int64, limit int) ([]*domain.LoginRecord, error) {
+	if limit <= 0 {
+		limit = DefaultAuditPageSize
+	}
+	if limit > MaxAuditPageSize {
+		limit = MaxAuditPageSize
+	}
+	return s.history.ListByUser(ctx, userID, beforeID, limit)
+}
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/mail"
+)
+
+// ClientInfo 发起登录的客户端信息，由传输层通过 WithClientInfo 放入 context
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+	// DeviceID 长期设备 Cookie 的值，非浏览器客户端可能为空
+	DeviceID string
+}
+
+type clientInfoKey struct{}
+
+// WithClientInfo 返回携带客户端信息的 context
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext 取出 WithClientInfo 放入的客户端信息
+func ClientInfoFromContext(ctx context.Context) (ClientInfo, bool) {
+	info, ok := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info, ok
+}
+
+// 登录异常原因
+const (
+	LoginRiskNewDevice        = "new_device"
+	LoginRiskNewCountry       = "new_country"
+	LoginRiskImpossibleTravel = "impossible_travel"
+)
+
+// LoginRisk 一次登录的风险评估结果
+type LoginRisk struct {
+	// Reasons 命中的异常原因，为空表示未发现异常
+	Reasons []string
+	// Location 本次登录 IP 的地理位置，未配置 GeoIP 时为 nil
+	Location *GeoLocation
+
+	fingerprint string
+	client      ClientInfo
+}
+
+// Flagged 是否判定为异常登录
+func (r *LoginRisk) Flagged() bool {
+	return r != nil && len(r.Reasons) > 0
+}
+
+// LoginRiskConfig 异常登录检测配置
+type LoginRiskConfig struct {
+	// MaxTravelSpeedKmh 两次登录间隔所需移动速度超过该值视为不可能旅行，默认 1000 km/h
+	MaxTravelSpeedKmh float64
+	// MinTravelDistanceKm 距离小于该值时不判断不可能旅行（GeoIP 精度有限），默认 500 km
+	MinTravelDistanceKm float64
+	// Notify 为 true 时异常登录成功后邮件通知用户
+	Notify bool
+}
+
+// LoginRiskService 新设备 / 新国家 / 不可能旅行检测
+type LoginRiskService interface {
+	// Assess 在签发 token 前评估本次登录（客户端信息取自 context）
+	Assess(ctx context.Context, user *domain.User) (*LoginRisk, error)
+	// Record 登录成功后记住设备；异常登录记录审计事件并通知用户。失败只记日志
+	Record(ctx context.Context, user *domain.User, risk *LoginRisk)
+}
+
+type loginRiskService struct {
+	devices domain.KnownDeviceRepository
+	history domain.LoginHistoryRepository
+	geoip   GeoIPResolver
+	mailer  mail.Mailer
+	audit   AuditService
+	cfg     LoginRiskConfig
+}
+
+// NewLoginRiskService 创建异常登录检测服务；geoip、mailer、audit 可为 nil
+func NewLoginRiskService(devices domain.KnownDeviceRepository, history domain.LoginHistoryRepository, geoip GeoIPResolver, mailer mail.Mailer, audit AuditService, cfg LoginRiskConfig) LoginRiskService {
+	if cfg.MaxTravelSpeedKmh <= 0 {
+		cfg.MaxTravelSpeedKmh = 1000
+	}
+	if cfg.MinTravelDistanceKm <= 0 {
+		cfg.MinTravelDistanceKm = 500
+	}
+	return &loginRiskService{devices: devices, history: history, geoip: geoip, mailer: mailer, audit: audit, cfg: cfg}
+}
+
+// uaVersionRe 去掉 User-Agent 中的版本号，浏览器自动升级后仍视为同一设备
+var uaVersionRe = regexp.MustCompile(`[0-9][0-9._]*`)
+
+// deviceFingerprint 设备 Cookie 与去版本号后的 User-Agent 的哈希
+func deviceFingerprint(info ClientInfo) string {
+	sum := sha256.Sum256([]byte(info.DeviceID + "\n" + uaVersionRe.ReplaceAllString(info.UserAgent, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *loginRiskService) Assess(ctx context.Context, user *domain.User) (*LoginRisk, error) {
+	info, _ := ClientInfoFromContext(ctx)
+	risk := &LoginRisk{fingerprint: deviceFingerprint(info), client: info}
+
+	// 新设备：用户已有已知设备且本设备不在其中（首次启用检测时不报警）
+	devices, err := s.devices.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) > 0 && !slices.ContainsFunc(devices, func(d *domain.KnownDevice) bool {
+		return d.Fingerprint == risk.fingerprint
+	}) {
+		risk.Reasons = append(risk.Reasons, LoginRiskNewDevice)
+	}
+
+	if s.geoip == nil {
+		return risk, nil
+	}
+	ip := net.ParseIP(info.IP)
+	if ip == nil {
+		return risk, nil
+	}
+	loc, err := s.geoip.Lookup(ip)
+	if err != nil {
+		return nil, err
+	}
+	risk.Location = loc
+	if loc.Country == "" {
+		return risk, nil
+	}
+
+	// 新国家：用户曾有带国家信息的登录且均不在该国家
+	countries, err := s.history.Countries(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(countries) > 0 && !slices.Contains(countries, loc.Country) {
+		risk.Reasons = append(risk.Reasons, LoginRiskNewCountry)
+	}
+
+	// 不可能旅行：与上次登录的距离 / 时间间隔超过最大速度
+	if !loc.hasCoordinates() {
+		return risk, nil
+	}
+	last, err := s.history.ListByUser(ctx, user.ID, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(last) == 0 || last[0].Latitude == nil || last[0].Longitude == nil || last[0].IP == info.IP {
+		return risk, nil
+	}
+	km := distanceKm(*last[0].Latitude, *last[0].Longitude, *loc.Latitude, *loc.Longitude)
+	hours := max(time.Since(last[0].CreatedAt).Hours(), 1.0/60)
+	if km >= s.cfg.MinTravelDistanceKm && km/hours > s.cfg.MaxTravelSpeedKmh {
+		risk.Reasons = append(risk.Reasons, LoginRiskImpossibleTravel)
+	}
+	return risk, nil
+}
+
+func (s *loginRiskService) Record(ctx context.Context, user *domain.User, risk *LoginRisk) {
+	if risk == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	country := ""
+	if risk.Location != nil {
+		country = risk.Location.Country
+	}
+	if err := s.devices.Save(ctx, &domain.KnownDevice{
+		UserID:      user.ID,
+		Fingerprint: risk.fingerprint,
+		UserAgent:   truncate(risk.client.UserAgent, 512),
+		LastIP:      risk.client.IP,
+		LastCountry: country,
+	}); err != nil {
+		log.Printf("[AUTH] save known device failed user_id=%d err=%v", user.ID, err)
+	}
+	if !risk.Flagged() {
+		return
+	}
+	log.Printf("[AUTH] anomalous login user_id=%d ip=%s country=%s reasons=%s", user.ID, risk.client.IP, country, strings.Join(risk.Reasons, ","))
+	if s.audit != nil {
+		s.audit.Record(ctx, &domain.AuditEvent{
+			Type:      domain.AuditLoginAnomaly,
+			Outcome:   domain.AuditOutcomeSuccess,
+			UserID:    user.ID,
+			ActorID:   user.ID,
+			IP:        risk.client.IP,
+			UserAgent: risk.client.UserAgent,
+			Reason:    strings.Join(risk.Reasons, ","),
+		})
+	}
+	if s.cfg.Notify && s.mailer != nil {
+		if err := s.mailer.Send(ctx, newLoginAlertMessage(user, risk, country)); err != nil {
+			log.Printf("[AUTH] send login alert failed user_id=%d err=%v", user.ID, err)
+		}
+	}
+}
+
+// newLoginAlertMessage 异常登录提醒邮件
+func newLoginAlertMessage(user *domain.User, risk *LoginRisk, country string) mail.Message {
+	descriptions := map[string]string{
+		LoginRiskNewDevice:        "a device you have not used before",
+		LoginRiskNewCountry:       "a country you have not signed in from before",
+		LoginRiskImpossibleTravel: "a location too far from your previous sign-in to travel in the time between them",
+	}
+	var why []string
+	for _, r := range risk.Reasons {
+		why = append(why, "  - "+descriptions[r])
+	}
+	if country == "" {
+		country = "unknown"
+	}
+	body := fmt.Sprintf("Hello %s,\n\n"+
+		"We noticed a new sign-in to your account from:\n%s\n\n"+
+		"Time: %s\nIP address: %s\nCountry: %s\nDevice: %s\n\n"+
+		"If this was you, you can ignore this email. If not, change your password immediately "+
+		"and enable two-factor authentication.\n",
+		user.Username, strings.Join(why, "\n"),
+		time.Now().UTC().Format(time.RFC1123), risk.client.IP, country, risk.client.UserAgent)
+	return mail.Message{To: user.Email, Subject: "New sign-in to your account", Body: body}
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/mail"
+)
+
+// AMREmail 通过邮件链接证明邮箱归属完成的认证（RFC 8176 未定义，沿用常见取值）
+const AMREmail = "email"
+
+// MagicLinkGrant 邮件登录链接绑定的信息
+type MagicLinkGrant struct {
+	UserID int64
+	// ServerState 申请链接时登录页持有的 server_state，为空表示非 SSO 登录
+	ServerState string
+}
+
+// MagicLinkStore 邮件登录链接存储：id -> MagicLinkGrant，一次性使用，短 TTL
+type MagicLinkStore interface {
+	Save(grant MagicLinkGrant) (id string, err error)
+	GetAndConsume(id string) (grant MagicLinkGrant, ok bool)
+}
+
+type magicLinkEntry struct {
+	grant     MagicLinkGrant
+	expiresAt time.Time
+}
+
+// MemoryMagicLinkStore 实现 MagicLinkStore
+type MemoryMagicLinkStore struct {
+	ttl   time.Duration
+	mu    sync.Mutex
+	store map[string]*magicLinkEntry
+}
+
+// NewMemoryMagicLinkStore 邮件登录链接内存存储，默认 TTL 10 分钟
+func NewMemoryMagicLinkStore(ttl time.Duration) *MemoryMagicLinkStore {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	s := &MemoryMagicLinkStore{ttl: ttl, store: make(map[string]*magicLinkEntry)}
+	go s.cleanup()
+	return s
+}
+
+func (s *MemoryMagicLinkStore) Save(grant MagicLinkGrant) (string, error) {
+	id, err := newRandomToken(24)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store[id] = &magicLinkEntry{grant: grant, expiresAt: time.Now().Add(s.ttl)}
+	return id, nil
+}
+
+func (s *MemoryMagicLinkStore) GetAndConsume(id string) (MagicLinkGrant, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.store[id]
+	if !ok || e == nil || time.Now().After(e.expiresAt) {
+		return MagicLinkGrant{}, false
+	}
+	delete(s.store, id)
+	return e.grant, true
+}
+
+func (s *MemoryMagicLinkStore) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mu.Lock()
+		now := time.Now()
+		for k, e := range s.store {
+			if e != nil && now.After(e.expiresAt) {
+				delete(s.store, k)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// MagicLinkService 邮件链接无密码登录
+type MagicLinkService interface {
+	// Request 在后台向邮箱对应的用户发送一次性登录链接后立即返回；邮箱不存在或账号不可用时静默成功，
+	// 各种情况耗时相同，避免枚举用户
+	Request(ctx context.Context, req domain.MagicLinkRequest) error
+	// Consume 校验链接令牌（签名、有效期、一次性）并返回绑定的用户与 server_state
+	Consume(ctx context.Context, token string) (MagicLinkGrant, error)
+}
+
+// MagicLinkConfig 邮件登录配置
+type MagicLinkConfig struct {
+	// TTL 仅用于邮件正文中的有效期提示，实际有效期由 MagicLinkStore 控制
+	TTL time.Duration
+	// LinkURL 登录落地页完整地址，令牌以 ?token= 追加。落地页需由用户点击按钮后再调用消费接口，
+	// 避免邮件安全网关预取链接时消耗令牌
+	LinkURL string
+	// Secret 签名密钥，令牌为 id.HMAC(id)，伪造或截断的令牌不会查询存储
+	Secret string
+}
+
+type magicLinkService struct {
+	users  domain.UserRepository
+	store  MagicLinkStore
+	mailer mail.Mailer
+	key    []byte
+	cfg    MagicLinkConfig
+	async  func(func())
+}
+
+// NewMagicLinkService 创建邮件登录服务
+func NewMagicLinkService(users domain.UserRepository, store MagicLinkStore, mailer mail.Mailer, cfg MagicLinkConfig) MagicLinkService {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 10 * time.Minute
+	}
+	mac := hmac.New(sha256.New, []byte(cfg.Secret))
+	mac.Write([]byte("monai-auth magic link"))
+	return &magicLinkService{users: users, store: store, mailer: mailer, key: mac.Sum(nil), cfg: cfg, async: runInBackground}
+}
+
+func (s *magicLinkService) Request(ctx context.Context, req domain.MagicLinkRequest) error {
+	email := domain.NormalizeEmail(req.Email)
+	if email == "" {
+		return nil
+	}
+	// 与重置密码相同，查找用户与发信放到后台，邮箱是否注册请求耗时都相同
+	ctx = context.WithoutCancel(ctx)
+	serverState := strings.TrimSpace(req.ServerState)
+	s.async(func() {
+		if err := s.sendLink(ctx, email, serverState); err != nil {
+			log.Printf("[AUTH] magic link mail failed email=%s err=%v", email, err)
+		}
+	})
+	return nil
+}
+
+// sendLink 为可用账号生成登录链接并发送邮件，邮箱未注册或账号不可用时不发送
+func (s *magicLinkService) sendLink(ctx context.Context, email, serverState string) error {
+	user, err := s.users.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil
+		}
+		return fmt.Errorf("repository lookup error: %w", err)
+	}
+	if user.Status != domain.UserStatusActive {
+		log.Printf("[AUTH] magic link skipped user_id=%d status=%s", user.ID, user.Status)
+		return nil
+	}
+	id, err := s.store.Save(MagicLinkGrant{UserID: user.ID, ServerState: serverState})
+	if err != nil {
+		return fmt.Errorf("save magic link: %w", err)
+	}
+	link, err := appendQuery(s.cfg.LinkURL, "token", id+"."+s.sign(id))
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Hello %s,\n\n"+
+		"Use the link below within %d minutes to sign in to your account. The link can only be used once:\n\n%s\n\n"+
+		"If you did not request this, you can safely ignore this email.\n",
+		user.Username, int(s.cfg.TTL.Minutes()), link)
+	if err := s.mailer.Send(ctx, mail.Message{To: user.Email, Subject: "Your sign-in link", Body: body}); err != nil {
+		return fmt.Errorf("send magic link mail: %w", err)
+	}
+	return nil
+}
+
+func (s *magicLinkService) Consume(ctx context.Context, token string) (MagicLinkGrant, error) {
+	id, sig, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok || id == "" || !hmac.Equal([]byte(sig), []byte(s.sign(id))) {
+		return MagicLinkGrant{}, domain.ErrInvalidMagicLink
+	}
+	grant, ok := s.store.GetAndConsume(id)
+	if !ok {
+		return MagicLinkGrant{}, domain.ErrInvalidMagicLink
+	}
+	return grant, nil
+}
+
+// sign 计算链接 id 的签名
+func (s *magicLinkService) sign(id string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/repository/inmemory"
+)
+
+// newMagicLinkFixture 创建同步发信的邮件登录服务
+func newMagicLinkFixture(t *testing.T, store MagicLinkStore, secret string) (*magicLinkService, *recordingMailer) {
+	t.Helper()
+	mailer := &recordingMailer{}
+	svc := NewMagicLinkService(inmemory.NewInMemoryUserRepo(), store, mailer,
+		MagicLinkConfig{LinkURL: "https://auth.example.com/magic-link", Secret: secret}).(*magicLinkService)
+	svc.async = func(task func()) { task() }
+	return svc, mailer
+}
+
+// requestMagicLink 为 test@example.com 申请登录链接并从邮件中取出令牌
+func requestMagicLink(t *testing.T, svc *magicLinkService, mailer *recordingMailer, serverState string) string {
+	t.Helper()
+	before := mailer.count()
+	if err := svc.Request(context.Background(), domain.MagicLinkRequest{Email: "Test@Example.com", ServerState: serverState}); err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	if mailer.count() != before+1 {
+		t.Fatalf("sent %d mails, want %d", mailer.count(), before+1)
+	}
+	for _, field := range strings.Fields(mailer.sent[before].Body) {
+		if u, err := url.Parse(field); err == nil && u.Host == "auth.example.com" {
+			return u.Query().Get("token")
+		}
+	}
+	t.Fatalf("mail has no sign-in link: %q", mailer.sent[before].Body)
+	return ""
+}
+
+func TestMagicLinkConsumeSingleUse(t *testing.T) {
+	svc, mailer := newMagicLinkFixture(t, NewMemoryMagicLinkStore(time.Minute), "magic-secret")
+	token := requestMagicLink(t, svc, mailer, "sso-state")
+	ctx := context.Background()
+
+	grant, err := svc.Consume(ctx, token)
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if grant.UserID != 1 || grant.ServerState != "sso-state" {
+		t.Fatalf("grant = %+v", grant)
+	}
+	if _, err := svc.Consume(ctx, token); !errors.Is(err, domain.ErrInvalidMagicLink) {
+		t.Fatalf("reused link: got %v, want ErrInvalidMagicLink", err)
+	}
+}
+
+func TestMagicLinkConsumeRejectsTamperedToken(t *testing.T) {
+	store := NewMemoryMagicLinkStore(time.Minute)
+	svc, mailer := newMagicLinkFixture(t, store, "magic-secret")
+	token := requestMagicLink(t, svc, mailer, "")
+	id, sig, _ := strings.Cut(token, ".")
+	flipped := []byte(sig)
+	flipped[0] ^= 1
+	otherID := []byte(id)
+	otherID[0] ^= 1
+	// 另一密钥签出的令牌对应同一存储条目
+	other, _ := newMagicLinkFixture(t, store, "other-secret")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"id only", id},
+		{"empty signature", id + "."},
+		{"flipped signature", id + "." + string(flipped)},
+		{"truncated signature", id + "." + sig[:len(sig)-2]},
+		{"other id", string(otherID) + "." + sig},
+		{"other secret", id + "." + other.sign(id)},
+	}
+	for _, tt := range tests {
+		if _, err := svc.Consume(context.Background(), tt.token); !errors.Is(err, domain.ErrInvalidMagicLink) {
+			t.Errorf("%s: got %v, want ErrInvalidMagicLink", tt.name, err)
+		}
+	}
+	// 签名校验失败不查询存储，原链接仍可使用
+	if _, err := svc.Consume(context.Background(), token); err != nil {
+		t.Fatalf("original link after tampering: %v", err)
+	}
+}
+
+func TestMagicLinkExpires(t *testing.T) {
+	svc, mailer := newMagicLinkFixture(t, NewMemoryMagicLinkStore(20*time.Millisecond), "magic-secret")
+	token := requestMagicLink(t, svc, mailer, "")
+	time.Sleep(30 * time.Millisecond)
+	if _, err := svc.Consume(context.Background(), token); !errors.Is(err, domain.ErrInvalidMagicLink) {
+		t.Fatalf("expired link: got %v, want ErrInvalidMagicLink", err)
+	}
+}
+
+func TestMagicLinkRequestSkipsUnknownAndInactiveUsers(t *testing.T) {
+	svc, mailer := newMagicLinkFixture(t, NewMemoryMagicLinkStore(time.Minute), "magic-secret")
+	ctx := context.Background()
+	suspended := &domain.User{Username: "suspended", Email: "suspended@example.com", Role: domain.RoleStandard, Status: domain.UserStatusSuspended}
+	if err := svc.users.CreateUser(ctx, suspended); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	for _, email := range []string{"nobody@example.com", "suspended@example.com", " "} {
+		if err := svc.Request(ctx, domain.MagicLinkRequest{Email: email}); err != nil {
+			t.Fatalf("Request(%q): %v", email, err)
+		}
+	}
+	if got := mailer.count(); got != 0 {
+		t.Fatalf("sent %d mails, want 0", got)
+	}
+}
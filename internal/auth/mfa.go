@@ -0,0 +1,259 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+// recoveryCodeCount 每次生成的恢复码数量
+const recoveryCodeCount = 10
+
+// MFAStatus 用户 MFA 状态
+type MFAStatus struct {
+	TOTPEnabled            bool `json:"totp_enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// TOTPEnrollment 开始绑定 TOTP 时返回给用户的信息
+type TOTPEnrollment struct {
+	// Secret base32 密钥，供无法扫码时手动输入
+	Secret string `json:"secret"`
+	// URI otpauth:// 地址，即二维码内容
+	URI string `json:"otpauth_uri"`
+}
+
+// MFAService TOTP 绑定、校验与恢复码管理
+type MFAService interface {
+	// Status 查询用户 MFA 状态
+	Status(ctx context.Context, userID int64) (*MFAStatus, error)
+	// IsEnabled 用户是否已启用 MFA（登录时判断是否需要第二步）
+	IsEnabled(ctx context.Context, userID int64) (bool, error)
+	// BeginTOTPEnrollment 生成新的 TOTP 密钥（未确认前不生效），已启用时返回 ErrMFAAlreadyEnabled
+	BeginTOTPEnrollment(ctx context.Context, user *domain.User) (*TOTPEnrollment, error)
+	// ConfirmTOTPEnrollment 用验证器 App 生成的验证码确认绑定，启用 MFA 并返回一组一次性恢复码（明文仅返回这一次）
+	ConfirmTOTPEnrollment(ctx context.Context, userID int64, code string) ([]string, error)
+	// DisableTOTP 校验验证码（TOTP 或恢复码）后关闭 MFA
+	DisableTOTP(ctx context.Context, userID int64, code string) error
+	// RegenerateRecoveryCodes 校验验证码后重新生成恢复码，旧恢复码全部作废
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error)
+	// Verify 校验 TOTP 验证码或恢复码，返回本次使用的认证方式（amr）
+	Verify(ctx context.Context, userID int64, code string) ([]string, error)
+}
+
+// MFAConfig MFA 配置
+type MFAConfig struct {
+	// Issuer 显示在验证器 App 中的发行方名称
+	Issuer string
+	// EncryptionKey 用于加密库中 TOTP 密钥的主密钥（任意长度，内部做 SHA-256 派生）
+	EncryptionKey string
+}
+
+type mfaService struct {
+	repo   domain.MFARepository
+	issuer string
+	aead   cipher.AEAD
+}
+
+// NewMFAService 创建 MFA 服务
+func NewMFAService(repo domain.MFARepository, cfg MFAConfig) (MFAService, error) {
+	if cfg.EncryptionKey == "" {
+		return nil, errors.New("mfa encryption key is required")
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = "Monai"
+	}
+	key := sha256.Sum256([]byte(cfg.EncryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &mfaService{repo: repo, issuer: cfg.Issuer, aead: aead}, nil
+}
+
+func (s *mfaService) Status(ctx context.Context, userID int64) (*MFAStatus, error) {
+	enabled, err := s.IsEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	st := &MFAStatus{TOTPEnabled: enabled}
+	if enabled {
+		n, err := s.repo.CountRecoveryCodes(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		st.RecoveryCodesRemaining = n
+	}
+	return st, nil
+}
+
+func (s *mfaService) IsEnabled(ctx context.Context, userID int64) (bool, error) {
+	cred, err := s.repo.FindTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrMFANotEnrolled) {
+			return false, nil
+		}
+		return false, err
+	}
+	return cred.Enabled, nil
+}
+
+func (s *mfaService) BeginTOTPEnrollment(ctx context.Context, user *domain.User) (*TOTPEnrollment, error) {
+	enabled, err := s.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	enc, err := s.encrypt(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveTOTP(ctx, &domain.TOTPCredential{UserID: user.ID, SecretEnc: enc}); err != nil {
+		return nil, err
+	}
+	return &TOTPEnrollment{Secret: secret, URI: totpURI(s.issuer, user.Email, secret)}, nil
+}
+
+func (s *mfaService) ConfirmTOTPEnrollment(ctx context.Context, userID int64, code string) ([]string, error) {
+	cred, err := s.repo.FindTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if cred.Enabled {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+	secret, err := s.decrypt(cred.SecretEnc)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := validateTOTP(secret, normalizeMFACode(code), time.Now())
+	if !ok {
+		return nil, domain.ErrInvalidMFACode
+	}
+	if err := s.repo.EnableTOTP(ctx, userID, step); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(ctx, userID)
+}
+
+func (s *mfaService) DisableTOTP(ctx context.Context, userID int64, code string) error {
+	if _, err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return s.repo.DeleteTOTP(ctx, userID)
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	if _, err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(ctx, userID)
+}
+
+func (s *mfaService) Verify(ctx context.Context, userID int64, code string) ([]string, error) {
+	cred, err := s.repo.FindTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !cred.Enabled {
+		return nil, domain.ErrMFANotEnrolled
+	}
+	code = normalizeMFACode(code)
+	if len(code) == totpDigits {
+		secret, err := s.decrypt(cred.SecretEnc)
+		if err != nil {
+			return nil, err
+		}
+		step, ok := validateTOTP(secret, code, time.Now())
+		if !ok {
+			return nil, domain.ErrInvalidMFACode
+		}
+		// 同一时间步的验证码只能使用一次
+		fresh, err := s.repo.UpdateTOTPLastUsedStep(ctx, userID, step)
+		if err != nil {
+			return nil, err
+		}
+		if !fresh {
+			return nil, domain.ErrInvalidMFACode
+		}
+		return []string{AMROTP}, nil
+	}
+	ok, err := s.repo.ConsumeRecoveryCode(ctx, userID, hashToken(code))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, domain.ErrInvalidMFACode
+	}
+	return nil, nil
+}
+
+// newRecoveryCodes 生成一组恢复码（格式 xxxxx-xxxxx），库中只保存哈希
+func (s *mfaService) newRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(totpEncoding.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashToken(raw))
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeMFACode 去除空白与连字符并转小写，兼容用户输入 "123 456"、"ABCDE-FGHIJ"
+func normalizeMFACode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
+
+// encrypt 使用 AES-GCM 加密 TOTP 密钥，输出 base64(nonce|ciphertext)
+func (s *mfaService) encrypt(plain string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := s.aead.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.StdEncoding.EncodeToString(out), nil
+}
+
+func (s *mfaService) decrypt(enc string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		return "", fmt.Errorf("decode totp secret: %w", err)
+	}
+	n := s.aead.NonceSize()
+	if len(raw) < n {
+		return "", errors.New("totp secret ciphertext too short")
+	}
+	plain, err := s.aead.Open(nil, raw[:n], raw[n:], nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt totp secret: %w", err)
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// MFAChallengeStore 登录第二步的挑战令牌：密码校验通过后签发，提交正确验证码后消费
+type MFAChallengeStore interface {
+	// Save 为用户生成挑战令牌，amr 记录第一步已完成的认证方式
+	Save(userID int64, amr []string) (token string, err error)
+	// Get 查询挑战令牌（不消费）
+	Get(token string) (userID int64, amr []string, ok bool)
+	// Fail 记录一次验证失败，超过最大尝试次数后令牌作废
+	Fail(token string)
+	// Delete 验证成功后删除令牌（一次性）
+	Delete(token string)
+}
+
+type mfaChallengeEntry struct {
+	userID    int64
+	amr       []string
+	attempts  int
+	expiresAt time.Time
+}
+
+// MemoryMFAChallengeStore 内存实现，带 TTL、一次性消费与失败次数限制
+type MemoryMFAChallengeStore struct {
+	ttl         time.Duration
+	maxAttempts int
+	mu          sync.Mutex
+	store       map[string]*mfaChallengeEntry
+}
+
+// NewMemoryMFAChallengeStore 默认 TTL 5 分钟、最多尝试 5 次
+func NewMemoryMFAChallengeStore(ttl time.Duration, maxAttempts int) *MemoryMFAChallengeStore {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	s := &MemoryMFAChallengeStore{ttl: ttl, maxAttempts: maxAttempts, store: make(map[string]*mfaChallengeEntry)}
+	go s.cleanup()
+	return s
+}
+
+func (s *MemoryMFAChallengeStore) Save(userID int64, amr []string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store[token] = &mfaChallengeEntry{userID: userID, amr: amr, expiresAt: time.Now().Add(s.ttl)}
+	return token, nil
+}
+
+func (s *MemoryMFAChallengeStore) Get(token string) (int64, []string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.store[token]
+	if !ok || e == nil || time.Now().After(e.expiresAt) {
+		return 0, nil, false
+	}
+	return e.userID, e.amr, true
+}
+
+func (s *MemoryMFAChallengeStore) Fail(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.store[token]
+	if !ok || e == nil {
+		return
+	}
+	e.attempts++
+	if e.attempts >= s.maxAttempts {
+		delete(s.store, token)
+	}
+}
+
+func (s *MemoryMFAChallengeStore) Delete(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.store, token)
+}
+
+func (s *MemoryMFAChallengeStore) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mu.Lock()
+		now := time.Now()
+		for k, e := range s.store {
+			if e != nil && now.After(e.expiresAt) {
+				delete(s.store, k)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"monai-auth/internal/domain"
+)
+
+// PasswordChecker 按配置的密码策略校验新密码（注册、修改密码、重置密码共用）
+type PasswordChecker interface {
+	// Check 校验新密码；user 提供邮箱、用户名与当前密码哈希，注册时 ID 为 0（不检查历史）
+	Check(ctx context.Context, password string, user *domain.User) error
+	// Remember 密码设置成功后记录其哈希，供历史密码检查使用
+	Remember(ctx context.Context, userID int64, passwordHash string) error
+}
+
+type passwordChecker struct {
+	policy   domain.PasswordPolicy
+	hasher   PasswordHasher
+	breached BreachedPasswordChecker
+	history  domain.PasswordHistoryRepository
+}
+
+// NewPasswordChecker 创建密码校验器；hasher 用于比对历史密码，breached、history 可为 nil 表示不检查泄露库 / 历史密码
+func NewPasswordChecker(policy domain.PasswordPolicy, hasher PasswordHasher, breached BreachedPasswordChecker, history domain.PasswordHistoryRepository) PasswordChecker {
+	if hasher == nil {
+		hasher = DefaultPasswordHasher()
+	}
+	return &passwordChecker{policy: policy, hasher: hasher, breached: breached, history: history}
+}
+
+func (c *passwordChecker) Check(ctx context.Context, password string, user *domain.User) error {
+	if err := c.policy.Validate(password, user.Email, user.Username); err != nil {
+		return err
+	}
+	if c.policy.HistorySize > 0 && c.history != nil && user.ID > 0 {
+		hashes, err := c.history.ListRecent(ctx, user.ID, c.policy.HistorySize)
+		if err != nil {
+			return err
+		}
+		// 启用历史检查前设置的密码不在历史表中，当前密码始终参与比较
+		if user.PasswordHash != "" {
+			hashes = append(hashes, user.PasswordHash)
+		}
+		for _, h := range hashes {
+			if ok, _, _ := c.hasher.Verify(h, password); ok {
+				return domain.ErrPasswordReused
+			}
+		}
+	}
+	if c.breached != nil {
+		breached, err := c.breached.IsBreached(ctx, password)
+		if err != nil {
+			return fmt.Errorf("breached password check: %w", err)
+		}
+		if breached {
+			return domain.ErrPasswordBreached
+		}
+	}
+	return nil
+}
+
+func (c *passwordChecker) Remember(ctx context.Context, userID int64, passwordHash string) error {
+	if c.policy.HistorySize <= 0 || c.history == nil {
+		return nil
+	}
+	return c.history.Add(ctx, userID, passwordHash, c.policy.HistorySize)
+}
+
+// BreachedPasswordChecker 检查密码是否出现在已知泄露密码库中
+type BreachedPasswordChecker interface {
+	IsBreached(ctx context.Context, password string) (bool, error)
+}
+
+// FileBreachedPasswordChecker 本地泄露密码库，采用 k-anonymity 前缀文件格式（与 Pwned Passwords range 接口响应一致）：
+// 目录下每个文件以密码 SHA-1 十六进制的前 5 位命名（如 5BAA6 或 5BAA6.txt），
+// 每行为 "其余 35 位:出现次数"。查询时只读取对应前缀的一个文件，无需整体加载到内存。
+type FileBreachedPasswordChecker struct {
+	dir      string
+	minCount int
+}
+
+// NewFileBreachedPasswordChecker 创建本地泄露库检查器；出现次数低于 minCount 的条目视为未泄露
+func NewFileBreachedPasswordChecker(dir string, minCount int) (*FileBreachedPasswordChecker, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("breached password dir: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("breached password dir: %s is not a directory", dir)
+	}
+	if minCount < 1 {
+		minCount = 1
+	}
+	return &FileBreachedPasswordChecker{dir: dir, minCount: minCount}, nil
+}
+
+func (c *FileBreachedPasswordChecker) IsBreached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digest[:5], digest[5:]
+
+	f, err := c.openPrefixFile(prefix)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		hashPart, countPart, _ := strings.Cut(line, ":")
+		if !strings.EqualFold(hashPart, suffix) {
+			continue
+		}
+		count := 1
+		if countPart != "" {
+			if n, err := strconv.Atoi(strings.TrimSpace(countPart)); err == nil {
+				count = n
+			}
+		}
+		return count >= c.minCount, nil
+	}
+	return false, scanner.Err()
+}
+
+func (c *FileBreachedPasswordChecker) openPrefixFile(prefix string) (*os.File, error) {
+	for _, name := range []string{prefix, prefix + ".txt", strings.ToLower(prefix), strings.ToLower(prefix) + ".txt"} {
+		f, err := os.Open(filepath.Join(c.dir, name))
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, os.ErrNotExist
+}
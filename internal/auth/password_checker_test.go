@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/repository/inmemory"
+)
+
+func TestPasswordCheckerPolicy(t *testing.T) {
+	policy := domain.PasswordPolicy{MinLength: 8, MaxBytes: 72, MinCharClasses: 3, RequireDigit: true, DisallowUserInfo: true}
+	checker := NewPasswordChecker(policy, nil, nil, nil)
+	user := &domain.User{Email: "alice.smith@example.com", Username: "wonderland"}
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{"valid", "Tr0ub4dor&3", nil},
+		{"too short", "Ab1!", domain.ErrPasswordTooShort},
+		{"too long", strings.Repeat("Ab1!", 19), domain.ErrPasswordTooLong},
+		{"missing digit", "Troubador&three", domain.ErrPasswordTooWeak},
+		{"two classes", "troubador33", domain.ErrPasswordTooWeak},
+		{"contains email local part", "xALICE.SMITH1!", domain.ErrPasswordContainsUserInfo},
+		{"contains username", "My-Wonderland-9", domain.ErrPasswordContainsUserInfo},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checker.Check(context.Background(), tt.password, user); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPasswordCheckerHistory(t *testing.T) {
+	ctx := context.Background()
+	hasher := DefaultPasswordHasher()
+	hash := func(password string) string {
+		h, err := hasher.Hash(password)
+		if err != nil {
+			t.Fatalf("Hash: %v", err)
+		}
+		return h
+	}
+	checker := NewPasswordChecker(domain.PasswordPolicy{MinLength: 6, HistorySize: 2}, hasher, nil, inmemory.NewInMemoryPasswordHistoryRepo())
+	// 依次设置 first、second、third，历史只保留最近 2 个
+	for _, password := range []string{"first-password", "second-password", "third-password"} {
+		if err := checker.Remember(ctx, 7, hash(password)); err != nil {
+			t.Fatalf("Remember: %v", err)
+		}
+	}
+	user := &domain.User{ID: 7, Email: "alice@example.com", PasswordHash: hash("current-password")}
+
+	tests := []struct {
+		name     string
+		user     *domain.User
+		password string
+		wantErr  error
+	}{
+		{"latest in history", user, "third-password", domain.ErrPasswordReused},
+		{"older in history", user, "second-password", domain.ErrPasswordReused},
+		{"evicted from history", user, "first-password", nil},
+		// 启用历史检查前设置的当前密码不在历史表中，同样不能重复使用
+		{"current password", user, "current-password", domain.ErrPasswordReused},
+		{"new password", user, "brand-new-password", nil},
+		{"registration skips history", &domain.User{Email: "bob@example.com"}, "third-password", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checker.Check(ctx, tt.password, tt.user); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// sha1Hex 返回密码 SHA-1 的大写十六进制，与 Pwned Passwords 前缀文件一致
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func TestFileBreachedPasswordChecker(t *testing.T) {
+	dir := t.TempDir()
+	writePrefix := func(name string, lines ...string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	// SHA-1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	if got := sha1Hex("password"); got != "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8" {
+		t.Fatalf("sha1Hex = %s", got)
+	}
+	writePrefix("5BAA6", "0018A45C4D1DEF81644B54AB7F969B88D65:1", "1E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493")
+	// 小写文件名、.txt 后缀、小写摘要同样识别；count 低于阈值视为未泄露
+	rare := sha1Hex("rarely-used-secret")
+	writePrefix(strings.ToLower(rare[:5])+".txt", strings.ToLower(rare[5:])+":2")
+	// 前缀文件存在但没有该密码的条目
+	absent := sha1Hex("not-in-the-list")
+	writePrefix(absent[:5], "00000000000000000000000000000000000:50")
+	// 缺少出现次数按 1 次计
+	nocount := sha1Hex("no-count-secret")
+	writePrefix(nocount[:5], nocount[5:])
+
+	checker, err := NewFileBreachedPasswordChecker(dir, 5)
+	if err != nil {
+		t.Fatalf("NewFileBreachedPasswordChecker: %v", err)
+	}
+	lenient, err := NewFileBreachedPasswordChecker(dir, 0)
+	if err != nil {
+		t.Fatalf("NewFileBreachedPasswordChecker: %v", err)
+	}
+	tests := []struct {
+		name     string
+		checker  *FileBreachedPasswordChecker
+		password string
+		want     bool
+	}{
+		{"hit", checker, "password", true},
+		{"below min count", checker, "rarely-used-secret", false},
+		{"below min count lenient", lenient, "rarely-used-secret", true},
+		{"suffix missing from prefix file", checker, "not-in-the-list", false},
+		{"missing prefix file", checker, "correct horse battery staple", false},
+		{"no count", lenient, "no-count-secret", true},
+		{"no count below min count", checker, "no-count-secret", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.checker.IsBreached(context.Background(), tt.password)
+			if err != nil {
+				t.Fatalf("IsBreached: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 泄露的密码经 PasswordChecker 返回 ErrPasswordBreached
+	passwords := NewPasswordChecker(domain.DefaultPasswordPolicy(), nil, checker, nil)
+	if err := passwords.Check(context.Background(), "password", &domain.User{}); !errors.Is(err, domain.ErrPasswordBreached) {
+		t.Fatalf("Check: got %v, want ErrPasswordBreached", err)
+	}
+
+	if _, err := NewFileBreachedPasswordChecker(filepath.Join(dir, "missing"), 1); err == nil {
+		t.Fatal("expected error for missing dir")
+	}
+	if _, err := NewFileBreachedPasswordChecker(filepath.Join(dir, "5BAA6"), 1); err == nil {
+		t.Fatal("expected error for regular file")
+	}
+}
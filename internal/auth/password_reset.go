@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/mail"
+)
+
+// PasswordResetService 忘记密码 / 重置密码流程
+type PasswordResetService interface {
+	// RequestReset 在后台为邮箱对应的用户生成重置令牌并发送邮件后立即返回；邮箱不存在时静默成功，
+	// 两种情况耗时相同，避免借响应内容或响应时间枚举用户
+	RequestReset(ctx context.Context, email string) error
+	// ConfirmReset 校验重置令牌（一次性）并设置新密码，同时吊销该用户所有已签发的 token，返回被重置的用户 ID
+	ConfirmReset(ctx context.Context, req domain.ResetPasswordRequest) (int64, error)
+}
+
+// PasswordResetConfig 重置流程配置
+type PasswordResetConfig struct {
+	// TTL 重置令牌有效期，默认 30 分钟
+	TTL time.Duration
+	// ResetURL 重置页面完整地址，令牌以 ?token= 追加，如 https://auth.example.com/reset-password
+	ResetURL string
+}
+
+type passwordResetService struct {
+	users     domain.UserRepository
+	tokens    domain.PasswordResetTokenRepository
+	mailer    mail.Mailer
+	hasher    PasswordHasher
+	passwords PasswordChecker
+	cfg       PasswordResetConfig
+	async     func(func())
+}
+
+// runInBackground 在新的 goroutine 中执行发信等耗时任务
+func runInBackground(task func()) { go task() }
+
+// NewPasswordResetService 创建重置密码服务；hasher、passwords 为 nil 时使用默认哈希与密码策略
+func NewPasswordResetService(users domain.UserRepository, tokens domain.PasswordResetTokenRepository, mailer mail.Mailer, hasher PasswordHasher, passwords PasswordChecker, cfg PasswordResetConfig) PasswordResetService {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 30 * time.Minute
+	}
+	if hasher == nil {
+		hasher = DefaultPasswordHasher()
+	}
+	if passwords == nil {
+		passwords = NewPasswordChecker(domain.DefaultPasswordPolicy(), hasher, nil, nil)
+	}
+	return &passwordResetService{users: users, tokens: tokens, mailer: mailer, hasher: hasher, passwords: passwords, cfg: cfg, async: runInBackground}
+}
+
+func (s *passwordResetService) RequestReset(ctx context.Context, email string) error {
+	email = domain.NormalizeEmail(email)
+	if email == "" {
+		return nil
+	}
+	// 查找用户、写入令牌与发信都放到后台，请求结束不影响发送
+	ctx = context.WithoutCancel(ctx)
+	s.async(func() {
+		if err := s.sendReset(ctx, email); err != nil {
+			log.Printf("[AUTH] password reset mail failed email=%s err=%v", email, err)
+		}
+	})
+	return nil
+}
+
+// sendReset 生成重置令牌并发送邮件，邮箱未注册时不做任何事
+func (s *passwordResetService) sendReset(ctx context.Context, email string) error {
+	user, err := s.users.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil
+		}
+		return fmt.Errorf("repository lookup error: %w", err)
+	}
+	token, err := newRandomToken(32)
+	if err != nil {
+		return err
+	}
+	if err := s.tokens.Create(ctx, user.ID, hashToken(token), time.Now().Add(s.cfg.TTL)); err != nil {
+		return err
+	}
+	link, err := appendQuery(s.cfg.ResetURL, "token", token)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Hello %s,\n\n"+
+		"We received a request to reset the password for your account.\n"+
+		"Open the link below within %d minutes to choose a new password:\n\n%s\n\n"+
+		"If you did not request this, you can safely ignore this email; your password will not change.\n",
+		user.Username, int(s.cfg.TTL.Minutes()), link)
+	if err := s.mailer.Send(ctx, mail.Message{To: user.Email, Subject: "Reset your password", Body: body}); err != nil {
+		return fmt.Errorf("send reset mail: %w", err)
+	}
+	return nil
+}
+
+func (s *passwordResetService) ConfirmReset(ctx context.Context, req domain.ResetPasswordRequest) (int64, error) {
+	token := strings.TrimSpace(req.Token)
+	if token == "" {
+		return 0, domain.ErrInvalidResetToken
+	}
+	// 先查出令牌所属用户并校验新密码，避免因密码不合规而白白消耗令牌
+	userID, err := s.tokens.Lookup(ctx, hashToken(token))
+	if err != nil {
+		return 0, err
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return 0, domain.ErrInvalidResetToken
+		}
+		return 0, err
+	}
+	if err := s.passwords.Check(ctx, req.NewPassword, user); err != nil {
+		return 0, err
+	}
+	if _, err := s.tokens.Consume(ctx, hashToken(token)); err != nil {
+		return 0, err
+	}
+	hashedPassword, err := s.hasher.Hash(req.NewPassword)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.users.ChangePassword(ctx, userID, hashedPassword); err != nil {
+		return 0, err
+	}
+	if err := s.passwords.Remember(ctx, userID, hashedPassword); err != nil {
+		log.Printf("[AUTH] save password history failed user_id=%d err=%v", userID, err)
+	}
+	// 通过邮箱证明了账号归属，同时解除登录失败锁定
+	if err := s.users.ResetLoginFailures(ctx, userID); err != nil {
+		return 0, fmt.Errorf("reset login failures: %w", err)
+	}
+	if err := s.tokens.DeleteByUser(ctx, userID); err != nil {
+		return 0, fmt.Errorf("invalidate reset tokens: %w", err)
+	}
+	return userID, nil
+}
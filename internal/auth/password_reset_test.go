@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"monai-auth/internal/repository/inmemory"
+)
+
+func TestRequestResetSendsInBackground(t *testing.T) {
+	users := inmemory.NewInMemoryUserRepo()
+	tokens := inmemory.NewInMemoryPasswordResetTokenRepo()
+	mailer := &recordingMailer{}
+	svc := NewPasswordResetService(users, tokens, mailer, nil, nil, PasswordResetConfig{ResetURL: "https://auth.example.com/reset-password"}).(*passwordResetService)
+	var queued []func()
+	svc.async = func(task func()) { queued = append(queued, task) }
+	ctx := context.Background()
+
+	// 已注册与未注册邮箱走同一路径：请求内不查库、不发信
+	for _, email := range []string{"Test@Example.com", "nobody@example.com"} {
+		if err := svc.RequestReset(ctx, email); err != nil {
+			t.Fatalf("RequestReset(%s): %v", email, err)
+		}
+	}
+	if len(queued) != 2 || mailer.count() != 0 {
+		t.Fatalf("queued %d tasks, sent %d mails before running, want 2 and 0", len(queued), mailer.count())
+	}
+
+	for _, task := range queued {
+		task()
+	}
+	if got := mailer.count(); got != 1 {
+		t.Fatalf("sent %d mails, want 1", got)
+	}
+	msg := mailer.sent[0]
+	if msg.To != "test@example.com" {
+		t.Fatalf("mail sent to %s", msg.To)
+	}
+	link := msg.Body[strings.Index(msg.Body, "https://"):]
+	link = link[:strings.IndexByte(link, '\n')]
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("parse link %q: %v", link, err)
+	}
+	if _, err := tokens.Lookup(ctx, hashToken(u.Query().Get("token"))); err != nil {
+		t.Fatalf("Lookup token from mail: %v", err)
+	}
+}
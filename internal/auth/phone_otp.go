@@ -0,0 +1,292 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/sms"
+)
+
+// AMRSMS 通过短信验证码完成的认证（RFC 8176）
+const AMRSMS = "sms"
+
+// otpDigits 短信验证码位数
+const otpDigits = 6
+
+// OTPStore 一次性验证码存储：key（用途 + 手机号）-> 验证码，带 TTL、重发间隔与失败次数限制
+type OTPStore interface {
+	// Issue 为 key 生成新验证码并替换旧码；距上次生成不足重发间隔时返回 *domain.RateLimitedError
+	Issue(key string) (code string, err error)
+	// Verify 校验验证码，成功后删除（一次性）；失败次数达到上限后验证码作废
+	Verify(key, code string) bool
+}
+
+type otpEntry struct {
+	code      string
+	attempts  int
+	issuedAt  time.Time
+	expiresAt time.Time
+}
+
+// MemoryOTPStore 内存实现
+type MemoryOTPStore struct {
+	ttl            time.Duration
+	maxAttempts    int
+	resendInterval time.Duration
+	mu             sync.Mutex
+	store          map[string]*otpEntry
+}
+
+// NewMemoryOTPStore 默认 TTL 5 分钟、最多尝试 5 次、重发间隔 60 秒
+func NewMemoryOTPStore(ttl time.Duration, maxAttempts int, resendInterval time.Duration) *MemoryOTPStore {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	if resendInterval <= 0 {
+		resendInterval = time.Minute
+	}
+	s := &MemoryOTPStore{ttl: ttl, maxAttempts: maxAttempts, resendInterval: resendInterval, store: make(map[string]*otpEntry)}
+	go s.cleanup()
+	return s
+}
+
+func (s *MemoryOTPStore) Issue(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if e, ok := s.store[key]; ok && e != nil {
+		if wait := e.issuedAt.Add(s.resendInterval).Sub(now); wait > 0 {
+			return "", &domain.RateLimitedError{RetryAfter: wait}
+		}
+	}
+	code, err := newNumericCode(otpDigits)
+	if err != nil {
+		return "", err
+	}
+	s.store[key] = &otpEntry{code: code, issuedAt: now, expiresAt: now.Add(s.ttl)}
+	return code, nil
+}
+
+func (s *MemoryOTPStore) Verify(key, code string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.store[key]
+	if !ok || e == nil || e.code == "" || time.Now().After(e.expiresAt) {
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(e.code), []byte(code)) == 1 {
+		delete(s.store, key)
+		return true
+	}
+	e.attempts++
+	if e.attempts >= s.maxAttempts {
+		// 保留条目以继续限制重发间隔，仅作废验证码
+		e.code = ""
+	}
+	return false
+}
+
+func (s *MemoryOTPStore) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mu.Lock()
+		now := time.Now()
+		for k, e := range s.store {
+			if e != nil && now.After(e.expiresAt) && now.After(e.issuedAt.Add(s.resendInterval)) {
+				delete(s.store, k)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// newNumericCode 生成 n 位随机数字验证码
+func newNumericCode(n int) (string, error) {
+	code := make([]byte, n)
+	for i := range code {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + d.Int64())
+	}
+	return string(code), nil
+}
+
+// PhoneOTPService 短信验证码登录与手机号验证
+type PhoneOTPService interface {
+	// SendLoginCode 在后台向已验证手机号发送登录验证码后立即返回；手机号未注册或未验证时静默成功，
+	// 各种情况耗时相同，避免枚举用户。重发间隔不足时返回 *domain.RateLimitedError
+	SendLoginCode(ctx context.Context, phone string) error
+	// VerifyLoginCode 校验登录验证码，返回手机号所属用户 ID；失败返回 domain.ErrInvalidOTP
+	VerifyLoginCode(ctx context.Context, phone, code string) (int64, error)
+	// SendVerificationCode 向待绑定的手机号发送验证码；手机号已被其他用户使用时返回 domain.ErrPhoneExists
+	SendVerificationCode(ctx context.Context, userID int64, phone string) error
+	// VerifyPhone 校验验证码后绑定手机号并标记为已验证，返回更新后的用户
+	VerifyPhone(ctx context.Context, userID int64, phone, code string) (*domain.User, error)
+	// RemovePhone 解绑当前手机号，返回更新后的用户
+	RemovePhone(ctx context.Context, userID int64) (*domain.User, error)
+}
+
+// PhoneOTPConfig 短信验证码配置
+type PhoneOTPConfig struct {
+	// TTL 验证码有效期，仅用于短信正文提示，实际有效期由 OTPStore 控制
+	TTL time.Duration
+	// AppName 短信正文中的应用名称
+	AppName string
+}
+
+type phoneOTPService struct {
+	users  domain.UserRepository
+	codes  OTPStore
+	sender sms.Sender
+	cfg    PhoneOTPConfig
+	async  func(func())
+}
+
+// NewPhoneOTPService 创建短信验证码服务
+func NewPhoneOTPService(users domain.UserRepository, codes OTPStore, sender sms.Sender, cfg PhoneOTPConfig) PhoneOTPService {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 5 * time.Minute
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = "Monai"
+	}
+	return &phoneOTPService{users: users, codes: codes, sender: sender, cfg: cfg, async: runInBackground}
+}
+
+func loginOTPKey(phone string) string { return "login:" + phone }
+
+func verifyOTPKey(userID int64, phone string) string {
+	return "verify:" + strconv.FormatInt(userID, 10) + ":" + phone
+}
+
+func (s *phoneOTPService) SendLoginCode(ctx context.Context, phone string) error {
+	phone, err := domain.NormalizePhoneNumber(phone)
+	if err != nil {
+		return err
+	}
+	// 无论手机号是否注册都先占用重发间隔，使已注册与未注册号码的响应一致
+	code, err := s.codes.Issue(loginOTPKey(phone))
+	if err != nil {
+		return err
+	}
+	// 查找用户与发送短信放到后台，号码是否注册请求耗时都相同
+	ctx = context.WithoutCancel(ctx)
+	s.async(func() {
+		if err := s.sendLoginCode(ctx, phone, code); err != nil {
+			log.Printf("[AUTH] sms login code failed err=%v", err)
+		}
+	})
+	return nil
+}
+
+// sendLoginCode 向已验证手机号所属的可用账号发送登录验证码，其余情况不发送
+func (s *phoneOTPService) sendLoginCode(ctx context.Context, phone, code string) error {
+	user, err := s.users.FindByPhone(ctx, phone)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil
+		}
+		return fmt.Errorf("repository lookup error: %w", err)
+	}
+	if user.PhoneVerifiedAt == nil || user.Status != domain.UserStatusActive {
+		log.Printf("[AUTH] sms login code skipped user_id=%d status=%s phone_verified=%t", user.ID, user.Status, user.PhoneVerifiedAt != nil)
+		return nil
+	}
+	return s.send(ctx, phone, fmt.Sprintf("[%s] Your sign-in code is %s. It expires in %d minutes. Do not share it with anyone.",
+		s.cfg.AppName, code, int(s.cfg.TTL.Minutes())))
+}
+
+func (s *phoneOTPService) VerifyLoginCode(ctx context.Context, phone, code string) (int64, error) {
+	phone, err := domain.NormalizePhoneNumber(phone)
+	if err != nil {
+		return 0, domain.ErrInvalidOTP
+	}
+	if !s.codes.Verify(loginOTPKey(phone), code) {
+		return 0, domain.ErrInvalidOTP
+	}
+	user, err := s.users.FindByPhone(ctx, phone)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return 0, domain.ErrInvalidOTP
+		}
+		return 0, fmt.Errorf("repository lookup error: %w", err)
+	}
+	// 发码后手机号被解绑或更换时，验证码不再有效
+	if user.PhoneVerifiedAt == nil {
+		return 0, domain.ErrInvalidOTP
+	}
+	return user.ID, nil
+}
+
+func (s *phoneOTPService) SendVerificationCode(ctx context.Context, userID int64, phone string) error {
+	phone, err := domain.NormalizePhoneNumber(phone)
+	if err != nil {
+		return err
+	}
+	owner, err := s.users.FindByPhone(ctx, phone)
+	switch {
+	case err == nil && owner.ID != userID:
+		return domain.ErrPhoneExists
+	case err != nil && !errors.Is(err, domain.ErrUserNotFound):
+		return fmt.Errorf("repository lookup error: %w", err)
+	}
+	code, err := s.codes.Issue(verifyOTPKey(userID, phone))
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, phone, fmt.Sprintf("[%s] Your verification code is %s. It expires in %d minutes.",
+		s.cfg.AppName, code, int(s.cfg.TTL.Minutes())))
+}
+
+func (s *phoneOTPService) VerifyPhone(ctx context.Context, userID int64, phone, code string) (*domain.User, error) {
+	phone, err := domain.NormalizePhoneNumber(phone)
+	if err != nil {
+		return nil, err
+	}
+	if !s.codes.Verify(verifyOTPKey(userID, phone), code) {
+		return nil, domain.ErrInvalidOTP
+	}
+	if err := s.users.SetPhoneVerified(ctx, userID, phone, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.users.FindByID(ctx, userID)
+}
+
+func (s *phoneOTPService) RemovePhone(ctx context.Context, userID int64) (*domain.User, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.PhoneNumber == "" {
+		return user, nil
+	}
+	user.PhoneNumber = ""
+	user.PhoneVerifiedAt = nil
+	if err := s.users.UpdateProfile(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// send 发送短信，失败时包装错误
+func (s *phoneOTPService) send(ctx context.Context, phone, body string) error {
+	if err := s.sender.Send(ctx, sms.Message{To: phone, Body: body}); err != nil {
+		return fmt.Errorf("send sms: %w", err)
+	}
+	return nil
+}
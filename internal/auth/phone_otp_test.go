@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/repository/inmemory"
+	"monai-auth/internal/sms"
+)
+
+// recordingSender 记录发出的短信
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []sms.Message
+}
+
+func (s *recordingSender) Send(ctx context.Context, msg sms.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func (s *recordingSender) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+// capturingOTPStore 记录最近签发的验证码，用于未发短信的场景
+type capturingOTPStore struct {
+	OTPStore
+	last string
+}
+
+func (s *capturingOTPStore) Issue(key string) (string, error) {
+	code, err := s.OTPStore.Issue(key)
+	if err == nil {
+		s.last = code
+	}
+	return code, err
+}
+
+// wrongCode 返回与 code 不同的同长度验证码
+func wrongCode(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func TestMemoryOTPStoreVoidsCodeAfterMaxAttempts(t *testing.T) {
+	store := NewMemoryOTPStore(time.Minute, 3, time.Minute)
+	code, err := store.Issue("login:+8613800000000")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if store.Verify("login:+8613800000000", wrongCode(code)) {
+			t.Fatalf("wrong code accepted")
+		}
+	}
+	// 次数用尽后正确的验证码也失效，且不能立即重发
+	if store.Verify("login:+8613800000000", code) {
+		t.Fatalf("code accepted after max attempts")
+	}
+	var rl *domain.RateLimitedError
+	if _, err := store.Issue("login:+8613800000000"); !errors.As(err, &rl) {
+		t.Fatalf("Issue after exhaustion: got %v, want RateLimitedError", err)
+	}
+}
+
+func TestMemoryOTPStoreResendIntervalAndExpiry(t *testing.T) {
+	store := NewMemoryOTPStore(20*time.Millisecond, 5, 40*time.Millisecond)
+	first, err := store.Issue("login:+8613800000000")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	var rl *domain.RateLimitedError
+	if _, err := store.Issue("login:+8613800000000"); !errors.As(err, &rl) || rl.RetryAfter <= 0 || rl.RetryAfter > 40*time.Millisecond {
+		t.Fatalf("resend within interval: got %v", err)
+	}
+	// 重发间隔按 key 计算
+	if _, err := store.Issue("login:+8613900000000"); err != nil {
+		t.Fatalf("Issue other key: %v", err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if store.Verify("login:+8613800000000", first) {
+		t.Fatalf("expired code accepted")
+	}
+	time.Sleep(20 * time.Millisecond)
+	second, err := store.Issue("login:+8613800000000")
+	if err != nil {
+		t.Fatalf("Issue after interval: %v", err)
+	}
+	if !store.Verify("login:+8613800000000", second) || store.Verify("login:+8613800000000", second) {
+		t.Fatalf("reissued code should verify exactly once")
+	}
+}
+
+// newPhoneOTPFixture 创建同步发短信的验证码服务，并注册一个已验证手机号与一个未验证手机号的用户
+func newPhoneOTPFixture(t *testing.T) (*phoneOTPService, *capturingOTPStore, *recordingSender, *domain.User, *domain.User) {
+	t.Helper()
+	users := inmemory.NewInMemoryUserRepo()
+	codes := &capturingOTPStore{OTPStore: NewMemoryOTPStore(time.Minute, 5, time.Millisecond)}
+	sender := &recordingSender{}
+	svc := NewPhoneOTPService(users, codes, sender, PhoneOTPConfig{}).(*phoneOTPService)
+	svc.async = func(task func()) { task() }
+	ctx := context.Background()
+	verifiedAt := time.Now()
+	verified := &domain.User{Username: "verified", Email: "verified@example.com", PhoneNumber: "+8613800000001", PhoneVerifiedAt: &verifiedAt, Role: domain.RoleStandard, Status: domain.UserStatusActive}
+	unverified := &domain.User{Username: "unverified", Email: "unverified@example.com", PhoneNumber: "+8613800000002", Role: domain.RoleStandard, Status: domain.UserStatusActive}
+	for _, u := range []*domain.User{verified, unverified} {
+		if err := users.CreateUser(ctx, u); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+	return svc, codes, sender, verified, unverified
+}
+
+func TestPhoneLoginWithVerifiedPhone(t *testing.T) {
+	svc, codes, sender, verified, _ := newPhoneOTPFixture(t)
+	ctx := context.Background()
+	if err := svc.SendLoginCode(ctx, "+86 138 0000 0001"); err != nil {
+		t.Fatalf("SendLoginCode: %v", err)
+	}
+	if sender.count() != 1 || sender.sent[0].To != verified.PhoneNumber || !strings.Contains(sender.sent[0].Body, codes.last) {
+		t.Fatalf("sent = %+v", sender.sent)
+	}
+	id, err := svc.VerifyLoginCode(ctx, verified.PhoneNumber, codes.last)
+	if err != nil || id != verified.ID {
+		t.Fatalf("VerifyLoginCode = %d, %v", id, err)
+	}
+	if _, err := svc.VerifyLoginCode(ctx, verified.PhoneNumber, codes.last); !errors.Is(err, domain.ErrInvalidOTP) {
+		t.Fatalf("reused code: got %v, want ErrInvalidOTP", err)
+	}
+}
+
+func TestPhoneLoginRejectsUnverifiedPhone(t *testing.T) {
+	svc, codes, sender, _, unverified := newPhoneOTPFixture(t)
+	ctx := context.Background()
+	// 未验证号码与未注册号码一样返回成功但不发短信
+	for _, phone := range []string{"+8613800000009", unverified.PhoneNumber} {
+		if err := svc.SendLoginCode(ctx, phone); err != nil {
+			t.Fatalf("SendLoginCode(%s): %v", phone, err)
+		}
+	}
+	if got := sender.count(); got != 0 {
+		t.Fatalf("sent %d sms, want 0", got)
+	}
+	// 即使拿到了验证码也不能登录未验证号码的账号
+	if _, err := svc.VerifyLoginCode(ctx, unverified.PhoneNumber, codes.last); !errors.Is(err, domain.ErrInvalidOTP) {
+		t.Fatalf("unverified phone: got %v, want ErrInvalidOTP", err)
+	}
+}
+
+func TestPhoneLoginRejectsChangedPhone(t *testing.T) {
+	ctx := context.Background()
+	t.Run("removed", func(t *testing.T) {
+		svc, codes, _, verified, _ := newPhoneOTPFixture(t)
+		if err := svc.SendLoginCode(ctx, verified.PhoneNumber); err != nil {
+			t.Fatalf("SendLoginCode: %v", err)
+		}
+		if _, err := svc.RemovePhone(ctx, verified.ID); err != nil {
+			t.Fatalf("RemovePhone: %v", err)
+		}
+		if _, err := svc.VerifyLoginCode(ctx, verified.PhoneNumber, codes.last); !errors.Is(err, domain.ErrInvalidOTP) {
+			t.Fatalf("removed phone: got %v, want ErrInvalidOTP", err)
+		}
+	})
+	t.Run("rebound to another user", func(t *testing.T) {
+		svc, codes, _, verified, unverified := newPhoneOTPFixture(t)
+		phone := verified.PhoneNumber
+		if err := svc.SendLoginCode(ctx, phone); err != nil {
+			t.Fatalf("SendLoginCode: %v", err)
+		}
+		// 原用户解绑后号码被另一用户以未验证状态写入
+		if _, err := svc.RemovePhone(ctx, verified.ID); err != nil {
+			t.Fatalf("RemovePhone: %v", err)
+		}
+		other, _ := svc.users.FindByID(ctx, unverified.ID)
+		other.PhoneNumber = phone
+		if err := svc.users.UpdateProfile(ctx, other); err != nil {
+			t.Fatalf("UpdateProfile: %v", err)
+		}
+		if _, err := svc.VerifyLoginCode(ctx, phone, codes.last); !errors.Is(err, domain.ErrInvalidOTP) {
+			t.Fatalf("rebound phone: got %v, want ErrInvalidOTP", err)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+// RateLimiter 按 key（如 IP、邮箱）限制请求频率
+type RateLimiter interface {
+	// Allow 消耗一次配额；不允许时返回需等待的时长
+	Allow(ctx context.Context, key string) (allowed bool, retryAfter time.Duration, err error)
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryRateLimiter 内存令牌桶：每个 key 最多积攒 limit 个令牌，每 window 补满，适合单实例部署
+type MemoryRateLimiter struct {
+	limit  float64
+	rate   float64 // 每秒补充的令牌数
+	mu     sync.Mutex
+	bucket map[string]*tokenBucket
+}
+
+// NewMemoryRateLimiter 创建令牌桶限流器：window 内最多 limit 次，允许 limit 次突发
+func NewMemoryRateLimiter(limit int, window time.Duration) *MemoryRateLimiter {
+	if limit <= 0 {
+		limit = 1
+	}
+	if window <= 0 {
+		window = time.Minute
+	}
+	l := &MemoryRateLimiter{
+		limit:  float64(limit),
+		rate:   float64(limit) / window.Seconds(),
+		bucket: make(map[string]*tokenBucket),
+	}
+	go l.cleanup()
+	return l
+}
+
+func (l *MemoryRateLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	b, ok := l.bucket[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.limit, last: now}
+		l.bucket[key] = b
+	}
+	b.tokens = math.Min(l.limit, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// cleanup 定期删除已补满的桶，等价于从未访问过
+func (l *MemoryRateLimiter) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		l.mu.Lock()
+		now := time.Now()
+		for k, b := range l.bucket {
+			if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.limit {
+				delete(l.bucket, k)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// StoreRateLimiter 基于共享存储（如 MySQL）的固定窗口限流器，适合多实例部署
+// 不同规则共用同一存储时，调用方需在 key 中带上规则前缀（RateLimitMiddleware 以 scope 作前缀）
+type StoreRateLimiter struct {
+	repo   domain.RateLimitRepository
+	limit  int64
+	window time.Duration
+}
+
+// NewStoreRateLimiter 创建共享存储限流器
+func NewStoreRateLimiter(repo domain.RateLimitRepository, limit int, window time.Duration) *StoreRateLimiter {
+	if limit <= 0 {
+		limit = 1
+	}
+	if window <= 0 {
+		window = time.Minute
+	}
+	l := &StoreRateLimiter{repo: repo, limit: int64(limit), window: window}
+	go l.cleanup()
+	return l
+}
+
+func (l *StoreRateLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	hits, resetAt, err := l.repo.Hit(ctx, key, l.window)
+	if err != nil {
+		return false, 0, err
+	}
+	if hits <= l.limit {
+		return true, 0, nil
+	}
+	return false, time.Until(resetAt), nil
+}
+
+func (l *StoreRateLimiter) cleanup() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := l.repo.DeleteExpired(context.Background(), time.Now()); err != nil {
+			log.Printf("rate limit cleanup failed err=%v", err)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/saml"
+)
+
+// SAML SP 可用的 NameID 格式（配置取值）
+const (
+	// SAMLNameIDEmail NameID 为用户邮箱
+	SAMLNameIDEmail = "email"
+	// SAMLNameIDPersistent NameID 为按 SP 派生的不透明标识，不同 SP 之间无法关联同一用户
+	SAMLNameIDPersistent = "persistent"
+	// SAMLNameIDUnspecified NameID 为用户名
+	SAMLNameIDUnspecified = "unspecified"
+)
+
+// maxRelayStateLength RelayState 长度上限；规范要求不超过 80 字节，但不少 SP 会超出，这里放宽
+const maxRelayStateLength = 1024
+
+// SAMLAttributeSources 属性映射可用的用户字段
+var SAMLAttributeSources = []string{"id", "username", "email", "first_name", "last_name", "display_name", "role", "phone_number"}
+
+// SAMLAttributeMapping 断言属性名到用户字段的映射
+type SAMLAttributeMapping struct {
+	Name   string
+	Source string
+}
+
+// DefaultSAMLAttributes SP 未配置属性映射时下发的属性
+var DefaultSAMLAttributes = []SAMLAttributeMapping{
+	{Name: "email", Source: "email"},
+	{Name: "username", Source: "username"},
+	{Name: "first_name", Source: "first_name"},
+	{Name: "last_name", Source: "last_name"},
+	{Name: "role", Source: "role"},
+}
+
+// SAMLServiceProvider 已登记的 SP
+type SAMLServiceProvider struct {
+	EntityID string
+	// ACSURLs 允许的断言接收地址（HTTP-POST），第一个为默认地址；AuthnRequest 指定的地址必须在其中
+	ACSURLs []string
+	// NameIDFormat email（缺省）/ persistent / unspecified
+	NameIDFormat string
+	// Attributes 为空时使用 DefaultSAMLAttributes
+	Attributes []SAMLAttributeMapping
+	// SignResponse 除断言外同时对外层 Response 签名（部分 SP 要求）
+	SignResponse bool
+}
+
+// SAMLConfig SAML IdP 配置
+type SAMLConfig struct {
+	ServiceProviders []SAMLServiceProvider
+	// PersistentIDSecret 派生 persistent NameID 的密钥，更换后所有 SP 看到的用户标识都会变化
+	PersistentIDSecret string
+}
+
+// SAMLRequest 待完成的 SP 登录请求，按 state 保存；UserID 非 0 表示用户已在本次请求中完成登录
+type SAMLRequest struct {
+	ServiceProvider string
+	RequestID       string
+	ACSURL          string
+	RelayState      string
+	ForceAuthn      bool
+	IsPassive       bool
+	UserID          int64
+	AMR             []string
+	AuthnInstant    time.Time
+}
+
+// SAMLPost 通过 HTTP-POST 绑定投递到 SP 的响应
+type SAMLPost struct {
+	ACSURL       string
+	SAMLResponse string
+	RelayState   string
+}
+
+// SAMLRequestStore 待完成的 SP 登录请求存储（state -> SAMLRequest）
+type SAMLRequestStore interface {
+	Save(req SAMLRequest) (state string, err error)
+	Get(state string) (SAMLRequest, bool)
+	Update(state string, req SAMLRequest) bool
+	Consume(state string) (SAMLRequest, bool)
+}
+
+// MemorySAMLRequestStore 实现 SAMLRequestStore
+type MemorySAMLRequestStore struct {
+	ttl      time.Duration
+	mu       sync.Mutex
+	requests map[string]*federationEntry[SAMLRequest]
+}
+
+// NewMemorySAMLRequestStore 请求默认 10 分钟有效（用户在登录页的时间）
+func NewMemorySAMLRequestStore(ttl time.Duration) *MemorySAMLRequestStore {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	s := &MemorySAMLRequestStore{ttl: ttl, requests: make(map[string]*federationEntry[SAMLRequest])}
+	go s.cleanup()
+	return s
+}
+
+func (s *MemorySAMLRequestStore) Save(req SAMLRequest) (string, error) {
+	state, err := newRandomToken(24)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[state] = &federationEntry[SAMLRequest]{value: req, expiresAt: time.Now().Add(s.ttl)}
+	return state, nil
+}
+
+func (s *MemorySAMLRequestStore) Get(state string) (SAMLRequest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.requests[state]
+	if !ok || e == nil || time.Now().After(e.expiresAt) {
+		return SAMLRequest{}, false
+	}
+	return e.value, true
+}
+
+func (s *MemorySAMLRequestStore) Update(state string, req SAMLRequest) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.requests[state]
+	if !ok || e == nil || time.Now().After(e.expiresAt) {
+		return false
+	}
+	e.value = req
+	return true
+}
+
+func (s *MemorySAMLRequestStore) Consume(state string) (SAMLRequest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.requests[state]
+	if !ok || e == nil || time.Now().After(e.expiresAt) {
+		return SAMLRequest{}, false
+	}
+	delete(s.requests, state)
+	return e.value, true
+}
+
+func (s *MemorySAMLRequestStore) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mu.Lock()
+		now := time.Now()
+		for k, e := range s.requests {
+			if e != nil && now.After(e.expiresAt) {
+				delete(s.requests, k)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// SAMLService 本服务作为 SAML 2.0 IdP：SP 发起登录时复用认证中心的登录会话，签发已签名的断言
+type SAMLService interface {
+	// Metadata 返回 IdP 元数据
+	Metadata() ([]byte, error)
+	// Begin 校验 AuthnRequest（SP 已登记、ACS 地址允许）并保存为待完成请求，返回 state
+	Begin(req *saml.AuthnRequest, relayState string) (state string, err error)
+	// Pending 查看待完成请求，不消费
+	Pending(state string) (SAMLRequest, bool)
+	// MarkAuthenticated 用户在登录页为该请求完成登录后记录用户与认证方式，返回更新后的请求
+	MarkAuthenticated(state string, userID int64, amr []string) (SAMLRequest, error)
+	// Complete 消费请求并为用户签发断言；请求已标记登录用户时 userID 必须一致
+	Complete(ctx context.Context, state string, userID int64) (SAMLPost, SAMLRequest, error)
+	// Fail 消费请求并构造失败响应（如 saml.StatusNoPassive），由 SP 自行处理
+	Fail(state, status string) (SAMLPost, SAMLRequest, error)
+}
+
+type samlService struct {
+	idp       *saml.IdentityProvider
+	users     domain.UserRepository
+	store     SAMLRequestStore
+	providers map[string]SAMLServiceProvider
+	secret    []byte
+}
+
+// NewSAMLService 创建 SAML IdP 服务
+func NewSAMLService(idp *saml.IdentityProvider, users domain.UserRepository, store SAMLRequestStore, cfg SAMLConfig) SAMLService {
+	m := make(map[string]SAMLServiceProvider, len(cfg.ServiceProviders))
+	for _, sp := range cfg.ServiceProviders {
+		if sp.NameIDFormat == "" {
+			sp.NameIDFormat = SAMLNameIDEmail
+		}
+		if len(sp.Attributes) == 0 {
+			sp.Attributes = DefaultSAMLAttributes
+		}
+		m[sp.EntityID] = sp
+	}
+	return &samlService{idp: idp, users: users, store: store, providers: m, secret: []byte(cfg.PersistentIDSecret)}
+}
+
+func (s *samlService) Metadata() ([]byte, error) {
+	return s.idp.Metadata()
+}
+
+func (s *samlService) Begin(req *saml.AuthnRequest, relayState string) (string, error) {
+	sp, ok := s.providers[req.Issuer]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", domain.ErrUnknownServiceProvider, req.Issuer)
+	}
+	if req.Destination != "" && req.Destination != s.idp.SSOURL {
+		return "", fmt.Errorf("%w: unexpected destination %q", domain.ErrInvalidSAMLRequest, req.Destination)
+	}
+	if len(relayState) > maxRelayStateLength {
+		return "", fmt.Errorf("%w: RelayState too long", domain.ErrInvalidSAMLRequest)
+	}
+	acsURL := sp.ACSURLs[0]
+	if req.ACSURL != "" {
+		if !slices.Contains(sp.ACSURLs, req.ACSURL) {
+			return "", fmt.Errorf("%w: unregistered AssertionConsumerServiceURL %q", domain.ErrInvalidSAMLRequest, req.ACSURL)
+		}
+		acsURL = req.ACSURL
+	}
+	return s.store.Save(SAMLRequest{
+		ServiceProvider: sp.EntityID,
+		RequestID:       req.ID,
+		ACSURL:          acsURL,
+		RelayState:      relayState,
+		ForceAuthn:      req.ForceAuthn,
+		IsPassive:       req.IsPassive,
+	})
+}
+
+func (s *samlService) Pending(state string) (SAMLRequest, bool) {
+	if state == "" {
+		return SAMLRequest{}, false
+	}
+	return s.store.Get(state)
+}
+
+func (s *samlService) MarkAuthenticated(state string, userID int64, amr []string) (SAMLRequest, error) {
+	req, ok := s.Pending(state)
+	if !ok {
+		return SAMLRequest{}, domain.ErrInvalidSAMLState
+	}
+	req.UserID = userID
+	req.AMR = amr
+	req.AuthnInstant = time.Now()
+	if !s.store.Update(state, req) {
+		return SAMLRequest{}, domain.ErrInvalidSAMLState
+	}
+	return req, nil
+}
+
+func (s *samlService) Complete(ctx context.Context, state string, userID int64) (SAMLPost, SAMLRequest, error) {
+	req, ok := s.store.Consume(state)
+	if !ok {
+		return SAMLPost{}, SAMLRequest{}, domain.ErrInvalidSAMLState
+	}
+	if req.UserID != 0 && req.UserID != userID {
+		return SAMLPost{}, req, domain.ErrInvalidSAMLState
+	}
+	sp, ok := s.providers[req.ServiceProvider]
+	if !ok {
+		return SAMLPost{}, req, domain.ErrUnknownServiceProvider
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return SAMLPost{}, req, err
+	}
+	if user.Status != domain.UserStatusActive {
+		return SAMLPost{}, req, domain.ErrInvalidCredentials
+	}
+
+	nameID, format := s.nameID(sp, user)
+	if nameID == "" {
+		return SAMLPost{}, req, fmt.Errorf("user %d has no value for NameID format %s", user.ID, sp.NameIDFormat)
+	}
+	contextClass := saml.AuthnContextUnspecified
+	if slices.Contains(req.AMR, AMRPassword) {
+		contextClass = saml.AuthnContextPassword
+	}
+	sessionIndex, err := newRandomToken(16)
+	if err != nil {
+		return SAMLPost{}, req, err
+	}
+	resp, err := s.idp.BuildResponse(saml.Assertion{
+		RequestID:         req.RequestID,
+		Audience:          sp.EntityID,
+		ACSURL:            req.ACSURL,
+		NameID:            nameID,
+		NameIDFormat:      format,
+		SessionIndex:      "_" + sessionIndex,
+		AuthnInstant:      req.AuthnInstant,
+		AuthnContextClass: contextClass,
+		Attributes:        samlAttributes(sp.Attributes, user),
+		SignResponse:      sp.SignResponse,
+	})
+	if err != nil {
+		return SAMLPost{}, req, err
+	}
+	return SAMLPost{ACSURL: req.ACSURL, SAMLResponse: resp, RelayState: req.RelayState}, req, nil
+}
+
+func (s *samlService) Fail(state, status string) (SAMLPost, SAMLRequest, error) {
+	req, ok := s.store.Consume(state)
+	if !ok {
+		return SAMLPost{}, SAMLRequest{}, domain.ErrInvalidSAMLState
+	}
+	resp, err := s.idp.BuildErrorResponse(req.RequestID, req.ACSURL, status)
+	if err != nil {
+		return SAMLPost{}, req, err
+	}
+	return SAMLPost{ACSURL: req.ACSURL, SAMLResponse: resp, RelayState: req.RelayState}, req, nil
+}
+
+// nameID 按 SP 登记的格式生成 NameID；persistent 为 HMAC(secret, SP 实体标识 + 用户 ID)，同一用户在不同 SP 下不同
+func (s *samlService) nameID(sp SAMLServiceProvider, user *domain.User) (string, string) {
+	switch sp.NameIDFormat {
+	case SAMLNameIDPersistent:
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write([]byte(sp.EntityID))
+		mac.Write([]byte{0})
+		mac.Write([]byte(strconv.FormatInt(user.ID, 10)))
+		return hex.EncodeToString(mac.Sum(nil)), saml.NameIDFormatPersistent
+	case SAMLNameIDUnspecified:
+		return user.Username, saml.NameIDFormatUnspecified
+	default:
+		return user.Email, saml.NameIDFormatEmail
+	}
+}
+
+// samlAttributes 按映射取用户字段，空值的属性不下发
+func samlAttributes(mappings []SAMLAttributeMapping, user *domain.User) []saml.Attribute {
+	out := make([]saml.Attribute, 0, len(mappings))
+	for _, m := range mappings {
+		if v := samlAttributeValue(m.Source, user); v != "" {
+			out = append(out, saml.Attribute{Name: m.Name, Values: []string{v}})
+		}
+	}
+	return out
+}
+
+func samlAttributeValue(source string, user *domain.User) string {
+	switch source {
+	case "id":
+		return strconv.FormatInt(user.ID, 10)
+	case "username":
+		return user.Username
+	case "email":
+		return user.Email
+	case "first_name":
+		return user.FirstName
+	case "last_name":
+		return user.LastName
+	case "display_name":
+		if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+			return name
+		}
+		return user.Username
+	case "role":
+		return user.Role
+	case "phone_number":
+		if user.PhoneVerifiedAt == nil {
+			return ""
+		}
+		return user.PhoneNumber
+	default:
+		return ""
+	}
+}
+
+// ValidateSAMLServiceProvider 校验 SP 登记配置，供启动时检查
+func ValidateSAMLServiceProvider(sp SAMLServiceProvider) error {
+	if strings.TrimSpace(sp.EntityID) == "" {
+		return errors.New("entity_id is required")
+	}
+	if len(sp.ACSURLs) == 0 {
+		return errors.New("at least one acs url is required")
+	}
+	switch sp.NameIDFormat {
+	case "", SAMLNameIDEmail, SAMLNameIDPersistent, SAMLNameIDUnspecified:
+	default:
+		return fmt.Errorf("invalid name_id_format %q, expected email, persistent or unspecified", sp.NameIDFormat)
+	}
+	for _, a := range sp.Attributes {
+		if strings.TrimSpace(a.Name) == "" {
+			return errors.New("attribute name is required")
+		}
+		if !slices.Contains(SAMLAttributeSources, a.Source) {
+			return fmt.Errorf("attribute %q has invalid source %q, expected one of %s", a.Name, a.Source, strings.Join(SAMLAttributeSources, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,714 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/scim"
+)
+
+// SCIMIdentityProvider SCIM 用户 externalId 在 user_identities.provider 中的名称，subject 为 externalId
+const SCIMIdentityProvider = "scim"
+
+// SCIM 列表分页：count 未指定时的默认值与上限
+const (
+	DefaultSCIMPageSize = 100
+	MaxSCIMPageSize     = 200
+)
+
+// maxGroupNameLength 组显示名长度上限，与 user_groups.display_name 一致
+const maxGroupNameLength = 255
+
+// SCIMConfig SCIM 服务配置
+type SCIMConfig struct {
+	// BaseURL SCIM 接口根地址（如 https://auth.example.com/scim/v2），用于 meta.location 与 $ref
+	BaseURL string
+	// Webhooks 非 nil 且未设置 Outbox 时直接发布用户创建、邮箱变更、停用 / 启用与删除事件
+	Webhooks WebhookPublisher
+	// Outbox 非 nil 时上述事件与对应的用户变更在同一事务中写入发件箱
+	Outbox EventOutbox
+}
+
+// SCIMListQuery 列表查询参数；StartIndex 从 1 开始，Count 小于 0 表示未指定
+type SCIMListQuery struct {
+	Filter     string
+	StartIndex int
+	Count      int
+}
+
+// SCIMService 供 HR / IdP 自动化同步用户与组（RFC 7644）。
+// 删除用户为软删除（deleted_at）；active=false 将在用状态改为 inactive，已登录会话随之失效
+type SCIMService interface {
+	ListUsers(ctx context.Context, q SCIMListQuery) (*scim.ListResponse, error)
+	GetUser(ctx context.Context, id string) (*scim.User, error)
+	CreateUser(ctx context.Context, res *scim.User) (*scim.User, error)
+	ReplaceUser(ctx context.Context, id string, res *scim.User) (*scim.User, error)
+	PatchUser(ctx context.Context, id string, ops []scim.PatchOperation) (*scim.User, error)
+	DeleteUser(ctx context.Context, id string) error
+
+	ListGroups(ctx context.Context, q SCIMListQuery) (*scim.ListResponse, error)
+	GetGroup(ctx context.Context, id string) (*scim.Group, error)
+	CreateGroup(ctx context.Context, res *scim.Group) (*scim.Group, error)
+	ReplaceGroup(ctx context.Context, id string, res *scim.Group) (*scim.Group, error)
+	PatchGroup(ctx context.Context, id string, ops []scim.PatchOperation) (*scim.Group, error)
+	DeleteGroup(ctx context.Context, id string) error
+}
+
+type scimService struct {
+	users      domain.UserRepository
+	groups     domain.GroupRepository
+	identities domain.UserIdentityRepository
+	passwords  PasswordChecker
+	hasher     PasswordHasher
+	events     eventEmitter
+	cfg        SCIMConfig
+}
+
+// NewSCIMService 创建 SCIM 服务；设置密码时按 passwords 的策略校验
+func NewSCIMService(users domain.UserRepository, groups domain.GroupRepository, identities domain.UserIdentityRepository, passwords PasswordChecker, hasher PasswordHasher, cfg SCIMConfig) SCIMService {
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	return &scimService{
+		users:      users,
+		groups:     groups,
+		identities: identities,
+		passwords:  passwords,
+		hasher:     hasher,
+		events:     eventEmitter{outbox: cfg.Outbox, webhooks: cfg.Webhooks},
+		cfg:        cfg,
+	}
+}
+
+func (s *scimService) ListUsers(ctx context.Context, q SCIMListQuery) (*scim.ListResponse, error) {
+	f, err := scim.ParseFilter(q.Filter)
+	if err != nil {
+		return nil, err
+	}
+	start, offset, count := scimPage(q)
+	filter := domain.UserFilter{}
+	for _, c := range f {
+		switch c.Attr {
+		case "active":
+			active, ok := c.Value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("%w: active requires a boolean", scim.ErrInvalidFilter)
+			}
+			filter.Statuses = []string{domain.UserStatusActive}
+			if !active {
+				filter.Statuses = []string{domain.UserStatusInactive, domain.UserStatusSuspended, domain.UserStatusPending}
+			}
+			continue
+		}
+		v, ok := c.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s requires a string", scim.ErrInvalidFilter, c.Attr)
+		}
+		var id int64
+		switch c.Attr {
+		case "username":
+			filter.Username = domain.NormalizeUsername(v)
+		case "emails", "emails.value":
+			filter.Email = domain.NormalizeEmail(v)
+		case "id":
+			id = parseSCIMID(v)
+		case "externalid":
+			linked, err := s.identities.FindByProviderSubject(ctx, SCIMIdentityProvider, v)
+			if err != nil && !errors.Is(err, domain.ErrIdentityNotFound) {
+				return nil, err
+			}
+			if err == nil {
+				id = linked.UserID
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported attribute %q", scim.ErrInvalidFilter, c.Attr)
+		}
+		if c.Attr == "id" || c.Attr == "externalid" {
+			// 标识不存在或与前一条件冲突时结果为空
+			if id <= 0 || (filter.ID != 0 && filter.ID != id) {
+				return scim.NewListResponse(0, start, nil), nil
+			}
+			filter.ID = id
+		}
+	}
+	filter.Offset, filter.Limit = offset, max(count, 1)
+	users, total, err := s.users.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var resources []any
+	for _, u := range users[:min(count, len(users))] {
+		res, err := s.userResource(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, res)
+	}
+	return scim.NewListResponse(total, start, resources), nil
+}
+
+func (s *scimService) GetUser(ctx context.Context, id string) (*scim.User, error) {
+	user, err := s.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.userResource(ctx, user)
+}
+
+func (s *scimService) CreateUser(ctx context.Context, res *scim.User) (*scim.User, error) {
+	req, email, phone, err := scimUserFields(res)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := s.users.ExistsByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, domain.ErrEmailExists
+	}
+	if _, err := s.users.FindByUsername(ctx, *req.Username); err == nil {
+		return nil, domain.ErrUserExists
+	} else if !errors.Is(err, domain.ErrUserNotFound) {
+		return nil, err
+	}
+	if res.ExternalID != "" {
+		if _, err := s.identities.FindByProviderSubject(ctx, SCIMIdentityProvider, res.ExternalID); err == nil {
+			return nil, domain.ErrIdentityExists
+		} else if !errors.Is(err, domain.ErrIdentityNotFound) {
+			return nil, err
+		}
+	}
+
+	// 不设置密码时用户可通过忘记密码流程设置，或只使用单点登录
+	user := &domain.User{Email: email, Role: domain.RoleStandard, Status: domain.UserStatusActive}
+	req.Apply(user)
+	setSCIMPhone(user, phone)
+	if res.Active != nil && !*res.Active {
+		user.Status = domain.UserStatusInactive
+	}
+	if res.Password != "" {
+		if err := s.passwords.Check(ctx, res.Password, user); err != nil {
+			return nil, err
+		}
+		if user.PasswordHash, err = s.hasher.Hash(res.Password); err != nil {
+			return nil, err
+		}
+	}
+	err = s.events.run(ctx, func(ctx context.Context) ([]domain.Event, error) {
+		if err := s.users.CreateUser(ctx, user); err != nil {
+			return nil, err
+		}
+		if user.PasswordHash != "" {
+			if err := s.passwords.Remember(ctx, user.ID, user.PasswordHash); err != nil {
+				log.Printf("[SCIM] save password history failed user_id=%d err=%v", user.ID, err)
+			}
+		}
+		if err := s.setExternalID(ctx, user, res.ExternalID); err != nil {
+			return nil, err
+		}
+		return []domain.Event{userEvent(domain.EventUserRegistered, user, map[string]any{"source": "scim"})}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetUser(ctx, strconv.FormatInt(user.ID, 10))
+}
+
+func (s *scimService) ReplaceUser(ctx context.Context, id string, res *scim.User) (*scim.User, error) {
+	user, err := s.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.events.run(ctx, func(ctx context.Context) ([]domain.Event, error) {
+		return s.replaceUser(ctx, user, res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetUser(ctx, id)
+}
+
+func (s *scimService) PatchUser(ctx context.Context, id string, ops []scim.PatchOperation) (*scim.User, error) {
+	user, err := s.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.userResource(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if err := scim.ApplyPatch(res, ops); err != nil {
+		return nil, err
+	}
+	err = s.events.run(ctx, func(ctx context.Context) ([]domain.Event, error) {
+		return s.replaceUser(ctx, user, res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetUser(ctx, id)
+}
+
+func (s *scimService) DeleteUser(ctx context.Context, id string) error {
+	user, err := s.findUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = s.events.run(ctx, func(ctx context.Context) ([]domain.Event, error) {
+		if err := s.users.SoftDelete(ctx, user.ID); err != nil {
+			return nil, err
+		}
+		return []domain.Event{userEvent(domain.EventUserDeleted, user, map[string]any{"source": "scim"})}, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.groups.RemoveUser(ctx, user.ID); err != nil {
+		log.Printf("[SCIM] remove deleted user from groups failed user_id=%d err=%v", user.ID, err)
+	}
+	// 释放 externalId，IdP 之后可用同一标识重新创建用户
+	if err := s.identities.Delete(ctx, user.ID, SCIMIdentityProvider); err != nil && !errors.Is(err, domain.ErrIdentityNotFound) {
+		log.Printf("[SCIM] delete external id failed user_id=%d err=%v", user.ID, err)
+	}
+	return nil
+}
+
+// replaceUser 以资源内容整体更新用户，返回邮箱变更与停用 / 启用事件；active 未携带时不修改状态，password 为空时不修改密码
+func (s *scimService) replaceUser(ctx context.Context, user *domain.User, res *scim.User) ([]domain.Event, error) {
+	req, email, phone, err := scimUserFields(res)
+	if err != nil {
+		return nil, err
+	}
+	req.Apply(user)
+	setSCIMPhone(user, phone)
+	if err := s.users.UpdateProfile(ctx, user); err != nil {
+		return nil, err
+	}
+	var events []domain.Event
+	if email != user.Email {
+		if err := s.users.UpdateEmail(ctx, user.ID, email); err != nil {
+			return nil, err
+		}
+		previous := user.Email
+		user.Email = email
+		events = append(events, userEvent(domain.EventUserEmailChanged, user, map[string]any{"previous_email": previous}))
+	}
+	if res.Active != nil {
+		if status := scimStatus(user.Status, *res.Active); status != user.Status {
+			if err := s.users.UpdateStatus(ctx, user.ID, status); err != nil {
+				return nil, err
+			}
+			previous := user.Status
+			user.Status = status
+			events = append(events, statusChangeEvents(user, previous)...)
+		}
+	}
+	if res.Password != "" {
+		if err := s.passwords.Check(ctx, res.Password, user); err != nil {
+			return nil, err
+		}
+		hash, err := s.hasher.Hash(res.Password)
+		if err != nil {
+			return nil, err
+		}
+		// 递增 token_version，已签发的会话随之失效
+		if _, err := s.users.ChangePassword(ctx, user.ID, hash); err != nil {
+			return nil, err
+		}
+		if err := s.passwords.Remember(ctx, user.ID, hash); err != nil {
+			log.Printf("[SCIM] save password history failed user_id=%d err=%v", user.ID, err)
+		}
+	}
+	if err := s.setExternalID(ctx, user, res.ExternalID); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// scimStatus active=true 激活 inactive / pending 用户；active=false 只停用 active 用户。
+// 管理员暂停（suspended）的账号不受同步影响，pending 账号保持待验证
+func scimStatus(current string, active bool) string {
+	switch {
+	case active && (current == domain.UserStatusInactive || current == domain.UserStatusPending):
+		return domain.UserStatusActive
+	case !active && current == domain.UserStatusActive:
+		return domain.UserStatusInactive
+	default:
+		return current
+	}
+}
+
+// scimUserFields 将资源转换为资料更新请求并校验，返回规范化后的邮箱（未提供邮箱时使用形如邮箱的 userName）与主手机号
+func scimUserFields(res *scim.User) (domain.UpdateProfileRequest, string, string, error) {
+	username := res.UserName
+	if strings.TrimSpace(username) == "" {
+		return domain.UpdateProfileRequest{}, "", "", fmt.Errorf("%w: userName is required", scim.ErrInvalidValue)
+	}
+	var first, last string
+	if res.Name != nil {
+		first, last = res.Name.GivenName, res.Name.FamilyName
+	}
+	phone := res.PrimaryPhoneNumber()
+	if phone != "" {
+		var err error
+		if phone, err = domain.NormalizePhoneNumber(phone); err != nil {
+			return domain.UpdateProfileRequest{}, "", "", err
+		}
+	}
+	req := domain.UpdateProfileRequest{FirstName: &first, LastName: &last}
+	req.Normalize()
+	if err := domain.ValidateUpdateProfileRequest(req); err != nil {
+		return domain.UpdateProfileRequest{}, "", "", err
+	}
+	username = domain.NormalizeUsername(username)
+	email := domain.NormalizeEmail(res.PrimaryEmail())
+	if email == "" {
+		email = username
+	}
+	// 目录常以邮箱作为 userName：按注册规则校验，含 @ 的 userName 必须与该用户的邮箱一致
+	if err := domain.ValidateRegisterRequest(domain.RegisterRequest{Email: email, Username: username}); err != nil {
+		return domain.UpdateProfileRequest{}, "", "", err
+	}
+	req.Username = &username
+	return req, email, phone, nil
+}
+
+// setSCIMPhone 写入目录下发的手机号，号码变更后需用户重新验证
+func setSCIMPhone(user *domain.User, phone string) {
+	if phone != user.PhoneNumber {
+		user.PhoneVerifiedAt = nil
+	}
+	user.PhoneNumber = phone
+}
+
+// setExternalID 保存或清除用户的 externalId
+func (s *scimService) setExternalID(ctx context.Context, user *domain.User, externalID string) error {
+	current, err := s.externalID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if current == externalID {
+		return nil
+	}
+	if current != "" {
+		if err := s.identities.Delete(ctx, user.ID, SCIMIdentityProvider); err != nil && !errors.Is(err, domain.ErrIdentityNotFound) {
+			return err
+		}
+	}
+	if externalID == "" {
+		return nil
+	}
+	return s.identities.Create(ctx, &domain.UserIdentity{
+		UserID:   user.ID,
+		Provider: SCIMIdentityProvider,
+		Subject:  externalID,
+		Email:    user.Email,
+	})
+}
+
+func (s *scimService) externalID(ctx context.Context, userID int64) (string, error) {
+	identities, err := s.identities.ListByUser(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	for _, i := range identities {
+		if i.Provider == SCIMIdentityProvider {
+			return i.Subject, nil
+		}
+	}
+	return "", nil
+}
+
+func (s *scimService) findUser(ctx context.Context, id string) (*domain.User, error) {
+	userID := parseSCIMID(id)
+	if userID <= 0 {
+		return nil, domain.ErrUserNotFound
+	}
+	return s.users.FindByID(ctx, userID)
+}
+
+func (s *scimService) userResource(ctx context.Context, u *domain.User) (*scim.User, error) {
+	active := u.Status == domain.UserStatusActive
+	res := &scim.User{
+		Schemas:  []string{scim.SchemaUser},
+		ID:       strconv.FormatInt(u.ID, 10),
+		UserName: u.Username,
+		Emails:   []scim.MultiValue{{Value: u.Email, Type: "work", Primary: true}},
+		Active:   &active,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      u.CreatedAt,
+			LastModified: u.UpdatedAt,
+			Location:     s.location("Users", u.ID),
+		},
+	}
+	if u.FirstName != "" || u.LastName != "" {
+		formatted := strings.TrimSpace(u.FirstName + " " + u.LastName)
+		res.Name = &scim.Name{GivenName: u.FirstName, FamilyName: u.LastName, Formatted: formatted}
+		res.DisplayName = formatted
+	}
+	if u.PhoneNumber != "" {
+		res.PhoneNumbers = []scim.MultiValue{{Value: u.PhoneNumber, Type: "mobile", Primary: true}}
+	}
+	var err error
+	if res.ExternalID, err = s.externalID(ctx, u.ID); err != nil {
+		return nil, err
+	}
+	groups, err := s.groups.ListByUser(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		res.Groups = append(res.Groups, scim.Reference{
+			Value:   strconv.FormatInt(g.ID, 10),
+			Ref:     s.location("Groups", g.ID),
+			Display: g.DisplayName,
+		})
+	}
+	return res, nil
+}
+
+func (s *scimService) ListGroups(ctx context.Context, q SCIMListQuery) (*scim.ListResponse, error) {
+	f, err := scim.ParseFilter(q.Filter)
+	if err != nil {
+		return nil, err
+	}
+	start, offset, count := scimPage(q)
+	filter := domain.GroupFilter{}
+	for _, c := range f {
+		v, ok := c.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s requires a string", scim.ErrInvalidFilter, c.Attr)
+		}
+		switch c.Attr {
+		case "displayname":
+			filter.DisplayName = strings.TrimSpace(v)
+		case "externalid":
+			filter.ExternalID = v
+		case "id":
+			id := parseSCIMID(v)
+			if id <= 0 || (filter.ID != 0 && filter.ID != id) {
+				return scim.NewListResponse(0, start, nil), nil
+			}
+			filter.ID = id
+		default:
+			return nil, fmt.Errorf("%w: unsupported attribute %q", scim.ErrInvalidFilter, c.Attr)
+		}
+	}
+	filter.Offset, filter.Limit = offset, max(count, 1)
+	groups, total, err := s.groups.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var resources []any
+	for _, g := range groups[:min(count, len(groups))] {
+		resources = append(resources, s.groupResource(g))
+	}
+	return scim.NewListResponse(total, start, resources), nil
+}
+
+func (s *scimService) GetGroup(ctx context.Context, id string) (*scim.Group, error) {
+	g, err := s.findGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.groupResource(g), nil
+}
+
+func (s *scimService) CreateGroup(ctx context.Context, res *scim.Group) (*scim.Group, error) {
+	name, err := scimGroupName(res)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := s.memberIDs(ctx, res.Members, nil)
+	if err != nil {
+		return nil, err
+	}
+	g := &domain.Group{DisplayName: name, ExternalID: res.ExternalID}
+	for _, id := range ids {
+		g.Members = append(g.Members, domain.GroupMember{UserID: id})
+	}
+	if err := s.groups.Create(ctx, g); err != nil {
+		return nil, err
+	}
+	return s.GetGroup(ctx, strconv.FormatInt(g.ID, 10))
+}
+
+func (s *scimService) ReplaceGroup(ctx context.Context, id string, res *scim.Group) (*scim.Group, error) {
+	g, err := s.findGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.replaceGroup(ctx, g, res); err != nil {
+		return nil, err
+	}
+	return s.GetGroup(ctx, id)
+}
+
+func (s *scimService) PatchGroup(ctx context.Context, id string, ops []scim.PatchOperation) (*scim.Group, error) {
+	g, err := s.findGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	res := s.groupResource(g)
+	if err := scim.ApplyPatch(res, ops); err != nil {
+		return nil, err
+	}
+	if err := s.replaceGroup(ctx, g, res); err != nil {
+		return nil, err
+	}
+	return s.GetGroup(ctx, id)
+}
+
+func (s *scimService) DeleteGroup(ctx context.Context, id string) error {
+	groupID := parseSCIMID(id)
+	if groupID <= 0 {
+		return domain.ErrGroupNotFound
+	}
+	return s.groups.Delete(ctx, groupID)
+}
+
+// replaceGroup 更新名称与外部标识，成员按差异增删（大组的 PATCH 不会重写全部成员）
+func (s *scimService) replaceGroup(ctx context.Context, g *domain.Group, res *scim.Group) error {
+	name, err := scimGroupName(res)
+	if err != nil {
+		return err
+	}
+	current := make(map[int64]bool, len(g.Members))
+	for _, m := range g.Members {
+		current[m.UserID] = true
+	}
+	ids, err := s.memberIDs(ctx, res.Members, current)
+	if err != nil {
+		return err
+	}
+	if name != g.DisplayName || res.ExternalID != g.ExternalID {
+		g.DisplayName, g.ExternalID = name, res.ExternalID
+		if err := s.groups.Update(ctx, g); err != nil {
+			return err
+		}
+	}
+	var added, removed []int64
+	wanted := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+		if !current[id] {
+			added = append(added, id)
+		}
+	}
+	for _, m := range g.Members {
+		if !wanted[m.UserID] {
+			removed = append(removed, m.UserID)
+		}
+	}
+	if len(added) > 0 {
+		if err := s.groups.AddMembers(ctx, g.ID, added); err != nil {
+			return err
+		}
+	}
+	if len(removed) > 0 {
+		if err := s.groups.RemoveMembers(ctx, g.ID, removed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// memberIDs 解析成员引用并去重；不在 known 中的成员必须是存在的用户（不支持嵌套组）
+func (s *scimService) memberIDs(ctx context.Context, members []scim.Reference, known map[int64]bool) ([]int64, error) {
+	seen := make(map[int64]bool, len(members))
+	var ids []int64
+	for _, m := range members {
+		if m.Type != "" && !strings.EqualFold(m.Type, "User") {
+			return nil, fmt.Errorf("%w: unsupported member type %q", scim.ErrInvalidValue, m.Type)
+		}
+		id := parseSCIMID(m.Value)
+		if id <= 0 {
+			return nil, fmt.Errorf("%w: unknown member %q", scim.ErrInvalidValue, m.Value)
+		}
+		if seen[id] {
+			continue
+		}
+		if !known[id] {
+			if _, err := s.users.FindByID(ctx, id); err != nil {
+				if errors.Is(err, domain.ErrUserNotFound) {
+					return nil, fmt.Errorf("%w: unknown member %q", scim.ErrInvalidValue, m.Value)
+				}
+				return nil, err
+			}
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func scimGroupName(res *scim.Group) (string, error) {
+	name := strings.TrimSpace(res.DisplayName)
+	if name == "" {
+		return "", fmt.Errorf("%w: displayName is required", scim.ErrInvalidValue)
+	}
+	if utf8.RuneCountInString(name) > maxGroupNameLength {
+		return "", fmt.Errorf("%w: displayName too long", scim.ErrInvalidValue)
+	}
+	return name, nil
+}
+
+func (s *scimService) findGroup(ctx context.Context, id string) (*domain.Group, error) {
+	groupID := parseSCIMID(id)
+	if groupID <= 0 {
+		return nil, domain.ErrGroupNotFound
+	}
+	return s.groups.FindByID(ctx, groupID)
+}
+
+func (s *scimService) groupResource(g *domain.Group) *scim.Group {
+	res := &scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          strconv.FormatInt(g.ID, 10),
+		ExternalID:  g.ExternalID,
+		DisplayName: g.DisplayName,
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Created:      g.CreatedAt,
+			LastModified: g.UpdatedAt,
+			Location:     s.location("Groups", g.ID),
+		},
+	}
+	for _, m := range g.Members {
+		res.Members = append(res.Members, scim.Reference{
+			Value:   strconv.FormatInt(m.UserID, 10),
+			Ref:     s.location("Users", m.UserID),
+			Display: m.Username,
+			Type:    "User",
+		})
+	}
+	return res
+}
+
+func (s *scimService) location(resource string, id int64) string {
+	return s.cfg.BaseURL + "/" + resource + "/" + strconv.FormatInt(id, 10)
+}
+
+// scimPage 计算分页：返回 startIndex（从 1 开始）、偏移量与本页数量
+func scimPage(q SCIMListQuery) (int, int, int) {
+	start := max(q.StartIndex, 1)
+	count := q.Count
+	if count < 0 {
+		count = DefaultSCIMPageSize
+	}
+	return start, start - 1, min(count, MaxSCIMPageSize)
+}
+
+func parseSCIMID(id string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
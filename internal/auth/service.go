@@ -4,61 +4,370 @@ import (
 	"context"
 	"errors"
 	"fmt"
-
-	"golang.org/x/crypto/bcrypt"
+	"log"
+	"slices"
+	"strings"
+	"time"
 
 	"monai-auth/internal/domain"
 )
 
+// LoginResult 登录结果：未启用 MFA 时 Token 非空；启用 MFA 时 MFAToken 非空，需调用 VerifyMFA 完成第二步
+type LoginResult struct {
+	UserID   int64
+	Token    string
+	AMR      []string
+	MFAToken string
+	// Risk 本次登录的风险评估结果，未启用异常登录检测时为 nil
+	Risk *LoginRisk
+}
+
 // Service 定义了鉴权服务的核心业务接口
 type Service interface {
-	Login(ctx context.Context, req domain.LoginRequest) (string, error)
+	Login(ctx context.Context, req domain.LoginRequest) (*LoginResult, error)
+	// VerifyMFA 登录第二步：校验 MFA 挑战令牌与验证码，通过后签发带 amr 的 token
+	VerifyMFA(ctx context.Context, mfaToken, code string) (*LoginResult, error)
+	// CompleteLogin 用户已通过其他方式（如 Passkey）完成认证，按与密码登录相同的规则签发 token 或 MFA 挑战
+	CompleteLogin(ctx context.Context, userID int64, amr []string) (*LoginResult, error)
 	Register(ctx context.Context, req domain.RegisterRequest) (int64, error)
 	Validate(ctx context.Context, tokenString string) (*domain.User, error)
-	// IssueToken 为指定用户签发 access_token（用于授权码换 token）
-	IssueToken(ctx context.Context, userID int64) (string, error)
+	// IssueToken 为指定用户签发 access_token（用于授权码换 token），amr 为登录时的认证方式
+	IssueToken(ctx context.Context, userID int64, amr ...string) (string, error)
+	// UpdateProfile 更新当前用户资料，返回更新后的用户
+	UpdateProfile(ctx context.Context, userID int64, req domain.UpdateProfileRequest) (*domain.User, error)
+	// ChangePassword 以当前会话的 token 鉴权并校验当前密码后修改密码，吊销该用户所有已签发的 token，
+	// 并返回当前会话使用的新 token（沿用原 token 的 amr）
+	ChangePassword(ctx context.Context, tokenString string, req domain.ChangePasswordRequest) (string, error)
+	// UnlockUser 管理员解除账号的登录失败锁定
+	UnlockUser(ctx context.Context, userID int64) error
 }
 
 type authService struct {
-	repo         domain.UserRepository
-	tokenService TokenService
+	repo              domain.UserRepository
+	tokenService      TokenService
+	emailVerification EmailVerificationService
+	mfa               MFAService
+	mfaChallenges     MFAChallengeStore
+	lockout           *LockoutPolicy
+	passwords         PasswordChecker
+	hasher            PasswordHasher
+	risk              LoginRiskService
+	stepUpMFA         bool
+	directory         DirectoryAuthenticator
+	directoryOnly     bool
+	events            eventEmitter
+}
+
+// AuthServiceOpts 鉴权服务可选配置
+type AuthServiceOpts struct {
+	// EmailVerification 非 nil 时新注册用户为 pending 状态，需通过邮件验证后才能登录
+	EmailVerification EmailVerificationService
+	// MFA 与 MFAChallenges 同时非 nil 时，已启用 MFA 的用户登录需完成第二步验证
+	MFA           MFAService
+	MFAChallenges MFAChallengeStore
+	// Lockout 非 nil 时对连续登录失败的账号渐进延迟并临时锁定
+	Lockout *LockoutPolicy
+	// Passwords 密码策略校验器，nil 时使用默认策略
+	Passwords PasswordChecker
+	// Hasher 密码哈希器，nil 时使用 bcrypt 默认 cost
+	Hasher PasswordHasher
+	// LoginRisk 非 nil 时登录成功前检测新设备 / 新国家 / 不可能旅行
+	LoginRisk LoginRiskService
+	// StepUpMFA 为 true 时已启用 MFA 的用户仅在登录被判定为异常时才需第二步验证（需同时设置 LoginRisk）；
+	// false 时始终需要
+	StepUpMFA bool
+	// Directory 非 nil 时密码登录先经外部目录（LDAP / AD）校验，通过后登录对应的本地用户
+	Directory DirectoryAuthenticator
+	// DirectoryMode 为 DirectoryModeOnly 时只接受目录校验，否则目录中不存在该账号或目录不可用时回退到本地密码校验
+	DirectoryMode string
+	// Webhooks 非 nil 且未设置 Outbox 时注册成功后直接发布 user.registered 事件
+	Webhooks WebhookPublisher
+	// Outbox 非 nil 时 user.registered 与创建用户在同一事务中写入发件箱，并在签发 token 后记录 token.issued
+	Outbox EventOutbox
 }
 
 // NewAuthService 创建鉴权服务实例
-func NewAuthService(repo domain.UserRepository, tokenService TokenService) Service {
-	return &authService{
+func NewAuthService(repo domain.UserRepository, tokenService TokenService, opts *AuthServiceOpts) Service {
+	s := &authService{
 		repo:         repo,
 		tokenService: tokenService,
 	}
+	if opts != nil {
+		s.emailVerification = opts.EmailVerification
+		s.lockout = opts.Lockout
+		s.passwords = opts.Passwords
+		s.hasher = opts.Hasher
+		s.risk = opts.LoginRisk
+		s.stepUpMFA = opts.StepUpMFA && opts.LoginRisk != nil
+		s.directory = opts.Directory
+		s.directoryOnly = opts.Directory != nil && opts.DirectoryMode == DirectoryModeOnly
+		s.events = eventEmitter{outbox: opts.Outbox, webhooks: opts.Webhooks}
+		if opts.MFA != nil && opts.MFAChallenges != nil {
+			s.mfa = opts.MFA
+			s.mfaChallenges = opts.MFAChallenges
+		}
+	}
+	if s.hasher == nil {
+		s.hasher = DefaultPasswordHasher()
+	}
+	if s.passwords == nil {
+		s.passwords = NewPasswordChecker(domain.DefaultPasswordPolicy(), s.hasher, nil, nil)
+	}
+	return s
 }
 
-// Login 处理用户登录逻辑
-func (s *authService) Login(ctx context.Context, req domain.LoginRequest) (string, error) {
-	user, err := s.repo.FindByEmail(ctx, req.Email)
-	if err != nil {
-		if errors.Is(err, domain.ErrUserNotFound) {
-			return "", domain.ErrInvalidCredentials
+// Login 处理用户登录逻辑，登录标识可为邮箱、用户名或已验证的手机号；配置了外部目录时先经目录校验
+func (s *authService) Login(ctx context.Context, req domain.LoginRequest) (*LoginResult, error) {
+	user, err := s.findByIdentifier(ctx, req.LoginIdentifier())
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return nil, fmt.Errorf("repository lookup error: %w", err)
+	}
+
+	// 账号处于锁定期：不校验密码，避免锁定期间继续被猜测（也不把猜测转发给目录）
+	if s.lockout != nil && user != nil && user.LockedUntil != nil {
+		if remaining := time.Until(*user.LockedUntil); remaining > 0 {
+			return nil, &domain.AccountLockedError{RetryAfter: remaining}
+		}
+	}
+
+	if s.directory != nil {
+		result, err := s.loginWithDirectory(ctx, req, user)
+		if result != nil || err != nil {
+			return result, err
 		}
-		return "", fmt.Errorf("repository lookup error: %w", err)
+	}
+	if user == nil {
+		return nil, domain.ErrInvalidCredentials
 	}
 
 	// 验证密码
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password))
+	ok, needsRehash, err := s.hasher.Verify(user.PasswordHash, req.Password)
 	if err != nil {
-		return "", domain.ErrInvalidCredentials
+		log.Printf("[AUTH] verify password hash failed user_id=%d err=%v", user.ID, err)
+	}
+	if !ok {
+		s.recordLoginFailure(ctx, user.ID)
+		return nil, domain.ErrInvalidCredentials
+	}
+	// 哈希算法或参数已过时：用刚验证过的明文按当前配置重新哈希保存，失败不影响登录
+	if needsRehash {
+		s.rehashPassword(ctx, user.ID, req.Password)
+	}
+	if s.lockout != nil && user.FailedLoginCount > 0 {
+		if err := s.repo.ResetLoginFailures(ctx, user.ID); err != nil {
+			log.Printf("reset login failures failed user_id=%d err=%v", user.ID, err)
+		}
+	}
+	// 密码正确但邮箱未验证：返回专用错误，便于前端引导用户验证或重发邮件
+	if user.Status == domain.UserStatusPending {
+		return nil, domain.ErrEmailNotVerified
+	}
+	// 已停用（inactive，如 SCIM 同步停用）或被暂停的账号不能登录
+	if user.Status != domain.UserStatusActive {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	return s.completeLogin(ctx, user, []string{AMRPassword})
+}
+
+// loginWithDirectory 经外部目录校验密码；目录中不存在该账号或目录不可用且允许回退时返回 (nil, nil)，由调用方继续本地密码校验。
+// local 为按登录标识找到的本地用户（可能为 nil），目录密码错误时为其累计失败次数
+func (s *authService) loginWithDirectory(ctx context.Context, req domain.LoginRequest, local *domain.User) (*LoginResult, error) {
+	user, err := s.directory.Authenticate(ctx, req.LoginIdentifier(), req.Password)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrDirectoryUserNotFound):
+		if s.directoryOnly {
+			return nil, domain.ErrInvalidCredentials
+		}
+		return nil, nil
+	case errors.Is(err, domain.ErrDirectoryUnavailable):
+		if s.directoryOnly {
+			return nil, err
+		}
+		log.Printf("[AUTH] directory unavailable, falling back to local password err=%v", err)
+		return nil, nil
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		if local != nil {
+			s.recordLoginFailure(ctx, local.ID)
+		}
+		return nil, err
+	default:
+		return nil, err
+	}
+
+	if user.LockedUntil != nil && s.lockout != nil {
+		if remaining := time.Until(*user.LockedUntil); remaining > 0 {
+			return nil, &domain.AccountLockedError{RetryAfter: remaining}
+		}
+	}
+	if s.lockout != nil && user.FailedLoginCount > 0 {
+		if err := s.repo.ResetLoginFailures(ctx, user.ID); err != nil {
+			log.Printf("reset login failures failed user_id=%d err=%v", user.ID, err)
+		}
+	}
+	return s.completeLogin(ctx, user, []string{AMRPassword})
+}
+
+// findByIdentifier 按登录标识查找用户：含 @ 时按邮箱（未命中再按用户名，兼容以邮箱作用户名的账号），
+// 形如手机号时按已验证的手机号，其余按用户名。未验证的手机号不能用于登录
+func (s *authService) findByIdentifier(ctx context.Context, identifier string) (*domain.User, error) {
+	if identifier == "" {
+		return nil, domain.ErrUserNotFound
+	}
+	if strings.Contains(identifier, "@") {
+		user, err := s.repo.FindByEmail(ctx, domain.NormalizeEmail(identifier))
+		if !errors.Is(err, domain.ErrUserNotFound) {
+			return user, err
+		}
+		return s.repo.FindByUsername(ctx, domain.NormalizeUsername(identifier))
+	}
+	if phone, err := domain.NormalizePhoneNumber(identifier); err == nil {
+		user, err := s.repo.FindByPhone(ctx, phone)
+		switch {
+		case err == nil && user.PhoneVerifiedAt != nil:
+			return user, nil
+		case err != nil && !errors.Is(err, domain.ErrUserNotFound):
+			return nil, err
+		}
+		// 未命中已验证手机号时继续按用户名查找，兼容此前注册的形如手机号的用户名
+	}
+	return s.repo.FindByUsername(ctx, domain.NormalizeUsername(identifier))
+}
+
+// rehashPassword 按当前哈希配置重新哈希并保存（不递增 token_version，已签发的 token 仍有效）
+func (s *authService) rehashPassword(ctx context.Context, userID int64, password string) {
+	hashed, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Printf("[AUTH] rehash password failed user_id=%d err=%v", userID, err)
+		return
+	}
+	if err := s.repo.UpdatePasswordHash(ctx, userID, hashed); err != nil {
+		log.Printf("[AUTH] save rehashed password failed user_id=%d err=%v", userID, err)
+	}
+}
+
+// recordLoginFailure 记录一次密码错误，并按策略设置锁定截止时间；失败仅记录日志，不影响登录响应
+func (s *authService) recordLoginFailure(ctx context.Context, userID int64) {
+	if s.lockout == nil {
+		return
+	}
+	failures, err := s.repo.RecordLoginFailure(ctx, userID)
+	if err != nil {
+		log.Printf("record login failure failed user_id=%d err=%v", userID, err)
+		return
+	}
+	if d := s.lockout.lockDuration(failures); d > 0 {
+		if err := s.repo.LockUntil(ctx, userID, time.Now().Add(d)); err != nil {
+			log.Printf("lock user failed user_id=%d err=%v", userID, err)
+			return
+		}
+		log.Printf("user locked user_id=%d failures=%d duration=%s", userID, failures, d)
+	}
+}
+
+// CompleteLogin 外部认证方式通过后继续登录流程：检查账号状态，再走 MFA 判断
+func (s *authService) CompleteLogin(ctx context.Context, userID int64, amr []string) (*LoginResult, error) {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, domain.ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("repository lookup error: %w", err)
+	}
+	if user.Status == domain.UserStatusPending {
+		return nil, domain.ErrEmailNotVerified
+	}
+	if user.Status != domain.UserStatusActive {
+		return nil, domain.ErrInvalidCredentials
+	}
+	return s.completeLogin(ctx, user, amr)
+}
+
+// completeLogin 第一因素认证通过后：已启用 MFA 则签发挑战令牌，否则直接签发 JWT；
+// 本次认证已是多因素（amr 含 mfa，如带用户验证的 Passkey）时不再要求第二步
+func (s *authService) completeLogin(ctx context.Context, user *domain.User, amr []string) (*LoginResult, error) {
+	risk := s.assessLoginRisk(ctx, user)
+	// step-up 模式下仅异常登录需要第二步；评估失败（risk 为 nil）时按需要处理
+	needMFA := !s.stepUpMFA || risk == nil || risk.Flagged()
+	if s.mfa != nil && needMFA && !slices.Contains(amr, AMRMFA) {
+		enabled, err := s.mfa.IsEnabled(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("mfa lookup error: %w", err)
+		}
+		if enabled {
+			mfaToken, err := s.mfaChallenges.Save(user.ID, amr)
+			if err != nil {
+				return nil, fmt.Errorf("mfa challenge failed: %w", err)
+			}
+			return &LoginResult{UserID: user.ID, MFAToken: mfaToken}, nil
+		}
 	}
 
 	// 生成并返回 JWT
-	token, err := s.tokenService.GenerateToken(user.ID, user.Role)
+	token, err := s.issueToken(ctx, user, user.TokenVersion, amr)
 	if err != nil {
-		return "", fmt.Errorf("token generation failed: %w", err)
+		return nil, fmt.Errorf("token generation failed: %w", err)
 	}
+	s.recordLoginRisk(ctx, user, risk)
+	return &LoginResult{UserID: user.ID, Token: token, AMR: amr, Risk: risk}, nil
+}
 
-	return token, nil
+// assessLoginRisk 评估登录风险；未启用或评估失败时返回 nil
+func (s *authService) assessLoginRisk(ctx context.Context, user *domain.User) *LoginRisk {
+	if s.risk == nil {
+		return nil
+	}
+	risk, err := s.risk.Assess(ctx, user)
+	if err != nil {
+		log.Printf("[AUTH] login risk assessment failed user_id=%d err=%v", user.ID, err)
+		return nil
+	}
+	return risk
+}
+
+// recordLoginRisk 登录成功后记住设备并处理异常登录通知
+func (s *authService) recordLoginRisk(ctx context.Context, user *domain.User, risk *LoginRisk) {
+	if s.risk != nil {
+		s.risk.Record(ctx, user, risk)
+	}
+}
+
+// VerifyMFA 校验登录第二步，连续失败超过上限后挑战令牌作废，需重新输入密码
+func (s *authService) VerifyMFA(ctx context.Context, mfaToken, code string) (*LoginResult, error) {
+	if s.mfa == nil {
+		return nil, domain.ErrMFANotConfigured
+	}
+	userID, amr, ok := s.mfaChallenges.Get(mfaToken)
+	if !ok {
+		return nil, domain.ErrInvalidMFAToken
+	}
+	methods, err := s.mfa.Verify(ctx, userID, code)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidMFACode) {
+			s.mfaChallenges.Fail(mfaToken)
+		}
+		return nil, err
+	}
+	s.mfaChallenges.Delete(mfaToken)
+
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	amr = append(append(append([]string{}, amr...), methods...), AMRMFA)
+	token, err := s.issueToken(ctx, user, user.TokenVersion, amr)
+	if err != nil {
+		return nil, fmt.Errorf("token generation failed: %w", err)
+	}
+	risk := s.assessLoginRisk(ctx, user)
+	s.recordLoginRisk(ctx, user, risk)
+	return &LoginResult{UserID: user.ID, Token: token, AMR: amr, Risk: risk}, nil
 }
 
 // Register 处理用户注册逻辑
 func (s *authService) Register(ctx context.Context, req domain.RegisterRequest) (int64, error) {
+	req.Normalize()
 	if err := domain.ValidateRegisterRequest(req); err != nil {
 		return -1, err
 	}
@@ -74,45 +383,171 @@ func (s *authService) Register(ctx context.Context, req domain.RegisterRequest)
 	if username == "" {
 		username = req.Email
 	}
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if _, err := s.repo.FindByUsername(ctx, username); err == nil {
+		return -1, domain.ErrUserExists
+	} else if !errors.Is(err, domain.ErrUserNotFound) {
+		return -1, err
+	}
+	if err := s.passwords.Check(ctx, req.Password, &domain.User{Email: req.Email, Username: username}); err != nil {
+		return -1, err
+	}
+	hashedPassword, err := s.hasher.Hash(req.Password)
 	if err != nil {
-		return -1, fmt.Errorf("password hash failed: %w", err)
+		return -1, err
 	}
 
 	newUser := &domain.User{
 		Username:     username,
 		Email:        req.Email,
-		PasswordHash: string(hashedPassword),
-		Role:         "standard",
+		PasswordHash: hashedPassword,
+		Role:         domain.RoleStandard,
+		Status:       domain.UserStatusActive,
+	}
+	if s.emailVerification != nil {
+		newUser.Status = domain.UserStatusPending
 	}
 
-	if err := s.repo.CreateUser(ctx, newUser); err != nil {
+	err = s.events.run(ctx, func(ctx context.Context) ([]domain.Event, error) {
+		if err := s.repo.CreateUser(ctx, newUser); err != nil {
+			return nil, err
+		}
+		return []domain.Event{userEvent(domain.EventUserRegistered, newUser, map[string]any{"source": "register"})}, nil
+	})
+	if err != nil {
 		return -1, err
 	}
+	if err := s.passwords.Remember(ctx, newUser.ID, hashedPassword); err != nil {
+		log.Printf("[AUTH] save password history failed user_id=%d err=%v", newUser.ID, err)
+	}
+	if s.emailVerification != nil {
+		// 邮件发送失败不回滚注册，用户可通过重发接口再次获取验证邮件
+		if err := s.emailVerification.SendVerification(ctx, newUser); err != nil {
+			log.Printf("[AUTH] send verification mail failed user_id=%d err=%v", newUser.ID, err)
+		}
+	}
 	return newUser.ID, nil
 }
 
 // Validate 验证令牌并返回用户模型 (用于其他服务调用)
 func (s *authService) Validate(ctx context.Context, tokenString string) (*domain.User, error) {
+	_, user, err := s.validateToken(ctx, tokenString)
+	return user, err
+}
+
+// validateToken 校验 JWT 并确认其未被吊销，返回 claims 与对应用户
+func (s *authService) validateToken(ctx context.Context, tokenString string) (*Claims, *domain.User, error) {
 	claims, err := s.tokenService.ValidateToken(tokenString)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// 查找用户确保用户未被禁用/删除
 	user, err := s.repo.FindByID(ctx, claims.UserID)
 	if err != nil {
-		return nil, domain.ErrUserNotFound
+		return nil, nil, domain.ErrUserNotFound
+	}
+	// 修改密码等操作会递增 token_version，旧 token 随之失效
+	if claims.TokenVersion != user.TokenVersion {
+		return nil, nil, domain.ErrTokenRevoked
+	}
+	// 账号停用后已签发的 token 随之失效
+	if user.Status != domain.UserStatusActive {
+		return nil, nil, domain.ErrTokenRevoked
 	}
 
-	return user, nil
+	return claims, user, nil
 }
 
 // IssueToken 根据 userID 签发 JWT（用于授权码兑换 token）
-func (s *authService) IssueToken(ctx context.Context, userID int64) (string, error) {
+func (s *authService) IssueToken(ctx context.Context, userID int64, amr ...string) (string, error) {
 	user, err := s.repo.FindByID(ctx, userID)
 	if err != nil {
 		return "", err
 	}
-	return s.tokenService.GenerateToken(user.ID, user.Role)
+	return s.issueToken(ctx, user, user.TokenVersion, amr)
+}
+
+// issueToken 签发 JWT 并发出 token.issued 事件
+func (s *authService) issueToken(ctx context.Context, user *domain.User, version int64, amr []string) (string, error) {
+	token, err := s.tokenService.GenerateToken(user.ID, user.Role, version, amr...)
+	if err != nil {
+		return "", err
+	}
+	s.events.emit(ctx, newEvent(domain.EventTokenIssued, user.ID, map[string]any{"role": user.Role, "amr": amr}))
+	return token, nil
+}
+
+// UpdateProfile 校验并更新用户资料（仅修改请求中携带的字段）
+func (s *authService) UpdateProfile(ctx context.Context, userID int64, req domain.UpdateProfileRequest) (*domain.User, error) {
+	req.Normalize()
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// 客户端回传未修改的用户名时不再校验，以邮箱为用户名的既有账号仍可更新其他资料
+	if req.Username != nil && *req.Username == user.Username {
+		req.Username = nil
+	}
+	if err := domain.ValidateUpdateProfileRequest(req); err != nil {
+		return nil, err
+	}
+	req.Apply(user)
+	if err := s.repo.UpdateProfile(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ChangePassword 修改密码：校验当前密码与密码策略，重新哈希后递增 token_version，
+// 使该用户此前签发的所有 token（包括其他设备上的会话）失效，并为当前会话签发新 token
+func (s *authService) ChangePassword(ctx context.Context, tokenString string, req domain.ChangePasswordRequest) (string, error) {
+	claims, user, err := s.validateToken(ctx, tokenString)
+	if err != nil {
+		return "", err
+	}
+	// 当前密码错误与登录失败一并计入锁定策略，锁定期间不校验，避免借已登录会话猜测密码
+	if s.lockout != nil && user.LockedUntil != nil {
+		if remaining := time.Until(*user.LockedUntil); remaining > 0 {
+			return "", &domain.AccountLockedError{RetryAfter: remaining}
+		}
+	}
+	if ok, _, _ := s.hasher.Verify(user.PasswordHash, req.CurrentPassword); !ok {
+		s.recordLoginFailure(ctx, user.ID)
+		return "", domain.ErrIncorrectPassword
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return "", domain.ErrPasswordUnchanged
+	}
+	if err := s.passwords.Check(ctx, req.NewPassword, user); err != nil {
+		return "", err
+	}
+	hashedPassword, err := s.hasher.Hash(req.NewPassword)
+	if err != nil {
+		return "", err
+	}
+	version, err := s.repo.ChangePassword(ctx, user.ID, hashedPassword)
+	if err != nil {
+		return "", err
+	}
+	if err := s.passwords.Remember(ctx, user.ID, hashedPassword); err != nil {
+		log.Printf("[AUTH] save password history failed user_id=%d err=%v", user.ID, err)
+	}
+	if s.lockout != nil && user.FailedLoginCount > 0 {
+		if err := s.repo.ResetLoginFailures(ctx, user.ID); err != nil {
+			log.Printf("reset login failures failed user_id=%d err=%v", user.ID, err)
+		}
+	}
+	token, err := s.issueToken(ctx, user, version, claims.AMR)
+	if err != nil {
+		return "", fmt.Errorf("token generation failed: %w", err)
+	}
+	return token, nil
+}
+
+// UnlockUser 清零连续失败次数并解除锁定
+func (s *authService) UnlockUser(ctx context.Context, userID int64) error {
+	if _, err := s.repo.FindByID(ctx, userID); err != nil {
+		return err
+	}
+	return s.repo.ResetLoginFailures(ctx, userID)
 }
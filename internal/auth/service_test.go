@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/repository/inmemory"
+)
+
+const testCurrentPassword = "Correct-Horse-42"
+
+// newPasswordChangeFixture 创建一个已设置密码的用户，并以给定 amr 为其签发 token
+func newPasswordChangeFixture(t *testing.T, amr ...string) (Service, TokenService, *inmemory.InMemoryUserRepo, int64, string) {
+	t.Helper()
+	ctx := context.Background()
+	users := inmemory.NewInMemoryUserRepo()
+	hash, err := DefaultPasswordHasher().Hash(testCurrentPassword)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	user := &domain.User{Email: "alice@example.com", Username: "alice", PasswordHash: hash, Status: domain.UserStatusActive, Role: domain.RoleStandard}
+	if err := users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	id := user.ID
+	tokens := NewJWTService("test-secret", time.Hour)
+	svc := NewAuthService(users, tokens, &AuthServiceOpts{Lockout: &LockoutPolicy{MaxFailures: 3, LockoutDuration: time.Hour}})
+	token, err := svc.IssueToken(ctx, id, amr...)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+	return svc, tokens, users, id, token
+}
+
+func TestChangePasswordCountsFailuresTowardsLockout(t *testing.T) {
+	svc, _, users, id, token := newPasswordChangeFixture(t, AMRPassword)
+	ctx := context.Background()
+	wrong := domain.ChangePasswordRequest{CurrentPassword: "wrong-password", NewPassword: "Another-Battery-7"}
+	for i := 0; i < 3; i++ {
+		if _, err := svc.ChangePassword(ctx, token, wrong); !errors.Is(err, domain.ErrIncorrectPassword) {
+			t.Fatalf("attempt %d: got %v, want ErrIncorrectPassword", i+1, err)
+		}
+	}
+	user, err := users.FindByID(ctx, id)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if user.FailedLoginCount != 3 || user.LockedUntil == nil {
+		t.Fatalf("failures = %d locked_until = %v, want locked after 3", user.FailedLoginCount, user.LockedUntil)
+	}
+
+	// 锁定期间即使当前密码正确也不校验
+	right := domain.ChangePasswordRequest{CurrentPassword: testCurrentPassword, NewPassword: "Another-Battery-7"}
+	var locked *domain.AccountLockedError
+	if _, err := svc.ChangePassword(ctx, token, right); !errors.As(err, &locked) {
+		t.Fatalf("got %v, want AccountLockedError", err)
+	}
+	// 登录同样被锁定
+	if _, err := svc.Login(ctx, domain.LoginRequest{Email: "alice@example.com", Password: testCurrentPassword}); !errors.As(err, &locked) {
+		t.Fatalf("login: got %v, want AccountLockedError", err)
+	}
+}
+
+func TestChangePasswordKeepsAMR(t *testing.T) {
+	amr := []string{AMRPassword, AMROTP, AMRMFA}
+	svc, tokens, _, id, token := newPasswordChangeFixture(t, amr...)
+	ctx := context.Background()
+
+	fresh, err := svc.ChangePassword(ctx, token, domain.ChangePasswordRequest{CurrentPassword: testCurrentPassword, NewPassword: "Another-Battery-7"})
+	if err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	claims, err := tokens.ValidateToken(fresh)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if claims.UserID != id || !slices.Equal(claims.AMR, amr) {
+		t.Fatalf("claims user_id=%d amr=%v, want %d %v", claims.UserID, claims.AMR, id, amr)
+	}
+	// 旧 token 已吊销，新 token 可用
+	if _, err := svc.Validate(ctx, token); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Fatalf("old token: got %v, want ErrTokenRevoked", err)
+	}
+	if _, err := svc.Validate(ctx, fresh); err != nil {
+		t.Fatalf("new token: %v", err)
+	}
+}
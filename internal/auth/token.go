@@ -11,12 +11,23 @@ import (
 type Claims struct {
 	UserID int64  `json:"user_id"`
 	Role   string `json:"role"`
+	// TokenVersion 签发时用户的 token_version，与库中不一致即视为已吊销
+	TokenVersion int64 `json:"ver"`
+	// AMR 认证方式引用（RFC 8176），如 ["pwd"]、["pwd","otp","mfa"]
+	AMR []string `json:"amr,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// 常用 amr 取值（RFC 8176）
+const (
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
+	AMRMFA      = "mfa"
+)
+
 // TokenService 定义了令牌操作接口
 type TokenService interface {
-	GenerateToken(userID int64, role string) (string, error)
+	GenerateToken(userID int64, role string, tokenVersion int64, amr ...string) (string, error)
 	ValidateToken(tokenString string) (*Claims, error)
 }
 
@@ -34,10 +45,12 @@ func NewJWTService(secret string, expiry time.Duration) TokenService {
 }
 
 // GenerateToken 生成 JWT
-func (s *jwtService) GenerateToken(userID int64, role string) (string, error) {
+func (s *jwtService) GenerateToken(userID int64, role string, tokenVersion int64, amr ...string) (string, error) {
 	claims := Claims{
-		UserID: userID,
-		Role:   role,
+		UserID:       userID,
+		Role:         role,
+		TokenVersion: tokenVersion,
+		AMR:          amr,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/repository/inmemory"
+)
+
+// rfc6238Secret RFC 6238 附录 B 的 SHA1 测试密钥 "12345678901234567890"
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// 附录 B 给出 8 位验证码，6 位验证码取其后 6 位
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},          // 94287082
+		{1111111109, "081804"},  // 07081804
+		{1111111111, "050471"},  // 14050471
+		{1234567890, "005924"},  // 89005924
+		{2000000000, "279037"},  // 69279037
+		{20000000000, "353130"}, // 65353130
+	}
+	for _, tt := range tests {
+		got, err := totpCode(rfc6238Secret, totpStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("totpCode(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("totpCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	// 密钥大小写不敏感，非法 base32 返回错误
+	if got, _ := totpCode(strings.ToLower(rfc6238Secret), 1); got != "287082" {
+		t.Errorf("lower-case secret: got %s", got)
+	}
+	if _, err := totpCode("not base32!", 1); err == nil {
+		t.Error("expected error for invalid secret")
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := totpStep(now)
+	tests := []struct {
+		offset int64
+		ok     bool
+	}{
+		{-2, false},
+		{-1, true},
+		{0, true},
+		{1, true},
+		{2, false},
+	}
+	for _, tt := range tests {
+		code, err := totpCode(rfc6238Secret, current+tt.offset)
+		if err != nil {
+			t.Fatalf("totpCode: %v", err)
+		}
+		step, ok := validateTOTP(rfc6238Secret, code, now)
+		if ok != tt.ok {
+			t.Errorf("offset %d: ok = %v, want %v", tt.offset, ok, tt.ok)
+		}
+		if ok && step != current+tt.offset {
+			t.Errorf("offset %d: step = %d, want %d", tt.offset, step, current+tt.offset)
+		}
+	}
+
+	for _, code := range []string{"", "05047", "0504710", "000000"} {
+		if _, ok := validateTOTP(rfc6238Secret, code, now); ok {
+			t.Errorf("code %q accepted", code)
+		}
+	}
+}
+
+// enrollTOTP 为用户绑定 TOTP，返回密钥与恢复码
+func enrollTOTP(t *testing.T, svc MFAService, user *domain.User) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+	enrollment, err := svc.BeginTOTPEnrollment(ctx, user)
+	if err != nil {
+		t.Fatalf("BeginTOTPEnrollment: %v", err)
+	}
+	code, err := totpCode(enrollment.Secret, totpStep(time.Now()))
+	if err != nil {
+		t.Fatalf("totpCode: %v", err)
+	}
+	codes, err := svc.ConfirmTOTPEnrollment(ctx, user.ID, code)
+	if err != nil {
+		t.Fatalf("ConfirmTOTPEnrollment: %v", err)
+	}
+	return enrollment.Secret, codes
+}
+
+func newTestMFAService(t *testing.T) MFAService {
+	t.Helper()
+	svc, err := NewMFAService(inmemory.NewInMemoryMFARepo(), MFAConfig{EncryptionKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewMFAService: %v", err)
+	}
+	return svc
+}
+
+func TestMFAVerifyRejectsReplayedStep(t *testing.T) {
+	svc := newTestMFAService(t)
+	ctx := context.Background()
+	user := &domain.User{ID: 7, Email: "alice@example.com"}
+	secret, _ := enrollTOTP(t, svc, user)
+	current := totpStep(time.Now())
+
+	codeAt := func(step int64) string {
+		code, err := totpCode(secret, step)
+		if err != nil {
+			t.Fatalf("totpCode: %v", err)
+		}
+		return code
+	}
+
+	// 绑定时使用过的验证码不能再用于登录
+	if _, err := svc.Verify(ctx, user.ID, codeAt(current)); !errors.Is(err, domain.ErrInvalidMFACode) {
+		t.Fatalf("code used for enrollment: got %v, want ErrInvalidMFACode", err)
+	}
+	amr, err := svc.Verify(ctx, user.ID, codeAt(current+1))
+	if err != nil {
+		t.Fatalf("Verify next step: %v", err)
+	}
+	if len(amr) != 1 || amr[0] != AMROTP {
+		t.Fatalf("amr = %v", amr)
+	}
+	// 同一时间步重放、以及早于最近一次使用的时间步都被拒绝
+	for _, step := range []int64{current + 1, current} {
+		if _, err := svc.Verify(ctx, user.ID, codeAt(step)); !errors.Is(err, domain.ErrInvalidMFACode) {
+			t.Fatalf("step %d after use: got %v, want ErrInvalidMFACode", step, err)
+		}
+	}
+}
+
+func TestMFARecoveryCodes(t *testing.T) {
+	svc := newTestMFAService(t)
+	ctx := context.Background()
+	user := &domain.User{ID: 7, Email: "alice@example.com"}
+	_, codes := enrollTOTP(t, svc, user)
+
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' || code != strings.ToLower(code) {
+			t.Fatalf("unexpected recovery code format %q", code)
+		}
+		if seen[code] {
+			t.Fatalf("duplicate recovery code %q", code)
+		}
+		seen[code] = true
+	}
+
+	// 输入时大小写、空白与连字符均可忽略
+	inputs := []string{codes[0], strings.ToUpper(codes[1]), strings.ReplaceAll(codes[2], "-", ""), " " + codes[3][:5] + " " + codes[3][6:] + " "}
+	for _, input := range inputs {
+		amr, err := svc.Verify(ctx, user.ID, input)
+		if err != nil {
+			t.Fatalf("Verify(%q): %v", input, err)
+		}
+		if len(amr) != 0 {
+			t.Fatalf("recovery code amr = %v, want none", amr)
+		}
+	}
+	// 恢复码只能使用一次
+	if _, err := svc.Verify(ctx, user.ID, codes[0]); !errors.Is(err, domain.ErrInvalidMFACode) {
+		t.Fatalf("reused recovery code: got %v, want ErrInvalidMFACode", err)
+	}
+	if _, err := svc.Verify(ctx, user.ID, "aaaaa-bbbbb"); !errors.Is(err, domain.ErrInvalidMFACode) {
+		t.Fatalf("unknown recovery code: got %v, want ErrInvalidMFACode", err)
+	}
+	status, err := svc.Status(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if status.RecoveryCodesRemaining != recoveryCodeCount-len(inputs) {
+		t.Fatalf("remaining = %d, want %d", status.RecoveryCodesRemaining, recoveryCodeCount-len(inputs))
+	}
+
+	// 重新生成后旧恢复码全部作废
+	fresh, err := svc.RegenerateRecoveryCodes(ctx, user.ID, codes[4])
+	if err != nil {
+		t.Fatalf("RegenerateRecoveryCodes: %v", err)
+	}
+	if _, err := svc.Verify(ctx, user.ID, codes[5]); !errors.Is(err, domain.ErrInvalidMFACode) {
+		t.Fatalf("old recovery code after regenerate: got %v, want ErrInvalidMFACode", err)
+	}
+	if _, err := svc.Verify(ctx, user.ID, fresh[0]); err != nil {
+		t.Fatalf("new recovery code: %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	"monai-auth/internal/domain"
+)
+
+// AMR 取值：硬件/平台认证器持有证明（RFC 8176 hwk）
+const AMRHardwareKey = "hwk"
+
+// PasskeyService WebAuthn 凭据注册与无密码登录
+type PasskeyService interface {
+	// BeginRegistration 为已登录用户生成注册选项（navigator.credentials.create 参数）
+	BeginRegistration(ctx context.Context, user *domain.User) (*protocol.CredentialCreation, string, error)
+	// FinishRegistration 校验浏览器返回的注册结果并保存凭据
+	FinishRegistration(ctx context.Context, user *domain.User, sessionID, name string, response []byte) (*domain.WebAuthnCredential, error)
+	// BeginLogin 生成无用户名（可发现凭据）登录选项（navigator.credentials.get 参数）
+	BeginLogin(ctx context.Context) (*protocol.CredentialAssertion, string, error)
+	// FinishLogin 校验断言结果，返回登录用户 ID 与本次认证方式（amr）
+	FinishLogin(ctx context.Context, sessionID string, response []byte) (int64, []string, error)
+	// ListCredentials 列出用户已注册的凭据
+	ListCredentials(ctx context.Context, userID int64) ([]*domain.WebAuthnCredential, error)
+	// DeleteCredential 删除用户的凭据
+	DeleteCredential(ctx context.Context, userID, id int64) error
+}
+
+// PasskeyConfig 依赖方（RP）配置
+type PasskeyConfig struct {
+	// RPID 依赖方 ID，通常为认证中心域名（不含协议与端口），如 auth.example.com
+	RPID string
+	// RPDisplayName 浏览器提示中显示的名称
+	RPDisplayName string
+	// RPOrigins 允许发起仪式的页面 Origin，如 https://auth.example.com
+	RPOrigins []string
+}
+
+type passkeyService struct {
+	users    domain.UserRepository
+	creds    domain.WebAuthnCredentialRepository
+	sessions WebAuthnSessionStore
+	wa       *webauthn.WebAuthn
+}
+
+// NewPasskeyService 创建 Passkey 服务
+func NewPasskeyService(users domain.UserRepository, creds domain.WebAuthnCredentialRepository, sessions WebAuthnSessionStore, cfg PasskeyConfig) (PasskeyService, error) {
+	if cfg.RPDisplayName == "" {
+		cfg.RPDisplayName = "Monai"
+	}
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.RPOrigins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationPreferred,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("init webauthn: %w", err)
+	}
+	return &passkeyService{users: users, creds: creds, sessions: sessions, wa: wa}, nil
+}
+
+// webAuthnUser 适配 webauthn.User；user handle 使用十进制用户 ID
+type webAuthnUser struct {
+	user  *domain.User
+	creds []*domain.WebAuthnCredential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte { return webAuthnUserHandle(u.user.ID) }
+
+func (u *webAuthnUser) WebAuthnName() string { return u.user.Email }
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	if name := strings.TrimSpace(u.user.FirstName + " " + u.user.LastName); name != "" {
+		return name
+	}
+	return u.user.Username
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	out := make([]webauthn.Credential, 0, len(u.creds))
+	for _, c := range u.creds {
+		transports := make([]protocol.AuthenticatorTransport, 0, len(c.Transports))
+		for _, t := range c.Transports {
+			transports = append(transports, protocol.AuthenticatorTransport(t))
+		}
+		out = append(out, webauthn.Credential{
+			ID:              c.CredentialID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{AAGUID: c.AAGUID, SignCount: c.SignCount},
+		})
+	}
+	return out
+}
+
+func webAuthnUserHandle(userID int64) []byte {
+	return []byte(strconv.FormatInt(userID, 10))
+}
+
+func (s *passkeyService) loadUser(ctx context.Context, user *domain.User) (*webAuthnUser, error) {
+	creds, err := s.creds.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &webAuthnUser{user: user, creds: creds}, nil
+}
+
+func (s *passkeyService) BeginRegistration(ctx context.Context, user *domain.User) (*protocol.CredentialCreation, string, error) {
+	waUser, err := s.loadUser(ctx, user)
+	if err != nil {
+		return nil, "", err
+	}
+	// 排除已注册的凭据，避免同一认证器重复注册
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(waUser.creds))
+	for _, c := range waUser.WebAuthnCredentials() {
+		exclusions = append(exclusions, c.Descriptor())
+	}
+	creation, session, err := s.wa.BeginRegistration(waUser, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, "", fmt.Errorf("begin webauthn registration: %w", err)
+	}
+	sessionID, err := s.sessions.Save(user.ID, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return creation, sessionID, nil
+}
+
+func (s *passkeyService) FinishRegistration(ctx context.Context, user *domain.User, sessionID, name string, response []byte) (*domain.WebAuthnCredential, error) {
+	sessionUserID, session, ok := s.sessions.GetAndConsume(sessionID)
+	if !ok || sessionUserID != user.ID {
+		return nil, domain.ErrInvalidWebAuthnSession
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrWebAuthnVerification, err)
+	}
+	waUser, err := s.loadUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := s.wa.CreateCredential(waUser, *session, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrWebAuthnVerification, err)
+	}
+	transports := make([]string, 0, len(credential.Transport))
+	for _, t := range credential.Transport {
+		transports = append(transports, string(t))
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+	}
+	cred := &domain.WebAuthnCredential{
+		UserID:          user.ID,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      transports,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		Name:            name,
+	}
+	if err := s.creds.Create(ctx, cred); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+func (s *passkeyService) BeginLogin(ctx context.Context) (*protocol.CredentialAssertion, string, error) {
+	assertion, session, err := s.wa.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, "", fmt.Errorf("begin webauthn login: %w", err)
+	}
+	sessionID, err := s.sessions.Save(0, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return assertion, sessionID, nil
+}
+
+func (s *passkeyService) FinishLogin(ctx context.Context, sessionID string, response []byte) (int64, []string, error) {
+	_, session, ok := s.sessions.GetAndConsume(sessionID)
+	if !ok {
+		return 0, nil, domain.ErrInvalidWebAuthnSession
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", domain.ErrWebAuthnVerification, err)
+	}
+	var loaded *webAuthnUser
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := strconv.ParseInt(string(userHandle), 10, 64)
+		if err != nil {
+			return nil, domain.ErrWebAuthnCredentialNotFound
+		}
+		user, err := s.users.FindByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		loaded, err = s.loadUser(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		return loaded, nil
+	}
+	credential, err := s.wa.ValidateDiscoverableLogin(handler, *session, parsed)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return 0, nil, domain.ErrWebAuthnCredentialNotFound
+		}
+		return 0, nil, fmt.Errorf("%w: %v", domain.ErrWebAuthnVerification, err)
+	}
+	if credential.Authenticator.CloneWarning {
+		return 0, nil, fmt.Errorf("%w: signature counter regressed, authenticator may be cloned", domain.ErrWebAuthnVerification)
+	}
+	if err := s.creds.UpdateAfterLogin(ctx, credential.ID, credential.Authenticator.SignCount, credential.Flags.BackupState); err != nil {
+		return 0, nil, err
+	}
+	// 认证器完成用户验证（生物识别/PIN）时，持有 + 验证已构成多因素
+	amr := []string{AMRHardwareKey}
+	if credential.Flags.UserVerified {
+		amr = append(amr, AMRMFA)
+	}
+	return loaded.user.ID, amr, nil
+}
+
+func (s *passkeyService) ListCredentials(ctx context.Context, userID int64) ([]*domain.WebAuthnCredential, error) {
+	return s.creds.ListByUser(ctx, userID)
+}
+
+func (s *passkeyService) DeleteCredential(ctx context.Context, userID, id int64) error {
+	return s.creds.Delete(ctx, userID, id)
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// WebAuthnSessionStore 保存 WebAuthn 仪式（注册/断言）的挑战数据：服务端生成 sessionID，一次性使用
+type WebAuthnSessionStore interface {
+	// Save 保存挑战数据，userID 为发起注册的用户（无用户名登录时为 0）
+	Save(userID int64, data *webauthn.SessionData) (sessionID string, err error)
+	// GetAndConsume 取出并删除挑战数据
+	GetAndConsume(sessionID string) (userID int64, data *webauthn.SessionData, ok bool)
+}
+
+type webAuthnSessionEntry struct {
+	userID    int64
+	data      *webauthn.SessionData
+	expiresAt time.Time
+}
+
+// MemoryWebAuthnSessionStore 内存实现，带 TTL 与一次性消费
+type MemoryWebAuthnSessionStore struct {
+	ttl   time.Duration
+	mu    sync.Mutex
+	store map[string]*webAuthnSessionEntry
+}
+
+// NewMemoryWebAuthnSessionStore 默认 TTL 5 分钟
+func NewMemoryWebAuthnSessionStore(ttl time.Duration) *MemoryWebAuthnSessionStore {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	s := &MemoryWebAuthnSessionStore{ttl: ttl, store: make(map[string]*webAuthnSessionEntry)}
+	go s.cleanup()
+	return s
+}
+
+func (s *MemoryWebAuthnSessionStore) Save(userID int64, data *webauthn.SessionData) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	sessionID := hex.EncodeToString(b)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store[sessionID] = &webAuthnSessionEntry{userID: userID, data: data, expiresAt: time.Now().Add(s.ttl)}
+	return sessionID, nil
+}
+
+func (s *MemoryWebAuthnSessionStore) GetAndConsume(sessionID string) (int64, *webauthn.SessionData, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.store[sessionID]
+	if !ok || e == nil || time.Now().After(e.expiresAt) {
+		return 0, nil, false
+	}
+	delete(s.store, sessionID)
+	return e.userID, e.data, true
+}
+
+func (s *MemoryWebAuthnSessionStore) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mu.Lock()
+		now := time.Now()
+		for k, e := range s.store {
+			if e != nil && now.After(e.expiresAt) {
+				delete(s.store, k)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,333 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+// 投递记录查询单页条数
+const (
+	DefaultWebhookPageSize = 50
+	MaxWebhookPageSize     = 200
+)
+
+// Webhook 请求头
+const (
+	WebhookHeaderID        = "X-Webhook-Id"        // 事件标识，订阅方据此去重
+	WebhookHeaderEvent     = "X-Webhook-Event"     // 事件类型
+	WebhookHeaderDelivery  = "X-Webhook-Delivery"  // 投递记录 ID
+	WebhookHeaderSignature = "X-Webhook-Signature" // t=<unix 秒>,v1=<签名>
+)
+
+const (
+	webhookBatchSize     = 20
+	webhookRetryBase     = 30 * time.Second
+	webhookRetryMax      = time.Hour
+	webhookMaxErrorBytes = 512
+)
+
+// WebhookPublisher 发布用户生命周期事件
+type WebhookPublisher interface {
+	// Name 返回 "webhook"，WebhookPublisher 因此可作为 eventbus.Sink 挂到领域事件发件箱
+	Name() string
+	// Publish 按订阅方的事件过滤写入投递记录并唤醒投递，不属于 domain.WebhookEventTypes 的事件被忽略；
+	// 返回写入错误，直接发布的调用方只需忽略，经发件箱发布时由发件箱重试
+	Publish(ctx context.Context, event domain.Event) error
+}
+
+// WebhookService 发布事件、投递到订阅方并提供投递日志查询与重放
+type WebhookService interface {
+	WebhookPublisher
+	// ListDeliveries 按条件查询投递记录，Limit 超出范围时按默认值 / 上限处理
+	ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error)
+	// Replay 将投递记录重新排队（清零尝试次数）并立即投递，不存在时返回 domain.ErrWebhookDeliveryNotFound
+	Replay(ctx context.Context, id int64) (*domain.WebhookDelivery, error)
+}
+
+// WebhookSubscriber 订阅方
+type WebhookSubscriber struct {
+	// Name 订阅方名称，写入投递记录；修改后未完成的投递将因找不到订阅方而失败
+	Name string
+	URL  string
+	// Secret HMAC-SHA256 签名密钥
+	Secret string
+	// Events 订阅的事件类型，为空表示全部
+	Events []string
+}
+
+func (s *WebhookSubscriber) accepts(eventType string) bool {
+	return len(s.Events) == 0 || slices.Contains(s.Events, eventType)
+}
+
+// WebhookConfig Webhook 配置
+type WebhookConfig struct {
+	Subscribers []WebhookSubscriber
+	// MaxAttempts 最多尝试次数（含首次），用尽后标记为 failed；缺省 10（约 3 小时内按指数退避重试）
+	MaxAttempts int
+	// Timeout 单次请求超时，缺省 10 秒
+	Timeout time.Duration
+	// PollInterval 扫描发件箱中到期重试的间隔，缺省 5 秒；新事件发布后立即投递，不等待扫描
+	PollInterval time.Duration
+	// Retention 已结束（delivered / failed）记录的保留时长，0 表示永久保留
+	Retention time.Duration
+}
+
+type webhookService struct {
+	repo        domain.WebhookDeliveryRepository
+	subscribers map[string]*WebhookSubscriber
+	client      *http.Client
+	cfg         WebhookConfig
+	wake        chan struct{}
+}
+
+// NewWebhookService 创建 Webhook 服务并在后台投递发件箱中的记录；多实例部署时各实例共同投递，同一记录不会被重复领取
+func NewWebhookService(repo domain.WebhookDeliveryRepository, cfg WebhookConfig) WebhookService {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+	s := &webhookService{
+		repo:        repo,
+		subscribers: make(map[string]*WebhookSubscriber, len(cfg.Subscribers)),
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// 重定向视为失败：签名只对配置的地址有意义
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		cfg:  cfg,
+		wake: make(chan struct{}, 1),
+	}
+	for i := range cfg.Subscribers {
+		s.subscribers[cfg.Subscribers[i].Name] = &cfg.Subscribers[i]
+	}
+	go s.run()
+	if cfg.Retention > 0 {
+		go s.cleanup()
+	}
+	return s
+}
+
+// webhookPayload 请求体
+type webhookPayload struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	CreatedAt time.Time      `json:"created_at"`
+	Data      map[string]any `json:"data"`
+}
+
+func (s *webhookService) Name() string { return "webhook" }
+
+func (s *webhookService) Publish(ctx context.Context, event domain.Event) error {
+	if !slices.Contains(domain.WebhookEventTypes, event.Type) {
+		return nil
+	}
+	ctx = context.WithoutCancel(ctx)
+	if event.ID == "" {
+		id, err := newRandomToken(16)
+		if err != nil {
+			log.Printf("[WEBHOOK] generate event id failed type=%s user_id=%d err=%v", event.Type, event.UserID, err)
+			return err
+		}
+		event.ID = "evt_" + id
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	var deliveries []*domain.WebhookDelivery
+	var payload []byte
+	for _, sub := range s.cfg.Subscribers {
+		if !sub.accepts(event.Type) {
+			continue
+		}
+		if payload == nil {
+			var err error
+			payload, err = json.Marshal(webhookPayload{ID: event.ID, Type: event.Type, CreatedAt: event.OccurredAt.UTC(), Data: event.Data})
+			if err != nil {
+				log.Printf("[WEBHOOK] encode event failed id=%s type=%s err=%v", event.ID, event.Type, err)
+				return err
+			}
+		}
+		deliveries = append(deliveries, &domain.WebhookDelivery{
+			Subscriber:    sub.Name,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			UserID:        event.UserID,
+			Payload:       payload,
+			Status:        domain.WebhookDeliveryPending,
+			NextAttemptAt: event.OccurredAt,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := s.repo.Create(ctx, deliveries); err != nil {
+		log.Printf("[WEBHOOK] enqueue failed id=%s type=%s user_id=%d err=%v", event.ID, event.Type, event.UserID, err)
+		return err
+	}
+	s.signal()
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultWebhookPageSize
+	}
+	if filter.Limit > MaxWebhookPageSize {
+		filter.Limit = MaxWebhookPageSize
+	}
+	return s.repo.List(ctx, filter)
+}
+
+func (s *webhookService) Replay(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	if err := s.repo.Requeue(ctx, id, time.Now()); err != nil {
+		return nil, err
+	}
+	s.signal()
+	return s.repo.FindByID(ctx, id)
+}
+
+// signal 唤醒投递协程；已有未处理的唤醒时忽略
+func (s *webhookService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *webhookService) run() {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		s.dispatch(context.Background())
+	}
+}
+
+// dispatch 领取并投递全部到期记录；每批并发投递，批满时继续领取下一批
+func (s *webhookService) dispatch(ctx context.Context) {
+	// 租约覆盖一次请求的最长耗时，进程在投递中退出时记录于租约到期后由其他实例重试
+	lease := 2*s.cfg.Timeout + time.Minute
+	for {
+		batch, err := s.repo.ClaimDue(ctx, time.Now(), lease, webhookBatchSize)
+		if err != nil {
+			log.Printf("[WEBHOOK] claim deliveries failed err=%v", err)
+			return
+		}
+		var wg sync.WaitGroup
+		for _, d := range batch {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.deliver(ctx, d)
+			}()
+		}
+		wg.Wait()
+		if len(batch) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// deliver 发送一次请求并保存结果：2xx 为成功，其余按指数退避重试，次数用尽标记为 failed
+func (s *webhookService) deliver(ctx context.Context, d *domain.WebhookDelivery) {
+	d.Attempts++
+	now := time.Now()
+	code, retry, err := s.send(ctx, d)
+	d.LastStatusCode = code
+	switch {
+	case err == nil:
+		d.Status = domain.WebhookDeliveryDelivered
+		d.DeliveredAt = &now
+		d.LastError = ""
+	case !retry || d.Attempts >= s.cfg.MaxAttempts:
+		d.Status = domain.WebhookDeliveryFailed
+		d.LastError = truncate(err.Error(), webhookMaxErrorBytes)
+	default:
+		d.NextAttemptAt = now.Add(retryDelay(d.Attempts, webhookRetryBase, webhookRetryMax))
+		d.LastError = truncate(err.Error(), webhookMaxErrorBytes)
+	}
+	if err != nil {
+		log.Printf("[WEBHOOK] delivery failed id=%d subscriber=%s event=%s attempts=%d status=%s err=%v",
+			d.ID, d.Subscriber, d.EventType, d.Attempts, d.Status, err)
+	}
+	if err := s.repo.UpdateAttempt(ctx, d); err != nil {
+		log.Printf("[WEBHOOK] save delivery attempt failed id=%d err=%v", d.ID, err)
+	}
+}
+
+// send 返回响应状态码（未收到响应时为 0）与失败后是否值得重试；订阅方已不在配置中时不再重试
+func (s *webhookService) send(ctx context.Context, d *domain.WebhookDelivery) (int, bool, error) {
+	sub, ok := s.subscribers[d.Subscriber]
+	if !ok {
+		return 0, false, fmt.Errorf("subscriber %q is not configured", d.Subscriber)
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "monai-auth-webhook/1.0")
+	req.Header.Set(WebhookHeaderID, d.EventID)
+	req.Header.Set(WebhookHeaderEvent, d.EventType)
+	req.Header.Set(WebhookHeaderDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(sub.Secret, time.Now().Unix(), d.Payload))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, true, err
+	}
+	defer resp.Body.Close()
+	// 读取少量响应体以便复用连接，并在失败时记录订阅方返回的原因
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxErrorBytes))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if body = bytes.TrimSpace(body); len(body) > 0 {
+			return resp.StatusCode, true, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+		}
+		return resp.StatusCode, true, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, false, nil
+}
+
+func (s *webhookService) cleanup() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := s.repo.DeleteBefore(context.Background(), time.Now().Add(-s.cfg.Retention)); err != nil {
+			log.Printf("[WEBHOOK] cleanup failed err=%v", err)
+		}
+	}
+}
+
+// SignWebhookPayload 计算签名头的值 t=<timestamp>,v1=<hex(HMAC-SHA256(secret, "<timestamp>.<body>"))>；
+// 签名包含时间戳，订阅方应拒绝时间戳偏差过大的请求以防重放
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	ts := strconv.FormatInt(timestamp, 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
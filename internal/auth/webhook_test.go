@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"monai-auth/internal/domain"
+	"monai-auth/internal/repository/inmemory"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	// 期望值由 HMAC-SHA256("whsec_test", "1700000000." + body) 独立计算
+	body := []byte(`{"id":"evt_1","type":"user.registered"}`)
+	want := "t=1700000000,v1=5bb28768ab12e6b55be693f4ee5a64c75823a433985593105489a3b03e65836b"
+	if got := SignWebhookPayload("whsec_test", 1700000000, body); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	// 时间戳参与签名
+	if got := SignWebhookPayload("whsec_test", 1700000001, body); strings.HasSuffix(got, want[len("t=1700000000"):]) {
+		t.Fatalf("signature does not depend on timestamp: %s", got)
+	}
+}
+
+// webhookSubscriberStub 按顺序返回预设状态码，并校验请求签名
+type webhookSubscriberStub struct {
+	t        *testing.T
+	secret   string
+	mu       sync.Mutex
+	statuses []int
+	calls    int
+}
+
+func (s *webhookSubscriberStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	ts, _, _ := strings.Cut(strings.TrimPrefix(r.Header.Get(WebhookHeaderSignature), "t="), ",")
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || r.Header.Get(WebhookHeaderSignature) != SignWebhookPayload(s.secret, unix, body) {
+		s.t.Errorf("bad signature %q", r.Header.Get(WebhookHeaderSignature))
+	}
+	if r.Header.Get(WebhookHeaderEvent) != domain.EventUserRegistered || r.Header.Get(WebhookHeaderID) == "" {
+		s.t.Errorf("unexpected headers %v", r.Header)
+	}
+	s.mu.Lock()
+	status := s.statuses[min(s.calls, len(s.statuses)-1)]
+	s.calls++
+	s.mu.Unlock()
+	w.WriteHeader(status)
+	if status >= 300 {
+		_, _ = io.WriteString(w, "subscriber unavailable")
+	}
+}
+
+// newWebhookFixture 创建不启动后台投递的 Webhook 服务，由测试逐次领取并投递
+func newWebhookFixture(t *testing.T, maxAttempts int, statuses ...int) (*webhookService, *inmemory.InMemoryWebhookDeliveryRepo, *webhookSubscriberStub) {
+	t.Helper()
+	stub := &webhookSubscriberStub{t: t, secret: "whsec_test", statuses: statuses}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	repo := inmemory.NewInMemoryWebhookDeliveryRepo()
+	sub := &WebhookSubscriber{Name: "crm", URL: server.URL, Secret: stub.secret}
+	s := &webhookService{
+		repo:        repo,
+		subscribers: map[string]*WebhookSubscriber{sub.Name: sub},
+		client:      server.Client(),
+		cfg:         WebhookConfig{Subscribers: []WebhookSubscriber{*sub}, MaxAttempts: maxAttempts, Timeout: 5 * time.Second},
+		wake:        make(chan struct{}, 1),
+	}
+	if err := s.Publish(context.Background(), newEvent(domain.EventUserRegistered, 42, map[string]any{"email": "alice@example.com"})); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	return s, repo, stub
+}
+
+// deliverDue 以 at 为当前时间领取到期记录并逐个投递，返回领取数量
+func deliverDue(t *testing.T, s *webhookService, at time.Time) int {
+	t.Helper()
+	batch, err := s.repo.ClaimDue(context.Background(), at, time.Minute, webhookBatchSize)
+	if err != nil {
+		t.Fatalf("ClaimDue: %v", err)
+	}
+	for _, d := range batch {
+		s.deliver(context.Background(), d)
+	}
+	return len(batch)
+}
+
+func findDelivery(t *testing.T, repo *inmemory.InMemoryWebhookDeliveryRepo) *domain.WebhookDelivery {
+	t.Helper()
+	d, err := repo.FindByID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	return d
+}
+
+func TestWebhookDeliverSuccess(t *testing.T) {
+	s, repo, stub := newWebhookFixture(t, 3, http.StatusNoContent)
+	if n := deliverDue(t, s, time.Now()); n != 1 {
+		t.Fatalf("claimed %d deliveries, want 1", n)
+	}
+	d := findDelivery(t, repo)
+	if d.Status != domain.WebhookDeliveryDelivered || d.Attempts != 1 || d.LastStatusCode != http.StatusNoContent || d.DeliveredAt == nil {
+		t.Fatalf("delivery = %+v", d)
+	}
+	if n := deliverDue(t, s, time.Now().Add(24*time.Hour)); n != 0 || stub.calls != 1 {
+		t.Fatalf("claimed %d after delivery, subscriber called %d times", n, stub.calls)
+	}
+}
+
+func TestWebhookDeliverRetriesWithBackoff(t *testing.T) {
+	s, repo, stub := newWebhookFixture(t, 3, http.StatusInternalServerError, http.StatusOK)
+	start := time.Now()
+	deliverDue(t, s, start)
+	d := findDelivery(t, repo)
+	if d.Status != domain.WebhookDeliveryPending || d.Attempts != 1 || d.LastStatusCode != http.StatusInternalServerError {
+		t.Fatalf("after 5xx: %+v", d)
+	}
+	if !strings.Contains(d.LastError, "subscriber unavailable") {
+		t.Fatalf("last error = %q", d.LastError)
+	}
+	// 首次重试约 30 秒后（±20% 抖动）
+	if wait := d.NextAttemptAt.Sub(start); wait < 24*time.Second || wait > 37*time.Second {
+		t.Fatalf("next attempt in %s, want about 30s", wait)
+	}
+	if n := deliverDue(t, s, start.Add(20*time.Second)); n != 0 {
+		t.Fatalf("claimed %d before backoff elapsed", n)
+	}
+	deliverDue(t, s, start.Add(time.Minute))
+	d = findDelivery(t, repo)
+	if d.Status != domain.WebhookDeliveryDelivered || d.Attempts != 2 || d.LastError != "" || stub.calls != 2 {
+		t.Fatalf("after retry: %+v calls=%d", d, stub.calls)
+	}
+}
+
+func TestWebhookDeliverFailsAfterMaxAttempts(t *testing.T) {
+	s, repo, stub := newWebhookFixture(t, 3, http.StatusServiceUnavailable)
+	at := time.Now()
+	for attempt := 1; attempt <= 3; attempt++ {
+		if n := deliverDue(t, s, at); n != 1 {
+			t.Fatalf("attempt %d: claimed %d", attempt, n)
+		}
+		at = at.Add(time.Hour)
+	}
+	d := findDelivery(t, repo)
+	if d.Status != domain.WebhookDeliveryFailed || d.Attempts != 3 || d.LastStatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("delivery = %+v", d)
+	}
+	if n := deliverDue(t, s, at.Add(24*time.Hour)); n != 0 || stub.calls != 3 {
+		t.Fatalf("claimed %d after failure, subscriber called %d times", n, stub.calls)
+	}
+}
+
+func TestWebhookDeliverUnknownSubscriberFailsWithoutRetry(t *testing.T) {
+	s, repo, stub := newWebhookFixture(t, 3, http.StatusOK)
+	// 订阅方从配置中移除后，未完成的投递直接失败
+	s.subscribers = map[string]*WebhookSubscriber{}
+	deliverDue(t, s, time.Now())
+	if d := findDelivery(t, repo); d.Status != domain.WebhookDeliveryFailed || d.Attempts != 1 || stub.calls != 0 {
+		t.Fatalf("delivery = %+v calls=%d", d, stub.calls)
+	}
+}
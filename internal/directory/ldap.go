@@ -0,0 +1,351 @@
+// Package directory 实现以 LDAP / Active Directory 作为外部账号目录校验用户名密码。
+// 只负责协议交互：以服务账号查找用户条目与所属组，再以用户 DN 绑定校验密码；本地账号的创建与角色映射由 auth 包完成。
+package directory
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// 用户过滤器与组过滤器中的占位符，替换前会按 RFC 4515 转义
+const (
+	// PlaceholderIdentifier 用户登录时输入的标识
+	PlaceholderIdentifier = "{identifier}"
+	// PlaceholderDN 用户条目的 DN
+	PlaceholderDN = "{dn}"
+)
+
+// 默认属性名（OpenLDAP / inetOrgPerson）
+const (
+	defaultIDAttribute        = "entryUUID"
+	defaultUsernameAttribute  = "uid"
+	defaultEmailAttribute     = "mail"
+	defaultFirstNameAttribute = "givenName"
+	defaultLastNameAttribute  = "sn"
+	defaultGroupNameAttribute = "cn"
+	defaultTimeout            = 5 * time.Second
+)
+
+var (
+	// ErrInvalidCredentials 目录中存在该用户但密码错误
+	ErrInvalidCredentials = errors.New("directory: invalid credentials")
+	// ErrUserNotFound 目录中没有匹配的用户，或匹配到多个条目（无法确定身份）
+	ErrUserNotFound = errors.New("directory: user not found")
+	// ErrUnavailable 无法连接目录服务器、服务账号绑定失败或查询出错；具体原因见包装的错误信息
+	ErrUnavailable = errors.New("directory: unavailable")
+)
+
+// Config 目录服务器连接与查询配置
+type Config struct {
+	// URL ldap://host:389 或 ldaps://host:636
+	URL string
+	// StartTLS 为 true 时在 ldap:// 连接上执行 StartTLS 后再绑定
+	StartTLS bool
+	// InsecureSkipVerify 跳过服务器证书校验，仅用于测试环境
+	InsecureSkipVerify bool
+	// CACertFile 校验服务器证书使用的 CA（PEM），为空时使用系统根证书
+	CACertFile string
+	// ServerName 校验证书时使用的主机名，为空时取 URL 中的主机
+	ServerName string
+	// Timeout 连接与单次请求超时，<=0 时为 5 秒
+	Timeout time.Duration
+
+	// BindDN / BindPassword 用于查找用户的服务账号；BindDN 为空时匿名查找
+	BindDN       string
+	BindPassword string
+
+	// BaseDN 用户搜索起点（子树）
+	BaseDN string
+	// UserFilter 用户过滤器，必须包含 {identifier}，如 (&(objectClass=person)(|(uid={identifier})(mail={identifier})))
+	UserFilter string
+
+	// 用户条目属性名，为空时使用 OpenLDAP 默认值
+	IDAttribute        string // 不可变的唯一标识，默认 entryUUID；AD 使用 objectGUID（二进制，按十六进制保存）
+	UsernameAttribute  string // 默认 uid；AD 使用 sAMAccountName
+	EmailAttribute     string // 默认 mail
+	FirstNameAttribute string // 默认 givenName
+	LastNameAttribute  string // 默认 sn
+	// MemberOfAttribute 用户条目上列出所属组 DN 的属性（AD / OpenLDAP memberof overlay 为 memberOf），为空时不读取
+	MemberOfAttribute string
+
+	// GroupBaseDN 非空时另外按 GroupFilter 搜索用户所属的组（适用于没有 memberOf 的目录）
+	GroupBaseDN string
+	// GroupFilter 组过滤器，可使用 {dn} 与 {identifier}，如 (&(objectClass=groupOfNames)(member={dn}))
+	GroupFilter string
+}
+
+// Entry 通过密码校验的目录用户
+type Entry struct {
+	DN string
+	// ID 不可变的唯一标识；条目没有该属性时为小写 DN
+	ID        string
+	Username  string
+	Email     string
+	FirstName string
+	LastName  string
+	// Groups 所属组的 DN
+	Groups []string
+}
+
+// Client 目录客户端；每次认证单独建立连接，可并发使用
+type Client struct {
+	cfg Config
+	tls *tls.Config
+}
+
+// New 校验配置并创建客户端
+func New(cfg Config) (*Client, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "" {
+		return nil, fmt.Errorf("ldap url must be ldap://host[:port] or ldaps://host[:port]")
+	}
+	if cfg.StartTLS && u.Scheme == "ldaps" {
+		return nil, fmt.Errorf("start_tls cannot be used with ldaps://")
+	}
+	if cfg.BaseDN == "" {
+		return nil, fmt.Errorf("ldap base_dn is required")
+	}
+	if !strings.Contains(cfg.UserFilter, PlaceholderIdentifier) {
+		return nil, fmt.Errorf("ldap user_filter must contain %s", PlaceholderIdentifier)
+	}
+	if _, err := ldap.CompileFilter(expandFilter(cfg.UserFilter, map[string]string{PlaceholderIdentifier: "x"})); err != nil {
+		return nil, fmt.Errorf("invalid ldap user_filter: %w", err)
+	}
+	if cfg.GroupBaseDN != "" {
+		if cfg.GroupFilter == "" {
+			return nil, fmt.Errorf("ldap group_filter is required when group_base_dn is set")
+		}
+		if _, err := ldap.CompileFilter(expandFilter(cfg.GroupFilter, map[string]string{PlaceholderDN: "x", PlaceholderIdentifier: "x"})); err != nil {
+			return nil, fmt.Errorf("invalid ldap group_filter: %w", err)
+		}
+	}
+	if cfg.IDAttribute == "" {
+		cfg.IDAttribute = defaultIDAttribute
+	}
+	if cfg.UsernameAttribute == "" {
+		cfg.UsernameAttribute = defaultUsernameAttribute
+	}
+	if cfg.EmailAttribute == "" {
+		cfg.EmailAttribute = defaultEmailAttribute
+	}
+	if cfg.FirstNameAttribute == "" {
+		cfg.FirstNameAttribute = defaultFirstNameAttribute
+	}
+	if cfg.LastNameAttribute == "" {
+		cfg.LastNameAttribute = defaultLastNameAttribute
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = u.Hostname()
+	}
+	if cfg.CACertFile != "" {
+		pem, err := os.ReadFile(cfg.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ldap ca_cert_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ldap ca_cert_file contains no certificates")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return &Client{cfg: cfg, tls: tlsConfig}, nil
+}
+
+// Authenticate 以服务账号按 identifier 查找唯一的用户条目及其所属组，再以该条目 DN 和 password 绑定校验密码
+func (c *Client) Authenticate(ctx context.Context, identifier, password string) (*Entry, error) {
+	// 空密码的简单绑定在 LDAP 中是“未认证绑定”，多数服务器会返回成功，必须在客户端拒绝
+	if identifier == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// ctx 取消时关闭连接以中断进行中的请求
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if c.cfg.BindDN != "" {
+		if err := conn.Bind(c.cfg.BindDN, c.cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("%w: service account bind: %v", ErrUnavailable, err)
+		}
+	}
+	entry, err := c.findUser(conn, identifier)
+	if err != nil {
+		return nil, err
+	}
+	// 组在用户绑定前以服务账号查询：普通用户通常没有搜索组的权限
+	if c.cfg.GroupBaseDN != "" {
+		groups, err := c.findGroups(conn, entry.DN, identifier)
+		if err != nil {
+			return nil, err
+		}
+		entry.Groups = appendUnique(entry.Groups, groups...)
+	}
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("%w: user bind: %v", ErrUnavailable, err)
+	}
+	return entry, nil
+}
+
+// dial 建立连接（ldaps 或 StartTLS）
+func (c *Client) dial() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(c.cfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: c.cfg.Timeout}),
+		ldap.DialWithTLSConfig(c.tls),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	conn.SetTimeout(c.cfg.Timeout)
+	if c.cfg.StartTLS {
+		if err := conn.StartTLS(c.tls); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w: start tls: %v", ErrUnavailable, err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *Client) findUser(conn *ldap.Conn, identifier string) (*Entry, error) {
+	attrs := []string{c.cfg.IDAttribute, c.cfg.UsernameAttribute, c.cfg.EmailAttribute, c.cfg.FirstNameAttribute, c.cfg.LastNameAttribute}
+	if c.cfg.MemberOfAttribute != "" {
+		attrs = append(attrs, c.cfg.MemberOfAttribute)
+	}
+	// 最多取 2 条：多于 1 条说明过滤器不能唯一确定用户，按不存在处理
+	req := ldap.NewSearchRequest(c.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2,
+		int(c.cfg.Timeout/time.Second), false,
+		expandFilter(c.cfg.UserFilter, map[string]string{PlaceholderIdentifier: identifier}), attrs, nil)
+	res, err := conn.Search(req)
+	if err != nil {
+		switch {
+		case ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded):
+			return nil, fmt.Errorf("%w: identifier matches multiple entries", ErrUserNotFound)
+		case ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject):
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%w: user search: %v", ErrUnavailable, err)
+	}
+	switch len(res.Entries) {
+	case 0:
+		return nil, ErrUserNotFound
+	case 1:
+	default:
+		return nil, fmt.Errorf("%w: identifier matches multiple entries", ErrUserNotFound)
+	}
+	e := res.Entries[0]
+	entry := &Entry{
+		DN:        e.DN,
+		ID:        attributeID(e, c.cfg.IDAttribute),
+		Username:  e.GetEqualFoldAttributeValue(c.cfg.UsernameAttribute),
+		Email:     e.GetEqualFoldAttributeValue(c.cfg.EmailAttribute),
+		FirstName: e.GetEqualFoldAttributeValue(c.cfg.FirstNameAttribute),
+		LastName:  e.GetEqualFoldAttributeValue(c.cfg.LastNameAttribute),
+	}
+	if entry.ID == "" {
+		entry.ID = strings.ToLower(e.DN)
+	}
+	if c.cfg.MemberOfAttribute != "" {
+		entry.Groups = appendUnique(nil, e.GetEqualFoldAttributeValues(c.cfg.MemberOfAttribute)...)
+	}
+	return entry, nil
+}
+
+func (c *Client) findGroups(conn *ldap.Conn, dn, identifier string) ([]string, error) {
+	req := ldap.NewSearchRequest(c.cfg.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0,
+		int(c.cfg.Timeout/time.Second), false,
+		expandFilter(c.cfg.GroupFilter, map[string]string{PlaceholderDN: dn, PlaceholderIdentifier: identifier}),
+		[]string{defaultGroupNameAttribute}, nil)
+	res, err := conn.Search(req)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: group search: %v", ErrUnavailable, err)
+	}
+	groups := make([]string, 0, len(res.Entries))
+	for _, e := range res.Entries {
+		groups = append(groups, e.DN)
+	}
+	return groups, nil
+}
+
+// attributeID 读取唯一标识属性；非 UTF-8 的二进制值（如 AD objectGUID）按十六进制保存
+func attributeID(e *ldap.Entry, attribute string) string {
+	raw := e.GetEqualFoldRawAttributeValue(attribute)
+	if len(raw) == 0 {
+		return ""
+	}
+	if strings.EqualFold(attribute, "objectGUID") || !utf8.Valid(raw) {
+		return hex.EncodeToString(raw)
+	}
+	return string(raw)
+}
+
+// expandFilter 用转义后的值替换过滤器中的占位符
+func expandFilter(filter string, values map[string]string) string {
+	pairs := make([]string, 0, len(values)*2)
+	for k, v := range values {
+		pairs = append(pairs, k, ldap.EscapeFilter(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(filter)
+}
+
+// GroupMatches 判断 groups（DN 列表）中是否有与 name 匹配的组：name 可为完整 DN 或组 DN 第一个 RDN 的值（如 cn），不区分大小写
+func GroupMatches(groups []string, name string) bool {
+	for _, g := range groups {
+		if strings.EqualFold(g, name) || strings.EqualFold(firstRDNValue(g), name) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstRDNValue(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+		return ""
+	}
+	return parsed.RDNs[0].Attributes[0].Value
+}
+
+func appendUnique(dst []string, values ...string) []string {
+	for _, v := range values {
+		dup := false
+		for _, d := range dst {
+			if strings.EqualFold(d, v) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,174 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"monai-auth/internal/directory/ldaptest"
+)
+
+const testBaseDN = "dc=example,dc=com"
+
+// newTestDirectory 启动包含服务账号、两个用户与一个组的目录
+func newTestDirectory(t *testing.T) *ldaptest.Server {
+	t.Helper()
+	srv := ldaptest.NewServer(
+		ldaptest.Entry{DN: testBaseDN, Attributes: map[string][]string{"objectClass": {"top", "domain"}}},
+		ldaptest.Entry{DN: "ou=people," + testBaseDN, Attributes: map[string][]string{"objectClass": {"organizationalUnit"}, "ou": {"people"}}},
+		ldaptest.Entry{DN: "ou=groups," + testBaseDN, Attributes: map[string][]string{"objectClass": {"organizationalUnit"}, "ou": {"groups"}}},
+		ldaptest.Entry{DN: "cn=admin," + testBaseDN, Attributes: map[string][]string{
+			"objectClass":  {"organizationalRole"},
+			"cn":           {"admin"},
+			"userPassword": {"admin-secret"},
+		}},
+		ldaptest.Entry{DN: "uid=alice,ou=people," + testBaseDN, Attributes: map[string][]string{
+			"objectClass":  {"inetOrgPerson"},
+			"uid":          {"alice"},
+			"mail":         {"alice@example.com"},
+			"givenName":    {"Alice"},
+			"sn":           {"Liddell"},
+			"entryUUID":    {"5f1c7a2e-0000-4000-8000-000000000001"},
+			"memberOf":     {"cn=admins,ou=groups," + testBaseDN},
+			"userPassword": {"alice-pass"},
+		}},
+		ldaptest.Entry{DN: "uid=bob,ou=people," + testBaseDN, Attributes: map[string][]string{
+			"objectClass":  {"inetOrgPerson"},
+			"uid":          {"bob"},
+			"mail":         {"bob@example.com"},
+			"userPassword": {"bob-pass"},
+		}},
+		ldaptest.Entry{DN: "cn=developers,ou=groups," + testBaseDN, Attributes: map[string][]string{
+			"objectClass": {"groupOfNames"},
+			"cn":          {"developers"},
+			"member":      {"uid=bob,ou=people," + testBaseDN},
+		}},
+	)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(t *testing.T, srv *ldaptest.Server, edit func(*Config)) *Client {
+	t.Helper()
+	cfg := Config{
+		URL:               srv.URL,
+		BindDN:            "cn=admin," + testBaseDN,
+		BindPassword:      "admin-secret",
+		BaseDN:            "ou=people," + testBaseDN,
+		UserFilter:        "(&(objectClass=inetOrgPerson)(|(uid={identifier})(mail={identifier})))",
+		MemberOfAttribute: "memberOf",
+	}
+	if edit != nil {
+		edit(&cfg)
+	}
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestAuthenticate(t *testing.T) {
+	srv := newTestDirectory(t)
+	c := newTestClient(t, srv, nil)
+	tests := []struct {
+		name       string
+		identifier string
+		password   string
+		wantErr    error
+		wantDN     string
+	}{
+		{"uid", "alice", "alice-pass", nil, "uid=alice,ou=people," + testBaseDN},
+		{"mail", "alice@example.com", "alice-pass", nil, "uid=alice,ou=people," + testBaseDN},
+		{"wrong password", "alice", "wrong", ErrInvalidCredentials, ""},
+		// 空密码的简单绑定是未认证绑定，服务器可能返回成功
+		{"empty password", "alice", "", ErrInvalidCredentials, ""},
+		{"empty identifier", "", "alice-pass", ErrInvalidCredentials, ""},
+		{"unknown user", "carol", "carol-pass", ErrUserNotFound, ""},
+		// 标识中的过滤器元字符必须转义，否则 * 会匹配任意用户、注入的子过滤器会改写查询条件
+		{"wildcard", "*", "alice-pass", ErrUserNotFound, ""},
+		{"prefix wildcard", "ali*", "alice-pass", ErrUserNotFound, ""},
+		{"filter injection", "alice)(uid=*", "alice-pass", ErrUserNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := c.Authenticate(context.Background(), tt.identifier, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && entry.DN != tt.wantDN {
+				t.Fatalf("dn = %q, want %q", entry.DN, tt.wantDN)
+			}
+		})
+	}
+}
+
+func TestAuthenticateEntryAttributes(t *testing.T) {
+	srv := newTestDirectory(t)
+	c := newTestClient(t, srv, nil)
+	entry, err := c.Authenticate(context.Background(), "alice", "alice-pass")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if entry.ID != "5f1c7a2e-0000-4000-8000-000000000001" || entry.Username != "alice" || entry.Email != "alice@example.com" ||
+		entry.FirstName != "Alice" || entry.LastName != "Liddell" {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	if !GroupMatches(entry.Groups, "admins") || !GroupMatches(entry.Groups, "CN=Admins,OU=Groups,DC=Example,DC=Com") {
+		t.Fatalf("groups = %v, want admins", entry.Groups)
+	}
+
+	// 没有唯一标识属性时以小写 DN 作为 ID
+	entry, err = c.Authenticate(context.Background(), "bob", "bob-pass")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if entry.ID != "uid=bob,ou=people,"+testBaseDN {
+		t.Fatalf("id = %q, want lower-case dn", entry.ID)
+	}
+}
+
+func TestAuthenticateGroupSearch(t *testing.T) {
+	srv := newTestDirectory(t)
+	c := newTestClient(t, srv, func(cfg *Config) {
+		cfg.MemberOfAttribute = ""
+		cfg.GroupBaseDN = "ou=groups," + testBaseDN
+		cfg.GroupFilter = "(&(objectClass=groupOfNames)(member={dn}))"
+	})
+	entry, err := c.Authenticate(context.Background(), "bob", "bob-pass")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if !GroupMatches(entry.Groups, "developers") {
+		t.Fatalf("groups = %v, want developers", entry.Groups)
+	}
+}
+
+func TestAuthenticateServiceAccountBindFailure(t *testing.T) {
+	srv := newTestDirectory(t)
+	c := newTestClient(t, srv, func(cfg *Config) { cfg.BindPassword = "wrong" })
+	if _, err := c.Authenticate(context.Background(), "alice", "alice-pass"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("got %v, want ErrUnavailable", err)
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"bad scheme", Config{URL: "http://ldap.example.com", BaseDN: testBaseDN, UserFilter: "(uid={identifier})"}},
+		{"starttls with ldaps", Config{URL: "ldaps://ldap.example.com", StartTLS: true, BaseDN: testBaseDN, UserFilter: "(uid={identifier})"}},
+		{"missing base dn", Config{URL: "ldap://ldap.example.com", UserFilter: "(uid={identifier})"}},
+		{"missing placeholder", Config{URL: "ldap://ldap.example.com", BaseDN: testBaseDN, UserFilter: "(uid=alice)"}},
+		{"invalid filter", Config{URL: "ldap://ldap.example.com", BaseDN: testBaseDN, UserFilter: "(uid={identifier}"}},
+		{"group base without filter", Config{URL: "ldap://ldap.example.com", BaseDN: testBaseDN, UserFilter: "(uid={identifier})", GroupBaseDN: testBaseDN}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
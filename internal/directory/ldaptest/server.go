@@ -0,0 +1,372 @@
+// Package ldaptest 提供进程内的最小 LDAP 服务器，用于本地联调与测试目录登录，用法类似 net/http/httptest。
+// 仅支持简单绑定、搜索（base / one / sub，过滤器 and / or / not / 等值 / 子串 / 存在 / 大小比较）与解绑，不支持 TLS 与写操作。
+package ldaptest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// passwordAttribute 条目密码属性，用于校验绑定，不会在搜索结果中返回
+const passwordAttribute = "userPassword"
+
+// Entry 目录条目；属性名不区分大小写，Attributes["userPassword"] 为绑定密码（明文）
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// Server 进程内 LDAP 服务器
+type Server struct {
+	// URL 形如 ldap://127.0.0.1:port
+	URL string
+
+	ln      net.Listener
+	entries []Entry
+	wg      sync.WaitGroup
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+// NewServer 在 127.0.0.1 随机端口启动服务器，失败时 panic（与 httptest.NewServer 一致）
+func NewServer(entries ...Entry) *Server {
+	s, err := Start("127.0.0.1:0", entries...)
+	if err != nil {
+		panic(fmt.Sprintf("ldaptest: failed to listen: %v", err))
+	}
+	return s
+}
+
+// Start 在 addr 上启动服务器
+func Start(addr string, entries ...Entry) (*Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		URL:     "ldap://" + ln.Addr().String(),
+		ln:      ln,
+		entries: entries,
+		conns:   make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Close 停止监听并断开所有连接
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.ln.Close()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		s.wg.Done()
+	}()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Printf("[LDAPTEST] read packet failed err=%v", err)
+			}
+			return
+		}
+		if len(packet.Children) < 2 {
+			return
+		}
+		id, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			code, msg := s.bind(op)
+			s.write(conn, result(id, ldap.ApplicationBindResponse, code, msg))
+		case ldap.ApplicationSearchRequest:
+			s.search(conn, id, op)
+		case ldap.ApplicationUnbindRequest:
+			return
+		case ldap.ApplicationAbandonRequest:
+		case ldap.ApplicationExtendedRequest:
+			// StartTLS 等扩展操作均不支持
+			s.write(conn, result(id, ldap.ApplicationExtendedResponse, ldap.LDAPResultProtocolError, "extended operations are not supported"))
+		default:
+			s.write(conn, result(id, uint8(op.Tag)+1, ldap.LDAPResultUnwillingToPerform, "operation not supported"))
+		}
+	}
+}
+
+func (s *Server) write(conn net.Conn, p *ber.Packet) {
+	if _, err := conn.Write(p.Bytes()); err != nil {
+		conn.Close()
+	}
+}
+
+// bind 简单绑定：DN 与密码都为空时匿名绑定，否则比对条目的 userPassword
+func (s *Server) bind(op *ber.Packet) (uint16, string) {
+	if len(op.Children) < 3 {
+		return ldap.LDAPResultProtocolError, "malformed bind request"
+	}
+	dn := value(op.Children[1])
+	auth := op.Children[2]
+	if auth.ClassType != ber.ClassContext || auth.Tag != 0 {
+		return ldap.LDAPResultAuthMethodNotSupported, "only simple bind is supported"
+	}
+	password := value(auth)
+	if dn == "" && password == "" {
+		return ldap.LDAPResultSuccess, ""
+	}
+	for _, e := range s.entries {
+		if strings.EqualFold(e.DN, dn) {
+			for _, p := range attribute(e, passwordAttribute) {
+				if p != "" && p == password {
+					return ldap.LDAPResultSuccess, ""
+				}
+			}
+			break
+		}
+	}
+	return ldap.LDAPResultInvalidCredentials, ""
+}
+
+func (s *Server) search(conn net.Conn, id int64, op *ber.Packet) {
+	if len(op.Children) < 8 {
+		s.write(conn, result(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError, "malformed search request"))
+		return
+	}
+	base := value(op.Children[0])
+	scope, _ := op.Children[1].Value.(int64)
+	sizeLimit, _ := op.Children[3].Value.(int64)
+	filter := op.Children[6]
+	var attrs []string
+	for _, a := range op.Children[7].Children {
+		attrs = append(attrs, value(a))
+	}
+
+	baseFound := base == ""
+	sent := int64(0)
+	for _, e := range s.entries {
+		if strings.EqualFold(e.DN, base) {
+			baseFound = true
+		}
+		if !inScope(e.DN, base, scope) || !matches(e, filter) {
+			continue
+		}
+		if sizeLimit > 0 && sent >= sizeLimit {
+			s.write(conn, result(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultSizeLimitExceeded, ""))
+			return
+		}
+		s.write(conn, searchEntry(id, e, attrs))
+		sent++
+	}
+	if !baseFound {
+		s.write(conn, result(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultNoSuchObject, ""))
+		return
+	}
+	s.write(conn, result(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess, ""))
+}
+
+// inScope 判断 dn 是否在 base 的搜索范围内（按 DN 字符串后缀比较，不做规范化）
+func inScope(dn, base string, scope int64) bool {
+	dn, base = strings.ToLower(dn), strings.ToLower(base)
+	switch scope {
+	case ldap.ScopeBaseObject:
+		return dn == base
+	case ldap.ScopeSingleLevel:
+		i := strings.Index(dn, ",")
+		return i >= 0 && dn[i+1:] == base
+	default:
+		return base == "" || dn == base || strings.HasSuffix(dn, ","+base)
+	}
+}
+
+// matches 按 RFC 4511 过滤器判断条目是否匹配，属性值比较不区分大小写
+func matches(e Entry, f *ber.Packet) bool {
+	switch f.Tag {
+	case ldap.FilterAnd:
+		for _, c := range f.Children {
+			if !matches(e, c) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, c := range f.Children {
+			if matches(e, c) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return len(f.Children) == 1 && !matches(e, f.Children[0])
+	case ldap.FilterPresent:
+		return len(attribute(e, value(f))) > 0
+	case ldap.FilterEqualityMatch, ldap.FilterApproxMatch, ldap.FilterGreaterOrEqual, ldap.FilterLessOrEqual:
+		if len(f.Children) != 2 {
+			return false
+		}
+		want := strings.ToLower(value(f.Children[1]))
+		for _, v := range attribute(e, value(f.Children[0])) {
+			v = strings.ToLower(v)
+			switch f.Tag {
+			case ldap.FilterGreaterOrEqual:
+				if v >= want {
+					return true
+				}
+			case ldap.FilterLessOrEqual:
+				if v <= want {
+					return true
+				}
+			default:
+				if v == want {
+					return true
+				}
+			}
+		}
+		return false
+	case ldap.FilterSubstrings:
+		if len(f.Children) != 2 {
+			return false
+		}
+		for _, v := range attribute(e, value(f.Children[0])) {
+			if substringMatch(strings.ToLower(v), f.Children[1].Children) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func substringMatch(v string, parts []*ber.Packet) bool {
+	for _, p := range parts {
+		s := strings.ToLower(value(p))
+		switch p.Tag {
+		case ldap.FilterSubstringsInitial:
+			if !strings.HasPrefix(v, s) {
+				return false
+			}
+			v = v[len(s):]
+		case ldap.FilterSubstringsAny:
+			i := strings.Index(v, s)
+			if i < 0 {
+				return false
+			}
+			v = v[i+len(s):]
+		case ldap.FilterSubstringsFinal:
+			if !strings.HasSuffix(v, s) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// attribute 按属性名（不区分大小写）读取条目的属性值
+func attribute(e Entry, name string) []string {
+	for k, v := range e.Attributes {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+// value 读取原始类型 BER 元素的内容
+func value(p *ber.Packet) string {
+	if p.Data == nil {
+		return ""
+	}
+	return string(p.Data.Bytes())
+}
+
+func envelope(id int64, op *ber.Packet) *ber.Packet {
+	p := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "MessageID"))
+	p.AppendChild(op)
+	return p
+}
+
+func result(id int64, application uint8, code uint16, message string) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ber.Tag(application), nil, "")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "resultCode"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, message, "diagnosticMessage"))
+	return envelope(id, op)
+}
+
+// searchEntry 构造搜索结果条目；attrs 为空或含 * 时返回全部属性，密码属性始终不返回
+func searchEntry(id int64, e Entry, attrs []string) *ber.Packet {
+	all := len(attrs) == 0
+	for _, a := range attrs {
+		if a == "*" {
+			all = true
+		}
+	}
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.DN, "objectName"))
+	list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for name, values := range e.Attributes {
+		if strings.EqualFold(name, passwordAttribute) || (!all && !containsFold(attrs, name)) {
+			continue
+		}
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, v := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, ""))
+		}
+		attr.AppendChild(set)
+		list.AppendChild(attr)
+	}
+	op.AppendChild(list)
+	return envelope(id, op)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package domain
+
+import "time"
+
+// 审计事件类型
+const (
+	AuditLogin              = "login"               // 登录（密码 / MFA / Passkey），成功时 Reason 记录 amr
+	AuditLoginMFAChallenge  = "login.mfa_challenge" // 密码校验通过，等待第二步验证
+	AuditLoginAnomaly       = "login.anomaly"       // 异常登录（新设备 / 新国家 / 不可能旅行），Reason 为命中原因
+	AuditCodeExchange       = "code.exchange"       // 授权码换取 access_token（成功即签发 token）
+	AuditLogout             = "logout"
+	AuditRegister           = "register"
+	AuditPasswordChange     = "password.change"
+	AuditPasswordReset      = "password.reset"
+	AuditMFAEnable          = "mfa.enable"
+	AuditMFADisable         = "mfa.disable"
+	AuditMFARecoveryCodes   = "mfa.recovery_codes" // 重新生成恢复码
+	AuditPasskeyRegister    = "passkey.register"
+	AuditPasskeyDelete      = "passkey.delete"
+	AuditPhoneVerify        = "phone.verify"     // 通过短信验证码绑定手机号
+	AuditPhoneRemove        = "phone.remove"     // 解绑手机号
+	AuditIdentityUnlink     = "identity.unlink"  // 解除第三方账号绑定
+	AuditSAMLAssertion      = "saml.assertion"   // 向 SAML SP 签发断言，ClientID 为 SP 实体标识
+	AuditSCIMUserCreate     = "scim.user.create" // SCIM 同步操作，ClientID 为同步客户端名称
+	AuditSCIMUserUpdate     = "scim.user.update"
+	AuditSCIMUserDelete     = "scim.user.delete"
+	AuditSCIMGroupCreate    = "scim.group.create"
+	AuditSCIMGroupUpdate    = "scim.group.update"
+	AuditSCIMGroupDelete    = "scim.group.delete"
+	AuditAdminUnlockUser    = "admin.unlock_user"
+	AuditAdminWebhookReplay = "admin.webhook_replay" // 重放 Webhook 投递，成功时 Reason 为投递记录 ID
+)
+
+// 审计事件结果
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEvent 一条安全审计事件
+type AuditEvent struct {
+	ID      int64
+	Type    string
+	Outcome string
+	// UserID 事件所属用户，0 表示无法确定（如使用未注册邮箱登录）
+	UserID int64
+	// ActorID 执行操作的用户；管理员操作时为管理员 ID，用户自身操作时与 UserID 相同
+	ActorID int64
+	// Email 请求中提交的登录标识，用于记录无法对应到用户的失败尝试
+	Email     string
+	IP        string
+	UserAgent string
+	ClientID  string
+	// Reason 失败原因（与错误码对应，如 invalid_credentials）或补充信息
+	Reason    string
+	CreatedAt time.Time
+}
+
+// AuditEventFilter 审计事件查询条件，零值字段不参与过滤；结果按 ID 倒序
+type AuditEventFilter struct {
+	UserID   int64
+	Type     string
+	Outcome  string
+	IP       string
+	ClientID string
+	Since    time.Time
+	Until    time.Time
+	// BeforeID 分页游标：只返回 ID 小于该值的事件
+	BeforeID int64
+	Limit    int
+}
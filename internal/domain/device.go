@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// KnownDevice 用户曾成功登录过的设备（设备 Cookie + User-Agent 指纹）
+type KnownDevice struct {
+	ID     int64
+	UserID int64
+	// Fingerprint 设备指纹哈希，同一用户内唯一
+	Fingerprint string
+	UserAgent   string
+	LastIP      string
+	LastCountry string
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// 领域事件类型
+const (
+	EventUserRegistered   = "user.registered"    // 新建用户：自助注册、SCIM 创建、第三方 / 目录登录自动开通
+	EventUserEmailChanged = "user.email_changed" // 邮箱变更，data 中带 previous_email
+	EventUserSuspended    = "user.suspended"     // 账号由 active 变为停用（inactive / suspended），data.status 为新状态
+	EventUserReactivated  = "user.reactivated"   // 停用的账号重新启用
+	EventUserDeleted      = "user.deleted"
+	EventUserLoggedIn     = "user.logged_in" // 登录成功（签发 token 或授权码），data 中带 method、client_id
+	EventTokenIssued      = "token.issued"   // 签发 access token，data 中带 amr
+)
+
+// Event 领域事件
+type Event struct {
+	// ID 事件标识，各下游（含重试）中相同，消费方可据此去重
+	ID         string
+	Type       string
+	UserID     int64
+	Data       map[string]any
+	OccurredAt time.Time
+}
+
+// OutboxRecord 发件箱中的一条事件：与引起它的状态变更在同一事务中写入，投递成功后设置 PublishedAt，重试次数用尽后设置 FailedAt
+type OutboxRecord struct {
+	ID        int64
+	EventID   string
+	EventType string
+	UserID    int64
+	// Payload 编码后的事件（见 eventbus.Encode）
+	Payload       []byte
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	PublishedAt   *time.Time
+	FailedAt      *time.Time
+	CreatedAt     time.Time
+}
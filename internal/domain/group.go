@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Group 用户组（由 SCIM 等外部系统维护的组织结构）
+type Group struct {
+	ID          int64
+	DisplayName string
+	// ExternalID 外部系统中的组标识，可为空
+	ExternalID string
+	Members    []GroupMember
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// GroupMember 组成员
+type GroupMember struct {
+	UserID   int64
+	Username string
+}
+
+// GroupFilter 组列表查询条件，零值字段不参与过滤；结果按 ID 升序
+type GroupFilter struct {
+	ID          int64
+	DisplayName string
+	ExternalID  string
+	Offset      int
+	Limit       int
+}
+
+// 用户组相关错误
+var (
+	ErrGroupNotFound = errors.New("group not found")
+	ErrGroupExists   = errors.New("group display name already exists")
+)
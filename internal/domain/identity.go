@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// UserIdentity 用户与上游身份提供方（GitHub、Google 等）账号的绑定关系
+type UserIdentity struct {
+	ID     int64
+	UserID int64
+	// Provider 配置中的提供方名称，如 github、google
+	Provider string
+	// Subject 提供方内的用户唯一标识（OIDC sub / GitHub 用户 ID），不随邮箱变化
+	Subject string
+	// Email 绑定或最近一次登录时提供方返回的邮箱，仅用于展示
+	Email       string
+	CreatedAt   time.Time
+	LastLoginAt *time.Time
+}
+
+// 第三方登录相关错误
+var (
+	ErrUnknownProvider          = errors.New("unknown identity provider")
+	ErrInvalidFederationState   = errors.New("invalid or expired federation state")
+	ErrInvalidFederationTicket  = errors.New("invalid or expired federation ticket")
+	ErrFederationFailed         = errors.New("identity provider authentication failed")
+	ErrFederatedEmailUnverified = errors.New("identity provider did not return a verified email")
+	ErrFederatedAccountNotFound = errors.New("no account matches the identity provider email")
+	ErrIdentityNotFound         = errors.New("linked identity not found")
+	ErrIdentityExists           = errors.New("identity already linked")
+)
+
+// ErrDirectoryUnavailable 无法连接外部账号目录（LDAP / AD）或目录查询出错
+var ErrDirectoryUnavailable = errors.New("directory unavailable")
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// LoginRecord 一次成功登录的记录，供用户查看近期登录、发现异常访问
+type LoginRecord struct {
+	ID        int64
+	UserID    int64
+	IP        string
+	UserAgent string
+	// ClientID SSO 登录的子应用，直接登录认证中心时为空
+	ClientID string
+	// Method 登录方式，如 password、password+totp、passkey
+	Method string
+	// Country / Latitude / Longitude 按 IP 解析的地理位置，未配置 GeoIP 或无法解析时为空
+	Country   string
+	Latitude  *float64
+	Longitude *float64
+	CreatedAt time.Time
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// TOTPCredential 用户绑定的 TOTP（RFC 6238）凭据
+type TOTPCredential struct {
+	UserID int64
+	// SecretEnc 加密后的 TOTP 密钥（由 auth 层加解密，仓库层只存取）
+	SecretEnc string
+	// Enabled 用户完成首次验证码确认后才为 true，未确认的凭据不参与登录
+	Enabled bool
+	// LastUsedStep 最近一次验证通过的时间步，防止同一验证码被重放
+	LastUsedStep int64
+	ConfirmedAt  *time.Time
+	CreatedAt    time.Time
+}
+
+// MFA 相关错误
+var (
+	ErrMFANotEnrolled    = errors.New("mfa not enrolled")
+	ErrMFAAlreadyEnabled = errors.New("mfa already enabled")
+	ErrInvalidMFACode    = errors.New("invalid mfa code")
+	ErrInvalidMFAToken   = errors.New("invalid or expired mfa token")
+	ErrMFANotConfigured  = errors.New("mfa not configured")
+)
+
+// MFAVerifyRequest 登录第二步：提交 MFA 挑战令牌与验证码
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token"`
+	// Code 6 位 TOTP 验证码或一次性恢复码
+	Code string `json:"code"`
+	// State / ServerState 与登录请求含义相同，SSO 流程需原样带上
+	State       string `json:"state"`
+	ServerState string `json:"server_state"`
+}
+
+// MFACodeRequest 需要提供当前验证码的 MFA 管理操作（确认绑定、关闭、重新生成恢复码）
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
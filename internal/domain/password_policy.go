@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BcryptMaxPasswordBytes bcrypt 只使用密码前 72 字节，超出部分会被静默截断
+const BcryptMaxPasswordBytes = 72
+
+// 密码策略相关错误（ErrPasswordTooShort 见 user.go）
+var (
+	ErrPasswordTooLong          = errors.New("password too long")
+	ErrPasswordTooWeak          = errors.New("password does not meet character requirements")
+	ErrPasswordContainsUserInfo = errors.New("password must not contain email or username")
+	ErrPasswordReused           = errors.New("password was used recently")
+	ErrPasswordBreached         = errors.New("password appears in a known data breach")
+)
+
+// PasswordPolicy 可配置的密码策略
+type PasswordPolicy struct {
+	// MinLength 最少字符数（按 Unicode 字符计）
+	MinLength int
+	// MaxBytes 最大字节数；使用 bcrypt 时不得超过 72 字节，防止截断后不同密码哈希相同
+	MaxBytes int
+	// MinCharClasses 至少包含的字符类别数（大写、小写、数字、符号），0 表示不要求
+	MinCharClasses int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireDigit   bool
+	RequireSymbol  bool
+	// DisallowUserInfo 禁止密码包含邮箱本地部分或用户名（不区分大小写）
+	DisallowUserInfo bool
+	// HistorySize 禁止与最近 N 次使用过的密码相同，0 表示不检查
+	HistorySize int
+}
+
+// DefaultPasswordPolicy 默认策略：仅要求最少长度与 bcrypt 字节上限
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{MinLength: MinPasswordLength, MaxBytes: BcryptMaxPasswordBytes}
+}
+
+// Validate 校验密码是否符合策略；email、username 用于 DisallowUserInfo，可为空
+func (p PasswordPolicy) Validate(password, email, username string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return ErrPasswordTooShort
+	}
+	maxBytes := p.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = BcryptMaxPasswordBytes
+	}
+	if len(password) > maxBytes {
+		return ErrPasswordTooLong
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	if (p.RequireUpper && !upper) || (p.RequireLower && !lower) ||
+		(p.RequireDigit && !digit) || (p.RequireSymbol && !symbol) {
+		return ErrPasswordTooWeak
+	}
+	classes := 0
+	for _, ok := range []bool{upper, lower, digit, symbol} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < p.MinCharClasses {
+		return ErrPasswordTooWeak
+	}
+
+	if p.DisallowUserInfo {
+		lowerPwd := strings.ToLower(password)
+		local, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+		for _, info := range []string{local, strings.ToLower(strings.TrimSpace(username))} {
+			// 过短的片段（如 "a"）不参与比较，避免误伤
+			if len(info) >= 3 && strings.Contains(lowerPwd, info) {
+				return ErrPasswordContainsUserInfo
+			}
+		}
+	}
+	return nil
+}
+
+// IsPasswordPolicyError 判断是否为密码策略校验失败（用于统一映射错误码）
+func IsPasswordPolicyError(err error) bool {
+	for _, target := range []error{
+		ErrPasswordTooShort, ErrPasswordTooLong, ErrPasswordTooWeak,
+		ErrPasswordContainsUserInfo, ErrPasswordReused, ErrPasswordBreached,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 )
 
 // UserRepository 定义了数据持久化的操作契约
@@ -12,14 +13,288 @@ type UserRepository interface {
 	// FindByEmail 根据 Email 查找用户
 	FindByEmail(ctx context.Context, email string) (*User, error)
 
+	// FindByUsername 根据用户名查找用户（大小写不敏感）
+	FindByUsername(ctx context.Context, username string) (*User, error)
+
 	// ExistsByEmail 检查指定 email 是否已存在
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 
 	// CreateUser 创建新用户
 	CreateUser(ctx context.Context, user *User) error
+
+	// UpdateProfile 更新用户资料（用户名、姓名、头像、手机号），唯一约束冲突返回 ErrUserExists / ErrPhoneExists
+	UpdateProfile(ctx context.Context, user *User) error
+
+	// ChangePassword 更新密码哈希并递增 token_version，返回新的 token_version
+	ChangePassword(ctx context.Context, id int64, passwordHash string) (int64, error)
+
+	// UpdatePasswordHash 仅替换密码哈希（登录时升级哈希算法/参数），不递增 token_version
+	UpdatePasswordHash(ctx context.Context, id int64, passwordHash string) error
+
+	// UpdateStatus 更新用户状态（active / inactive / suspended / pending）
+	UpdateStatus(ctx context.Context, id int64, status string) error
+
+	// ActivatePending 将 pending 用户激活，同时清空本地密码并递增 token_version（单条语句原子完成）。
+	// 用于外部身份（第三方登录 / 企业目录）证明邮箱归属后认领未验证账号：注册该账号的人未必是邮箱所有者，其设置的密码不能保留。
+	// 用户不存在或已不是 pending 状态时返回 ErrUserNotFound
+	ActivatePending(ctx context.Context, id int64) error
+
+	// UpdateRole 更新用户角色（standard / admin）
+	UpdateRole(ctx context.Context, id int64, role string) error
+
+	// RecordLoginFailure 原子递增连续登录失败次数，返回递增后的次数
+	RecordLoginFailure(ctx context.Context, id int64) (int, error)
+
+	// LockUntil 设置账号锁定截止时间
+	LockUntil(ctx context.Context, id int64, until time.Time) error
+
+	// ResetLoginFailures 清零连续失败次数并解除锁定
+	ResetLoginFailures(ctx context.Context, id int64) error
+
+	// UpdateLastLogin 更新最近一次登录成功时间
+	UpdateLastLogin(ctx context.Context, id int64, at time.Time) error
+
+	// FindByPhone 根据手机号查找用户
+	FindByPhone(ctx context.Context, phone string) (*User, error)
+
+	// SetPhoneVerified 绑定手机号并标记为已验证，手机号已被其他用户使用时返回 ErrPhoneExists
+	SetPhoneVerified(ctx context.Context, id int64, phone string, at time.Time) error
+
+	// UpdateEmail 修改邮箱，已被其他用户使用时返回 ErrEmailExists
+	UpdateEmail(ctx context.Context, id int64, email string) error
+
+	// List 按条件分页查询用户（不含已删除），同时返回满足条件的总数
+	List(ctx context.Context, filter UserFilter) ([]*User, int64, error)
+
+	// SoftDelete 软删除用户（设置 deleted_at），之后按 ID / 邮箱等均查不到该用户
+	SoftDelete(ctx context.Context, id int64) error
 }
 
 // UserAssetRepository 用户上传资源（如头像）的持久化
 type UserAssetRepository interface {
 	Create(ctx context.Context, userID int64, filePath, fileType, originalName string, size *int) error
 }
+
+// PasswordResetTokenRepository 密码重置令牌的持久化（仅保存令牌的哈希，不保存明文）
+type PasswordResetTokenRepository interface {
+	// Create 保存令牌哈希及过期时间
+	Create(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error
+
+	// Lookup 返回未使用且未过期的令牌所属 userID（不消费），无效时返回 ErrInvalidResetToken
+	Lookup(ctx context.Context, tokenHash string) (int64, error)
+
+	// Consume 将未使用且未过期的令牌标记为已使用并返回所属 userID（一次性），无效时返回 ErrInvalidResetToken
+	Consume(ctx context.Context, tokenHash string) (int64, error)
+
+	// DeleteByUser 删除用户所有未使用的令牌（重置成功后使其余链接失效）
+	DeleteByUser(ctx context.Context, userID int64) error
+}
+
+// EmailVerificationTokenRepository 邮箱验证令牌的持久化（仅保存令牌的哈希）
+type EmailVerificationTokenRepository interface {
+	// Create 保存令牌哈希及过期时间
+	Create(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error
+
+	// Consume 将未使用且未过期的令牌标记为已使用并返回所属 userID，无效时返回 ErrInvalidVerifyToken
+	Consume(ctx context.Context, tokenHash string) (int64, error)
+
+	// CreateIfIdle 用户在 idleSince 之后没有生成过令牌时保存新令牌并返回 true，否则不保存并返回 false；
+	// 检查与写入原子完成（同一用户的并发调用串行执行），用于限制重发频率
+	CreateIfIdle(ctx context.Context, userID int64, tokenHash string, expiresAt, idleSince time.Time) (bool, error)
+}
+
+// MFARepository 多因素认证（TOTP 与恢复码）的持久化
+type MFARepository interface {
+	// FindTOTP 查找用户的 TOTP 凭据，不存在时返回 ErrMFANotEnrolled
+	FindTOTP(ctx context.Context, userID int64) (*TOTPCredential, error)
+
+	// SaveTOTP 保存（覆盖）用户的 TOTP 凭据，用于开始绑定
+	SaveTOTP(ctx context.Context, cred *TOTPCredential) error
+
+	// EnableTOTP 标记 TOTP 凭据已确认启用
+	EnableTOTP(ctx context.Context, userID int64, step int64) error
+
+	// UpdateTOTPLastUsedStep 仅当 step 大于已记录的时间步时更新并返回 true，用于防止验证码重放
+	UpdateTOTPLastUsedStep(ctx context.Context, userID int64, step int64) (bool, error)
+
+	// DeleteTOTP 删除 TOTP 凭据及全部恢复码（关闭 MFA）
+	DeleteTOTP(ctx context.Context, userID int64) error
+
+	// ReplaceRecoveryCodes 以新的恢复码哈希替换用户全部恢复码
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+
+	// ConsumeRecoveryCode 将未使用的恢复码标记为已使用，成功返回 true
+	ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+
+	// CountRecoveryCodes 返回用户剩余可用恢复码数量
+	CountRecoveryCodes(ctx context.Context, userID int64) (int, error)
+}
+
+// WebAuthnCredentialRepository WebAuthn 凭据的持久化
+type WebAuthnCredentialRepository interface {
+	// Create 保存新凭据，credential_id 冲突时返回 ErrWebAuthnCredentialExists
+	Create(ctx context.Context, cred *WebAuthnCredential) error
+
+	// ListByUser 列出用户全部凭据
+	ListByUser(ctx context.Context, userID int64) ([]*WebAuthnCredential, error)
+
+	// UpdateAfterLogin 登录成功后更新签名计数、备份状态与最近使用时间
+	UpdateAfterLogin(ctx context.Context, credentialID []byte, signCount uint32, backupState bool) error
+
+	// Delete 删除用户的指定凭据，不存在时返回 ErrWebAuthnCredentialNotFound
+	Delete(ctx context.Context, userID int64, id int64) error
+}
+
+// RateLimitRepository 多实例共享的限流计数（固定窗口）
+type RateLimitRepository interface {
+	// Hit 对 key 在当前窗口内计数 +1，返回窗口内累计次数与窗口结束时间；窗口已过期时重新开始计数
+	Hit(ctx context.Context, key string, window time.Duration) (count int64, resetAt time.Time, err error)
+
+	// DeleteExpired 删除窗口已结束的计数
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// PasswordHistoryRepository 用户历史密码哈希，用于禁止重复使用近期密码
+type PasswordHistoryRepository interface {
+	// Add 记录一次密码哈希，并只保留最近 keep 条
+	Add(ctx context.Context, userID int64, passwordHash string, keep int) error
+
+	// ListRecent 按时间倒序返回最近 n 条密码哈希
+	ListRecent(ctx context.Context, userID int64, n int) ([]string, error)
+}
+
+// LoginHistoryRepository 登录成功记录的持久化
+type LoginHistoryRepository interface {
+	// Create 写入一条登录记录
+	Create(ctx context.Context, record *LoginRecord) error
+
+	// ListByUser 按 ID 倒序返回用户的登录记录，beforeID > 0 时只返回 ID 小于该值的记录
+	ListByUser(ctx context.Context, userID int64, beforeID int64, limit int) ([]*LoginRecord, error)
+
+	// Countries 返回用户曾登录过的国家代码（去重，不含空值）
+	Countries(ctx context.Context, userID int64) ([]string, error)
+}
+
+// KnownDeviceRepository 用户已知设备的持久化
+type KnownDeviceRepository interface {
+	// ListByUser 返回用户全部已知设备
+	ListByUser(ctx context.Context, userID int64) ([]*KnownDevice, error)
+
+	// Save 按 (user_id, fingerprint) 新增设备或更新最近使用信息
+	Save(ctx context.Context, device *KnownDevice) error
+}
+
+// AuditEventRepository 安全审计事件的持久化
+type AuditEventRepository interface {
+	// Create 写入一条审计事件
+	Create(ctx context.Context, event *AuditEvent) error
+
+	// List 按条件查询审计事件，按 ID 倒序
+	List(ctx context.Context, filter AuditEventFilter) ([]*AuditEvent, error)
+
+	// DeleteBefore 删除早于 before 的事件（按保留期清理）
+	DeleteBefore(ctx context.Context, before time.Time) error
+}
+
+// Transactor 在同一数据库事务中执行多个仓库操作
+type Transactor interface {
+	// WithinTx 在事务中执行 fn：fn 内以其参数 ctx 调用的仓库方法共享该事务，fn 返回错误时回滚；ctx 已在事务中时直接复用
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// OutboxRepository 领域事件发件箱的持久化
+type OutboxRepository interface {
+	// Create 写入事件；在 Transactor 事务中调用时与状态变更一同提交
+	Create(ctx context.Context, records []*OutboxRecord) error
+
+	// ClaimDue 按 ID 升序领取到期、未投递且未标记失败的事件，并将其 next_attempt_at 推迟 lease，使多实例部署时同一事件不会被并发投递
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*OutboxRecord, error)
+
+	// MarkPublished 标记事件已投递
+	MarkPublished(ctx context.Context, id int64, at time.Time) error
+
+	// Reschedule 投递失败后记录尝试次数与原因，并于 next 重试
+	Reschedule(ctx context.Context, id int64, attempts int, next time.Time, lastError string) error
+
+	// MarkFailed 重试次数用尽后记录尝试次数与原因并标记失败，此后不再领取
+	MarkFailed(ctx context.Context, id int64, attempts int, at time.Time, lastError string) error
+
+	// DeletePublishedBefore 删除早于 before 且已投递的事件（按保留期清理）
+	DeletePublishedBefore(ctx context.Context, before time.Time) error
+}
+
+// WebhookDeliveryRepository Webhook 发件箱与投递日志的持久化
+type WebhookDeliveryRepository interface {
+	// Create 批量写入待投递记录
+	Create(ctx context.Context, deliveries []*WebhookDelivery) error
+
+	// ClaimDue 领取 next_attempt_at 已到的 pending 记录，并将其 next_attempt_at 推迟 lease，
+	// 使多实例部署时同一记录不会被并发投递；进程在投递中退出时记录在 lease 后重新到期
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*WebhookDelivery, error)
+
+	// UpdateAttempt 保存一次投递尝试的结果（状态、尝试次数、下次尝试时间与最近一次响应）
+	UpdateAttempt(ctx context.Context, delivery *WebhookDelivery) error
+
+	// FindByID 查找投递记录，不存在时返回 ErrWebhookDeliveryNotFound
+	FindByID(ctx context.Context, id int64) (*WebhookDelivery, error)
+
+	// List 按条件查询投递记录，按 ID 倒序
+	List(ctx context.Context, filter WebhookDeliveryFilter) ([]*WebhookDelivery, error)
+
+	// Requeue 将记录重置为 pending、清零尝试次数并于 at 到期（重放），不存在时返回 ErrWebhookDeliveryNotFound
+	Requeue(ctx context.Context, id int64, at time.Time) error
+
+	// DeleteBefore 删除早于 before 且已结束（delivered / failed）的记录（按保留期清理）
+	DeleteBefore(ctx context.Context, before time.Time) error
+}
+
+// UserIdentityRepository 第三方登录身份绑定的持久化
+type UserIdentityRepository interface {
+	// Create 保存绑定关系，(provider, subject) 或 (user_id, provider) 冲突时返回 ErrIdentityExists
+	Create(ctx context.Context, identity *UserIdentity) error
+
+	// FindByProviderSubject 按提供方与其用户标识查找绑定，不存在时返回 ErrIdentityNotFound
+	FindByProviderSubject(ctx context.Context, provider, subject string) (*UserIdentity, error)
+
+	// ListByUser 列出用户全部绑定
+	ListByUser(ctx context.Context, userID int64) ([]*UserIdentity, error)
+
+	// UpdateLogin 登录成功后更新邮箱与最近登录时间
+	UpdateLogin(ctx context.Context, id int64, email string, at time.Time) error
+
+	// Delete 解除用户在指定提供方的绑定，不存在时返回 ErrIdentityNotFound
+	Delete(ctx context.Context, userID int64, provider string) error
+}
+
+// GroupRepository 用户组及成员关系的持久化
+type GroupRepository interface {
+	// Create 创建组（含成员），显示名冲突时返回 ErrGroupExists
+	Create(ctx context.Context, group *Group) error
+
+	// FindByID 查找组及其成员，不存在时返回 ErrGroupNotFound
+	FindByID(ctx context.Context, id int64) (*Group, error)
+
+	// List 按条件分页查询组（含成员），同时返回满足条件的总数
+	List(ctx context.Context, filter GroupFilter) ([]*Group, int64, error)
+
+	// ListByUser 列出用户所属的组（不含成员）
+	ListByUser(ctx context.Context, userID int64) ([]*Group, error)
+
+	// Update 修改显示名与外部标识，显示名冲突时返回 ErrGroupExists
+	Update(ctx context.Context, group *Group) error
+
+	// ReplaceMembers 以 userIDs 替换全部成员
+	ReplaceMembers(ctx context.Context, groupID int64, userIDs []int64) error
+
+	// AddMembers 添加成员，已是成员的忽略
+	AddMembers(ctx context.Context, groupID int64, userIDs []int64) error
+
+	// RemoveMembers 移除成员，不是成员的忽略
+	RemoveMembers(ctx context.Context, groupID int64, userIDs []int64) error
+
+	// RemoveUser 从所有组中移除用户（用户删除时调用）
+	RemoveUser(ctx context.Context, userID int64) error
+
+	// Delete 删除组及其成员关系，不存在时返回 ErrGroupNotFound
+	Delete(ctx context.Context, id int64) error
+}
@@ -0,0 +1,10 @@
+package domain
+
+import "errors"
+
+// SAML IdP 相关错误
+var (
+	ErrUnknownServiceProvider = errors.New("unknown saml service provider")
+	ErrInvalidSAMLRequest     = errors.New("invalid saml authn request")
+	ErrInvalidSAMLState       = errors.New("invalid or expired saml request state")
+)
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 限流与账号锁定相关错误
+var (
+	ErrAccountLocked = errors.New("account temporarily locked")
+	ErrRateLimited   = errors.New("too many requests")
+)
+
+// AccountLockedError 账号因连续登录失败被临时锁定，RetryAfter 为剩余锁定时长
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrAccountLocked, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap 便于 errors.Is(err, ErrAccountLocked)
+func (e *AccountLockedError) Unwrap() error { return ErrAccountLocked }
+
+// RateLimitedError 操作过于频繁（如重发短信验证码间隔不足），RetryAfter 为需等待的时长
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrRateLimited, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap 便于 errors.Is(err, ErrRateLimited)
+func (e *RateLimitedError) Unwrap() error { return ErrRateLimited }
@@ -2,21 +2,65 @@ package domain
 
 import (
 	"errors"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // User 是核心用户模型
 type User struct {
-	ID           int64
-	Username     string
-	Role         string
-	Email        string
-	PasswordHash string
-	CreatedAt    time.Time
+	ID          int64
+	Username    string
+	Role        string
+	Email       string
+	FirstName   string
+	LastName    string
+	AvatarURL   string
+	PhoneNumber string // 空字符串表示未绑定手机号
+	// PhoneVerifiedAt 手机号通过短信验证码验证的时间，nil 表示未验证（不可用于短信登录）
+	PhoneVerifiedAt *time.Time
+	PasswordHash    string
+	Status          string
+	// TokenVersion 令牌版本号，修改密码等操作时递增，使此前签发的 token 全部失效
+	TokenVersion int64
+	// FailedLoginCount 连续登录失败次数，登录成功或管理员解锁后清零
+	FailedLoginCount int
+	// LockedUntil 非空且晚于当前时间表示账号被临时锁定
+	LockedUntil *time.Time
+	// LastLoginAt 最近一次登录成功时间，从未登录为 nil
+	LastLoginAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 }
 
+// 用户角色，与 users.role 一致
+const (
+	RoleStandard = "standard"
+	RoleAdmin    = "admin"
+)
+
+// UserFilter 用户列表查询条件，零值字段不参与过滤；结果按 ID 升序
+type UserFilter struct {
+	ID int64
+	// Username / Email 精确匹配，不区分大小写
+	Username string
+	Email    string
+	// Statuses 非空时只返回这些状态的用户
+	Statuses []string
+	Offset   int
+	Limit    int
+}
+
+// 用户状态，与 users.status 枚举一致
+const (
+	UserStatusActive    = "active"
+	UserStatusInactive  = "inactive"
+	UserStatusSuspended = "suspended"
+	UserStatusPending   = "pending" // 已注册、邮箱待验证
+)
+
 // 定义服务可能返回的常见错误
 var (
 	ErrUserNotFound       = errors.New("user not found")
@@ -25,42 +69,246 @@ var (
 	ErrEmailExists        = errors.New("email already exists")
 	ErrInvalidEmail       = errors.New("invalid email format")
 	ErrPasswordTooShort   = errors.New("password too short")
+	ErrPhoneExists        = errors.New("phone number already exists")
+	ErrInvalidUsername    = errors.New("invalid username")
+	ErrInvalidPhone       = errors.New("invalid phone number format")
+	ErrInvalidProfile     = errors.New("invalid profile field")
+	ErrIncorrectPassword  = errors.New("current password is incorrect")
+	ErrPasswordUnchanged  = errors.New("new password must differ from current password")
+	ErrTokenRevoked       = errors.New("token has been revoked")
+	ErrInvalidResetToken  = errors.New("invalid or expired reset token")
+	ErrEmailNotVerified   = errors.New("email not verified")
+	ErrInvalidVerifyToken = errors.New("invalid or expired verification token")
+	ErrInvalidMagicLink   = errors.New("invalid or expired magic link")
+	ErrInvalidOTP         = errors.New("invalid or expired verification code")
 )
 
 // 简单邮箱格式校验
 var emailRegexp = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 
+// 用户名：字母数字及 . _ + -；不允许 @，注册未填写用户名时默认使用的邮箱由 validUsername 单独放行
+var usernameRegexp = regexp.MustCompile(`^[a-zA-Z0-9._+-]{3,100}$`)
+
+// 手机号：可选 + 前缀，6~19 位数字（与 users.phone_number VARCHAR(20) 一致）
+var phoneRegexp = regexp.MustCompile(`^\+?[0-9]{6,19}$`)
+
 // MinPasswordLength 注册时密码最小长度
 const MinPasswordLength = 6
 
-// ValidateRegisterRequest 校验注册请求
-func ValidateRegisterRequest(req RegisterRequest) error {
-	if strings.TrimSpace(req.Email) == "" {
-		return ErrInvalidEmail
+// 资料字段长度上限，与 users 表列定义一致
+const (
+	MaxNameLength      = 100
+	MaxAvatarURLLength = 512
+)
+
+// NormalizeEmail 去除首尾空白并转为小写，注册、登录与按邮箱查找前统一调用
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizeUsername 去除首尾空白并转为小写；用户名大小写不敏感（与 users 表排序规则一致）
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// validUsername 校验用户名格式；形如手机号的用户名会与手机号登录混淆，不允许使用。
+// 含 @ 的用户名仅允许等于账号自身的邮箱 email（注册默认值）：否则可以把他人邮箱注册为用户名，冒充对方或干扰按邮箱登录
+func validUsername(username, email string) bool {
+	if strings.Contains(username, "@") {
+		return email != "" && username == email
 	}
-	if !emailRegexp.MatchString(req.Email) {
+	return usernameRegexp.MatchString(username) && !phoneRegexp.MatchString(username)
+}
+
+// ValidateRegisterRequest 校验注册请求的邮箱与用户名格式（需先调用 Normalize；密码按配置策略在服务内校验）
+func ValidateRegisterRequest(req RegisterRequest) error {
+	if req.Email == "" || !emailRegexp.MatchString(req.Email) {
 		return ErrInvalidEmail
 	}
-	if len(req.Password) < MinPasswordLength {
-		return ErrPasswordTooShort
+	if req.Username != "" && !validUsername(req.Username, req.Email) {
+		return ErrInvalidUsername
 	}
 	return nil
 }
 
+// ValidatePassword 按默认密码策略校验密码；服务内按配置策略校验见 auth.PasswordChecker
+func ValidatePassword(password string) error {
+	return DefaultPasswordPolicy().Validate(password, "", "")
+}
+
 // LoginRequest 是登录时需要的DTO
 type LoginRequest struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	// Identifier 登录标识：邮箱、用户名或已验证的手机号；为空时依次使用 Email、Username（兼容旧客户端）
+	Identifier string `json:"identifier"`
+	Username   string `json:"username"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
 	// State 子应用传来的 state，登录成功后重定向回子应用时原样带回
 	State string `json:"state"`
 	// ServerState 认证中心下发的 server_state，用于服务端从 StateStore 取回 client_id、redirect_uri、client state
 	ServerState string `json:"server_state"`
 }
 
+// LoginIdentifier 返回请求中的登录标识（已去除首尾空白，未做大小写或手机号规范化）
+func (req LoginRequest) LoginIdentifier() string {
+	for _, v := range []string{req.Identifier, req.Email, req.Username} {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 // RegisterRequest  注册DTO
 type RegisterRequest struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
+
+// Normalize 规范化邮箱与用户名（去除首尾空白、转小写）
+func (req *RegisterRequest) Normalize() {
+	req.Email = NormalizeEmail(req.Email)
+	req.Username = NormalizeUsername(req.Username)
+}
+
+// UpdateProfileRequest 更新个人资料 DTO（PATCH 语义：字段为 nil 表示不修改）；手机号只能经短信验证绑定
+type UpdateProfileRequest struct {
+	Username  *string `json:"username"`
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+	AvatarURL *string `json:"avatar_url"`
+}
+
+// Normalize 去除各字段首尾空白，用户名转小写
+func (req *UpdateProfileRequest) Normalize() {
+	for _, f := range []*string{req.Username, req.FirstName, req.LastName, req.AvatarURL} {
+		if f != nil {
+			*f = strings.TrimSpace(*f)
+		}
+	}
+	if req.Username != nil {
+		*req.Username = NormalizeUsername(*req.Username)
+	}
+}
+
+// ValidateUpdateProfileRequest 校验个人资料更新请求（需先调用 Normalize）；用户名不能含 @
+func ValidateUpdateProfileRequest(req UpdateProfileRequest) error {
+	if req.Username != nil && !validUsername(*req.Username, "") {
+		return ErrInvalidUsername
+	}
+	if req.FirstName != nil && utf8.RuneCountInString(*req.FirstName) > MaxNameLength {
+		return ErrInvalidProfile
+	}
+	if req.LastName != nil && utf8.RuneCountInString(*req.LastName) > MaxNameLength {
+		return ErrInvalidProfile
+	}
+	if req.AvatarURL != nil && !validAvatarURL(*req.AvatarURL) {
+		return ErrInvalidProfile
+	}
+	return nil
+}
+
+// validAvatarURL 头像地址须为带主机名的 http/https 绝对地址，空字符串表示清除头像
+func validAvatarURL(raw string) bool {
+	if raw == "" {
+		return true
+	}
+	if len(raw) > MaxAvatarURLLength {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// Apply 将请求中非 nil 的字段写入用户模型
+func (req UpdateProfileRequest) Apply(user *User) {
+	if req.Username != nil {
+		user.Username = *req.Username
+	}
+	if req.FirstName != nil {
+		user.FirstName = *req.FirstName
+	}
+	if req.LastName != nil {
+		user.LastName = *req.LastName
+	}
+	if req.AvatarURL != nil {
+		user.AvatarURL = *req.AvatarURL
+	}
+}
+
+// ChangePasswordRequest 修改密码 DTO
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ForgotPasswordRequest 申请重置密码 DTO
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// MagicLinkRequest 申请邮件登录链接 DTO
+type MagicLinkRequest struct {
+	Email string `json:"email"`
+	// ServerState SSO 流程中登录页收到的 server_state，点击链接登录后用其生成授权码
+	ServerState string `json:"server_state"`
+}
+
+// ConsumeMagicLinkRequest 使用邮件登录链接中的令牌登录 DTO
+type ConsumeMagicLinkRequest struct {
+	Token string `json:"token"`
+}
+
+// PhoneCodeRequest 发送短信验证码 DTO（短信登录、绑定手机号）
+type PhoneCodeRequest struct {
+	PhoneNumber string `json:"phone_number"`
+}
+
+// PhoneLoginRequest 短信验证码登录 DTO
+type PhoneLoginRequest struct {
+	PhoneNumber string `json:"phone_number"`
+	Code        string `json:"code"`
+	// ServerState SSO 流程中登录页收到的 server_state
+	ServerState string `json:"server_state"`
+}
+
+// VerifyPhoneRequest 验证并绑定手机号 DTO
+type VerifyPhoneRequest struct {
+	PhoneNumber string `json:"phone_number"`
+	Code        string `json:"code"`
+}
+
+// NormalizePhoneNumber 去除空格、短横线与括号后校验格式，不合法时返回 ErrInvalidPhone
+func NormalizePhoneNumber(phone string) (string, error) {
+	phone = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+	if !phoneRegexp.MatchString(phone) {
+		return "", ErrInvalidPhone
+	}
+	return phone, nil
+}
+
+// ResetPasswordRequest 使用重置令牌设置新密码 DTO
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// VerifyEmailRequest 邮箱验证 DTO
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// ResendVerificationRequest 重新发送验证邮件 DTO
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateRegisterRequestUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		username string
+		wantErr  error
+	}{
+		{"empty username", "alice@example.com", "", nil},
+		{"plain", "alice@example.com", "alice.w_1+dev-x", nil},
+		{"own email", "alice@example.com", "alice@example.com", nil},
+		// 以他人邮箱作为用户名可冒充对方
+		{"other email", "mallory@example.com", "alice@example.com", ErrInvalidUsername},
+		{"at sign", "alice@example.com", "alice@corp", ErrInvalidUsername},
+		{"phone-like", "alice@example.com", "+8613800138000", ErrInvalidUsername},
+		{"too short", "alice@example.com", "al", ErrInvalidUsername},
+		{"invalid char", "alice@example.com", "alice smith", ErrInvalidUsername},
+		{"invalid email", "not-an-email", "", ErrInvalidEmail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRegisterRequest(RegisterRequest{Email: tt.email, Username: tt.username})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateUpdateProfileRequestUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		wantErr  error
+	}{
+		{"alice", nil},
+		{"alice@example.com", ErrInvalidUsername},
+		{"@alice", ErrInvalidUsername},
+		{"13800138000", ErrInvalidUsername},
+	}
+	for _, tt := range tests {
+		username := tt.username
+		req := UpdateProfileRequest{Username: &username}
+		req.Normalize()
+		if err := ValidateUpdateProfileRequest(req); !errors.Is(err, tt.wantErr) {
+			t.Errorf("username %q: got %v, want %v", tt.username, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateUpdateProfileRequestAvatarURL(t *testing.T) {
+	tests := []struct {
+		avatarURL string
+		wantErr   error
+	}{
+		{"https://cdn.example.com/avatars/alice.png", nil},
+		{"http://localhost:8888/static/uploads/alice/avatar.jpg", nil},
+		{"  https://cdn.example.com/a.png  ", nil},
+		{"", nil}, // 清除头像
+		{"javascript:alert(1)", ErrInvalidProfile},
+		{"data:image/png;base64,iVBORw0KGgo=", ErrInvalidProfile},
+		{"/static/uploads/alice/avatar.jpg", ErrInvalidProfile},
+		{"//cdn.example.com/a.png", ErrInvalidProfile},
+		{"ftp://example.com/a.png", ErrInvalidProfile},
+		{"https:///a.png", ErrInvalidProfile},
+		{"https://exa mple.com/a.png", ErrInvalidProfile},
+		{"https://cdn.example.com/" + strings.Repeat("a", MaxAvatarURLLength), ErrInvalidProfile},
+	}
+	for _, tt := range tests {
+		avatarURL := tt.avatarURL
+		req := UpdateProfileRequest{AvatarURL: &avatarURL}
+		req.Normalize()
+		if err := ValidateUpdateProfileRequest(req); !errors.Is(err, tt.wantErr) {
+			t.Errorf("avatar_url %q: got %v, want %v", tt.avatarURL, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// WebAuthnCredential 用户注册的 WebAuthn 凭据（Passkey / 平台认证器 / 安全密钥）
+type WebAuthnCredential struct {
+	ID              int64
+	UserID          int64
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType string
+	AAGUID          []byte
+	SignCount       uint32
+	Transports      []string
+	BackupEligible  bool
+	BackupState     bool
+	// Name 用户为凭据起的名称，便于在列表中区分设备
+	Name       string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+}
+
+// WebAuthn 相关错误
+var (
+	ErrWebAuthnCredentialNotFound = errors.New("webauthn credential not found")
+	ErrWebAuthnCredentialExists   = errors.New("webauthn credential already registered")
+	ErrInvalidWebAuthnSession     = errors.New("invalid or expired webauthn session")
+	ErrWebAuthnVerification       = errors.New("webauthn verification failed")
+)
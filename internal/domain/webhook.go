@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// WebhookEventTypes 可通过 Webhook 订阅的事件类型（用户生命周期）
+var WebhookEventTypes = []string{
+	EventUserRegistered,
+	EventUserEmailChanged,
+	EventUserSuspended,
+	EventUserReactivated,
+	EventUserDeleted,
+}
+
+// Webhook 投递状态
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed" // 重试次数用尽，可通过重放重新投递
+)
+
+// WebhookDelivery 一条投递记录：既是持久化的发件箱，也是投递日志
+type WebhookDelivery struct {
+	ID         int64
+	Subscriber string
+	EventID    string
+	EventType  string
+	UserID     int64
+	// Payload 请求体 JSON，重试与重放时原样发送
+	Payload       []byte
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	// LastStatusCode 最近一次尝试的 HTTP 状态码，0 表示未收到响应
+	LastStatusCode int
+	LastError      string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// WebhookDeliveryFilter 投递记录查询条件，零值字段不参与过滤；结果按 ID 倒序
+type WebhookDeliveryFilter struct {
+	Subscriber string
+	EventType  string
+	Status     string
+	UserID     int64
+	// BeforeID 分页游标：只返回 ID 小于该值的记录
+	BeforeID int64
+	Limit    int
+}
+
+// ErrWebhookDeliveryNotFound 投递记录不存在
+var ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
@@ -0,0 +1,135 @@
+package eventbus
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"monai-auth/internal/domain"
+)
+
+// 发布到 Broker 的消息头
+const (
+	HeaderEventID   = "Event-Id"
+	HeaderEventType = "Event-Type"
+)
+
+// Message 发往消息系统的一条消息
+type Message struct {
+	// Subject 主题，以 . 分隔（对应 NATS subject 或 Kafka topic）
+	Subject string
+	// Key 分区键（Kafka），同一用户的事件使用相同的键以保持顺序；为空表示不指定
+	Key     string
+	Data    []byte
+	Headers map[string]string
+}
+
+// Broker 消息系统客户端的最小接口；接入 NATS、Kafka 等时实现该接口
+type Broker interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// BrokerSink 将事件编码后发布到 Broker，主题为 <prefix><事件类型>，如 identity.user.registered
+type BrokerSink struct {
+	name   string
+	broker Broker
+	prefix string
+}
+
+// NewBrokerSink 创建 Broker 投递目标
+func NewBrokerSink(name string, broker Broker, subjectPrefix string) *BrokerSink {
+	return &BrokerSink{name: name, broker: broker, prefix: subjectPrefix}
+}
+
+func (s *BrokerSink) Name() string { return s.name }
+
+func (s *BrokerSink) Publish(ctx context.Context, event domain.Event) error {
+	data, err := Encode(event)
+	if err != nil {
+		return err
+	}
+	msg := Message{
+		Subject: s.prefix + event.Type,
+		Data:    data,
+		Headers: map[string]string{HeaderEventID: event.ID, HeaderEventType: event.Type},
+	}
+	if event.UserID > 0 {
+		msg.Key = "user:" + strconv.FormatInt(event.UserID, 10)
+	}
+	return s.broker.Publish(ctx, msg)
+}
+
+// Handler 处理一条消息；在 Publish 的调用协程中同步执行
+type Handler func(ctx context.Context, msg Message)
+
+type subscription struct {
+	pattern []string
+	handler Handler
+}
+
+// MemoryBroker 进程内 Broker：同步分发给匹配的订阅者，供同进程的模块消费事件及测试使用。
+// 与 NATS 相同，订阅主题以 . 分隔，* 匹配一段，> 匹配其后的全部段（如 identity.user.>）
+type MemoryBroker struct {
+	subs map[int]*subscription
+	next int
+	mu   sync.RWMutex
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subs: make(map[int]*subscription)}
+}
+
+// Subscribe 订阅匹配 pattern 的消息，返回取消订阅函数
+func (b *MemoryBroker) Subscribe(pattern string, handler Handler) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.subs[id] = &subscription{pattern: strings.Split(pattern, "."), handler: handler}
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, id)
+	}
+}
+
+// Publish 依次调用匹配的订阅者；订阅者 panic 时记录日志，不影响其他订阅者
+func (b *MemoryBroker) Publish(ctx context.Context, msg Message) error {
+	subject := strings.Split(msg.Subject, ".")
+	b.mu.RLock()
+	var handlers []Handler
+	for _, sub := range b.subs {
+		if matchSubject(sub.pattern, subject) {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	b.mu.RUnlock()
+	for _, h := range handlers {
+		deliver(ctx, h, msg)
+	}
+	return nil
+}
+
+func deliver(ctx context.Context, h Handler, msg Message) {
+	defer func() {
+		if v := recover(); v != nil {
+			log.Printf("[EVENT] subscriber panic subject=%s err=%v", msg.Subject, v)
+		}
+	}()
+	h(ctx, msg)
+}
+
+// matchSubject NATS 风格的主题匹配
+func matchSubject(pattern, subject []string) bool {
+	for i, p := range pattern {
+		if p == ">" {
+			return len(subject) > i
+		}
+		if i >= len(subject) || (p != "*" && p != subject[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(subject)
+}
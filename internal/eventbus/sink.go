@@ -0,0 +1,65 @@
+// Package eventbus 提供领域事件的编码与投递目标（Sink）抽象：日志、消息系统（NATS / Kafka 等按 Broker 接口适配）
+// 及进程内 Broker。事件由发件箱后台协程投递，保证至少一次，消费方应按事件 ID 去重。
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+// Sink 事件投递目标；Publish 返回错误时发件箱稍后重试该事件（对全部 Sink 重新投递）
+type Sink interface {
+	// Name 投递目标名称，用于日志
+	Name() string
+	Publish(ctx context.Context, event domain.Event) error
+}
+
+// envelope 事件的 JSON 编码
+type envelope struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	UserID     int64          `json:"user_id,omitempty"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	Data       map[string]any `json:"data,omitempty"`
+}
+
+// Encode 将事件编码为 JSON：{"id","type","user_id","occurred_at","data"}
+func Encode(event domain.Event) ([]byte, error) {
+	return json.Marshal(envelope{
+		ID:         event.ID,
+		Type:       event.Type,
+		UserID:     event.UserID,
+		OccurredAt: event.OccurredAt.UTC(),
+		Data:       event.Data,
+	})
+}
+
+// Decode 解码 Encode 的结果；data 中的数字解码为 float64
+func Decode(data []byte) (domain.Event, error) {
+	var e envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return domain.Event{}, fmt.Errorf("decode event: %w", err)
+	}
+	return domain.Event{ID: e.ID, Type: e.Type, UserID: e.UserID, Data: e.Data, OccurredAt: e.OccurredAt}, nil
+}
+
+// LogSink 将事件输出到应用日志，用于本地开发与排查
+type LogSink struct{}
+
+func NewLogSink() LogSink { return LogSink{} }
+
+func (LogSink) Name() string { return "log" }
+
+func (LogSink) Publish(ctx context.Context, event domain.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	log.Printf("[EVENT] id=%s type=%s user_id=%d data=%s", event.ID, event.Type, event.UserID, data)
+	return nil
+}
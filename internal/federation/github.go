@@ -0,0 +1,101 @@
+package federation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GitHub 默认端点；GitHub Enterprise 需在配置中覆盖
+const (
+	githubAuthorizationURL = "https://github.com/login/oauth/authorize"
+	githubTokenURL         = "https://github.com/login/oauth/access_token"
+	githubAPIURL           = "https://api.github.com"
+)
+
+// githubProvider GitHub OAuth App：用户 ID 作为 subject，邮箱取 /user/emails 中已验证的主邮箱
+type githubProvider struct {
+	cfg    ProviderConfig
+	client *http.Client
+	// userURL / emailsURL 由 UserInfoURL（缺省 https://api.github.com/user）推导
+	userURL   string
+	emailsURL string
+}
+
+func newGitHubProvider(cfg ProviderConfig, client *http.Client) *githubProvider {
+	if cfg.AuthorizationURL == "" {
+		cfg.AuthorizationURL = githubAuthorizationURL
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = githubTokenURL
+	}
+	if cfg.UserInfoURL == "" {
+		cfg.UserInfoURL = githubAPIURL + "/user"
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"read:user", "user:email"}
+	}
+	if cfg.TokenAuthMethod == "" {
+		cfg.TokenAuthMethod = AuthMethodClientSecretPost
+	}
+	return &githubProvider{
+		cfg:       cfg,
+		client:    client,
+		userURL:   cfg.UserInfoURL,
+		emailsURL: strings.TrimSuffix(cfg.UserInfoURL, "/") + "/emails",
+	}
+}
+
+func (p *githubProvider) Name() string        { return p.cfg.Name }
+func (p *githubProvider) DisplayName() string { return p.cfg.DisplayName }
+
+func (p *githubProvider) AuthCodeURL(ctx context.Context, req AuthRequest) (string, error) {
+	return authCodeURL(p.cfg.AuthorizationURL, p.cfg.ClientID, p.cfg.Scopes, req, nil)
+}
+
+// githubUser GET /user 响应中用到的字段
+type githubUser struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+// githubEmail GET /user/emails 响应元素
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, req Exchange) (*Identity, error) {
+	tok, err := exchangeCode(ctx, p.client, p.cfg.TokenURL, p.cfg, p.cfg.TokenAuthMethod, req)
+	if err != nil {
+		return nil, err
+	}
+	var user githubUser
+	if err := getJSON(ctx, p.client, p.userURL, tok.AccessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, fmt.Errorf("%w: github user response missing id", ErrProvider)
+	}
+	identity := &Identity{Subject: strconv.FormatInt(user.ID, 10), Name: user.Name}
+	if identity.Name == "" {
+		identity.Name = user.Login
+	}
+	// /user 中的 email 是用户公开展示的邮箱，未必已验证；以 /user/emails 的主邮箱为准
+	var emails []githubEmail
+	if err := getJSON(ctx, p.client, p.emailsURL, tok.AccessToken, &emails); err != nil {
+		return nil, err
+	}
+	for _, e := range emails {
+		if e.Primary {
+			identity.Email = e.Email
+			identity.EmailVerified = e.Verified
+			break
+		}
+	}
+	return identity, nil
+}
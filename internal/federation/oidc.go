@@ -0,0 +1,206 @@
+package federation
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// idTokenLeeway 校验 ID Token 时间声明时允许的时钟偏差
+const idTokenLeeway = time.Minute
+
+// idTokenAlgorithms 接受的 ID Token 签名算法；不接受 none 与 HMAC（客户端无法持有提供方的对称密钥）
+var idTokenAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// discoveryDocument OpenID Provider Metadata 中用到的字段
+type discoveryDocument struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	UserInfoEndpoint      string   `json:"userinfo_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	TokenAuthMethods      []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// oidcProvider 标准 OIDC 提供方：端点首次使用时通过发现文档获取（配置中显式给出的端点优先），
+// 用户身份来自经过签名、iss、aud、exp、nonce 校验的 ID Token，缺少邮箱时再查询 UserInfo
+type oidcProvider struct {
+	cfg    ProviderConfig
+	client *http.Client
+
+	mu         sync.Mutex
+	discovered bool
+	authMethod string
+	keys       *keySet
+}
+
+func newOIDCProvider(cfg ProviderConfig, client *http.Client) (*oidcProvider, error) {
+	if cfg.Issuer == "" {
+		return nil, fmt.Errorf("provider %s: issuer is required for oidc providers", cfg.Name)
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	} else if !slices.Contains(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+	return &oidcProvider{cfg: cfg, client: client}, nil
+}
+
+func (p *oidcProvider) Name() string        { return p.cfg.Name }
+func (p *oidcProvider) DisplayName() string { return p.cfg.DisplayName }
+
+// discover 补全未配置的端点；失败时不缓存，下次请求重试
+func (p *oidcProvider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovered {
+		return nil
+	}
+	authMethod := p.cfg.TokenAuthMethod
+	if p.cfg.AuthorizationURL == "" || p.cfg.TokenURL == "" || p.cfg.JWKSURL == "" {
+		var doc discoveryDocument
+		endpoint := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := getJSON(ctx, p.client, endpoint, "", &doc); err != nil {
+			return err
+		}
+		// OIDC Discovery 4.3：文档中的 issuer 必须与请求时使用的 issuer 完全一致
+		if doc.Issuer != p.cfg.Issuer {
+			return fmt.Errorf("%w: discovery issuer %q does not match configured issuer %q", ErrProvider, doc.Issuer, p.cfg.Issuer)
+		}
+		p.cfg.AuthorizationURL = firstNonEmpty(p.cfg.AuthorizationURL, doc.AuthorizationEndpoint)
+		p.cfg.TokenURL = firstNonEmpty(p.cfg.TokenURL, doc.TokenEndpoint)
+		p.cfg.UserInfoURL = firstNonEmpty(p.cfg.UserInfoURL, doc.UserInfoEndpoint)
+		p.cfg.JWKSURL = firstNonEmpty(p.cfg.JWKSURL, doc.JWKSURI)
+		if authMethod == "" && len(doc.TokenAuthMethods) > 0 && !slices.Contains(doc.TokenAuthMethods, AuthMethodClientSecretBasic) &&
+			slices.Contains(doc.TokenAuthMethods, AuthMethodClientSecretPost) {
+			authMethod = AuthMethodClientSecretPost
+		}
+	}
+	if p.cfg.AuthorizationURL == "" || p.cfg.TokenURL == "" || p.cfg.JWKSURL == "" {
+		return fmt.Errorf("%w: provider %s is missing authorization, token or jwks endpoint", ErrProvider, p.cfg.Name)
+	}
+	if authMethod == "" {
+		authMethod = AuthMethodClientSecretBasic
+	}
+	p.authMethod = authMethod
+	p.keys = newKeySet(p.cfg.JWKSURL, p.client)
+	p.discovered = true
+	return nil
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, req AuthRequest) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+	return authCodeURL(p.cfg.AuthorizationURL, p.cfg.ClientID, p.cfg.Scopes, req, url.Values{"nonce": {req.Nonce}})
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, req Exchange) (*Identity, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+	tok, err := exchangeCode(ctx, p.client, p.cfg.TokenURL, p.cfg, p.authMethod, req)
+	if err != nil {
+		return nil, err
+	}
+	if tok.IDToken == "" {
+		return nil, fmt.Errorf("%w: token response missing id_token", ErrProvider)
+	}
+	claims, err := p.verifyIDToken(ctx, tok.IDToken, req.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	identity := claims.identity()
+	if identity.Email == "" && p.cfg.UserInfoURL != "" {
+		var info oidcClaims
+		if err := getJSON(ctx, p.client, p.cfg.UserInfoURL, tok.AccessToken, &info); err != nil {
+			return nil, err
+		}
+		// OIDC Core 5.3.2：UserInfo 的 sub 必须与 ID Token 一致，否则不得使用
+		if info.Subject != identity.Subject {
+			return nil, fmt.Errorf("%w: userinfo sub does not match id_token", ErrProvider)
+		}
+		fromInfo := info.identity()
+		identity.Email, identity.EmailVerified = fromInfo.Email, fromInfo.EmailVerified
+		identity.Name = firstNonEmpty(identity.Name, fromInfo.Name)
+	}
+	return identity, nil
+}
+
+// oidcClaims ID Token / UserInfo 中用到的声明
+type oidcClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string      `json:"nonce"`
+	AuthorizedParty   string      `json:"azp"`
+	Email             string      `json:"email"`
+	EmailVerified     interface{} `json:"email_verified"`
+	Name              string      `json:"name"`
+	PreferredUsername string      `json:"preferred_username"`
+}
+
+func (c *oidcClaims) identity() *Identity {
+	// 部分提供方将 email_verified 返回为字符串 "true"
+	verified := false
+	switch v := c.EmailVerified.(type) {
+	case bool:
+		verified = v
+	case string:
+		verified = v == "true"
+	}
+	return &Identity{
+		Subject:       c.Subject,
+		Email:         c.Email,
+		EmailVerified: c.Email != "" && verified,
+		Name:          firstNonEmpty(c.Name, c.PreferredUsername),
+	}
+}
+
+// verifyIDToken 校验 ID Token 的签名、iss、aud、azp、exp、iat 与 nonce（OIDC Core 3.1.3.7）
+func (p *oidcProvider) verifyIDToken(ctx context.Context, raw, nonce string) (*oidcClaims, error) {
+	var claims oidcClaims
+	_, err := jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.keys.key(ctx, kid)
+	},
+		jwt.WithValidMethods(idTokenAlgorithms),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(idTokenLeeway),
+	)
+	if err != nil {
+		if errors.Is(err, ErrProvider) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: invalid id_token: %v", ErrProvider, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: id_token missing sub", ErrProvider)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: id_token azp does not match client_id", ErrProvider)
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: id_token nonce mismatch", ErrProvider)
+	}
+	return &claims, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,227 @@
+package federation
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIdP 模拟 OIDC 提供方：发现文档、JWKS 与令牌端点；令牌端点按 RFC 7636 校验 code_verifier
+type mockIdP struct {
+	srv    *httptest.Server
+	key    *rsa.PrivateKey
+	issuer string
+
+	mu     sync.Mutex
+	grants map[string]mockGrant
+}
+
+type mockGrant struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	m := &mockIdP{key: key, grants: map[string]mockGrant{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, http.StatusOK, map[string]any{
+			"issuer":                 m.issuer,
+			"authorization_endpoint": m.srv.URL + "/authorize",
+			"token_endpoint":         m.srv.URL + "/token",
+			"jwks_uri":               m.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "client" || secret != "secret" {
+			writeTestJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+			return
+		}
+		m.mu.Lock()
+		grant, ok := m.grants[r.PostFormValue("code")]
+		delete(m.grants, r.PostFormValue("code"))
+		m.mu.Unlock()
+		if !ok || CodeChallengeS256(r.PostFormValue("code_verifier")) != grant.challenge {
+			writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		writeTestJSON(w, http.StatusOK, map[string]string{
+			"access_token": "at",
+			"token_type":   "Bearer",
+			"id_token":     m.sign(t, grant.claims),
+		})
+	})
+	m.srv = httptest.NewServer(mux)
+	m.issuer = m.srv.URL
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+func (m *mockIdP) sign(t *testing.T, claims jwt.MapClaims) string {
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = "k1"
+	raw, err := tok.SignedString(m.key)
+	if err != nil {
+		t.Errorf("sign id_token: %v", err)
+	}
+	return raw
+}
+
+// authorize 模拟用户在提供方完成登录：记录授权地址中的 code_challenge，签发携带 nonce 的授权码
+func (m *mockIdP) authorize(t *testing.T, p Provider, verifier string, edit func(jwt.MapClaims)) string {
+	t.Helper()
+	authURL, err := p.AuthCodeURL(context.Background(), AuthRequest{
+		State:         "state",
+		Nonce:         "nonce-1",
+		CodeChallenge: CodeChallengeS256(verifier),
+		RedirectURI:   "https://auth.example.com/callback",
+	})
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	u, _ := url.Parse(authURL)
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != "client" {
+		t.Fatalf("unexpected authorization request %s", authURL)
+	}
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            m.issuer,
+		"aud":            "client",
+		"sub":            "user-1",
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          q.Get("nonce"),
+		"email":          "user@example.com",
+		"email_verified": true,
+	}
+	if edit != nil {
+		edit(claims)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.grants["code"] = mockGrant{challenge: q.Get("code_challenge"), claims: claims}
+	return "code"
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newTestOIDCProvider(t *testing.T, issuer string) Provider {
+	t.Helper()
+	p, err := New(ProviderConfig{Name: "corp", Type: TypeOIDC, ClientID: "client", ClientSecret: "secret", Issuer: issuer}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return p
+}
+
+func TestOIDCExchange(t *testing.T) {
+	tests := []struct {
+		name         string
+		edit         func(jwt.MapClaims)
+		verifier     string // 换取令牌时提交的 code_verifier，空表示与发起时一致
+		nonce        string // 换取令牌时期望的 nonce，空表示与发起时一致
+		wantErr      bool
+		wantVerified bool
+	}{
+		{name: "email verified", wantVerified: true},
+		{name: "email verified as string", edit: func(c jwt.MapClaims) { c["email_verified"] = "true" }, wantVerified: true},
+		{name: "email not verified", edit: func(c jwt.MapClaims) { c["email_verified"] = false }},
+		{name: "email_verified missing", edit: func(c jwt.MapClaims) { delete(c, "email_verified") }},
+		{name: "verified without email", edit: func(c jwt.MapClaims) { delete(c, "email") }},
+		{name: "wrong code_verifier", verifier: "attacker-verifier", wantErr: true},
+		{name: "nonce mismatch", nonce: "nonce-2", wantErr: true},
+		{name: "wrong audience", edit: func(c jwt.MapClaims) { c["aud"] = "other-client" }, wantErr: true},
+		{name: "azp mismatch", edit: func(c jwt.MapClaims) { c["aud"] = []string{"client", "other"}; c["azp"] = "other" }, wantErr: true},
+		{name: "wrong issuer", edit: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, wantErr: true},
+		{name: "expired", edit: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, wantErr: true},
+		{name: "missing sub", edit: func(c jwt.MapClaims) { delete(c, "sub") }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newMockIdP(t)
+			p := newTestOIDCProvider(t, idp.issuer)
+			verifier := "verifier-0123456789-0123456789-0123456789"
+			code := idp.authorize(t, p, verifier, tt.edit)
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+			nonce := "nonce-1"
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+			identity, err := p.Exchange(context.Background(), Exchange{Code: code, CodeVerifier: verifier, RedirectURI: "https://auth.example.com/callback", Nonce: nonce})
+			if tt.wantErr {
+				if !errors.Is(err, ErrProvider) {
+					t.Fatalf("got %v, want ErrProvider", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+			if identity.Subject != "user-1" {
+				t.Fatalf("subject = %q", identity.Subject)
+			}
+			if identity.EmailVerified != tt.wantVerified {
+				t.Fatalf("email_verified = %v, want %v", identity.EmailVerified, tt.wantVerified)
+			}
+		})
+	}
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	idp := newMockIdP(t)
+	// 发现文档声明的 issuer 带尾部斜杠，与配置不完全一致
+	p := newTestOIDCProvider(t, idp.issuer)
+	idp.issuer = idp.srv.URL + "/"
+	_, err := p.AuthCodeURL(context.Background(), AuthRequest{State: "s", Nonce: "n", CodeChallenge: CodeChallengeS256("v")})
+	if !errors.Is(err, ErrProvider) {
+		t.Fatalf("got %v, want ErrProvider", err)
+	}
+}
+
+func TestOIDCRejectsForgedSignature(t *testing.T) {
+	idp := newMockIdP(t)
+	p := newTestOIDCProvider(t, idp.issuer)
+	code := idp.authorize(t, p, "verifier", nil)
+	// 沿用 JWKS 中的 kid，但用另一把私钥签名
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	idp.key = other
+	if _, err := p.Exchange(context.Background(), Exchange{Code: code, CodeVerifier: "verifier", Nonce: "nonce-1"}); !errors.Is(err, ErrProvider) {
+		t.Fatalf("got %v, want ErrProvider", err)
+	}
+}
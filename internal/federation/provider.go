@@ -0,0 +1,232 @@
+// Package federation 实现作为 OAuth2 / OIDC 客户端接入上游身份提供方（GitHub、Google 及任意标准 OIDC 提供方）。
+// 只负责协议交互：生成授权地址、用授权码换取令牌并得到经过校验的用户身份；账号绑定与登录由 auth 包完成。
+package federation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 提供方类型
+const (
+	// TypeOIDC 标准 OpenID Connect 提供方（Google、Keycloak、Auth0 等），通过 issuer 自动发现端点并校验 ID Token
+	TypeOIDC = "oidc"
+	// TypeGitHub GitHub OAuth App（非 OIDC），通过 REST API 获取用户与已验证邮箱
+	TypeGitHub = "github"
+)
+
+// 令牌端点客户端认证方式
+const (
+	AuthMethodClientSecretBasic = "client_secret_basic"
+	AuthMethodClientSecretPost  = "client_secret_post"
+)
+
+// maxResponseBytes 读取上游响应体的上限
+const maxResponseBytes = 1 << 20
+
+// ErrProvider 上游返回错误或响应不符合预期；具体原因见包装的错误信息
+var ErrProvider = errors.New("identity provider error")
+
+// Identity 经过校验的上游用户身份
+type Identity struct {
+	// Subject 提供方内的用户唯一标识
+	Subject string
+	Email   string
+	// EmailVerified 提供方确认用户拥有该邮箱；仅已验证的邮箱可用于匹配本地账号
+	EmailVerified bool
+	Name          string
+}
+
+// AuthRequest 发起授权所需的一次性参数
+type AuthRequest struct {
+	State string
+	// Nonce 写入 ID Token 的随机数，防止令牌重放（仅 OIDC）
+	Nonce string
+	// CodeChallenge PKCE S256 challenge，由 CodeChallengeS256(verifier) 计算
+	CodeChallenge string
+	RedirectURI   string
+}
+
+// Exchange 用授权码换取身份所需的参数
+type Exchange struct {
+	Code         string
+	CodeVerifier string
+	RedirectURI  string
+	// Nonce 与 AuthRequest.Nonce 一致，用于校验 ID Token
+	Nonce string
+}
+
+// Provider 上游身份提供方
+type Provider interface {
+	// Name 配置中的提供方名称，出现在回调地址与 user_identities.provider 中
+	Name() string
+	// DisplayName 登录页按钮上展示的名称
+	DisplayName() string
+	// AuthCodeURL 返回授权端点地址（授权码模式 + PKCE）
+	AuthCodeURL(ctx context.Context, req AuthRequest) (string, error)
+	// Exchange 用授权码换取令牌并返回校验后的用户身份
+	Exchange(ctx context.Context, req Exchange) (*Identity, error)
+}
+
+// ProviderConfig 提供方配置
+type ProviderConfig struct {
+	Name         string
+	Type         string
+	DisplayName  string
+	ClientID     string
+	ClientSecret string
+	// Scopes 为空时 OIDC 使用 openid email profile，GitHub 使用 read:user user:email
+	Scopes []string
+	// Issuer OIDC 提供方标识，用于发现端点（<issuer>/.well-known/openid-configuration）与校验 ID Token 的 iss
+	Issuer string
+	// 以下端点可覆盖发现结果或 GitHub 默认值（如 GitHub Enterprise）
+	AuthorizationURL string
+	TokenURL         string
+	UserInfoURL      string
+	JWKSURL          string
+	// TokenAuthMethod 令牌端点客户端认证方式，缺省 OIDC 为 client_secret_basic，GitHub 为 client_secret_post
+	TokenAuthMethod string
+}
+
+// New 按类型创建提供方；client 为空时使用 10 秒超时的默认客户端
+func New(cfg ProviderConfig, client *http.Client) (Provider, error) {
+	if cfg.Name == "" || cfg.ClientID == "" {
+		return nil, errors.New("provider name and client_id are required")
+	}
+	if cfg.DisplayName == "" {
+		cfg.DisplayName = cfg.Name
+	}
+	switch cfg.TokenAuthMethod {
+	case "", AuthMethodClientSecretBasic, AuthMethodClientSecretPost:
+	default:
+		return nil, fmt.Errorf("provider %s: token_auth_method must be %s or %s", cfg.Name, AuthMethodClientSecretBasic, AuthMethodClientSecretPost)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	switch cfg.Type {
+	case TypeOIDC:
+		return newOIDCProvider(cfg, client)
+	case TypeGitHub:
+		return newGitHubProvider(cfg, client), nil
+	default:
+		return nil, fmt.Errorf("provider %s: unsupported type %q, expected %s or %s", cfg.Name, cfg.Type, TypeOIDC, TypeGitHub)
+	}
+}
+
+// CodeChallengeS256 计算 PKCE S256 code_challenge
+func CodeChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// authCodeURL 拼接授权端点地址
+func authCodeURL(endpoint, clientID string, scopes []string, req AuthRequest, extra url.Values) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("parse authorization endpoint: %w", err)
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", clientID)
+	q.Set("redirect_uri", req.RedirectURI)
+	q.Set("scope", strings.Join(scopes, " "))
+	q.Set("state", req.State)
+	q.Set("code_challenge", req.CodeChallenge)
+	q.Set("code_challenge_method", "S256")
+	for k, v := range extra {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// tokenResponse 令牌端点响应（RFC 6749 5.1 / 5.2）
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchangeCode 调用令牌端点用授权码换取令牌
+func exchangeCode(ctx context.Context, client *http.Client, endpoint string, cfg ProviderConfig, authMethod string, req Exchange) (*tokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", req.Code)
+	form.Set("redirect_uri", req.RedirectURI)
+	form.Set("code_verifier", req.CodeVerifier)
+	form.Set("client_id", cfg.ClientID)
+	if authMethod == AuthMethodClientSecretPost {
+		form.Set("client_secret", cfg.ClientSecret)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+	if authMethod == AuthMethodClientSecretBasic {
+		// RFC 6749 2.3.1：client_id 与 client_secret 需先做 form 编码
+		httpReq.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+	var tok tokenResponse
+	status, err := doJSON(client, httpReq, &tok)
+	if err != nil {
+		return nil, err
+	}
+	if tok.Error != "" {
+		return nil, fmt.Errorf("%w: token endpoint returned %s: %s", ErrProvider, tok.Error, tok.ErrorDescription)
+	}
+	if status != http.StatusOK || tok.AccessToken == "" {
+		return nil, fmt.Errorf("%w: token endpoint returned status %d without access_token", ErrProvider, status)
+	}
+	return &tok, nil
+}
+
+// getJSON 以 Bearer 令牌（可为空）请求 JSON 资源，非 200 时返回错误
+func getJSON(ctx context.Context, client *http.Client, endpoint, accessToken string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	status, err := doJSON(client, req, out)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("%w: GET %s returned status %d", ErrProvider, endpoint, status)
+	}
+	return nil
+}
+
+// doJSON 发送请求并解析 JSON 响应体，返回 HTTP 状态码
+func doJSON(client *http.Client, req *http.Request, out interface{}) (int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s %s: %v", ErrProvider, req.Method, req.URL.Redacted(), err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("%w: read response: %v", ErrProvider, err)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("%w: %s %s returned status %d with invalid JSON", ErrProvider, req.Method, req.URL.Redacted(), resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,71 @@
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileMailer 将邮件写入本地目录（每封一个 .eml 文件），用于本地开发与测试
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer 创建文件邮件发送器，目录不存在时自动创建
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create mail dir: %w", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := validateHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102T150405.000"), hex.EncodeToString(b))
+	if err := os.WriteFile(filepath.Join(m.dir, name), buildMessage(m.from, msg), 0644); err != nil {
+		return fmt.Errorf("write mail file: %w", err)
+	}
+	return nil
+}
+
+// LogMailer 仅将邮件内容打印到日志，并保留在内存中供测试读取
+type LogMailer struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+// NewLogMailer 创建日志邮件发送器
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if err := validateHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+	log.Printf("[MAIL] to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// Sent 返回已发送邮件的副本
+func (m *LogMailer) Sent() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.sent...)
+}
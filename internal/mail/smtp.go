@@ -0,0 +1,89 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+)
+
+// SMTPConfig SMTP 服务器配置
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	// ImplicitTLS 为 true 时直接建立 TLS 连接（如 465 端口）；否则由 net/smtp 在服务器支持时自动 STARTTLS
+	ImplicitTLS bool
+}
+
+// SMTPMailer 通过 SMTP 发送邮件
+type SMTPMailer struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPMailer 创建 SMTP 邮件发送器
+func NewSMTPMailer(cfg SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{cfg: cfg}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := validateHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+	addr := net.JoinHostPort(m.cfg.Host, m.cfg.Port)
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	// From 可带显示名（"Name <addr>"），SMTP 信封只使用地址部分
+	envelopeFrom := m.cfg.From
+	if a, err := netmail.ParseAddress(m.cfg.From); err == nil {
+		envelopeFrom = a.Address
+	}
+	body := buildMessage(m.cfg.From, msg)
+	if !m.cfg.ImplicitTLS {
+		if err := smtp.SendMail(addr, auth, envelopeFrom, []string{msg.To}, body); err != nil {
+			return fmt.Errorf("smtp send: %w", err)
+		}
+		return nil
+	}
+
+	dialer := &tls.Dialer{Config: &tls.Config{ServerName: m.cfg.Host}}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("smtp dial: %w", err)
+	}
+	c, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp client: %w", err)
+	}
+	defer c.Close()
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := c.Mail(envelopeFrom); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("smtp rcpt to: %w", err)
+	}
+	wc, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := wc.Write(body); err != nil {
+		wc.Close()
+		return fmt.Errorf("smtp write: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("smtp data close: %w", err)
+	}
+	return c.Quit()
+}
@@ -0,0 +1,71 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+// InMemoryAuditEventRepo 审计事件内存实现，用于本地开发与测试
+type InMemoryAuditEventRepo struct {
+	events []*domain.AuditEvent // 按 ID 升序
+	nextID int64
+	mu     sync.Mutex
+}
+
+func NewInMemoryAuditEventRepo() *InMemoryAuditEventRepo {
+	return &InMemoryAuditEventRepo{nextID: 1}
+}
+
+func (r *InMemoryAuditEventRepo) Create(ctx context.Context, event *domain.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	event.ID = r.nextID
+	r.nextID++
+	e := *event
+	r.events = append(r.events, &e)
+	return nil
+}
+
+func (r *InMemoryAuditEventRepo) List(ctx context.Context, f domain.AuditEventFilter) ([]*domain.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.AuditEvent
+	for i := len(r.events) - 1; i >= 0; i-- {
+		e := r.events[i]
+		if (f.UserID > 0 && e.UserID != f.UserID) ||
+			(f.Type != "" && e.Type != f.Type) ||
+			(f.Outcome != "" && e.Outcome != f.Outcome) ||
+			(f.IP != "" && e.IP != f.IP) ||
+			(f.ClientID != "" && e.ClientID != f.ClientID) ||
+			(!f.Since.IsZero() && e.CreatedAt.Before(f.Since)) ||
+			(!f.Until.IsZero() && !e.CreatedAt.Before(f.Until)) ||
+			(f.BeforeID > 0 && e.ID >= f.BeforeID) {
+			continue
+		}
+		c := *e
+		out = append(out, &c)
+		if f.Limit > 0 && len(out) >= f.Limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (r *InMemoryAuditEventRepo) DeleteBefore(ctx context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.events[:0]
+	for _, e := range r.events {
+		if !e.CreatedAt.Before(before) {
+			kept = append(kept, e)
+		}
+	}
+	r.events = kept
+	return nil
+}
@@ -0,0 +1,56 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+type verifyTokenEntry struct {
+	userID    int64
+	expiresAt time.Time
+	createdAt time.Time
+	used      bool
+}
+
+// InMemoryEmailVerificationTokenRepo 邮箱验证令牌内存实现，用于本地开发与测试
+type InMemoryEmailVerificationTokenRepo struct {
+	tokens map[string]*verifyTokenEntry
+	mu     sync.Mutex
+}
+
+func NewInMemoryEmailVerificationTokenRepo() *InMemoryEmailVerificationTokenRepo {
+	return &InMemoryEmailVerificationTokenRepo{tokens: make(map[string]*verifyTokenEntry)}
+}
+
+func (r *InMemoryEmailVerificationTokenRepo) Create(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[tokenHash] = &verifyTokenEntry{userID: userID, expiresAt: expiresAt, createdAt: time.Now()}
+	return nil
+}
+
+func (r *InMemoryEmailVerificationTokenRepo) Consume(ctx context.Context, tokenHash string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.tokens[tokenHash]
+	if !ok || e.used || time.Now().After(e.expiresAt) {
+		return 0, domain.ErrInvalidVerifyToken
+	}
+	e.used = true
+	return e.userID, nil
+}
+
+func (r *InMemoryEmailVerificationTokenRepo) CreateIfIdle(ctx context.Context, userID int64, tokenHash string, expiresAt, idleSince time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.tokens {
+		if e.userID == userID && e.createdAt.After(idleSince) {
+			return false, nil
+		}
+	}
+	r.tokens[tokenHash] = &verifyTokenEntry{userID: userID, expiresAt: expiresAt, createdAt: time.Now()}
+	return true, nil
+}
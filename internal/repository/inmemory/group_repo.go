@@ -0,0 +1,222 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+// InMemoryGroupRepo 用户组内存实现，用于本地开发与测试；成员用户名通过 users 查询，已删除的用户不计入成员
+type InMemoryGroupRepo struct {
+	users   domain.UserRepository
+	groups  map[int64]*domain.Group
+	members map[int64]map[int64]struct{}
+	nextID  int64
+	mu      sync.Mutex
+}
+
+func NewInMemoryGroupRepo(users domain.UserRepository) *InMemoryGroupRepo {
+	return &InMemoryGroupRepo{
+		users:   users,
+		groups:  make(map[int64]*domain.Group),
+		members: make(map[int64]map[int64]struct{}),
+	}
+}
+
+func (r *InMemoryGroupRepo) Create(ctx context.Context, group *domain.Group) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.displayNameTaken(group.DisplayName, 0) {
+		return domain.ErrGroupExists
+	}
+	r.nextID++
+	now := time.Now()
+	group.ID = r.nextID
+	group.CreatedAt = now
+	group.UpdatedAt = now
+	g := *group
+	g.Members = nil
+	r.groups[g.ID] = &g
+	r.members[g.ID] = make(map[int64]struct{})
+	for _, m := range group.Members {
+		r.members[g.ID][m.UserID] = struct{}{}
+	}
+	return nil
+}
+
+func (r *InMemoryGroupRepo) FindByID(ctx context.Context, id int64) (*domain.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.groups[id]
+	if !ok {
+		return nil, domain.ErrGroupNotFound
+	}
+	return r.withMembers(ctx, g)
+}
+
+func (r *InMemoryGroupRepo) List(ctx context.Context, filter domain.GroupFilter) ([]*domain.Group, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matched []*domain.Group
+	for _, g := range r.groups {
+		if filter.ID > 0 && g.ID != filter.ID {
+			continue
+		}
+		if filter.DisplayName != "" && g.DisplayName != filter.DisplayName {
+			continue
+		}
+		if filter.ExternalID != "" && g.ExternalID != filter.ExternalID {
+			continue
+		}
+		matched = append(matched, g)
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+	total := int64(len(matched))
+	if filter.Offset >= len(matched) {
+		matched = nil
+	} else {
+		matched = matched[filter.Offset:]
+	}
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+	out := make([]*domain.Group, 0, len(matched))
+	for _, g := range matched {
+		c, err := r.withMembers(ctx, g)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, c)
+	}
+	return out, total, nil
+}
+
+func (r *InMemoryGroupRepo) ListByUser(ctx context.Context, userID int64) ([]*domain.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.Group
+	for id, members := range r.members {
+		if _, ok := members[userID]; ok {
+			g := *r.groups[id]
+			out = append(out, &g)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (r *InMemoryGroupRepo) Update(ctx context.Context, group *domain.Group) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.groups[group.ID]
+	if !ok {
+		return domain.ErrGroupNotFound
+	}
+	if r.displayNameTaken(group.DisplayName, group.ID) {
+		return domain.ErrGroupExists
+	}
+	g.DisplayName = group.DisplayName
+	g.ExternalID = group.ExternalID
+	g.UpdatedAt = time.Now()
+	group.UpdatedAt = g.UpdatedAt
+	return nil
+}
+
+func (r *InMemoryGroupRepo) ReplaceMembers(ctx context.Context, groupID int64, userIDs []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.groups[groupID]
+	if !ok {
+		return domain.ErrGroupNotFound
+	}
+	members := make(map[int64]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		members[id] = struct{}{}
+	}
+	r.members[groupID] = members
+	g.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *InMemoryGroupRepo) AddMembers(ctx context.Context, groupID int64, userIDs []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.groups[groupID]
+	if !ok {
+		return domain.ErrGroupNotFound
+	}
+	for _, id := range userIDs {
+		r.members[groupID][id] = struct{}{}
+	}
+	g.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *InMemoryGroupRepo) RemoveMembers(ctx context.Context, groupID int64, userIDs []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.groups[groupID]
+	if !ok {
+		return domain.ErrGroupNotFound
+	}
+	for _, id := range userIDs {
+		delete(r.members[groupID], id)
+	}
+	g.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *InMemoryGroupRepo) RemoveUser(ctx context.Context, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, members := range r.members {
+		delete(members, userID)
+	}
+	return nil
+}
+
+func (r *InMemoryGroupRepo) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.groups[id]; !ok {
+		return domain.ErrGroupNotFound
+	}
+	delete(r.groups, id)
+	delete(r.members, id)
+	return nil
+}
+
+func (r *InMemoryGroupRepo) displayNameTaken(name string, exceptID int64) bool {
+	for _, g := range r.groups {
+		if g.ID != exceptID && g.DisplayName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// withMembers 返回组副本并按用户 ID 升序填充成员
+func (r *InMemoryGroupRepo) withMembers(ctx context.Context, g *domain.Group) (*domain.Group, error) {
+	c := *g
+	ids := make([]int64, 0, len(r.members[g.ID]))
+	for id := range r.members[g.ID] {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		u, err := r.users.FindByID(ctx, id)
+		if errors.Is(err, domain.ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.Members = append(c.Members, domain.GroupMember{UserID: id, Username: u.Username})
+	}
+	return &c, nil
+}
@@ -0,0 +1,85 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+// InMemoryUserIdentityRepo 第三方身份绑定内存实现，用于本地开发与测试
+type InMemoryUserIdentityRepo struct {
+	identities map[int64]*domain.UserIdentity
+	nextID     int64
+	mu         sync.Mutex
+}
+
+func NewInMemoryUserIdentityRepo() *InMemoryUserIdentityRepo {
+	return &InMemoryUserIdentityRepo{identities: make(map[int64]*domain.UserIdentity)}
+}
+
+func (r *InMemoryUserIdentityRepo) Create(ctx context.Context, identity *domain.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, i := range r.identities {
+		if i.Provider == identity.Provider && (i.Subject == identity.Subject || i.UserID == identity.UserID) {
+			return domain.ErrIdentityExists
+		}
+	}
+	r.nextID++
+	identity.ID = r.nextID
+	identity.CreatedAt = time.Now()
+	i := *identity
+	r.identities[i.ID] = &i
+	return nil
+}
+
+func (r *InMemoryUserIdentityRepo) FindByProviderSubject(ctx context.Context, provider, subject string) (*domain.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			cp := *i
+			return &cp, nil
+		}
+	}
+	return nil, domain.ErrIdentityNotFound
+}
+
+func (r *InMemoryUserIdentityRepo) ListByUser(ctx context.Context, userID int64) ([]*domain.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.UserIdentity
+	for id := int64(1); id <= r.nextID; id++ {
+		if i, ok := r.identities[id]; ok && i.UserID == userID {
+			cp := *i
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (r *InMemoryUserIdentityRepo) UpdateLogin(ctx context.Context, id int64, email string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, ok := r.identities[id]
+	if !ok {
+		return domain.ErrIdentityNotFound
+	}
+	i.Email = email
+	i.LastLoginAt = &at
+	return nil
+}
+
+func (r *InMemoryUserIdentityRepo) Delete(ctx context.Context, userID int64, provider string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, i := range r.identities {
+		if i.UserID == userID && i.Provider == provider {
+			delete(r.identities, id)
+			return nil
+		}
+	}
+	return domain.ErrIdentityNotFound
+}
@@ -0,0 +1,54 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+// InMemoryKnownDeviceRepo 已知设备内存实现，用于本地开发与测试
+type InMemoryKnownDeviceRepo struct {
+	devices map[int64][]*domain.KnownDevice
+	nextID  int64
+	mu      sync.Mutex
+}
+
+func NewInMemoryKnownDeviceRepo() *InMemoryKnownDeviceRepo {
+	return &InMemoryKnownDeviceRepo{devices: make(map[int64][]*domain.KnownDevice), nextID: 1}
+}
+
+func (r *InMemoryKnownDeviceRepo) ListByUser(ctx context.Context, userID int64) ([]*domain.KnownDevice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]*domain.KnownDevice, 0, len(r.devices[userID]))
+	for _, d := range r.devices[userID] {
+		c := *d
+		out = append(out, &c)
+	}
+	return out, nil
+}
+
+func (r *InMemoryKnownDeviceRepo) Save(ctx context.Context, device *domain.KnownDevice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if device.LastSeenAt.IsZero() {
+		device.LastSeenAt = time.Now()
+	}
+	for _, d := range r.devices[device.UserID] {
+		if d.Fingerprint == device.Fingerprint {
+			d.UserAgent = device.UserAgent
+			d.LastIP = device.LastIP
+			d.LastCountry = device.LastCountry
+			d.LastSeenAt = device.LastSeenAt
+			return nil
+		}
+	}
+	c := *device
+	c.ID = r.nextID
+	r.nextID++
+	c.FirstSeenAt = c.LastSeenAt
+	r.devices[device.UserID] = append(r.devices[device.UserID], &c)
+	return nil
+}
@@ -0,0 +1,61 @@
+package inmemory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+// InMemoryLoginHistoryRepo 登录记录内存实现，用于本地开发与测试
+type InMemoryLoginHistoryRepo struct {
+	records []*domain.LoginRecord // 按 ID 升序
+	nextID  int64
+	mu      sync.Mutex
+}
+
+func NewInMemoryLoginHistoryRepo() *InMemoryLoginHistoryRepo {
+	return &InMemoryLoginHistoryRepo{nextID: 1}
+}
+
+func (r *InMemoryLoginHistoryRepo) Create(ctx context.Context, record *domain.LoginRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	record.ID = r.nextID
+	r.nextID++
+	c := *record
+	r.records = append(r.records, &c)
+	return nil
+}
+
+func (r *InMemoryLoginHistoryRepo) ListByUser(ctx context.Context, userID int64, beforeID int64, limit int) ([]*domain.LoginRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.LoginRecord
+	for i := len(r.records) - 1; i >= 0 && (limit <= 0 || len(out) < limit); i-- {
+		rec := r.records[i]
+		if rec.UserID != userID || (beforeID > 0 && rec.ID >= beforeID) {
+			continue
+		}
+		c := *rec
+		out = append(out, &c)
+	}
+	return out, nil
+}
+
+func (r *InMemoryLoginHistoryRepo) Countries(ctx context.Context, userID int64) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []string
+	for _, rec := range r.records {
+		if rec.UserID == userID && rec.Country != "" && !slices.Contains(out, rec.Country) {
+			out = append(out, rec.Country)
+		}
+	}
+	return out, nil
+}
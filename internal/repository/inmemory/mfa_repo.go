@@ -0,0 +1,116 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+type recoveryCodeEntry struct {
+	hash string
+	used bool
+}
+
+// InMemoryMFARepo MFA 内存实现，用于本地开发与测试
+type InMemoryMFARepo struct {
+	totp     map[int64]*domain.TOTPCredential
+	recovery map[int64][]*recoveryCodeEntry
+	mu       sync.Mutex
+}
+
+func NewInMemoryMFARepo() *InMemoryMFARepo {
+	return &InMemoryMFARepo{
+		totp:     make(map[int64]*domain.TOTPCredential),
+		recovery: make(map[int64][]*recoveryCodeEntry),
+	}
+}
+
+func (r *InMemoryMFARepo) FindTOTP(ctx context.Context, userID int64) (*domain.TOTPCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cred, ok := r.totp[userID]
+	if !ok {
+		return nil, domain.ErrMFANotEnrolled
+	}
+	c := *cred
+	return &c, nil
+}
+
+func (r *InMemoryMFARepo) SaveTOTP(ctx context.Context, cred *domain.TOTPCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := *cred
+	c.CreatedAt = time.Now()
+	r.totp[cred.UserID] = &c
+	return nil
+}
+
+func (r *InMemoryMFARepo) EnableTOTP(ctx context.Context, userID int64, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cred, ok := r.totp[userID]
+	if !ok {
+		return domain.ErrMFANotEnrolled
+	}
+	now := time.Now()
+	cred.Enabled = true
+	cred.LastUsedStep = step
+	cred.ConfirmedAt = &now
+	return nil
+}
+
+func (r *InMemoryMFARepo) UpdateTOTPLastUsedStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cred, ok := r.totp[userID]
+	if !ok || step <= cred.LastUsedStep {
+		return false, nil
+	}
+	cred.LastUsedStep = step
+	return true, nil
+}
+
+func (r *InMemoryMFARepo) DeleteTOTP(ctx context.Context, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.totp, userID)
+	delete(r.recovery, userID)
+	return nil
+}
+
+func (r *InMemoryMFARepo) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]*recoveryCodeEntry, 0, len(codeHashes))
+	for _, h := range codeHashes {
+		entries = append(entries, &recoveryCodeEntry{hash: h})
+	}
+	r.recovery[userID] = entries
+	return nil
+}
+
+func (r *InMemoryMFARepo) ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.recovery[userID] {
+		if e.hash == codeHash && !e.used {
+			e.used = true
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *InMemoryMFARepo) CountRecoveryCodes(ctx context.Context, userID int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, e := range r.recovery[userID] {
+		if !e.used {
+			n++
+		}
+	}
+	return n, nil
+}
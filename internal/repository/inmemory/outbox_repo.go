@@ -0,0 +1,119 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+// InMemoryTransactor domain.Transactor 内存实现：直接执行 fn，不支持回滚，用于本地开发与测试
+type InMemoryTransactor struct{}
+
+func (InMemoryTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// InMemoryOutboxRepo 发件箱内存实现，用于本地开发与测试
+type InMemoryOutboxRepo struct {
+	records []*domain.OutboxRecord // 按 ID 升序
+	nextID  int64
+	mu      sync.Mutex
+}
+
+func NewInMemoryOutboxRepo() *InMemoryOutboxRepo {
+	return &InMemoryOutboxRepo{nextID: 1}
+}
+
+func (r *InMemoryOutboxRepo) Create(ctx context.Context, records []*domain.OutboxRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, rec := range records {
+		if rec.CreatedAt.IsZero() {
+			rec.CreatedAt = now
+		}
+		if rec.NextAttemptAt.IsZero() {
+			rec.NextAttemptAt = rec.CreatedAt
+		}
+		rec.ID = r.nextID
+		r.nextID++
+		c := *rec
+		c.Payload = append([]byte(nil), rec.Payload...)
+		r.records = append(r.records, &c)
+	}
+	return nil
+}
+
+func (r *InMemoryOutboxRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.OutboxRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.OutboxRecord
+	for _, rec := range r.records {
+		if rec.PublishedAt != nil || rec.FailedAt != nil || rec.NextAttemptAt.After(now) {
+			continue
+		}
+		c := *rec
+		out = append(out, &c)
+		rec.NextAttemptAt = now.Add(lease)
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (r *InMemoryOutboxRepo) MarkPublished(ctx context.Context, id int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rec := r.find(id); rec != nil {
+		rec.PublishedAt = &at
+		rec.LastError = ""
+	}
+	return nil
+}
+
+func (r *InMemoryOutboxRepo) Reschedule(ctx context.Context, id int64, attempts int, next time.Time, lastError string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rec := r.find(id); rec != nil {
+		rec.Attempts = attempts
+		rec.NextAttemptAt = next
+		rec.LastError = lastError
+	}
+	return nil
+}
+
+func (r *InMemoryOutboxRepo) MarkFailed(ctx context.Context, id int64, attempts int, at time.Time, lastError string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rec := r.find(id); rec != nil {
+		rec.Attempts = attempts
+		rec.FailedAt = &at
+		rec.LastError = lastError
+	}
+	return nil
+}
+
+func (r *InMemoryOutboxRepo) DeletePublishedBefore(ctx context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.records[:0]
+	for _, rec := range r.records {
+		if rec.PublishedAt == nil || !rec.CreatedAt.Before(before) {
+			kept = append(kept, rec)
+		}
+	}
+	r.records = kept
+	return nil
+}
+
+func (r *InMemoryOutboxRepo) find(id int64) *domain.OutboxRecord {
+	for _, rec := range r.records {
+		if rec.ID == id {
+			return rec
+		}
+	}
+	return nil
+}
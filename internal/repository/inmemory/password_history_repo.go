@@ -0,0 +1,37 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+)
+
+// InMemoryPasswordHistoryRepo 历史密码内存实现，用于本地开发与测试
+type InMemoryPasswordHistoryRepo struct {
+	history map[int64][]string // 按时间倒序
+	mu      sync.Mutex
+}
+
+func NewInMemoryPasswordHistoryRepo() *InMemoryPasswordHistoryRepo {
+	return &InMemoryPasswordHistoryRepo{history: make(map[int64][]string)}
+}
+
+func (r *InMemoryPasswordHistoryRepo) Add(ctx context.Context, userID int64, passwordHash string, keep int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h := append([]string{passwordHash}, r.history[userID]...)
+	if keep > 0 && len(h) > keep {
+		h = h[:keep]
+	}
+	r.history[userID] = h
+	return nil
+}
+
+func (r *InMemoryPasswordHistoryRepo) ListRecent(ctx context.Context, userID int64, n int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h := r.history[userID]
+	if n < len(h) {
+		h = h[:n]
+	}
+	return append([]string(nil), h...), nil
+}
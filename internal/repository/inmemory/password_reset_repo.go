@@ -0,0 +1,64 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+type resetTokenEntry struct {
+	userID    int64
+	expiresAt time.Time
+	used      bool
+}
+
+// InMemoryPasswordResetTokenRepo 重置令牌内存实现，用于本地开发与测试
+type InMemoryPasswordResetTokenRepo struct {
+	tokens map[string]*resetTokenEntry
+	mu     sync.Mutex
+}
+
+func NewInMemoryPasswordResetTokenRepo() *InMemoryPasswordResetTokenRepo {
+	return &InMemoryPasswordResetTokenRepo{tokens: make(map[string]*resetTokenEntry)}
+}
+
+func (r *InMemoryPasswordResetTokenRepo) Create(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[tokenHash] = &resetTokenEntry{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (r *InMemoryPasswordResetTokenRepo) Lookup(ctx context.Context, tokenHash string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.tokens[tokenHash]
+	if !ok || e.used || time.Now().After(e.expiresAt) {
+		return 0, domain.ErrInvalidResetToken
+	}
+	return e.userID, nil
+}
+
+func (r *InMemoryPasswordResetTokenRepo) Consume(ctx context.Context, tokenHash string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.tokens[tokenHash]
+	if !ok || e.used || time.Now().After(e.expiresAt) {
+		return 0, domain.ErrInvalidResetToken
+	}
+	e.used = true
+	return e.userID, nil
+}
+
+func (r *InMemoryPasswordResetTokenRepo) DeleteByUser(ctx context.Context, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, e := range r.tokens {
+		if e.userID == userID && !e.used {
+			delete(r.tokens, k)
+		}
+	}
+	return nil
+}
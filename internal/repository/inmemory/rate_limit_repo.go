@@ -0,0 +1,46 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type rateLimitCounter struct {
+	hits      int64
+	windowEnd time.Time
+}
+
+// InMemoryRateLimitRepo 限流计数内存实现，用于本地开发与测试
+type InMemoryRateLimitRepo struct {
+	counters map[string]*rateLimitCounter
+	mu       sync.Mutex
+}
+
+func NewInMemoryRateLimitRepo() *InMemoryRateLimitRepo {
+	return &InMemoryRateLimitRepo{counters: make(map[string]*rateLimitCounter)}
+}
+
+func (r *InMemoryRateLimitRepo) Hit(ctx context.Context, key string, window time.Duration) (int64, time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	c, ok := r.counters[key]
+	if !ok || !now.Before(c.windowEnd) {
+		c = &rateLimitCounter{windowEnd: now.Add(window)}
+		r.counters[key] = c
+	}
+	c.hits++
+	return c.hits, c.windowEnd, nil
+}
+
+func (r *InMemoryRateLimitRepo) DeleteExpired(ctx context.Context, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, c := range r.counters {
+		if !now.Before(c.windowEnd) {
+			delete(r.counters, k)
+		}
+	}
+	return nil
+}
@@ -2,6 +2,9 @@ package inmemory
 
 import (
 	"context"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,22 +13,27 @@ import (
 
 // InMemoryUserRepo 内存实现，仅用于演示接口
 type InMemoryUserRepo struct {
-	users map[string]*domain.User
-	mu    sync.RWMutex
+	users  map[string]*domain.User
+	nextID int64
+	mu     sync.RWMutex
 }
 
 func NewInMemoryUserRepo() *InMemoryUserRepo {
 	// 预设一个测试用户
 	initialUser := &domain.User{
+		ID:           1,
+		Username:     "test@example.com",
 		Email:        "test@example.com",
 		PasswordHash: "$2a$10$w1qZ3gKz0gL8b/Q/hXjU0.Q/hXjU0.Q/hXjU0.Q/hXjU0.Q/hXjU0.Q/hXjU0.Q/hXjU0.Q", // 密码: password123
 		Role:         "admin",
+		Status:       "active",
 		CreatedAt:    time.Now(),
 	}
 	return &InMemoryUserRepo{
 		users: map[string]*domain.User{
 			initialUser.Email: initialUser,
 		},
+		nextID: 1,
 	}
 }
 
@@ -34,7 +42,8 @@ func (r *InMemoryUserRepo) FindByID(ctx context.Context, id int64) (*domain.User
 	defer r.mu.RUnlock()
 	for _, user := range r.users {
 		if user.ID == id {
-			return user, nil
+			u := *user // 返回副本，避免调用方修改影响存储
+			return &u, nil
 		}
 	}
 	return nil, domain.ErrUserNotFound
@@ -47,7 +56,20 @@ func (r *InMemoryUserRepo) FindByEmail(ctx context.Context, email string) (*doma
 	if !ok {
 		return nil, domain.ErrUserNotFound
 	}
-	return user, nil
+	u := *user
+	return &u, nil
+}
+
+func (r *InMemoryUserRepo) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if strings.EqualFold(user.Username, username) {
+			u := *user
+			return &u, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
 }
 
 func (r *InMemoryUserRepo) CreateUser(ctx context.Context, user *domain.User) error {
@@ -56,6 +78,252 @@ func (r *InMemoryUserRepo) CreateUser(ctx context.Context, user *domain.User) er
 	if _, ok := r.users[user.Email]; ok {
 		return domain.ErrEmailExists
 	}
+	r.nextID++
+	user.ID = r.nextID
+	if user.Status == "" {
+		user.Status = domain.UserStatusActive
+	}
 	r.users[user.Email] = user
 	return nil
 }
+
+func (r *InMemoryUserRepo) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.users[email]
+	return ok, nil
+}
+
+func (r *InMemoryUserRepo) UpdateProfile(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var target *domain.User
+	for _, u := range r.users {
+		if u.ID == user.ID {
+			target = u
+			continue
+		}
+		if u.Username == user.Username {
+			return domain.ErrUserExists
+		}
+		if user.PhoneNumber != "" && u.PhoneNumber == user.PhoneNumber {
+			return domain.ErrPhoneExists
+		}
+	}
+	if target == nil {
+		return domain.ErrUserNotFound
+	}
+	target.Username = user.Username
+	target.FirstName = user.FirstName
+	target.LastName = user.LastName
+	target.AvatarURL = user.AvatarURL
+	target.PhoneNumber = user.PhoneNumber
+	target.PhoneVerifiedAt = user.PhoneVerifiedAt
+	return nil
+}
+
+func (r *InMemoryUserRepo) ChangePassword(ctx context.Context, id int64, passwordHash string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.ID == id {
+			u.PasswordHash = passwordHash
+			u.TokenVersion++
+			return u.TokenVersion, nil
+		}
+	}
+	return 0, domain.ErrUserNotFound
+}
+
+func (r *InMemoryUserRepo) UpdatePasswordHash(ctx context.Context, id int64, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.ID == id {
+			u.PasswordHash = passwordHash
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
+
+func (r *InMemoryUserRepo) UpdateStatus(ctx context.Context, id int64, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.ID == id {
+			u.Status = status
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
+
+func (r *InMemoryUserRepo) ActivatePending(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.ID == id && u.Status == domain.UserStatusPending {
+			u.Status = domain.UserStatusActive
+			u.PasswordHash = ""
+			u.TokenVersion++
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
+
+func (r *InMemoryUserRepo) UpdateRole(ctx context.Context, id int64, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.ID == id {
+			u.Role = role
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
+
+func (r *InMemoryUserRepo) RecordLoginFailure(ctx context.Context, id int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.ID == id {
+			u.FailedLoginCount++
+			return u.FailedLoginCount, nil
+		}
+	}
+	return 0, domain.ErrUserNotFound
+}
+
+func (r *InMemoryUserRepo) LockUntil(ctx context.Context, id int64, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.ID == id {
+			u.LockedUntil = &until
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
+
+func (r *InMemoryUserRepo) ResetLoginFailures(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.ID == id {
+			u.FailedLoginCount = 0
+			u.LockedUntil = nil
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
+
+func (r *InMemoryUserRepo) UpdateLastLogin(ctx context.Context, id int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.ID == id {
+			u.LastLoginAt = &at
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
+
+func (r *InMemoryUserRepo) FindByPhone(ctx context.Context, phone string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if phone != "" && user.PhoneNumber == phone {
+			u := *user
+			return &u, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *InMemoryUserRepo) SetPhoneVerified(ctx context.Context, id int64, phone string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var target *domain.User
+	for _, u := range r.users {
+		if u.ID == id {
+			target = u
+			continue
+		}
+		if u.PhoneNumber == phone {
+			return domain.ErrPhoneExists
+		}
+	}
+	if target == nil {
+		return domain.ErrUserNotFound
+	}
+	target.PhoneNumber = phone
+	target.PhoneVerifiedAt = &at
+	return nil
+}
+
+func (r *InMemoryUserRepo) UpdateEmail(ctx context.Context, id int64, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.users[email]; ok && existing.ID != id {
+		return domain.ErrEmailExists
+	}
+	for key, u := range r.users {
+		if u.ID == id {
+			delete(r.users, key)
+			u.Email = email
+			r.users[email] = u
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
+
+func (r *InMemoryUserRepo) List(ctx context.Context, filter domain.UserFilter) ([]*domain.User, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var matched []*domain.User
+	for _, user := range r.users {
+		if filter.ID > 0 && user.ID != filter.ID {
+			continue
+		}
+		if filter.Username != "" && !strings.EqualFold(user.Username, filter.Username) {
+			continue
+		}
+		if filter.Email != "" && !strings.EqualFold(user.Email, filter.Email) {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, user.Status) {
+			continue
+		}
+		u := *user
+		matched = append(matched, &u)
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+	total := int64(len(matched))
+	if filter.Offset > 0 {
+		matched = matched[min(filter.Offset, len(matched)):]
+	}
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+	return matched, total, nil
+}
+
+// SoftDelete 内存实现直接移除
+func (r *InMemoryUserRepo) SoftDelete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, u := range r.users {
+		if u.ID == id {
+			delete(r.users, key)
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
@@ -0,0 +1,76 @@
+package inmemory
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+// InMemoryWebAuthnCredentialRepo WebAuthn 凭据内存实现，用于本地开发与测试
+type InMemoryWebAuthnCredentialRepo struct {
+	creds  map[int64]*domain.WebAuthnCredential
+	nextID int64
+	mu     sync.Mutex
+}
+
+func NewInMemoryWebAuthnCredentialRepo() *InMemoryWebAuthnCredentialRepo {
+	return &InMemoryWebAuthnCredentialRepo{creds: make(map[int64]*domain.WebAuthnCredential)}
+}
+
+func (r *InMemoryWebAuthnCredentialRepo) Create(ctx context.Context, cred *domain.WebAuthnCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.creds {
+		if bytes.Equal(c.CredentialID, cred.CredentialID) {
+			return domain.ErrWebAuthnCredentialExists
+		}
+	}
+	r.nextID++
+	cred.ID = r.nextID
+	cred.CreatedAt = time.Now()
+	c := *cred
+	r.creds[c.ID] = &c
+	return nil
+}
+
+func (r *InMemoryWebAuthnCredentialRepo) ListByUser(ctx context.Context, userID int64) ([]*domain.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.WebAuthnCredential
+	for id := int64(1); id <= r.nextID; id++ {
+		if c, ok := r.creds[id]; ok && c.UserID == userID {
+			cp := *c
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (r *InMemoryWebAuthnCredentialRepo) UpdateAfterLogin(ctx context.Context, credentialID []byte, signCount uint32, backupState bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.creds {
+		if bytes.Equal(c.CredentialID, credentialID) {
+			now := time.Now()
+			c.SignCount = signCount
+			c.BackupState = backupState
+			c.LastUsedAt = &now
+			return nil
+		}
+	}
+	return domain.ErrWebAuthnCredentialNotFound
+}
+
+func (r *InMemoryWebAuthnCredentialRepo) Delete(ctx context.Context, userID int64, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.creds[id]
+	if !ok || c.UserID != userID {
+		return domain.ErrWebAuthnCredentialNotFound
+	}
+	delete(r.creds, id)
+	return nil
+}
@@ -0,0 +1,150 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"monai-auth/internal/domain"
+)
+
+// InMemoryWebhookDeliveryRepo Webhook 投递记录内存实现，用于本地开发与测试
+type InMemoryWebhookDeliveryRepo struct {
+	deliveries []*domain.WebhookDelivery // 按 ID 升序
+	nextID     int64
+	mu         sync.Mutex
+}
+
+func NewInMemoryWebhookDeliveryRepo() *InMemoryWebhookDeliveryRepo {
+	return &InMemoryWebhookDeliveryRepo{nextID: 1}
+}
+
+func (r *InMemoryWebhookDeliveryRepo) Create(ctx context.Context, deliveries []*domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, d := range deliveries {
+		if d.CreatedAt.IsZero() {
+			d.CreatedAt = now
+		}
+		if d.NextAttemptAt.IsZero() {
+			d.NextAttemptAt = d.CreatedAt
+		}
+		d.UpdatedAt = d.CreatedAt
+		d.ID = r.nextID
+		r.nextID++
+		r.deliveries = append(r.deliveries, copyWebhookDelivery(d))
+	}
+	return nil
+}
+
+func (r *InMemoryWebhookDeliveryRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.WebhookDelivery
+	for _, d := range r.deliveries {
+		if d.Status != domain.WebhookDeliveryPending || d.NextAttemptAt.After(now) {
+			continue
+		}
+		out = append(out, copyWebhookDelivery(d))
+		d.NextAttemptAt = now.Add(lease)
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (r *InMemoryWebhookDeliveryRepo) UpdateAttempt(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.find(delivery.ID)
+	if d == nil {
+		return nil
+	}
+	d.Status = delivery.Status
+	d.Attempts = delivery.Attempts
+	d.NextAttemptAt = delivery.NextAttemptAt
+	d.LastStatusCode = delivery.LastStatusCode
+	d.LastError = delivery.LastError
+	d.DeliveredAt = delivery.DeliveredAt
+	d.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *InMemoryWebhookDeliveryRepo) FindByID(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.find(id)
+	if d == nil {
+		return nil, domain.ErrWebhookDeliveryNotFound
+	}
+	return copyWebhookDelivery(d), nil
+}
+
+func (r *InMemoryWebhookDeliveryRepo) List(ctx context.Context, f domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.WebhookDelivery
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		d := r.deliveries[i]
+		if (f.Subscriber != "" && d.Subscriber != f.Subscriber) ||
+			(f.EventType != "" && d.EventType != f.EventType) ||
+			(f.Status != "" && d.Status != f.Status) ||
+			(f.UserID > 0 && d.UserID != f.UserID) ||
+			(f.BeforeID > 0 && d.ID >= f.BeforeID) {
+			continue
+		}
+		out = append(out, copyWebhookDelivery(d))
+		if f.Limit > 0 && len(out) >= f.Limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (r *InMemoryWebhookDeliveryRepo) Requeue(ctx context.Context, id int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.find(id)
+	if d == nil {
+		return domain.ErrWebhookDeliveryNotFound
+	}
+	d.Status = domain.WebhookDeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = at
+	d.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *InMemoryWebhookDeliveryRepo) DeleteBefore(ctx context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.deliveries[:0]
+	for _, d := range r.deliveries {
+		if d.Status == domain.WebhookDeliveryPending || !d.CreatedAt.Before(before) {
+			kept = append(kept, d)
+		}
+	}
+	r.deliveries = kept
+	return nil
+}
+
+func (r *InMemoryWebhookDeliveryRepo) find(id int64) *domain.WebhookDelivery {
+	for _, d := range r.deliveries {
+		if d.ID == id {
+			return d
+		}
+	}
+	return nil
+}
+
+func copyWebhookDelivery(d *domain.WebhookDelivery) *domain.WebhookDelivery {
+	c := *d
+	c.Payload = append([]byte(nil), d.Payload...)
+	if d.DeliveredAt != nil {
+		t := *d.DeliveredAt
+		c.DeliveredAt = &t
+	}
+	return &c
+}
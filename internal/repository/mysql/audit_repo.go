@@ -0,0 +1,107 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monai-auth/internal/domain"
+)
+
+// GORMAuditEventRepository 实现 domain.AuditEventRepository
+type GORMAuditEventRepository struct {
+	DB *gorm.DB
+}
+
+// NewGORMAuditEventRepository 创建审计事件仓库实例
+func NewGORMAuditEventRepository(db *gorm.DB) *GORMAuditEventRepository {
+	return &GORMAuditEventRepository{DB: db}
+}
+
+// Create 写入一条审计事件
+func (r *GORMAuditEventRepository) Create(ctx context.Context, event *domain.AuditEvent) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	m := AuditEventGORM{
+		EventType: event.Type,
+		Outcome:   event.Outcome,
+		UserID:    event.UserID,
+		ActorID:   event.ActorID,
+		Email:     event.Email,
+		IP:        event.IP,
+		UserAgent: event.UserAgent,
+		ClientID:  event.ClientID,
+		Reason:    event.Reason,
+		CreatedAt: event.CreatedAt,
+	}
+	if err := conn(ctx, r.DB).Create(&m).Error; err != nil {
+		return fmt.Errorf("create audit_event: %w", err)
+	}
+	event.ID = m.ID
+	return nil
+}
+
+// List 按条件查询审计事件，按 ID 倒序
+func (r *GORMAuditEventRepository) List(ctx context.Context, filter domain.AuditEventFilter) ([]*domain.AuditEvent, error) {
+	q := conn(ctx, r.DB).Model(&AuditEventGORM{})
+	if filter.UserID > 0 {
+		q = q.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Type != "" {
+		q = q.Where("event_type = ?", filter.Type)
+	}
+	if filter.Outcome != "" {
+		q = q.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.IP != "" {
+		q = q.Where("ip = ?", filter.IP)
+	}
+	if filter.ClientID != "" {
+		q = q.Where("client_id = ?", filter.ClientID)
+	}
+	if !filter.Since.IsZero() {
+		q = q.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		q = q.Where("created_at < ?", filter.Until)
+	}
+	if filter.BeforeID > 0 {
+		q = q.Where("id < ?", filter.BeforeID)
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	var rows []AuditEventGORM
+	if err := q.Order("id DESC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list audit_events: %w", err)
+	}
+	events := make([]*domain.AuditEvent, 0, len(rows))
+	for i := range rows {
+		m := &rows[i]
+		events = append(events, &domain.AuditEvent{
+			ID:        m.ID,
+			Type:      m.EventType,
+			Outcome:   m.Outcome,
+			UserID:    m.UserID,
+			ActorID:   m.ActorID,
+			Email:     m.Email,
+			IP:        m.IP,
+			UserAgent: m.UserAgent,
+			ClientID:  m.ClientID,
+			Reason:    m.Reason,
+			CreatedAt: m.CreatedAt,
+		})
+	}
+	return events, nil
+}
+
+// DeleteBefore 删除早于 before 的事件
+func (r *GORMAuditEventRepository) DeleteBefore(ctx context.Context, before time.Time) error {
+	if err := conn(ctx, r.DB).Where("created_at < ?", before).Delete(&AuditEventGORM{}).Error; err != nil {
+		return fmt.Errorf("delete audit_events: %w", err)
+	}
+	return nil
+}
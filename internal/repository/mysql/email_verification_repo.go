@@ -0,0 +1,90 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"monai-auth/internal/domain"
+)
+
+// GORMEmailVerificationTokenRepository 实现 domain.EmailVerificationTokenRepository
+type GORMEmailVerificationTokenRepository struct {
+	DB *gorm.DB
+}
+
+// NewGORMEmailVerificationTokenRepository 创建 email_verification_tokens 仓库实例
+func NewGORMEmailVerificationTokenRepository(db *gorm.DB) *GORMEmailVerificationTokenRepository {
+	return &GORMEmailVerificationTokenRepository{DB: db}
+}
+
+// Create 写入一条验证令牌记录
+func (r *GORMEmailVerificationTokenRepository) Create(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	m := EmailVerificationTokenGORM{
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}
+	if err := conn(ctx, r.DB).Create(&m).Error; err != nil {
+		return fmt.Errorf("create email_verification_token: %w", err)
+	}
+	return nil
+}
+
+// Consume 以条件更新的方式标记令牌已使用，并发请求中只有一个能成功
+func (r *GORMEmailVerificationTokenRepository) Consume(ctx context.Context, tokenHash string) (int64, error) {
+	now := time.Now()
+	result := conn(ctx, r.DB).
+		Model(&EmailVerificationTokenGORM{}).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return 0, fmt.Errorf("consume email_verification_token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, domain.ErrInvalidVerifyToken
+	}
+	var m EmailVerificationTokenGORM
+	if err := conn(ctx, r.DB).Where("token_hash = ?", tokenHash).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, domain.ErrInvalidVerifyToken
+		}
+		return 0, fmt.Errorf("find email_verification_token: %w", err)
+	}
+	return m.UserID, nil
+}
+
+// CreateIfIdle 锁定用户行后检查冷却期并写入令牌：同一用户的并发重发串行执行，冷却期内只有第一个请求能写入
+func (r *GORMEmailVerificationTokenRepository) CreateIfIdle(ctx context.Context, userID int64, tokenHash string, expiresAt, idleSince time.Time) (bool, error) {
+	created := false
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		var user UserGORM
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", userID).Take(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrUserNotFound
+			}
+			return fmt.Errorf("lock user: %w", err)
+		}
+		var recent int64
+		if err := tx.Model(&EmailVerificationTokenGORM{}).Where("user_id = ? AND created_at > ?", userID, idleSince).Count(&recent).Error; err != nil {
+			return fmt.Errorf("count recent email_verification_tokens: %w", err)
+		}
+		if recent > 0 {
+			return nil
+		}
+		m := EmailVerificationTokenGORM{UserID: userID, TokenHash: tokenHash, ExpiresAt: expiresAt}
+		if err := tx.Create(&m).Error; err != nil {
+			return fmt.Errorf("create email_verification_token: %w", err)
+		}
+		created = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return created, nil
+}
@@ -0,0 +1,287 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"monai-auth/internal/domain"
+)
+
+// GORMGroupRepository 实现 domain.GroupRepository
+type GORMGroupRepository struct {
+	DB *gorm.DB
+}
+
+// NewGORMGroupRepository 创建用户组仓库实例
+func NewGORMGroupRepository(db *gorm.DB) *GORMGroupRepository {
+	return &GORMGroupRepository{DB: db}
+}
+
+// Create 在同一事务中创建组与成员关系
+func (r *GORMGroupRepository) Create(ctx context.Context, group *domain.Group) error {
+	now := time.Now()
+	m := UserGroupGORM{
+		DisplayName: group.DisplayName,
+		ExternalID:  group.ExternalID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+		return insertGroupMembers(tx, m.ID, memberIDs(group.Members))
+	})
+	if err != nil {
+		if isDuplicateEntryError(err) {
+			return domain.ErrGroupExists
+		}
+		return fmt.Errorf("create user_groups: %w", err)
+	}
+	group.ID = m.ID
+	group.CreatedAt = m.CreatedAt
+	group.UpdatedAt = m.UpdatedAt
+	return nil
+}
+
+// FindByID 查找组及其成员
+func (r *GORMGroupRepository) FindByID(ctx context.Context, id int64) (*domain.Group, error) {
+	var m UserGroupGORM
+	if err := conn(ctx, r.DB).Where("id = ?", id).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrGroupNotFound
+		}
+		return nil, fmt.Errorf("find user_groups: %w", err)
+	}
+	groups := []*domain.Group{mapGroupGORMToDomain(&m)}
+	if err := r.loadMembers(ctx, groups); err != nil {
+		return nil, err
+	}
+	return groups[0], nil
+}
+
+// List 按条件分页查询组（含成员）
+func (r *GORMGroupRepository) List(ctx context.Context, filter domain.GroupFilter) ([]*domain.Group, int64, error) {
+	q := conn(ctx, r.DB).Model(&UserGroupGORM{})
+	if filter.ID > 0 {
+		q = q.Where("id = ?", filter.ID)
+	}
+	if filter.DisplayName != "" {
+		q = q.Where("display_name = ?", filter.DisplayName)
+	}
+	if filter.ExternalID != "" {
+		q = q.Where("external_id = ?", filter.ExternalID)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("count user_groups: %w", err)
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		q = q.Offset(filter.Offset)
+	}
+	var rows []UserGroupGORM
+	if err := q.Order("id").Find(&rows).Error; err != nil {
+		return nil, 0, fmt.Errorf("list user_groups: %w", err)
+	}
+	out := make([]*domain.Group, 0, len(rows))
+	for i := range rows {
+		out = append(out, mapGroupGORMToDomain(&rows[i]))
+	}
+	if err := r.loadMembers(ctx, out); err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+// ListByUser 列出用户所属的组（不含成员）
+func (r *GORMGroupRepository) ListByUser(ctx context.Context, userID int64) ([]*domain.Group, error) {
+	var rows []UserGroupGORM
+	err := conn(ctx, r.DB).
+		Joins("JOIN user_group_members ON user_group_members.group_id = user_groups.id").
+		Where("user_group_members.user_id = ?", userID).
+		Order("user_groups.id").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("list user_groups by user: %w", err)
+	}
+	out := make([]*domain.Group, 0, len(rows))
+	for i := range rows {
+		out = append(out, mapGroupGORMToDomain(&rows[i]))
+	}
+	return out, nil
+}
+
+// Update 修改显示名与外部标识
+func (r *GORMGroupRepository) Update(ctx context.Context, group *domain.Group) error {
+	now := time.Now()
+	result := conn(ctx, r.DB).
+		Model(&UserGroupGORM{}).
+		Where("id = ?", group.ID).
+		Updates(map[string]interface{}{
+			"display_name": group.DisplayName,
+			"external_id":  group.ExternalID,
+			"updated_at":   now,
+		})
+	if result.Error != nil {
+		if isDuplicateEntryError(result.Error) {
+			return domain.ErrGroupExists
+		}
+		return fmt.Errorf("update user_groups: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrGroupNotFound
+	}
+	group.UpdatedAt = now
+	return nil
+}
+
+// ReplaceMembers 在同一事务中删除旧成员并写入新成员
+func (r *GORMGroupRepository) ReplaceMembers(ctx context.Context, groupID int64, userIDs []int64) error {
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", groupID).Delete(&UserGroupMemberGORM{}).Error; err != nil {
+			return err
+		}
+		if err := insertGroupMembers(tx, groupID, userIDs); err != nil {
+			return err
+		}
+		return touchGroup(tx, groupID)
+	})
+	if err != nil {
+		return fmt.Errorf("replace user_group_members: %w", err)
+	}
+	return nil
+}
+
+// AddMembers 添加成员，已是成员的忽略
+func (r *GORMGroupRepository) AddMembers(ctx context.Context, groupID int64, userIDs []int64) error {
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := insertGroupMembers(tx, groupID, userIDs); err != nil {
+			return err
+		}
+		return touchGroup(tx, groupID)
+	})
+	if err != nil {
+		return fmt.Errorf("add user_group_members: %w", err)
+	}
+	return nil
+}
+
+// RemoveMembers 移除成员，不是成员的忽略
+func (r *GORMGroupRepository) RemoveMembers(ctx context.Context, groupID int64, userIDs []int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ? AND user_id IN ?", groupID, userIDs).Delete(&UserGroupMemberGORM{}).Error; err != nil {
+			return err
+		}
+		return touchGroup(tx, groupID)
+	})
+	if err != nil {
+		return fmt.Errorf("remove user_group_members: %w", err)
+	}
+	return nil
+}
+
+// RemoveUser 从所有组中移除用户
+func (r *GORMGroupRepository) RemoveUser(ctx context.Context, userID int64) error {
+	if err := conn(ctx, r.DB).Where("user_id = ?", userID).Delete(&UserGroupMemberGORM{}).Error; err != nil {
+		return fmt.Errorf("remove user from user_group_members: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除组及其成员关系
+func (r *GORMGroupRepository) Delete(ctx context.Context, id int64) error {
+	var affected int64
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&UserGroupMemberGORM{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", id).Delete(&UserGroupGORM{})
+		affected = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return fmt.Errorf("delete user_groups: %w", err)
+	}
+	if affected == 0 {
+		return domain.ErrGroupNotFound
+	}
+	return nil
+}
+
+// loadMembers 批量读取组成员及用户名，已软删除的用户不计入成员
+func (r *GORMGroupRepository) loadMembers(ctx context.Context, groups []*domain.Group) error {
+	if len(groups) == 0 {
+		return nil
+	}
+	byID := make(map[int64]*domain.Group, len(groups))
+	ids := make([]int64, 0, len(groups))
+	for _, g := range groups {
+		byID[g.ID] = g
+		ids = append(ids, g.ID)
+	}
+	var rows []struct {
+		GroupID  int64
+		UserID   int64
+		Username string
+	}
+	err := conn(ctx, r.DB).
+		Table("user_group_members").
+		Select("user_group_members.group_id, user_group_members.user_id, users.username").
+		Joins("JOIN users ON users.id = user_group_members.user_id AND users.deleted_at IS NULL").
+		Where("user_group_members.group_id IN ?", ids).
+		Order("user_group_members.group_id, user_group_members.user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("list user_group_members: %w", err)
+	}
+	for _, row := range rows {
+		g := byID[row.GroupID]
+		g.Members = append(g.Members, domain.GroupMember{UserID: row.UserID, Username: row.Username})
+	}
+	return nil
+}
+
+func insertGroupMembers(tx *gorm.DB, groupID int64, userIDs []int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	rows := make([]UserGroupMemberGORM, 0, len(userIDs))
+	for _, id := range userIDs {
+		rows = append(rows, UserGroupMemberGORM{GroupID: groupID, UserID: id, CreatedAt: now})
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+func touchGroup(tx *gorm.DB, groupID int64) error {
+	return tx.Model(&UserGroupGORM{}).Where("id = ?", groupID).Update("updated_at", time.Now()).Error
+}
+
+func memberIDs(members []domain.GroupMember) []int64 {
+	ids := make([]int64, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.UserID)
+	}
+	return ids
+}
+
+func mapGroupGORMToDomain(m *UserGroupGORM) *domain.Group {
+	return &domain.Group{
+		ID:          m.ID,
+		DisplayName: m.DisplayName,
+		ExternalID:  m.ExternalID,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
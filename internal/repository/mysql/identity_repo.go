@@ -0,0 +1,111 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monai-auth/internal/domain"
+)
+
+// GORMUserIdentityRepository 实现 domain.UserIdentityRepository
+type GORMUserIdentityRepository struct {
+	DB *gorm.DB
+}
+
+// NewGORMUserIdentityRepository 创建第三方身份绑定仓库实例
+func NewGORMUserIdentityRepository(db *gorm.DB) *GORMUserIdentityRepository {
+	return &GORMUserIdentityRepository{DB: db}
+}
+
+// Create 保存绑定关系
+func (r *GORMUserIdentityRepository) Create(ctx context.Context, identity *domain.UserIdentity) error {
+	m := UserIdentityGORM{
+		UserID:      identity.UserID,
+		Provider:    identity.Provider,
+		Subject:     identity.Subject,
+		Email:       identity.Email,
+		LastLoginAt: identity.LastLoginAt,
+		CreatedAt:   time.Now(),
+	}
+	if err := conn(ctx, r.DB).Create(&m).Error; err != nil {
+		if isDuplicateEntryError(err) {
+			return domain.ErrIdentityExists
+		}
+		return fmt.Errorf("create user_identities: %w", err)
+	}
+	identity.ID = m.ID
+	identity.CreatedAt = m.CreatedAt
+	return nil
+}
+
+// FindByProviderSubject 按提供方与其用户标识查找绑定
+func (r *GORMUserIdentityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*domain.UserIdentity, error) {
+	var m UserIdentityGORM
+	err := conn(ctx, r.DB).Where("provider = ? AND subject = ?", provider, subject).First(&m).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrIdentityNotFound
+		}
+		return nil, fmt.Errorf("find user_identities: %w", err)
+	}
+	return mapIdentityGORMToDomain(&m), nil
+}
+
+// ListByUser 按绑定时间列出用户全部绑定
+func (r *GORMUserIdentityRepository) ListByUser(ctx context.Context, userID int64) ([]*domain.UserIdentity, error) {
+	var rows []UserIdentityGORM
+	if err := conn(ctx, r.DB).Where("user_id = ?", userID).Order("id").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list user_identities: %w", err)
+	}
+	out := make([]*domain.UserIdentity, 0, len(rows))
+	for i := range rows {
+		out = append(out, mapIdentityGORMToDomain(&rows[i]))
+	}
+	return out, nil
+}
+
+// UpdateLogin 登录成功后更新邮箱与最近登录时间
+func (r *GORMUserIdentityRepository) UpdateLogin(ctx context.Context, id int64, email string, at time.Time) error {
+	result := conn(ctx, r.DB).
+		Model(&UserIdentityGORM{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"email":         email,
+			"last_login_at": at,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("update user_identities: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrIdentityNotFound
+	}
+	return nil
+}
+
+// Delete 解除用户在指定提供方的绑定
+func (r *GORMUserIdentityRepository) Delete(ctx context.Context, userID int64, provider string) error {
+	result := conn(ctx, r.DB).Where("user_id = ? AND provider = ?", userID, provider).Delete(&UserIdentityGORM{})
+	if result.Error != nil {
+		return fmt.Errorf("delete user_identities: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrIdentityNotFound
+	}
+	return nil
+}
+
+func mapIdentityGORMToDomain(m *UserIdentityGORM) *domain.UserIdentity {
+	return &domain.UserIdentity{
+		ID:          m.ID,
+		UserID:      m.UserID,
+		Provider:    m.Provider,
+		Subject:     m.Subject,
+		Email:       m.Email,
+		CreatedAt:   m.CreatedAt,
+		LastLoginAt: m.LastLoginAt,
+	}
+}
@@ -0,0 +1,70 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"monai-auth/internal/domain"
+)
+
+// GORMKnownDeviceRepository 实现 domain.KnownDeviceRepository
+type GORMKnownDeviceRepository struct {
+	DB *gorm.DB
+}
+
+// NewGORMKnownDeviceRepository 创建已知设备仓库实例
+func NewGORMKnownDeviceRepository(db *gorm.DB) *GORMKnownDeviceRepository {
+	return &GORMKnownDeviceRepository{DB: db}
+}
+
+// ListByUser 返回用户全部已知设备，按最近使用时间倒序
+func (r *GORMKnownDeviceRepository) ListByUser(ctx context.Context, userID int64) ([]*domain.KnownDevice, error) {
+	var rows []KnownDeviceGORM
+	if err := conn(ctx, r.DB).Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list known_devices: %w", err)
+	}
+	devices := make([]*domain.KnownDevice, 0, len(rows))
+	for i := range rows {
+		m := &rows[i]
+		devices = append(devices, &domain.KnownDevice{
+			ID:          m.ID,
+			UserID:      m.UserID,
+			Fingerprint: m.Fingerprint,
+			UserAgent:   m.UserAgent,
+			LastIP:      m.LastIP,
+			LastCountry: m.LastCountry,
+			FirstSeenAt: m.FirstSeenAt,
+			LastSeenAt:  m.LastSeenAt,
+		})
+	}
+	return devices, nil
+}
+
+// Save 按 (user_id, fingerprint) 新增或更新设备
+func (r *GORMKnownDeviceRepository) Save(ctx context.Context, device *domain.KnownDevice) error {
+	now := time.Now()
+	if device.LastSeenAt.IsZero() {
+		device.LastSeenAt = now
+	}
+	m := KnownDeviceGORM{
+		UserID:      device.UserID,
+		Fingerprint: device.Fingerprint,
+		UserAgent:   device.UserAgent,
+		LastIP:      device.LastIP,
+		LastCountry: device.LastCountry,
+		FirstSeenAt: device.LastSeenAt,
+		LastSeenAt:  device.LastSeenAt,
+	}
+	err := conn(ctx, r.DB).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "fingerprint"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_agent", "last_ip", "last_country", "last_seen_at"}),
+	}).Create(&m).Error
+	if err != nil {
+		return fmt.Errorf("save known_device: %w", err)
+	}
+	return nil
+}
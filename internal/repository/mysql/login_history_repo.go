@@ -0,0 +1,87 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monai-auth/internal/domain"
+)
+
+// GORMLoginHistoryRepository 实现 domain.LoginHistoryRepository
+type GORMLoginHistoryRepository struct {
+	DB *gorm.DB
+}
+
+// NewGORMLoginHistoryRepository 创建登录记录仓库实例
+func NewGORMLoginHistoryRepository(db *gorm.DB) *GORMLoginHistoryRepository {
+	return &GORMLoginHistoryRepository{DB: db}
+}
+
+// Create 写入一条登录记录
+func (r *GORMLoginHistoryRepository) Create(ctx context.Context, record *domain.LoginRecord) error {
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	m := LoginHistoryGORM{
+		UserID:    record.UserID,
+		IP:        record.IP,
+		UserAgent: record.UserAgent,
+		ClientID:  record.ClientID,
+		Method:    record.Method,
+		Country:   record.Country,
+		Latitude:  record.Latitude,
+		Longitude: record.Longitude,
+		CreatedAt: record.CreatedAt,
+	}
+	if err := conn(ctx, r.DB).Create(&m).Error; err != nil {
+		return fmt.Errorf("create login_history: %w", err)
+	}
+	record.ID = m.ID
+	return nil
+}
+
+// ListByUser 按 ID 倒序返回用户的登录记录
+func (r *GORMLoginHistoryRepository) ListByUser(ctx context.Context, userID int64, beforeID int64, limit int) ([]*domain.LoginRecord, error) {
+	q := conn(ctx, r.DB).Where("user_id = ?", userID)
+	if beforeID > 0 {
+		q = q.Where("id < ?", beforeID)
+	}
+	var rows []LoginHistoryGORM
+	if err := q.Order("id DESC").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list login_history: %w", err)
+	}
+	records := make([]*domain.LoginRecord, 0, len(rows))
+	for i := range rows {
+		m := &rows[i]
+		records = append(records, &domain.LoginRecord{
+			ID:        m.ID,
+			UserID:    m.UserID,
+			IP:        m.IP,
+			UserAgent: m.UserAgent,
+			ClientID:  m.ClientID,
+			Method:    m.Method,
+			Country:   m.Country,
+			Latitude:  m.Latitude,
+			Longitude: m.Longitude,
+			CreatedAt: m.CreatedAt,
+		})
+	}
+	return records, nil
+}
+
+// Countries 返回用户曾登录过的国家代码
+func (r *GORMLoginHistoryRepository) Countries(ctx context.Context, userID int64) ([]string, error) {
+	var countries []string
+	err := conn(ctx, r.DB).
+		Model(&LoginHistoryGORM{}).
+		Where("user_id = ? AND country <> ''", userID).
+		Distinct().
+		Pluck("country", &countries).Error
+	if err != nil {
+		return nil, fmt.Errorf("list login_history countries: %w", err)
+	}
+	return countries, nil
+}
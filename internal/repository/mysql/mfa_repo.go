@@ -0,0 +1,156 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"monai-auth/internal/domain"
+)
+
+// GORMMFARepository 实现 domain.MFARepository
+type GORMMFARepository struct {
+	DB *gorm.DB
+}
+
+// NewGORMMFARepository 创建 MFA 仓库实例
+func NewGORMMFARepository(db *gorm.DB) *GORMMFARepository {
+	return &GORMMFARepository{DB: db}
+}
+
+// FindTOTP 查找用户的 TOTP 凭据
+func (r *GORMMFARepository) FindTOTP(ctx context.Context, userID int64) (*domain.TOTPCredential, error) {
+	var m UserTOTPGORM
+	if err := conn(ctx, r.DB).Where("user_id = ?", userID).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrMFANotEnrolled
+		}
+		return nil, fmt.Errorf("find user_mfa_totp: %w", err)
+	}
+	return &domain.TOTPCredential{
+		UserID:       m.UserID,
+		SecretEnc:    m.SecretEnc,
+		Enabled:      m.Enabled,
+		LastUsedStep: m.LastUsedStep,
+		ConfirmedAt:  m.ConfirmedAt,
+		CreatedAt:    m.CreatedAt,
+	}, nil
+}
+
+// SaveTOTP 写入或覆盖用户的 TOTP 凭据（重新绑定时重置为未启用）
+func (r *GORMMFARepository) SaveTOTP(ctx context.Context, cred *domain.TOTPCredential) error {
+	now := time.Now()
+	m := UserTOTPGORM{
+		UserID:    cred.UserID,
+		SecretEnc: cred.SecretEnc,
+		Enabled:   cred.Enabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err := conn(ctx, r.DB).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret_enc", "enabled", "last_used_step", "confirmed_at", "updated_at"}),
+	}).Create(&m).Error
+	if err != nil {
+		return fmt.Errorf("save user_mfa_totp: %w", err)
+	}
+	return nil
+}
+
+// EnableTOTP 标记 TOTP 已启用，并记录确认时使用的时间步
+func (r *GORMMFARepository) EnableTOTP(ctx context.Context, userID int64, step int64) error {
+	now := time.Now()
+	result := conn(ctx, r.DB).
+		Model(&UserTOTPGORM{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"enabled":        true,
+			"last_used_step": step,
+			"confirmed_at":   now,
+			"updated_at":     now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("enable user_mfa_totp: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrMFANotEnrolled
+	}
+	return nil
+}
+
+// UpdateTOTPLastUsedStep 条件更新，保证同一时间步的验证码只能通过一次
+func (r *GORMMFARepository) UpdateTOTPLastUsedStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	result := conn(ctx, r.DB).
+		Model(&UserTOTPGORM{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Updates(map[string]interface{}{"last_used_step": step, "updated_at": time.Now()})
+	if result.Error != nil {
+		return false, fmt.Errorf("update user_mfa_totp step: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteTOTP 删除 TOTP 凭据及全部恢复码
+func (r *GORMMFARepository) DeleteTOTP(ctx context.Context, userID int64) error {
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCodeGORM{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&UserTOTPGORM{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("delete user mfa: %w", err)
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes 在事务中删除旧恢复码并写入新恢复码
+func (r *GORMMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCodeGORM{}).Error; err != nil {
+			return err
+		}
+		if len(codeHashes) == 0 {
+			return nil
+		}
+		rows := make([]RecoveryCodeGORM, 0, len(codeHashes))
+		for _, h := range codeHashes {
+			rows = append(rows, RecoveryCodeGORM{UserID: userID, CodeHash: h})
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return fmt.Errorf("replace recovery codes: %w", err)
+	}
+	return nil
+}
+
+// ConsumeRecoveryCode 以条件更新的方式标记恢复码已使用
+func (r *GORMMFARepository) ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	result := conn(ctx, r.DB).
+		Model(&RecoveryCodeGORM{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Limit(1).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("consume recovery code: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountRecoveryCodes 统计剩余可用恢复码
+func (r *GORMMFARepository) CountRecoveryCodes(ctx context.Context, userID int64) (int, error) {
+	var count int64
+	err := conn(ctx, r.DB).
+		Model(&RecoveryCodeGORM{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("count recovery codes: %w", err)
+	}
+	return int(count), nil
+}
@@ -19,10 +19,17 @@ type UserGORM struct {
 	Email        string  `gorm:"unique;type:varchar(255);not null"`
 	PasswordHash string  `gorm:"type:varchar(255);not null"`
 	Status       string  `gorm:"type:enum('active', 'inactive', 'suspended', 'pending');default:'active'"`
-	LastLoginAt  time.Time
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	DeletedAt    gorm.DeletedAt `gorm:"column:deleted_at;index"` // 映射到您的 deleted_at 字段，启用 GORM 软删除
+	TokenVersion int64   `gorm:"not null;default:0"`
+	Role         string  `gorm:"type:varchar(32);not null;default:'standard'"`
+	// FailedLoginCount / LockedUntil 连续登录失败次数与临时锁定截止时间
+	FailedLoginCount int `gorm:"not null;default:0"`
+	LockedUntil      *time.Time
+	LastLoginAt      *time.Time
+	// PhoneVerifiedAt 手机号验证时间，NULL 表示未验证
+	PhoneVerifiedAt *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"column:deleted_at;index"` // 映射到您的 deleted_at 字段，启用 GORM 软删除
 }
 
 // TableName 指定 GORM 使用的表名
@@ -42,3 +49,207 @@ type UserAssetGORM struct {
 }
 
 func (UserAssetGORM) TableName() string { return "user_assets" }
+
+// PasswordResetTokenGORM 对应 password_reset_tokens 表（仅存令牌 SHA-256）
+type PasswordResetTokenGORM struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement"`
+	UserID    int64     `gorm:"not null;index"`
+	TokenHash string    `gorm:"type:char(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (PasswordResetTokenGORM) TableName() string { return "password_reset_tokens" }
+
+// EmailVerificationTokenGORM 对应 email_verification_tokens 表（仅存令牌 SHA-256）
+type EmailVerificationTokenGORM struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement"`
+	UserID    int64     `gorm:"not null;index"`
+	TokenHash string    `gorm:"type:char(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (EmailVerificationTokenGORM) TableName() string { return "email_verification_tokens" }
+
+// UserTOTPGORM 对应 user_mfa_totp 表（每个用户至多一条）
+type UserTOTPGORM struct {
+	UserID       int64  `gorm:"primaryKey;autoIncrement:false"`
+	SecretEnc    string `gorm:"type:varchar(255);not null"`
+	Enabled      bool   `gorm:"not null;default:false"`
+	LastUsedStep int64  `gorm:"not null;default:0"`
+	ConfirmedAt  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (UserTOTPGORM) TableName() string { return "user_mfa_totp" }
+
+// RecoveryCodeGORM 对应 user_mfa_recovery_codes 表（仅存恢复码 SHA-256）
+type RecoveryCodeGORM struct {
+	ID        int64  `gorm:"primaryKey;autoIncrement"`
+	UserID    int64  `gorm:"not null;index"`
+	CodeHash  string `gorm:"type:char(64);not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (RecoveryCodeGORM) TableName() string { return "user_mfa_recovery_codes" }
+
+// WebAuthnCredentialGORM 对应 webauthn_credentials 表
+type WebAuthnCredentialGORM struct {
+	ID              int64  `gorm:"primaryKey;autoIncrement"`
+	UserID          int64  `gorm:"not null;index"`
+	CredentialID    []byte `gorm:"type:varbinary(1023);not null;uniqueIndex"`
+	PublicKey       []byte `gorm:"type:blob;not null"`
+	AttestationType string `gorm:"type:varchar(32);not null;default:''"`
+	AAGUID          []byte `gorm:"column:aaguid;type:varbinary(16)"`
+	SignCount       uint32 `gorm:"not null;default:0"`
+	// Transports 逗号分隔，如 "internal,hybrid"
+	Transports     string `gorm:"type:varchar(255);not null;default:''"`
+	BackupEligible bool   `gorm:"not null;default:false"`
+	BackupState    bool   `gorm:"not null;default:false"`
+	Name           string `gorm:"type:varchar(100);not null;default:''"`
+	LastUsedAt     *time.Time
+	CreatedAt      time.Time
+}
+
+func (WebAuthnCredentialGORM) TableName() string { return "webauthn_credentials" }
+
+// UserIdentityGORM 对应 user_identities 表
+type UserIdentityGORM struct {
+	ID          int64  `gorm:"primaryKey;autoIncrement"`
+	UserID      int64  `gorm:"not null;uniqueIndex:uk_user_identities_user_provider"`
+	Provider    string `gorm:"type:varchar(64);not null;uniqueIndex:uk_user_identities_provider_subject;uniqueIndex:uk_user_identities_user_provider"`
+	Subject     string `gorm:"type:varchar(255);not null;uniqueIndex:uk_user_identities_provider_subject"`
+	Email       string `gorm:"type:varchar(255);not null;default:''"`
+	LastLoginAt *time.Time
+	CreatedAt   time.Time
+}
+
+func (UserIdentityGORM) TableName() string { return "user_identities" }
+
+// UserGroupGORM 对应 user_groups 表
+type UserGroupGORM struct {
+	ID          int64  `gorm:"primaryKey;autoIncrement"`
+	DisplayName string `gorm:"type:varchar(255);not null;uniqueIndex:uk_user_groups_display_name"`
+	ExternalID  string `gorm:"type:varchar(255);not null;default:'';index:idx_user_groups_external_id"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (UserGroupGORM) TableName() string { return "user_groups" }
+
+// UserGroupMemberGORM 对应 user_group_members 表
+type UserGroupMemberGORM struct {
+	GroupID   int64 `gorm:"primaryKey"`
+	UserID    int64 `gorm:"primaryKey;index:idx_user_group_members_user"`
+	CreatedAt time.Time
+}
+
+func (UserGroupMemberGORM) TableName() string { return "user_group_members" }
+
+// RateLimitCounterGORM 对应 rate_limit_counters 表（固定窗口计数）
+type RateLimitCounterGORM struct {
+	BucketKey string    `gorm:"primaryKey;type:varchar(191)"`
+	Hits      int64     `gorm:"not null;default:0"`
+	WindowEnd time.Time `gorm:"not null;index"`
+}
+
+func (RateLimitCounterGORM) TableName() string { return "rate_limit_counters" }
+
+// PasswordHistoryGORM 对应 password_history 表（用户历史密码哈希）
+type PasswordHistoryGORM struct {
+	ID           int64  `gorm:"primaryKey;autoIncrement"`
+	UserID       int64  `gorm:"not null;index"`
+	PasswordHash string `gorm:"type:varchar(255);not null"`
+	CreatedAt    time.Time
+}
+
+func (PasswordHistoryGORM) TableName() string { return "password_history" }
+
+// LoginHistoryGORM 对应 login_history 表（登录成功记录）
+type LoginHistoryGORM struct {
+	ID        int64  `gorm:"primaryKey;autoIncrement"`
+	UserID    int64  `gorm:"not null;index"`
+	IP        string `gorm:"column:ip;type:varchar(64);not null;default:''"`
+	UserAgent string `gorm:"type:varchar(512);not null;default:''"`
+	ClientID  string `gorm:"type:varchar(128);not null;default:''"`
+	Method    string `gorm:"type:varchar(64);not null;default:''"`
+	Country   string `gorm:"type:varchar(8);not null;default:''"`
+	Latitude  *float64
+	Longitude *float64
+	CreatedAt time.Time
+}
+
+// KnownDeviceGORM 对应 known_devices 表（用户已知设备）
+type KnownDeviceGORM struct {
+	ID          int64  `gorm:"primaryKey;autoIncrement"`
+	UserID      int64  `gorm:"not null;uniqueIndex:uk_known_devices_user_fp"`
+	Fingerprint string `gorm:"type:char(64);not null;uniqueIndex:uk_known_devices_user_fp"`
+	UserAgent   string `gorm:"type:varchar(512);not null;default:''"`
+	LastIP      string `gorm:"column:last_ip;type:varchar(64);not null;default:''"`
+	LastCountry string `gorm:"type:varchar(8);not null;default:''"`
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+func (KnownDeviceGORM) TableName() string { return "known_devices" }
+
+func (LoginHistoryGORM) TableName() string { return "login_history" }
+
+// AuditEventGORM 对应 audit_events 表（安全审计事件）
+type AuditEventGORM struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement"`
+	EventType string    `gorm:"type:varchar(64);not null;index"`
+	Outcome   string    `gorm:"type:varchar(16);not null"`
+	UserID    int64     `gorm:"not null;default:0;index"`
+	ActorID   int64     `gorm:"not null;default:0"`
+	Email     string    `gorm:"type:varchar(255);not null;default:''"`
+	IP        string    `gorm:"column:ip;type:varchar(64);not null;default:''"`
+	UserAgent string    `gorm:"type:varchar(512);not null;default:''"`
+	ClientID  string    `gorm:"type:varchar(128);not null;default:''"`
+	Reason    string    `gorm:"type:varchar(255);not null;default:''"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (AuditEventGORM) TableName() string { return "audit_events" }
+
+// WebhookDeliveryGORM 对应 webhook_deliveries 表（Webhook 发件箱与投递日志）
+type WebhookDeliveryGORM struct {
+	ID             int64      `gorm:"primaryKey;autoIncrement"`
+	Subscriber     string     `gorm:"type:varchar(64);not null;index"`
+	EventID        string     `gorm:"type:varchar(64);not null;index"`
+	EventType      string     `gorm:"type:varchar(64);not null"`
+	UserID         int64      `gorm:"not null;default:0;index"`
+	Payload        string     `gorm:"type:mediumtext;not null"`
+	Status         string     `gorm:"type:varchar(16);not null;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int        `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time  `gorm:"not null;index:idx_webhook_deliveries_due,priority:2"`
+	LastStatusCode int        `gorm:"not null;default:0"`
+	LastError      string     `gorm:"type:varchar(512);not null;default:''"`
+	DeliveredAt    *time.Time `gorm:"default:null"`
+	CreatedAt      time.Time  `gorm:"index"`
+	UpdatedAt      time.Time
+}
+
+func (WebhookDeliveryGORM) TableName() string { return "webhook_deliveries" }
+
+// OutboxEventGORM 对应 outbox_events 表（领域事件发件箱）
+type OutboxEventGORM struct {
+	ID            int64      `gorm:"primaryKey;autoIncrement"`
+	EventID       string     `gorm:"type:varchar(64);not null;uniqueIndex"`
+	EventType     string     `gorm:"type:varchar(64);not null"`
+	UserID        int64      `gorm:"not null;default:0;index"`
+	Payload       string     `gorm:"type:mediumtext;not null"`
+	Attempts      int        `gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_events_due,priority:3"`
+	LastError     string     `gorm:"type:varchar(512);not null;default:''"`
+	PublishedAt   *time.Time `gorm:"default:null;index:idx_outbox_events_due,priority:1"`
+	FailedAt      *time.Time `gorm:"default:null;index:idx_outbox_events_due,priority:2"`
+	CreatedAt     time.Time  `gorm:"index"`
+}
+
+func (OutboxEventGORM) TableName() string { return "outbox_events" }
@@ -0,0 +1,141 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"monai-auth/internal/domain"
+)
+
+// GORMOutboxRepository 实现 domain.OutboxRepository
+type GORMOutboxRepository struct {
+	DB *gorm.DB
+}
+
+// NewGORMOutboxRepository 创建发件箱仓库实例
+func NewGORMOutboxRepository(db *gorm.DB) *GORMOutboxRepository {
+	return &GORMOutboxRepository{DB: db}
+}
+
+// Create 批量写入事件；ctx 带有事务（见 GORMTransactor）时随事务提交
+func (r *GORMOutboxRepository) Create(ctx context.Context, records []*domain.OutboxRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	now := time.Now()
+	rows := make([]OutboxEventGORM, len(records))
+	for i, rec := range records {
+		if rec.CreatedAt.IsZero() {
+			rec.CreatedAt = now
+		}
+		if rec.NextAttemptAt.IsZero() {
+			rec.NextAttemptAt = rec.CreatedAt
+		}
+		rows[i] = OutboxEventGORM{
+			EventID:       rec.EventID,
+			EventType:     rec.EventType,
+			UserID:        rec.UserID,
+			Payload:       string(rec.Payload),
+			NextAttemptAt: rec.NextAttemptAt,
+			CreatedAt:     rec.CreatedAt,
+		}
+	}
+	if err := conn(ctx, r.DB).Create(&rows).Error; err != nil {
+		return fmt.Errorf("create outbox_events: %w", err)
+	}
+	for i := range rows {
+		records[i].ID = rows[i].ID
+	}
+	return nil
+}
+
+// ClaimDue 以 SELECT ... FOR UPDATE SKIP LOCKED 领取到期事件并推迟其 next_attempt_at，多实例间互不阻塞
+func (r *GORMOutboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.OutboxRecord, error) {
+	var rows []OutboxEventGORM
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?", now).
+			Order("id").Limit(limit).Find(&rows).Error
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+		ids := make([]int64, len(rows))
+		for i := range rows {
+			ids[i] = rows[i].ID
+		}
+		return tx.Model(&OutboxEventGORM{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox_events: %w", err)
+	}
+	records := make([]*domain.OutboxRecord, 0, len(rows))
+	for i := range rows {
+		m := &rows[i]
+		records = append(records, &domain.OutboxRecord{
+			ID:            m.ID,
+			EventID:       m.EventID,
+			EventType:     m.EventType,
+			UserID:        m.UserID,
+			Payload:       []byte(m.Payload),
+			Attempts:      m.Attempts,
+			NextAttemptAt: m.NextAttemptAt,
+			LastError:     m.LastError,
+			PublishedAt:   m.PublishedAt,
+			FailedAt:      m.FailedAt,
+			CreatedAt:     m.CreatedAt,
+		})
+	}
+	return records, nil
+}
+
+// MarkPublished 标记事件已投递
+func (r *GORMOutboxRepository) MarkPublished(ctx context.Context, id int64, at time.Time) error {
+	err := conn(ctx, r.DB).Model(&OutboxEventGORM{}).Where("id = ?", id).Updates(map[string]any{
+		"published_at": at,
+		"last_error":   "",
+	}).Error
+	if err != nil {
+		return fmt.Errorf("mark outbox_event published: %w", err)
+	}
+	return nil
+}
+
+// Reschedule 记录投递失败并设置下次投递时间
+func (r *GORMOutboxRepository) Reschedule(ctx context.Context, id int64, attempts int, next time.Time, lastError string) error {
+	err := conn(ctx, r.DB).Model(&OutboxEventGORM{}).Where("id = ?", id).Updates(map[string]any{
+		"attempts":        attempts,
+		"next_attempt_at": next,
+		"last_error":      lastError,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("reschedule outbox_event: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed 记录最后一次投递失败并标记事件失败
+func (r *GORMOutboxRepository) MarkFailed(ctx context.Context, id int64, attempts int, at time.Time, lastError string) error {
+	err := conn(ctx, r.DB).Model(&OutboxEventGORM{}).Where("id = ?", id).Updates(map[string]any{
+		"attempts":   attempts,
+		"failed_at":  at,
+		"last_error": lastError,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("mark outbox_event failed: %w", err)
+	}
+	return nil
+}
+
+// DeletePublishedBefore 删除早于 before 且已投递的事件
+func (r *GORMOutboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) error {
+	err := conn(ctx, r.DB).Where("published_at IS NOT NULL AND created_at < ?", before).Delete(&OutboxEventGORM{}).Error
+	if err != nil {
+		return fmt.Errorf("delete outbox_events: %w", err)
+	}
+	return nil
+}
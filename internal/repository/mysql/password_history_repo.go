@@ -0,0 +1,57 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GORMPasswordHistoryRepository 实现 domain.PasswordHistoryRepository
+type GORMPasswordHistoryRepository struct {
+	DB *gorm.DB
+}
+
+// NewGORMPasswordHistoryRepository 创建历史密码仓库实例
+func NewGORMPasswordHistoryRepository(db *gorm.DB) *GORMPasswordHistoryRepository {
+	return &GORMPasswordHistoryRepository{DB: db}
+}
+
+// Add 写入新哈希并删除超出 keep 条的旧记录
+func (r *GORMPasswordHistoryRepository) Add(ctx context.Context, userID int64, passwordHash string, keep int) error {
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		m := PasswordHistoryGORM{UserID: userID, PasswordHash: passwordHash, CreatedAt: time.Now()}
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+		var keepIDs []int64
+		if err := tx.Model(&PasswordHistoryGORM{}).
+			Where("user_id = ?", userID).
+			Order("id DESC").
+			Limit(keep).
+			Pluck("id", &keepIDs).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND id NOT IN ?", userID, keepIDs).Delete(&PasswordHistoryGORM{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("add password_history: %w", err)
+	}
+	return nil
+}
+
+// ListRecent 按时间倒序返回最近 n 条密码哈希
+func (r *GORMPasswordHistoryRepository) ListRecent(ctx context.Context, userID int64, n int) ([]string, error) {
+	var hashes []string
+	err := conn(ctx, r.DB).
+		Model(&PasswordHistoryGORM{}).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(n).
+		Pluck("password_hash", &hashes).Error
+	if err != nil {
+		return nil, fmt.Errorf("list password_history: %w", err)
+	}
+	return hashes, nil
+}
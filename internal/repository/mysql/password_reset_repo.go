@@ -0,0 +1,84 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monai-auth/internal/domain"
+)
+
+// GORMPasswordResetTokenRepository 实现 domain.PasswordResetTokenRepository
+type GORMPasswordResetTokenRepository struct {
+	DB *gorm.DB
+}
+
+// NewGORMPasswordResetTokenRepository 创建 password_reset_tokens 仓库实例
+func NewGORMPasswordResetTokenRepository(db *gorm.DB) *GORMPasswordResetTokenRepository {
+	return &GORMPasswordResetTokenRepository{DB: db}
+}
+
+// Create 写入一条重置令牌记录
+func (r *GORMPasswordResetTokenRepository) Create(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	m := PasswordResetTokenGORM{
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}
+	if err := conn(ctx, r.DB).Create(&m).Error; err != nil {
+		return fmt.Errorf("create password_reset_token: %w", err)
+	}
+	return nil
+}
+
+// Lookup 查询未使用且未过期的令牌所属用户，不消费令牌
+func (r *GORMPasswordResetTokenRepository) Lookup(ctx context.Context, tokenHash string) (int64, error) {
+	var m PasswordResetTokenGORM
+	err := conn(ctx, r.DB).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, time.Now()).
+		First(&m).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, domain.ErrInvalidResetToken
+		}
+		return 0, fmt.Errorf("find password_reset_token: %w", err)
+	}
+	return m.UserID, nil
+}
+
+// Consume 以条件更新的方式标记令牌已使用，并发请求中只有一个能成功
+func (r *GORMPasswordResetTokenRepository) Consume(ctx context.Context, tokenHash string) (int64, error) {
+	now := time.Now()
+	result := conn(ctx, r.DB).
+		Model(&PasswordResetTokenGORM{}).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return 0, fmt.Errorf("consume password_reset_token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, domain.ErrInvalidResetToken
+	}
+	var m PasswordResetTokenGORM
+	if err := conn(ctx, r.DB).Where("token_hash = ?", tokenHash).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, domain.ErrInvalidResetToken
+		}
+		return 0, fmt.Errorf("find password_reset_token: %w", err)
+	}
+	return m.UserID, nil
+}
+
+// DeleteByUser 删除用户所有未使用的重置令牌
+func (r *GORMPasswordResetTokenRepository) DeleteByUser(ctx context.Context, userID int64) error {
+	err := conn(ctx, r.DB).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Delete(&PasswordResetTokenGORM{}).Error
+	if err != nil {
+		return fmt.Errorf("delete password_reset_tokens: %w", err)
+	}
+	return nil
+}
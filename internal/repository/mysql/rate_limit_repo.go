@@ -0,0 +1,51 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GORMRateLimitRepository 实现 domain.RateLimitRepository，多实例部署时共享限流计数
+type GORMRateLimitRepository struct {
+	DB *gorm.DB
+}
+
+// NewGORMRateLimitRepository 创建限流计数仓库实例
+func NewGORMRateLimitRepository(db *gorm.DB) *GORMRateLimitRepository {
+	return &GORMRateLimitRepository{DB: db}
+}
+
+// Hit 使用 INSERT ... ON DUPLICATE KEY UPDATE 原子计数；窗口已结束时重置为 1 并开启新窗口
+func (r *GORMRateLimitRepository) Hit(ctx context.Context, key string, window time.Duration) (int64, time.Time, error) {
+	now := time.Now()
+	windowEnd := now.Add(window)
+	var row RateLimitCounterGORM
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		// 注意 MySQL 按书写顺序赋值：先用旧 window_end 判断 hits，再更新 window_end
+		err := tx.Exec(
+			"INSERT INTO rate_limit_counters (bucket_key, hits, window_end) VALUES (?, 1, ?) "+
+				"ON DUPLICATE KEY UPDATE hits = IF(window_end <= ?, 1, hits + 1), "+
+				"window_end = IF(window_end <= ?, VALUES(window_end), window_end)",
+			key, windowEnd, now, now,
+		).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("bucket_key = ?", key).First(&row).Error
+	})
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("hit rate_limit_counters: %w", err)
+	}
+	return row.Hits, row.WindowEnd, nil
+}
+
+// DeleteExpired 删除窗口已结束的计数
+func (r *GORMRateLimitRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	if err := conn(ctx, r.DB).Where("window_end <= ?", now).Delete(&RateLimitCounterGORM{}).Error; err != nil {
+		return fmt.Errorf("delete expired rate_limit_counters: %w", err)
+	}
+	return nil
+}
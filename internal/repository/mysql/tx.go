@@ -0,0 +1,37 @@
+package mysql
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// GORMTransactor 实现 domain.Transactor：事务保存在 ctx 中，本包的仓库通过 conn 取得
+type GORMTransactor struct {
+	DB *gorm.DB
+}
+
+// NewGORMTransactor 创建事务管理器
+func NewGORMTransactor(db *gorm.DB) *GORMTransactor {
+	return &GORMTransactor{DB: db}
+}
+
+type txKey struct{}
+
+// WithinTx 在事务中执行 fn，fn 返回错误或 panic 时回滚
+func (t *GORMTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return t.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn 返回 ctx 中的事务，不在事务中时返回绑定 ctx 的 db
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db.WithContext(ctx)
+}
@@ -29,7 +29,7 @@ func (r *GORMUserAssetRepository) Create(ctx context.Context, userID int64, file
 		OriginalName: originalName,
 		Size:         size,
 	}
-	if err := r.DB.WithContext(ctx).Create(&m).Error; err != nil {
+	if err := conn(ctx, r.DB).Create(&m).Error; err != nil {
 		return fmt.Errorf("create user_asset: %w", err)
 	}
 	return nil
@@ -4,33 +4,75 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 
 	"monai-auth/internal/domain"
 )
 
-// GORMUserRepository 实现了 domain.UserRepository 接口
+// GORMUserRepository 实现了 domain.UserRepository 接口，MySQL 与 PostgreSQL 共用
 type GORMUserRepository struct {
 	DB *gorm.DB
+	// foldCase 为 true 时用户名、邮箱以 lower() 比较（PostgreSQL 没有 _ci 排序规则）
+	foldCase bool
 }
 
-// NewGORMUserRepository 创建一个新的 GORM 仓库实例
+// NewGORMUserRepository 创建一个新的 GORM 仓库实例，按 db 的方言决定用户名、邮箱的比较方式
 func NewGORMUserRepository(db *gorm.DB) *GORMUserRepository {
-	return &GORMUserRepository{DB: db}
+	return &GORMUserRepository{DB: db, foldCase: db.Dialector.Name() == "postgres"}
+}
+
+// equalFold 返回大小写不敏感的等值条件：MySQL 依赖列的 _ci 排序规则，
+// PostgreSQL 使用 lower()，命中 scripts/postgres/create_users.sql 中的表达式唯一索引
+func (r *GORMUserRepository) equalFold(column string) string {
+	if r.foldCase {
+		return "lower(" + column + ") = lower(?)"
+	}
+	return column + " = ?"
 }
 
 // mapGORMToDomain 将 GORM 模型转换为领域模型
 func mapGORMToDomain(gormUser *UserGORM) *domain.User {
+	phone := ""
+	if gormUser.PhoneNumber != nil {
+		phone = *gormUser.PhoneNumber
+	}
+	role := gormUser.Role
+	if role == "" {
+		role = domain.RoleStandard
+	}
 	return &domain.User{
 		ID:           gormUser.ID,
 		Username:     gormUser.Username,
 		Email:        gormUser.Email,
+		FirstName:    gormUser.FirstName,
+		LastName:     gormUser.LastName,
+		AvatarURL:    gormUser.AvatarURL,
+		PhoneNumber:  phone,
 		PasswordHash: gormUser.PasswordHash,
-		Role:         "standard",
+		Status:       gormUser.Status,
+		TokenVersion: gormUser.TokenVersion,
+		Role:         role,
 		CreatedAt:    gormUser.CreatedAt,
+		UpdatedAt:    gormUser.UpdatedAt,
+
+		FailedLoginCount: gormUser.FailedLoginCount,
+		LockedUntil:      gormUser.LockedUntil,
+		LastLoginAt:      gormUser.LastLoginAt,
+		PhoneVerifiedAt:  gormUser.PhoneVerifiedAt,
+	}
+}
+
+// nullableString 空字符串映射为 NULL（phone_number 有唯一约束，多个空值需存 NULL）
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
 	}
+	return &s
 }
 
 // FindByID 根据 ID 查找用户
@@ -38,7 +80,7 @@ func (r *GORMUserRepository) FindByID(ctx context.Context, id int64) (*domain.Us
 	var userGORM UserGORM
 
 	// GORM 的 First 方法会自动添加 WHERE deleted_at IS NULL
-	result := r.DB.WithContext(ctx).Where("id = ?", id).First(&userGORM)
+	result := conn(ctx, r.DB).Where("id = ?", id).First(&userGORM)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -55,7 +97,7 @@ func (r *GORMUserRepository) FindByEmail(ctx context.Context, email string) (*do
 	var userGORM UserGORM
 
 	// GORM 的 First 方法会自动添加 WHERE deleted_at IS NULL
-	result := r.DB.WithContext(ctx).Where("email = ?", email).First(&userGORM)
+	result := conn(ctx, r.DB).Where(r.equalFold("email"), email).First(&userGORM)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -67,12 +109,25 @@ func (r *GORMUserRepository) FindByEmail(ctx context.Context, email string) (*do
 	return mapGORMToDomain(&userGORM), nil
 }
 
+// FindByUsername 根据用户名查找用户，大小写不敏感
+func (r *GORMUserRepository) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
+	var userGORM UserGORM
+	result := conn(ctx, r.DB).Where(r.equalFold("username"), username).First(&userGORM)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("gorm find by username failed: %w", result.Error)
+	}
+	return mapGORMToDomain(&userGORM), nil
+}
+
 // ExistsByEmail 检查指定 email 是否已存在
 func (r *GORMUserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	var count int64
-	err := r.DB.WithContext(ctx).
+	err := conn(ctx, r.DB).
 		Model(&UserGORM{}).
-		Where("email = ?", email).
+		Where(r.equalFold("email"), email).
 		Count(&count).Error
 
 	if err != nil {
@@ -88,35 +143,371 @@ func (r *GORMUserRepository) CreateUser(ctx context.Context, user *domain.User)
 	if username == "" {
 		username = user.Email
 	}
+	status := user.Status
+	if status == "" {
+		status = domain.UserStatusActive
+	}
+	role := user.Role
+	if role == "" {
+		role = domain.RoleStandard
+	}
 	userGORM := UserGORM{
 		Username:     username,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		AvatarURL:    user.AvatarURL,
+		PhoneNumber:  nullableString(user.PhoneNumber),
 		Email:        user.Email,
 		PasswordHash: user.PasswordHash,
-		Status:       "active",
-		LastLoginAt:  time.Now(),
+		Status:       status,
+		Role:         role,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
-	result := r.DB.WithContext(ctx).Create(&userGORM)
+	result := conn(ctx, r.DB).Create(&userGORM)
 
 	if result.Error != nil {
 		if isDuplicateEntryError(result.Error) {
-			return domain.ErrEmailExists
+			return duplicateEntryDomainError(result.Error)
 		}
 		return fmt.Errorf("gorm create user failed: %w", result.Error)
 	}
 
 	user.ID = userGORM.ID
+	user.Status = status
+	return nil
+}
+
+// UpdateProfile 更新用户资料字段
+func (r *GORMUserRepository) UpdateProfile(ctx context.Context, user *domain.User) error {
+	// 使用 map 以便空字符串、NULL 也能写入（struct 方式会忽略零值）
+	result := conn(ctx, r.DB).
+		Model(&UserGORM{}).
+		Where("id = ?", user.ID).
+		Updates(map[string]interface{}{
+			"username":          user.Username,
+			"first_name":        user.FirstName,
+			"last_name":         user.LastName,
+			"avatar_url":        user.AvatarURL,
+			"phone_number":      nullableString(user.PhoneNumber),
+			"phone_verified_at": user.PhoneVerifiedAt,
+			"updated_at":        time.Now(),
+		})
+
+	if result.Error != nil {
+		if isDuplicateEntryError(result.Error) {
+			return duplicateEntryDomainError(result.Error)
+		}
+		return fmt.Errorf("gorm update profile failed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
 	return nil
 }
 
-// isDuplicateEntryError 检查 GORM 错误是否是 MySQL 唯一约束冲突 (错误码 1062)
+// ChangePassword 更新密码哈希并递增 token_version（同一事务内读回新版本号）
+func (r *GORMUserRepository) ChangePassword(ctx context.Context, id int64, passwordHash string) (int64, error) {
+	var version int64
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&UserGORM{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"password_hash": passwordHash,
+				"token_version": gorm.Expr("token_version + 1"),
+				"updated_at":    time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrUserNotFound
+		}
+		return tx.Model(&UserGORM{}).Where("id = ?", id).Pluck("token_version", &version).Error
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("gorm change password failed: %w", err)
+	}
+	return version, nil
+}
+
+// UpdatePasswordHash 仅替换密码哈希，不影响已签发的 token
+func (r *GORMUserRepository) UpdatePasswordHash(ctx context.Context, id int64, passwordHash string) error {
+	result := conn(ctx, r.DB).
+		Model(&UserGORM{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"password_hash": passwordHash, "updated_at": time.Now()})
+	if result.Error != nil {
+		return fmt.Errorf("gorm update password hash failed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateStatus 更新用户状态
+func (r *GORMUserRepository) UpdateStatus(ctx context.Context, id int64, status string) error {
+	result := conn(ctx, r.DB).
+		Model(&UserGORM{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "updated_at": time.Now()})
+	if result.Error != nil {
+		return fmt.Errorf("gorm update status failed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// ActivatePending 仅当用户仍为 pending 时激活，并清空密码、递增 token_version
+func (r *GORMUserRepository) ActivatePending(ctx context.Context, id int64) error {
+	result := conn(ctx, r.DB).
+		Model(&UserGORM{}).
+		Where("id = ? AND status = ?", id, domain.UserStatusPending).
+		Updates(map[string]interface{}{
+			"status":        domain.UserStatusActive,
+			"password_hash": "",
+			"token_version": gorm.Expr("token_version + 1"),
+			"updated_at":    time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("gorm activate pending user failed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateRole 更新用户角色
+func (r *GORMUserRepository) UpdateRole(ctx context.Context, id int64, role string) error {
+	result := conn(ctx, r.DB).
+		Model(&UserGORM{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"role": role, "updated_at": time.Now()})
+	if result.Error != nil {
+		return fmt.Errorf("gorm update role failed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+const (
+	// mysqlErrDuplicateEntry MySQL 唯一约束冲突错误码
+	mysqlErrDuplicateEntry = 1062
+	// pgUniqueViolation PostgreSQL 唯一约束冲突的 SQLSTATE
+	pgUniqueViolation = "23505"
+)
+
+// isDuplicateEntryError 检查 GORM 错误是否是唯一约束冲突：MySQL 错误码 1062，或 PostgreSQL SQLSTATE 23505。
+// database.driver 为 postgres 时同样使用本包实现，因此两种驱动都需识别
 func isDuplicateEntryError(err error) bool {
-	// GORM 错误通常需要解包才能获取底层驱动错误
+	// 开启 TranslateError 时 GORM 会转换为 ErrDuplicatedKey
 	if errors.Is(err, gorm.ErrDuplicatedKey) {
 		return true
 	}
-	// 复杂的错误检查可能需要导入 go-sql-driver/mysql 并检查 *mysql.MySQLError.Number == 1062
-	return false
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrDuplicateEntry
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
+
+// duplicateEntryDomainError 根据冲突的唯一索引名映射为领域错误。
+// MySQL 报错信息形如 "Duplicate entry 'x' for key 'users.users_pk_3'"，索引名来自 scripts/create_users.sql（users_pk_2/3/4）
+// 或 GORM 自动迁移（idx_users_username 等）；PostgreSQL 直接给出约束名，来自 scripts/postgres/create_users.sql
+// （users_username_key / users_phone_number_key / users_email_key）。无法识别时按邮箱冲突处理。
+func duplicateEntryDomainError(err error) error {
+	var key string
+	var mysqlErr *mysqldriver.MySQLError
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &mysqlErr):
+		key = mysqlErr.Message
+		if i := strings.LastIndex(key, "for key "); i >= 0 {
+			key = key[i+len("for key "):]
+		}
+	case errors.As(err, &pgErr):
+		key = pgErr.ConstraintName
+	default:
+		return domain.ErrEmailExists
+	}
+	switch {
+	case strings.Contains(key, "users_pk_2"), strings.Contains(key, "username"):
+		return domain.ErrUserExists
+	case strings.Contains(key, "users_pk_3"), strings.Contains(key, "phone_number"):
+		return domain.ErrPhoneExists
+	default:
+		return domain.ErrEmailExists
+	}
+}
+
+// RecordLoginFailure 原子递增连续登录失败次数
+func (r *GORMUserRepository) RecordLoginFailure(ctx context.Context, id int64) (int, error) {
+	var count int
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&UserGORM{}).
+			Where("id = ?", id).
+			Update("failed_login_count", gorm.Expr("failed_login_count + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrUserNotFound
+		}
+		return tx.Model(&UserGORM{}).Where("id = ?", id).Pluck("failed_login_count", &count).Error
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("gorm record login failure failed: %w", err)
+	}
+	return count, nil
+}
+
+// LockUntil 设置账号锁定截止时间
+func (r *GORMUserRepository) LockUntil(ctx context.Context, id int64, until time.Time) error {
+	result := conn(ctx, r.DB).Model(&UserGORM{}).Where("id = ?", id).Update("locked_until", until)
+	if result.Error != nil {
+		return fmt.Errorf("gorm lock user failed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// ResetLoginFailures 清零连续失败次数并解除锁定
+func (r *GORMUserRepository) ResetLoginFailures(ctx context.Context, id int64) error {
+	result := conn(ctx, r.DB).
+		Model(&UserGORM{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"failed_login_count": 0, "locked_until": nil})
+	if result.Error != nil {
+		return fmt.Errorf("gorm reset login failures failed: %w", result.Error)
+	}
+	return nil
+}
+
+// UpdateLastLogin 更新最近一次登录成功时间（不修改 updated_at）
+func (r *GORMUserRepository) UpdateLastLogin(ctx context.Context, id int64, at time.Time) error {
+	result := conn(ctx, r.DB).
+		Model(&UserGORM{}).
+		Where("id = ?", id).
+		UpdateColumn("last_login_at", at)
+	if result.Error != nil {
+		return fmt.Errorf("gorm update last login failed: %w", result.Error)
+	}
+	return nil
+}
+
+// FindByPhone 根据手机号查找用户
+func (r *GORMUserRepository) FindByPhone(ctx context.Context, phone string) (*domain.User, error) {
+	var userGORM UserGORM
+	result := conn(ctx, r.DB).Where("phone_number = ?", phone).First(&userGORM)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("gorm find by phone failed: %w", result.Error)
+	}
+	return mapGORMToDomain(&userGORM), nil
+}
+
+// SetPhoneVerified 绑定手机号并标记为已验证
+func (r *GORMUserRepository) SetPhoneVerified(ctx context.Context, id int64, phone string, at time.Time) error {
+	result := conn(ctx, r.DB).
+		Model(&UserGORM{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"phone_number":      phone,
+			"phone_verified_at": at,
+			"updated_at":        time.Now(),
+		})
+	if result.Error != nil {
+		if isDuplicateEntryError(result.Error) {
+			return domain.ErrPhoneExists
+		}
+		return fmt.Errorf("gorm set phone verified failed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateEmail 修改邮箱
+func (r *GORMUserRepository) UpdateEmail(ctx context.Context, id int64, email string) error {
+	result := conn(ctx, r.DB).
+		Model(&UserGORM{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"email": email, "updated_at": time.Now()})
+	if result.Error != nil {
+		if isDuplicateEntryError(result.Error) {
+			return duplicateEntryDomainError(result.Error)
+		}
+		return fmt.Errorf("gorm update email failed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// List 按条件分页查询用户，GORM 自动排除已软删除的记录
+func (r *GORMUserRepository) List(ctx context.Context, filter domain.UserFilter) ([]*domain.User, int64, error) {
+	q := conn(ctx, r.DB).Model(&UserGORM{})
+	if filter.ID > 0 {
+		q = q.Where("id = ?", filter.ID)
+	}
+	if filter.Username != "" {
+		q = q.Where(r.equalFold("username"), filter.Username)
+	}
+	if filter.Email != "" {
+		q = q.Where(r.equalFold("email"), filter.Email)
+	}
+	if len(filter.Statuses) > 0 {
+		q = q.Where("status IN ?", filter.Statuses)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("gorm count users failed: %w", err)
+	}
+	var rows []UserGORM
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		q = q.Offset(filter.Offset)
+	}
+	if err := q.Order("id").Find(&rows).Error; err != nil {
+		return nil, 0, fmt.Errorf("gorm list users failed: %w", err)
+	}
+	out := make([]*domain.User, 0, len(rows))
+	for i := range rows {
+		out = append(out, mapGORMToDomain(&rows[i]))
+	}
+	return out, total, nil
+}
+
+// SoftDelete 设置 deleted_at；唯一索引仍包含已删除的行，其邮箱、用户名不能立即被新用户使用
+func (r *GORMUserRepository) SoftDelete(ctx context.Context, id int64) error {
+	result := conn(ctx, r.DB).Where("id = ?", id).Delete(&UserGORM{})
+	if result.Error != nil {
+		return fmt.Errorf("gorm soft delete user failed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
 }
@@ -0,0 +1,79 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"monai-auth/internal/domain"
+)
+
+func TestIsDuplicateEntryError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"translated", gorm.ErrDuplicatedKey, true},
+		{"mysql duplicate entry", &mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'x' for key 'uk_user_identities_provider_subject'"}, true},
+		{"mysql other error", &mysqldriver.MySQLError{Number: 1452}, false},
+		// database.driver 为 postgres 时本包仓库收到的是 pgconn 错误
+		{"postgres unique violation", &pgconn.PgError{Code: "23505", ConstraintName: "uk_user_identities_provider_subject"}, true},
+		{"wrapped postgres unique violation", fmt.Errorf("create: %w", &pgconn.PgError{Code: "23505"}), true},
+		{"postgres foreign key violation", &pgconn.PgError{Code: "23503"}, false},
+		{"record not found", gorm.ErrRecordNotFound, false},
+		{"other", errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := isDuplicateEntryError(tt.err); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDuplicateEntryDomainError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"mysql username", &mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'alice' for key 'users.users_pk_2'"}, domain.ErrUserExists},
+		{"mysql phone", &mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry '+8613800000000' for key 'users.users_pk_3'"}, domain.ErrPhoneExists},
+		{"mysql email", &mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'a@example.com' for key 'users.users_pk_4'"}, domain.ErrEmailExists},
+		{"mysql automigrate index", &mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'alice' for key 'users.idx_users_username'"}, domain.ErrUserExists},
+		// 约束名来自 scripts/postgres/create_users.sql
+		{"postgres username", &pgconn.PgError{Code: "23505", ConstraintName: "users_username_key"}, domain.ErrUserExists},
+		{"postgres phone", fmt.Errorf("update: %w", &pgconn.PgError{Code: "23505", ConstraintName: "users_phone_number_key"}), domain.ErrPhoneExists},
+		{"postgres email", &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}, domain.ErrEmailExists},
+		{"translated", gorm.ErrDuplicatedKey, domain.ErrEmailExists},
+	}
+	for _, tt := range tests {
+		if got := duplicateEntryDomainError(tt.err); !errors.Is(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUserRepositoryEqualFold(t *testing.T) {
+	pg, err := gorm.Open(postgres.Open("host=localhost"), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open postgres dry run: %v", err)
+	}
+	// PostgreSQL 没有 _ci 排序规则，以 lower() 比较；MySQL 依赖列排序规则
+	if got := NewGORMUserRepository(pg).equalFold("email"); got != "lower(email) = lower(?)" {
+		t.Errorf("postgres: got %q", got)
+	}
+	if got := (&GORMUserRepository{}).equalFold("email"); got != "email = ?" {
+		t.Errorf("mysql: got %q", got)
+	}
+	stmt := pg.Session(&gorm.Session{DryRun: true}).Where(NewGORMUserRepository(pg).equalFold("username"), "Alice").First(&UserGORM{}).Statement
+	if sql := stmt.SQL.String(); !strings.Contains(sql, "lower(username) = lower($1)") {
+		t.Errorf("postgres query: %s", sql)
+	}
+}
@@ -0,0 +1,111 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"monai-auth/internal/domain"
+)
+
+// GORMWebAuthnCredentialRepository 实现 domain.WebAuthnCredentialRepository
+type GORMWebAuthnCredentialRepository struct {
+	DB *gorm.DB
+}
+
+// NewGORMWebAuthnCredentialRepository 创建 WebAuthn 凭据仓库实例
+func NewGORMWebAuthnCredentialRepository(db *gorm.DB) *GORMWebAuthnCredentialRepository {
+	return &GORMWebAuthnCredentialRepository{DB: db}
+}
+
+// Create 保存新凭据
+func (r *GORMWebAuthnCredentialRepository) Create(ctx context.Context, cred *domain.WebAuthnCredential) error {
+	m := WebAuthnCredentialGORM{
+		UserID:          cred.UserID,
+		CredentialID:    cred.CredentialID,
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		AAGUID:          cred.AAGUID,
+		SignCount:       cred.SignCount,
+		Transports:      strings.Join(cred.Transports, ","),
+		BackupEligible:  cred.BackupEligible,
+		BackupState:     cred.BackupState,
+		Name:            cred.Name,
+		CreatedAt:       time.Now(),
+	}
+	if err := conn(ctx, r.DB).Create(&m).Error; err != nil {
+		if isDuplicateEntryError(err) {
+			return domain.ErrWebAuthnCredentialExists
+		}
+		return fmt.Errorf("create webauthn_credentials: %w", err)
+	}
+	cred.ID = m.ID
+	cred.CreatedAt = m.CreatedAt
+	return nil
+}
+
+// ListByUser 按创建时间列出用户凭据
+func (r *GORMWebAuthnCredentialRepository) ListByUser(ctx context.Context, userID int64) ([]*domain.WebAuthnCredential, error) {
+	var rows []WebAuthnCredentialGORM
+	if err := conn(ctx, r.DB).Where("user_id = ?", userID).Order("id").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list webauthn_credentials: %w", err)
+	}
+	out := make([]*domain.WebAuthnCredential, 0, len(rows))
+	for i := range rows {
+		m := &rows[i]
+		var transports []string
+		if m.Transports != "" {
+			transports = strings.Split(m.Transports, ",")
+		}
+		out = append(out, &domain.WebAuthnCredential{
+			ID:              m.ID,
+			UserID:          m.UserID,
+			CredentialID:    m.CredentialID,
+			PublicKey:       m.PublicKey,
+			AttestationType: m.AttestationType,
+			AAGUID:          m.AAGUID,
+			SignCount:       m.SignCount,
+			Transports:      transports,
+			BackupEligible:  m.BackupEligible,
+			BackupState:     m.BackupState,
+			Name:            m.Name,
+			CreatedAt:       m.CreatedAt,
+			LastUsedAt:      m.LastUsedAt,
+		})
+	}
+	return out, nil
+}
+
+// UpdateAfterLogin 登录成功后更新签名计数、备份状态与最近使用时间
+func (r *GORMWebAuthnCredentialRepository) UpdateAfterLogin(ctx context.Context, credentialID []byte, signCount uint32, backupState bool) error {
+	result := conn(ctx, r.DB).
+		Model(&WebAuthnCredentialGORM{}).
+		Where("credential_id = ?", credentialID).
+		Updates(map[string]interface{}{
+			"sign_count":   signCount,
+			"backup_state": backupState,
+			"last_used_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("update webauthn_credentials: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrWebAuthnCredentialNotFound
+	}
+	return nil
+}
+
+// Delete 删除用户的指定凭据
+func (r *GORMWebAuthnCredentialRepository) Delete(ctx context.Context, userID int64, id int64) error {
+	result := conn(ctx, r.DB).Where("id = ? AND user_id = ?", id, userID).Delete(&WebAuthnCredentialGORM{})
+	if result.Error != nil {
+		return fmt.Errorf("delete webauthn_credentials: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrWebAuthnCredentialNotFound
+	}
+	return nil
+}
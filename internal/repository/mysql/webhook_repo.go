@@ -0,0 +1,203 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"monai-auth/internal/domain"
+)
+
+// GORMWebhookDeliveryRepository 实现 domain.WebhookDeliveryRepository
+type GORMWebhookDeliveryRepository struct {
+	DB *gorm.DB
+}
+
+// NewGORMWebhookDeliveryRepository 创建 Webhook 投递记录仓库实例
+func NewGORMWebhookDeliveryRepository(db *gorm.DB) *GORMWebhookDeliveryRepository {
+	return &GORMWebhookDeliveryRepository{DB: db}
+}
+
+// Create 批量写入待投递记录
+func (r *GORMWebhookDeliveryRepository) Create(ctx context.Context, deliveries []*domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	now := time.Now()
+	rows := make([]WebhookDeliveryGORM, len(deliveries))
+	for i, d := range deliveries {
+		if d.CreatedAt.IsZero() {
+			d.CreatedAt = now
+		}
+		if d.NextAttemptAt.IsZero() {
+			d.NextAttemptAt = d.CreatedAt
+		}
+		d.UpdatedAt = d.CreatedAt
+		rows[i] = toWebhookDeliveryGORM(d)
+	}
+	if err := conn(ctx, r.DB).Create(&rows).Error; err != nil {
+		return fmt.Errorf("create webhook_deliveries: %w", err)
+	}
+	for i := range rows {
+		deliveries[i].ID = rows[i].ID
+	}
+	return nil
+}
+
+// ClaimDue 以 SELECT ... FOR UPDATE SKIP LOCKED 领取到期记录并推迟其 next_attempt_at，多实例间互不阻塞
+func (r *GORMWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDelivery, error) {
+	var rows []WebhookDeliveryGORM
+	err := conn(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", domain.WebhookDeliveryPending, now).
+			Order("next_attempt_at").Limit(limit).Find(&rows).Error
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+		ids := make([]int64, len(rows))
+		for i := range rows {
+			ids[i] = rows[i].ID
+		}
+		return tx.Model(&WebhookDeliveryGORM{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook_deliveries: %w", err)
+	}
+	deliveries := make([]*domain.WebhookDelivery, 0, len(rows))
+	for i := range rows {
+		deliveries = append(deliveries, toDomainWebhookDelivery(&rows[i]))
+	}
+	return deliveries, nil
+}
+
+// UpdateAttempt 保存一次投递尝试的结果
+func (r *GORMWebhookDeliveryRepository) UpdateAttempt(ctx context.Context, d *domain.WebhookDelivery) error {
+	err := conn(ctx, r.DB).Model(&WebhookDeliveryGORM{}).Where("id = ?", d.ID).Updates(map[string]any{
+		"status":           d.Status,
+		"attempts":         d.Attempts,
+		"next_attempt_at":  d.NextAttemptAt,
+		"last_status_code": d.LastStatusCode,
+		"last_error":       d.LastError,
+		"delivered_at":     d.DeliveredAt,
+		"updated_at":       time.Now(),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("update webhook_delivery: %w", err)
+	}
+	return nil
+}
+
+// FindByID 查找投递记录
+func (r *GORMWebhookDeliveryRepository) FindByID(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	var m WebhookDeliveryGORM
+	if err := conn(ctx, r.DB).Where("id = ?", id).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("find webhook_delivery: %w", err)
+	}
+	return toDomainWebhookDelivery(&m), nil
+}
+
+// List 按条件查询投递记录，按 ID 倒序
+func (r *GORMWebhookDeliveryRepository) List(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	q := conn(ctx, r.DB).Model(&WebhookDeliveryGORM{})
+	if filter.Subscriber != "" {
+		q = q.Where("subscriber = ?", filter.Subscriber)
+	}
+	if filter.EventType != "" {
+		q = q.Where("event_type = ?", filter.EventType)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if filter.UserID > 0 {
+		q = q.Where("user_id = ?", filter.UserID)
+	}
+	if filter.BeforeID > 0 {
+		q = q.Where("id < ?", filter.BeforeID)
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	var rows []WebhookDeliveryGORM
+	if err := q.Order("id DESC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list webhook_deliveries: %w", err)
+	}
+	deliveries := make([]*domain.WebhookDelivery, 0, len(rows))
+	for i := range rows {
+		deliveries = append(deliveries, toDomainWebhookDelivery(&rows[i]))
+	}
+	return deliveries, nil
+}
+
+// Requeue 重置为 pending 并于 at 到期
+func (r *GORMWebhookDeliveryRepository) Requeue(ctx context.Context, id int64, at time.Time) error {
+	res := conn(ctx, r.DB).Model(&WebhookDeliveryGORM{}).Where("id = ?", id).Updates(map[string]any{
+		"status":          domain.WebhookDeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": at,
+		"updated_at":      time.Now(),
+	})
+	if res.Error != nil {
+		return fmt.Errorf("requeue webhook_delivery: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+// DeleteBefore 删除早于 before 且已结束的记录
+func (r *GORMWebhookDeliveryRepository) DeleteBefore(ctx context.Context, before time.Time) error {
+	err := conn(ctx, r.DB).
+		Where("created_at < ? AND status <> ?", before, domain.WebhookDeliveryPending).
+		Delete(&WebhookDeliveryGORM{}).Error
+	if err != nil {
+		return fmt.Errorf("delete webhook_deliveries: %w", err)
+	}
+	return nil
+}
+
+func toWebhookDeliveryGORM(d *domain.WebhookDelivery) WebhookDeliveryGORM {
+	return WebhookDeliveryGORM{
+		ID:             d.ID,
+		Subscriber:     d.Subscriber,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		UserID:         d.UserID,
+		Payload:        string(d.Payload),
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+func toDomainWebhookDelivery(m *WebhookDeliveryGORM) *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:             m.ID,
+		Subscriber:     m.Subscriber,
+		EventID:        m.EventID,
+		EventType:      m.EventType,
+		UserID:         m.UserID,
+		Payload:        []byte(m.Payload),
+		Status:         m.Status,
+		Attempts:       m.Attempts,
+		NextAttemptAt:  m.NextAttemptAt,
+		LastStatusCode: m.LastStatusCode,
+		LastError:      m.LastError,
+		DeliveredAt:    m.DeliveredAt,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+}
@@ -1,3 +0,0 @@
-// Package postgres 预留 PostgreSQL 仓库实现。
-// 当前项目仅使用 MySQL；若需支持 Postgres，在此实现 domain.UserRepository。
-package postgres
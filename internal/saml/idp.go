@@ -0,0 +1,278 @@
+// Package saml 实现 SAML 2.0 身份提供方（IdP）所需的协议部分：解析 SP 的 AuthnRequest、生成 IdP 元数据、
+// 构造并签名 Response / Assertion。账号与会话逻辑在 internal/auth 中。
+package saml
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+// 协议常量
+const (
+	BindingHTTPPost     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	BindingHTTPRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+
+	NameIDFormatEmail       = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	NameIDFormatPersistent  = "urn:oasis:names:tc:SAML:2.0:nameid-format:persistent"
+	NameIDFormatUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+
+	StatusSuccess              = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	StatusRequester            = "urn:oasis:names:tc:SAML:2.0:status:Requester"
+	StatusResponder            = "urn:oasis:names:tc:SAML:2.0:status:Responder"
+	StatusNoPassive            = "urn:oasis:names:tc:SAML:2.0:status:NoPassive"
+	StatusAuthnFailed          = "urn:oasis:names:tc:SAML:2.0:status:AuthnFailed"
+	StatusInvalidNameIDPolicy  = "urn:oasis:names:tc:SAML:2.0:status:InvalidNameIDPolicy"
+	AuthnContextPassword       = "urn:oasis:names:tc:SAML:2.0:ac:classes:PasswordProtectedTransport"
+	AuthnContextUnspecified    = "urn:oasis:names:tc:SAML:2.0:ac:classes:unspecified"
+	attributeNameFormatBasic   = "urn:oasis:names:tc:SAML:2.0:attrname-format:basic"
+	subjectConfirmationBearer  = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	namespaceProtocol          = "urn:oasis:names:tc:SAML:2.0:protocol"
+	namespaceAssertion         = "urn:oasis:names:tc:SAML:2.0:assertion"
+	namespaceMetadata          = "urn:oasis:names:tc:SAML:2.0:metadata"
+	namespaceXMLDSig           = "http://www.w3.org/2000/09/xmldsig#"
+	defaultAssertionTTL        = 5 * time.Minute
+	clockSkew                  = 90 * time.Second
+	samlTimeFormat             = "2006-01-02T15:04:05.000Z"
+	metadataValidUntilDuration = 7 * 24 * time.Hour
+)
+
+// IdentityProvider 本服务作为 SAML IdP 的身份与签名密钥
+type IdentityProvider struct {
+	// EntityID IdP 实体标识，通常为元数据地址
+	EntityID string
+	// SSOURL 接收 AuthnRequest 的地址（HTTP-Redirect 与 HTTP-POST 共用）
+	SSOURL string
+	// Key 签名私钥，需为 RSA 密钥（SP 普遍只支持 rsa-sha256）
+	Key crypto.Signer
+	// Certificate 与 Key 对应的证书，发布在元数据中供 SP 验签
+	Certificate *x509.Certificate
+	// AssertionTTL 断言有效期（SubjectConfirmationData / Conditions 的 NotOnOrAfter），缺省 5 分钟
+	AssertionTTL time.Duration
+}
+
+// Attribute 断言中的一个属性
+type Attribute struct {
+	Name   string
+	Values []string
+}
+
+// Assertion 构造成功响应所需的信息
+type Assertion struct {
+	// RequestID 对应 AuthnRequest 的 ID，写入 InResponseTo
+	RequestID string
+	// Audience SP 实体标识
+	Audience string
+	// ACSURL 响应投递地址，写入 Destination 与 Recipient
+	ACSURL       string
+	NameID       string
+	NameIDFormat string
+	// SessionIndex IdP 侧的会话标识，供 SP 关联登出
+	SessionIndex string
+	AuthnInstant time.Time
+	// AuthnContextClass 认证方式，为空时为 unspecified
+	AuthnContextClass string
+	Attributes        []Attribute
+	// SignResponse 除 Assertion 外同时对外层 Response 签名
+	SignResponse bool
+}
+
+func (idp *IdentityProvider) signingContext() (*dsig.SigningContext, error) {
+	if idp.Key == nil || idp.Certificate == nil {
+		return nil, errors.New("saml: identity provider has no signing key")
+	}
+	ctx, err := dsig.NewSigningContext(idp.Key, [][]byte{idp.Certificate.Raw})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.SetSignatureMethod(dsig.RSASHA256SignatureMethod); err != nil {
+		return nil, err
+	}
+	ctx.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+	return ctx, nil
+}
+
+// sign 对元素做 enveloped 签名，按 SAML schema 要求把 <ds:Signature> 放在 <saml:Issuer> 之后
+func (idp *IdentityProvider) sign(el *etree.Element) error {
+	ctx, err := idp.signingContext()
+	if err != nil {
+		return err
+	}
+	sig, err := ctx.ConstructSignature(el, true)
+	if err != nil {
+		return fmt.Errorf("saml: sign: %w", err)
+	}
+	index := 0
+	if issuer := el.SelectElement("Issuer"); issuer != nil {
+		index = issuer.Index() + 1
+	}
+	el.InsertChildAt(index, sig)
+	return nil
+}
+
+// Metadata 生成 IdP 元数据（EntityDescriptor），SP 管理员导入后即可完成对接
+func (idp *IdentityProvider) Metadata() ([]byte, error) {
+	if idp.Certificate == nil {
+		return nil, errors.New("saml: identity provider has no certificate")
+	}
+	doc := etree.NewDocument()
+	ed := doc.CreateElement("md:EntityDescriptor")
+	ed.CreateAttr("xmlns:md", namespaceMetadata)
+	ed.CreateAttr("xmlns:ds", namespaceXMLDSig)
+	ed.CreateAttr("entityID", idp.EntityID)
+	ed.CreateAttr("validUntil", formatTime(time.Now().Add(metadataValidUntilDuration)))
+
+	sso := ed.CreateElement("md:IDPSSODescriptor")
+	sso.CreateAttr("WantAuthnRequestsSigned", "false")
+	sso.CreateAttr("protocolSupportEnumeration", namespaceProtocol)
+
+	kd := sso.CreateElement("md:KeyDescriptor")
+	kd.CreateAttr("use", "signing")
+	cert := kd.CreateElement("ds:KeyInfo").CreateElement("ds:X509Data").CreateElement("ds:X509Certificate")
+	cert.SetText(base64.StdEncoding.EncodeToString(idp.Certificate.Raw))
+
+	for _, format := range []string{NameIDFormatEmail, NameIDFormatPersistent, NameIDFormatUnspecified} {
+		sso.CreateElement("md:NameIDFormat").SetText(format)
+	}
+	for _, binding := range []string{BindingHTTPRedirect, BindingHTTPPost} {
+		s := sso.CreateElement("md:SingleSignOnService")
+		s.CreateAttr("Binding", binding)
+		s.CreateAttr("Location", idp.SSOURL)
+	}
+	doc.Indent(2)
+	return doc.WriteToBytes()
+}
+
+// BuildResponse 构造已签名的成功响应，返回可直接放入 SAMLResponse 表单字段的 base64 文本
+func (idp *IdentityProvider) BuildResponse(a Assertion) (string, error) {
+	now := time.Now().UTC()
+	ttl := idp.AssertionTTL
+	if ttl <= 0 {
+		ttl = defaultAssertionTTL
+	}
+	notOnOrAfter := formatTime(now.Add(ttl))
+
+	assertion := etree.NewElement("saml:Assertion")
+	assertion.CreateAttr("xmlns:saml", namespaceAssertion)
+	assertion.CreateAttr("ID", newID())
+	assertion.CreateAttr("Version", "2.0")
+	assertion.CreateAttr("IssueInstant", formatTime(now))
+	assertion.CreateElement("saml:Issuer").SetText(idp.EntityID)
+
+	subject := assertion.CreateElement("saml:Subject")
+	nameID := subject.CreateElement("saml:NameID")
+	nameID.CreateAttr("Format", a.NameIDFormat)
+	nameID.SetText(a.NameID)
+	confirmation := subject.CreateElement("saml:SubjectConfirmation")
+	confirmation.CreateAttr("Method", subjectConfirmationBearer)
+	data := confirmation.CreateElement("saml:SubjectConfirmationData")
+	if a.RequestID != "" {
+		data.CreateAttr("InResponseTo", a.RequestID)
+	}
+	data.CreateAttr("NotOnOrAfter", notOnOrAfter)
+	data.CreateAttr("Recipient", a.ACSURL)
+
+	conditions := assertion.CreateElement("saml:Conditions")
+	conditions.CreateAttr("NotBefore", formatTime(now.Add(-clockSkew)))
+	conditions.CreateAttr("NotOnOrAfter", notOnOrAfter)
+	conditions.CreateElement("saml:AudienceRestriction").CreateElement("saml:Audience").SetText(a.Audience)
+
+	authnInstant := a.AuthnInstant
+	if authnInstant.IsZero() {
+		authnInstant = now
+	}
+	contextClass := a.AuthnContextClass
+	if contextClass == "" {
+		contextClass = AuthnContextUnspecified
+	}
+	statement := assertion.CreateElement("saml:AuthnStatement")
+	statement.CreateAttr("AuthnInstant", formatTime(authnInstant))
+	if a.SessionIndex != "" {
+		statement.CreateAttr("SessionIndex", a.SessionIndex)
+	}
+	statement.CreateElement("saml:AuthnContext").CreateElement("saml:AuthnContextClassRef").SetText(contextClass)
+
+	if len(a.Attributes) > 0 {
+		attrs := assertion.CreateElement("saml:AttributeStatement")
+		for _, attr := range a.Attributes {
+			el := attrs.CreateElement("saml:Attribute")
+			el.CreateAttr("Name", attr.Name)
+			el.CreateAttr("NameFormat", attributeNameFormatBasic)
+			for _, v := range attr.Values {
+				el.CreateElement("saml:AttributeValue").SetText(v)
+			}
+		}
+	}
+	if err := idp.sign(assertion); err != nil {
+		return "", err
+	}
+
+	response := idp.newResponse(now, a.RequestID, a.ACSURL, StatusSuccess)
+	response.AddChild(assertion)
+	if a.SignResponse {
+		if err := idp.sign(response); err != nil {
+			return "", err
+		}
+	}
+	return encodeResponse(response)
+}
+
+// BuildErrorResponse 构造不含断言的失败响应（如 IsPassive 请求但用户未登录时的 NoPassive）；
+// statusCode 为二级状态码，顶层状态按 SAML 约定取 Responder
+func (idp *IdentityProvider) BuildErrorResponse(requestID, acsURL, statusCode string) (string, error) {
+	response := idp.newResponse(time.Now().UTC(), requestID, acsURL, StatusResponder)
+	if statusCode != "" && statusCode != StatusResponder {
+		response.SelectElement("samlp:Status").SelectElement("samlp:StatusCode").
+			CreateElement("samlp:StatusCode").CreateAttr("Value", statusCode)
+	}
+	if err := idp.sign(response); err != nil {
+		return "", err
+	}
+	return encodeResponse(response)
+}
+
+func (idp *IdentityProvider) newResponse(now time.Time, requestID, acsURL, status string) *etree.Element {
+	response := etree.NewElement("samlp:Response")
+	response.CreateAttr("xmlns:samlp", namespaceProtocol)
+	response.CreateAttr("xmlns:saml", namespaceAssertion)
+	response.CreateAttr("ID", newID())
+	response.CreateAttr("Version", "2.0")
+	response.CreateAttr("IssueInstant", formatTime(now))
+	response.CreateAttr("Destination", acsURL)
+	if requestID != "" {
+		response.CreateAttr("InResponseTo", requestID)
+	}
+	response.CreateElement("saml:Issuer").SetText(idp.EntityID)
+	response.CreateElement("samlp:Status").CreateElement("samlp:StatusCode").CreateAttr("Value", status)
+	return response
+}
+
+func encodeResponse(response *etree.Element) (string, error) {
+	doc := etree.NewDocument()
+	doc.SetRoot(response)
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// newID 生成 XML ID（必须以字母或下划线开头）
+func newID() string {
+	b := make([]byte, 20)
+	_, _ = rand.Read(b)
+	return "_" + hex.EncodeToString(b)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(samlTimeFormat)
+}
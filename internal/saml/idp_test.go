@@ -0,0 +1,184 @@
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+func newTestIdP(t *testing.T) *IdentityProvider {
+	t.Helper()
+	key, cert, err := GenerateKeyPair("idp.example.com")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	return &IdentityProvider{EntityID: "https://idp.example.com/saml/metadata", SSOURL: "https://idp.example.com/saml/sso", Key: key, Certificate: cert}
+}
+
+// decodeResponse 解码 SAMLResponse 表单字段并返回根元素
+func decodeResponse(t *testing.T, encoded string) *etree.Element {
+	t.Helper()
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(raw); err != nil {
+		t.Fatalf("parse response: %v", err)
+	}
+	return doc.Root()
+}
+
+// validate 按 SP 的方式用 IdP 证书校验元素的签名
+func validate(cert *x509.Certificate, el *etree.Element) error {
+	ctx := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: []*x509.Certificate{cert}})
+	_, err := ctx.Validate(el)
+	return err
+}
+
+func testAssertion() Assertion {
+	return Assertion{
+		RequestID:         "_req1",
+		Audience:          "https://sp.example.com/metadata",
+		ACSURL:            "https://sp.example.com/acs",
+		NameID:            "alice@example.com",
+		NameIDFormat:      NameIDFormatEmail,
+		SessionIndex:      "_session1",
+		AuthnContextClass: AuthnContextPassword,
+		Attributes:        []Attribute{{Name: "email", Values: []string{"alice@example.com"}}, {Name: "groups", Values: []string{"admins", "developers"}}},
+	}
+}
+
+func TestBuildResponseSignsAssertion(t *testing.T) {
+	tests := []struct {
+		name         string
+		signResponse bool
+	}{
+		{"assertion only", false},
+		{"assertion and response", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newTestIdP(t)
+			a := testAssertion()
+			a.SignResponse = tt.signResponse
+			encoded, err := idp.BuildResponse(a)
+			if err != nil {
+				t.Fatalf("BuildResponse: %v", err)
+			}
+			response := decodeResponse(t, encoded)
+			if got := response.SelectAttrValue("InResponseTo", ""); got != "_req1" {
+				t.Fatalf("InResponseTo = %q", got)
+			}
+			if got := response.SelectAttrValue("Destination", ""); got != a.ACSURL {
+				t.Fatalf("Destination = %q", got)
+			}
+			if status := response.FindElement("./Status/StatusCode").SelectAttrValue("Value", ""); status != StatusSuccess {
+				t.Fatalf("status = %q", status)
+			}
+
+			assertion := response.FindElement("./Assertion")
+			if err := validate(idp.Certificate, assertion); err != nil {
+				t.Fatalf("assertion signature: %v", err)
+			}
+			// SAML schema 要求 <ds:Signature> 紧跟在 <saml:Issuer> 之后
+			if children := assertion.ChildElements(); children[0].Tag != "Issuer" || children[1].Tag != "Signature" {
+				t.Fatalf("assertion children start with %s, %s", children[0].Tag, children[1].Tag)
+			}
+			if got := assertion.FindElement("./Subject/NameID").Text(); got != "alice@example.com" {
+				t.Fatalf("NameID = %q", got)
+			}
+			if got := assertion.FindElement("./Conditions/AudienceRestriction/Audience").Text(); got != a.Audience {
+				t.Fatalf("Audience = %q", got)
+			}
+			if got := len(assertion.FindElements("./AttributeStatement/Attribute[@Name='groups']/AttributeValue")); got != 2 {
+				t.Fatalf("groups values = %d, want 2", got)
+			}
+
+			signature := response.SelectElement("Signature")
+			if tt.signResponse != (signature != nil) {
+				t.Fatalf("response signature present = %v, want %v", signature != nil, tt.signResponse)
+			}
+			if tt.signResponse {
+				if err := validate(idp.Certificate, response); err != nil {
+					t.Fatalf("response signature: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildResponseTamperedAssertion(t *testing.T) {
+	idp := newTestIdP(t)
+	encoded, err := idp.BuildResponse(testAssertion())
+	if err != nil {
+		t.Fatalf("BuildResponse: %v", err)
+	}
+	assertion := decodeResponse(t, encoded).FindElement("./Assertion")
+	assertion.FindElement("./Subject/NameID").SetText("admin@example.com")
+	if err := validate(idp.Certificate, assertion); err == nil {
+		t.Fatal("tampered assertion passed signature validation")
+	}
+
+	// 其他密钥签名的断言不能通过本 IdP 证书的校验
+	other := newTestIdP(t)
+	encoded, err = other.BuildResponse(testAssertion())
+	if err != nil {
+		t.Fatalf("BuildResponse: %v", err)
+	}
+	if err := validate(idp.Certificate, decodeResponse(t, encoded).FindElement("./Assertion")); err == nil {
+		t.Fatal("assertion signed by another key passed validation")
+	}
+}
+
+func TestBuildResponseValidityWindow(t *testing.T) {
+	idp := newTestIdP(t)
+	idp.AssertionTTL = 2 * time.Minute
+	before := time.Now().UTC()
+	encoded, err := idp.BuildResponse(testAssertion())
+	if err != nil {
+		t.Fatalf("BuildResponse: %v", err)
+	}
+	conditions := decodeResponse(t, encoded).FindElement("./Assertion/Conditions")
+	notBefore, err := time.Parse(samlTimeFormat, conditions.SelectAttrValue("NotBefore", ""))
+	if err != nil {
+		t.Fatalf("NotBefore: %v", err)
+	}
+	notOnOrAfter, err := time.Parse(samlTimeFormat, conditions.SelectAttrValue("NotOnOrAfter", ""))
+	if err != nil {
+		t.Fatalf("NotOnOrAfter: %v", err)
+	}
+	if notBefore.After(before) || notOnOrAfter.Sub(before) > idp.AssertionTTL+time.Second || notOnOrAfter.Sub(before) < idp.AssertionTTL-time.Second {
+		t.Fatalf("validity window %s - %s, issued at %s with ttl %s", notBefore, notOnOrAfter, before, idp.AssertionTTL)
+	}
+}
+
+func TestBuildErrorResponse(t *testing.T) {
+	idp := newTestIdP(t)
+	encoded, err := idp.BuildErrorResponse("_req1", "https://sp.example.com/acs", StatusNoPassive)
+	if err != nil {
+		t.Fatalf("BuildErrorResponse: %v", err)
+	}
+	response := decodeResponse(t, encoded)
+	if err := validate(idp.Certificate, response); err != nil {
+		t.Fatalf("response signature: %v", err)
+	}
+	status := response.FindElement("./Status/StatusCode")
+	if status.SelectAttrValue("Value", "") != StatusResponder || status.FindElement("./StatusCode").SelectAttrValue("Value", "") != StatusNoPassive {
+		t.Fatalf("unexpected status")
+	}
+	if response.FindElement("./Assertion") != nil {
+		t.Fatal("error response contains an assertion")
+	}
+}
+
+func TestBuildResponseWithoutKey(t *testing.T) {
+	idp := &IdentityProvider{EntityID: "https://idp.example.com"}
+	if _, err := idp.BuildResponse(testAssertion()); err == nil {
+		t.Fatal("expected error without signing key")
+	}
+}
@@ -0,0 +1,67 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ServiceProviderMetadata 从 SP 元数据中读取的对接信息
+type ServiceProviderMetadata struct {
+	EntityID string
+	// ACSURLs HTTP-POST 绑定的断言接收地址，默认地址（isDefault 或 index 最小）排在最前
+	ACSURLs []string
+}
+
+type entityDescriptorXML struct {
+	XMLName  xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:metadata EntityDescriptor"`
+	EntityID string   `xml:"entityID,attr"`
+	SP       *struct {
+		ACS []struct {
+			Binding   string `xml:"Binding,attr"`
+			Location  string `xml:"Location,attr"`
+			Index     int    `xml:"index,attr"`
+			IsDefault bool   `xml:"isDefault,attr"`
+		} `xml:"AssertionConsumerService"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:metadata SPSSODescriptor"`
+}
+
+// ParseServiceProviderMetadata 解析 SP 元数据（单个 EntityDescriptor），不校验元数据签名
+func ParseServiceProviderMetadata(data []byte) (*ServiceProviderMetadata, error) {
+	if bytes.Contains(data, []byte("<!DOCTYPE")) {
+		return nil, errors.New("saml metadata: DOCTYPE is not allowed")
+	}
+	var x entityDescriptorXML
+	if err := xml.Unmarshal(data, &x); err != nil {
+		return nil, fmt.Errorf("saml metadata: %w", err)
+	}
+	if strings.TrimSpace(x.EntityID) == "" {
+		return nil, errors.New("saml metadata: missing entityID")
+	}
+	if x.SP == nil {
+		return nil, errors.New("saml metadata: missing SPSSODescriptor")
+	}
+	acs := x.SP.ACS[:0]
+	for _, a := range x.SP.ACS {
+		if a.Binding == BindingHTTPPost && a.Location != "" {
+			acs = append(acs, a)
+		}
+	}
+	if len(acs) == 0 {
+		return nil, errors.New("saml metadata: no HTTP-POST AssertionConsumerService")
+	}
+	sort.SliceStable(acs, func(i, j int) bool {
+		if acs[i].IsDefault != acs[j].IsDefault {
+			return acs[i].IsDefault
+		}
+		return acs[i].Index < acs[j].Index
+	})
+	md := &ServiceProviderMetadata{EntityID: strings.TrimSpace(x.EntityID)}
+	for _, a := range acs {
+		md.ACSURLs = append(md.ACSURLs, a.Location)
+	}
+	return md, nil
+}
@@ -0,0 +1,117 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxRequestBytes AuthnRequest 解码（及解压）后的大小上限
+const maxRequestBytes = 64 << 10
+
+// ErrInvalidRequest AuthnRequest 无法解析或不符合规范；具体原因见包装的错误信息
+var ErrInvalidRequest = errors.New("invalid saml request")
+
+// AuthnRequest SP 发起登录时的 <samlp:AuthnRequest> 中用到的字段
+type AuthnRequest struct {
+	ID           string
+	Issuer       string
+	IssueInstant time.Time
+	Destination  string
+	// ACSURL SP 指定的断言接收地址，为空时使用注册的默认地址
+	ACSURL string
+	// ProtocolBinding SP 指定的响应绑定，仅支持 HTTP-POST
+	ProtocolBinding string
+	ForceAuthn      bool
+	IsPassive       bool
+	// NameIDFormat NameIDPolicy 中要求的格式，为空表示不限
+	NameIDFormat string
+}
+
+type authnRequestXML struct {
+	XMLName         xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:protocol AuthnRequest"`
+	ID              string   `xml:"ID,attr"`
+	Version         string   `xml:"Version,attr"`
+	IssueInstant    string   `xml:"IssueInstant,attr"`
+	Destination     string   `xml:"Destination,attr"`
+	ACSURL          string   `xml:"AssertionConsumerServiceURL,attr"`
+	ACSIndex        string   `xml:"AssertionConsumerServiceIndex,attr"`
+	ProtocolBinding string   `xml:"ProtocolBinding,attr"`
+	ForceAuthn      bool     `xml:"ForceAuthn,attr"`
+	IsPassive       bool     `xml:"IsPassive,attr"`
+	Issuer          string   `xml:"urn:oasis:names:tc:SAML:2.0:assertion Issuer"`
+	NameIDPolicy    *struct {
+		Format string `xml:"Format,attr"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:protocol NameIDPolicy"`
+}
+
+// ParseRedirectRequest 解析 HTTP-Redirect 绑定的 SAMLRequest 参数（base64 + DEFLATE）
+func ParseRedirectRequest(samlRequest string) (*AuthnRequest, error) {
+	raw, err := base64.StdEncoding.DecodeString(samlRequest)
+	if err != nil {
+		return nil, fmt.Errorf("%w: base64: %v", ErrInvalidRequest, err)
+	}
+	inflated, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(raw)), maxRequestBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: inflate: %v", ErrInvalidRequest, err)
+	}
+	return parseAuthnRequest(inflated)
+}
+
+// ParsePostRequest 解析 HTTP-POST 绑定的 SAMLRequest 表单字段（base64）
+func ParsePostRequest(samlRequest string) (*AuthnRequest, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(samlRequest))
+	if err != nil {
+		return nil, fmt.Errorf("%w: base64: %v", ErrInvalidRequest, err)
+	}
+	return parseAuthnRequest(raw)
+}
+
+func parseAuthnRequest(data []byte) (*AuthnRequest, error) {
+	if len(data) > maxRequestBytes {
+		return nil, fmt.Errorf("%w: request too large", ErrInvalidRequest)
+	}
+	// encoding/xml 不解析 DTD 与外部实体；拒绝 DOCTYPE 以免任何实体相关的歧义
+	if bytes.Contains(data, []byte("<!DOCTYPE")) {
+		return nil, fmt.Errorf("%w: DOCTYPE is not allowed", ErrInvalidRequest)
+	}
+	var x authnRequestXML
+	if err := xml.Unmarshal(data, &x); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	if x.Version != "2.0" {
+		return nil, fmt.Errorf("%w: unsupported version %q", ErrInvalidRequest, x.Version)
+	}
+	if x.ID == "" || strings.TrimSpace(x.Issuer) == "" {
+		return nil, fmt.Errorf("%w: missing ID or Issuer", ErrInvalidRequest)
+	}
+	if x.ProtocolBinding != "" && x.ProtocolBinding != BindingHTTPPost {
+		return nil, fmt.Errorf("%w: unsupported response binding %q", ErrInvalidRequest, x.ProtocolBinding)
+	}
+	req := &AuthnRequest{
+		ID:              x.ID,
+		Issuer:          strings.TrimSpace(x.Issuer),
+		Destination:     x.Destination,
+		ACSURL:          x.ACSURL,
+		ProtocolBinding: x.ProtocolBinding,
+		ForceAuthn:      x.ForceAuthn,
+		IsPassive:       x.IsPassive,
+	}
+	if x.NameIDPolicy != nil {
+		req.NameIDFormat = x.NameIDPolicy.Format
+	}
+	if x.IssueInstant != "" {
+		t, err := time.Parse(time.RFC3339, x.IssueInstant)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid IssueInstant", ErrInvalidRequest)
+		}
+		req.IssueInstant = t
+	}
+	return req, nil
+}
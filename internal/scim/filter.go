@@ -0,0 +1,192 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Comparison 过滤条件中的一项 attrPath eq value
+type Comparison struct {
+	// Attr 小写的属性路径（已去掉核心 schema 前缀），如 username、emails.value
+	Attr string
+	// Value 解码后的比较值：string、bool、float64 或 nil
+	Value any
+}
+
+// Filter 以 and 连接的比较条件；仅支持 eq，足以覆盖主流 IdP 的同步查询
+type Filter []Comparison
+
+// ParseFilter 解析过滤表达式，如 userName eq "alice" and active eq true；空字符串返回 nil
+func ParseFilter(s string) (Filter, error) {
+	p := &filterParser{s: s}
+	var f Filter
+	for {
+		p.skipSpace()
+		if p.done() {
+			if len(f) == 0 && strings.TrimSpace(s) != "" {
+				return nil, fmt.Errorf("%w: empty expression", ErrInvalidFilter)
+			}
+			return f, nil
+		}
+		if len(f) > 0 {
+			if word := p.word(); !strings.EqualFold(word, "and") {
+				return nil, fmt.Errorf("%w: unsupported logical operator %q", ErrInvalidFilter, word)
+			}
+		}
+		c, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		f = append(f, c)
+	}
+}
+
+// Match 判断 JSON 对象（如多值属性中的一项）是否满足全部条件，属性名不区分大小写
+func (f Filter) Match(obj map[string]any) bool {
+	for _, c := range f {
+		key, ok := lookupKey(obj, c.Attr)
+		if !ok || !valuesEqual(obj[key], c.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// String 以规范形式输出，便于日志
+func (c Comparison) String() string {
+	v, _ := json.Marshal(c.Value)
+	return c.Attr + " eq " + string(v)
+}
+
+type filterParser struct {
+	s   string
+	pos int
+}
+
+func (p *filterParser) done() bool { return p.pos >= len(p.s) }
+
+func (p *filterParser) skipSpace() {
+	for !p.done() && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// word 读取到下一个空格为止
+func (p *filterParser) word() string {
+	p.skipSpace()
+	start := p.pos
+	for !p.done() && p.s[p.pos] != ' ' {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *filterParser) comparison() (Comparison, error) {
+	attr := p.word()
+	if attr == "" || strings.ContainsAny(attr, "()[]\"") {
+		return Comparison{}, fmt.Errorf("%w: invalid attribute %q", ErrInvalidFilter, attr)
+	}
+	op := p.word()
+	if !strings.EqualFold(op, "eq") {
+		return Comparison{}, fmt.Errorf("%w: unsupported operator %q", ErrInvalidFilter, op)
+	}
+	p.skipSpace()
+	raw, err := p.value()
+	if err != nil {
+		return Comparison{}, err
+	}
+	var v any
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return Comparison{}, fmt.Errorf("%w: invalid value %s", ErrInvalidFilter, raw)
+	}
+	if _, ok := v.(map[string]any); ok {
+		return Comparison{}, fmt.Errorf("%w: invalid value %s", ErrInvalidFilter, raw)
+	}
+	if _, ok := v.([]any); ok {
+		return Comparison{}, fmt.Errorf("%w: invalid value %s", ErrInvalidFilter, raw)
+	}
+	return Comparison{Attr: normalizeAttr(attr), Value: v}, nil
+}
+
+// value 读取 JSON 字面量：带引号的字符串（支持转义）或 true / false / null / 数字
+func (p *filterParser) value() (string, error) {
+	if p.done() {
+		return "", fmt.Errorf("%w: missing value", ErrInvalidFilter)
+	}
+	if p.s[p.pos] != '"' {
+		return p.word(), nil
+	}
+	start := p.pos
+	for i := p.pos + 1; i < len(p.s); i++ {
+		switch p.s[i] {
+		case '\\':
+			i++
+		case '"':
+			p.pos = i + 1
+			return p.s[start:p.pos], nil
+		}
+	}
+	return "", fmt.Errorf("%w: unterminated string", ErrInvalidFilter)
+}
+
+// normalizeAttr 去掉核心 schema 前缀并转为小写
+func normalizeAttr(attr string) string {
+	lower := strings.ToLower(attr)
+	for _, schema := range []string{SchemaUser, SchemaGroup} {
+		prefix := strings.ToLower(schema) + ":"
+		if strings.HasPrefix(lower, prefix) {
+			return strings.TrimPrefix(lower, prefix)
+		}
+	}
+	return lower
+}
+
+// lookupKey 不区分大小写地查找对象中的属性名
+func lookupKey(obj map[string]any, name string) (string, bool) {
+	if _, ok := obj[name]; ok {
+		return name, true
+	}
+	for k := range obj {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return name, false
+}
+
+// valuesEqual 比较 JSON 值；字符串不区分大小写，布尔值允许以字符串形式出现（部分 IdP 发送 "True"）
+func valuesEqual(a, b any) bool {
+	if ab, ok := asBool(a); ok {
+		if bb, ok := asBool(b); ok {
+			return ab == bb
+		}
+	}
+	switch av := a.(type) {
+	case string:
+		bv, ok := b.(string)
+		return ok && strings.EqualFold(av, bv)
+	case float64:
+		bv, ok := b.(float64)
+		return ok && av == bv
+	case nil:
+		return b == nil
+	default:
+		return false
+	}
+}
+
+func asBool(v any) (bool, bool) {
+	switch b := v.(type) {
+	case bool:
+		return b, true
+	case string:
+		switch strings.ToLower(b) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,94 @@
+package scim
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		want   Filter
+	}{
+		{"empty", "", nil},
+		{"blank", "   ", nil},
+		{"string", `userName eq "alice"`, Filter{{Attr: "username", Value: "alice"}}},
+		{"operator case insensitive", `userName EQ "alice"`, Filter{{Attr: "username", Value: "alice"}}},
+		{"schema prefix", `urn:ietf:params:scim:schemas:core:2.0:User:userName eq "alice"`, Filter{{Attr: "username", Value: "alice"}}},
+		{"and chain", `userName eq "alice" and active eq true AND externalId eq "42"`, Filter{
+			{Attr: "username", Value: "alice"},
+			{Attr: "active", Value: true},
+			{Attr: "externalid", Value: "42"},
+		}},
+		{"sub attribute", `emails.value eq "a@example.com"`, Filter{{Attr: "emails.value", Value: "a@example.com"}}},
+		{"space inside string", `displayName eq "Alice Smith"`, Filter{{Attr: "displayname", Value: "Alice Smith"}}},
+		{"escaped quote", `displayName eq "say \"hi\""`, Filter{{Attr: "displayname", Value: `say "hi"`}}},
+		{"escaped backslash before closing quote", `displayName eq "C:\\"`, Filter{{Attr: "displayname", Value: `C:\`}}},
+		{"unicode escape", `displayName eq "\u5f20\u4e09"`, Filter{{Attr: "displayname", Value: "张三"}}},
+		{"number and null", `x eq 1.5 and y eq null`, Filter{{Attr: "x", Value: 1.5}, {Attr: "y", Value: nil}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilter(tt.filter)
+			if err != nil {
+				t.Fatalf("ParseFilter(%s): %v", tt.filter, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+	}{
+		{"unterminated string", `userName eq "alice`},
+		{"unterminated after escape", `userName eq "alice\"`},
+		{"unsupported operator", `userName co "ali"`},
+		{"presence operator", `title pr`},
+		{"or", `userName eq "alice" or userName eq "bob"`},
+		{"not", `not userName eq "alice"`},
+		{"missing value", `userName eq`},
+		{"dangling and", `userName eq "alice" and`},
+		{"grouping", `(userName eq "alice")`},
+		{"value filter", `emails[type eq "work"] eq "x"`},
+		{"bare word value", `userName eq alice`},
+		{"object value", `userName eq {}`},
+		{"array value", `userName eq []`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if f, err := ParseFilter(tt.filter); !errors.Is(err, ErrInvalidFilter) {
+				t.Fatalf("got %v, %v; want ErrInvalidFilter", f, err)
+			}
+		})
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	f, err := ParseFilter(`type eq "Work" and primary eq true`)
+	if err != nil {
+		t.Fatalf("ParseFilter: %v", err)
+	}
+	tests := []struct {
+		obj  map[string]any
+		want bool
+	}{
+		{map[string]any{"type": "work", "primary": true}, true},
+		// 属性名与字符串值不区分大小写，布尔值允许字符串形式
+		{map[string]any{"Type": "WORK", "Primary": "True"}, true},
+		{map[string]any{"type": "home", "primary": true}, false},
+		{map[string]any{"type": "work"}, false},
+		{map[string]any{"type": "work", "primary": false}, false},
+	}
+	for _, tt := range tests {
+		if got := f.Match(tt.obj); got != tt.want {
+			t.Errorf("Match(%v) = %v, want %v", tt.obj, got, tt.want)
+		}
+	}
+}
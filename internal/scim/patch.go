@@ -0,0 +1,325 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// PatchOperation PATCH 请求中的单个操作
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyPatch 按顺序将操作应用到资源（*User 或 *Group）上：资源先转为 JSON 对象，逐个操作修改后再解码回资源。
+// 属性名不区分大小写；扩展 schema 与资源未定义的属性被忽略；路径支持 attr、attr.sub、attr[filter]、attr[filter].sub
+func ApplyPatch(resource any, ops []PatchOperation) error {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	for _, op := range ops {
+		var value any
+		if len(op.Value) > 0 {
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return fmt.Errorf("%w: invalid operation value", ErrInvalidSyntax)
+			}
+		}
+		name := strings.ToLower(op.Op)
+		switch name {
+		case "add", "replace", "remove":
+		default:
+			return fmt.Errorf("%w: unsupported operation %q", ErrInvalidSyntax, op.Op)
+		}
+		if err := applyOperation(doc, name, op.Path, value); err != nil {
+			return err
+		}
+	}
+	normalizeBooleans(doc)
+	if data, err = json.Marshal(doc); err != nil {
+		return err
+	}
+	// 先清零，使被移除的属性不保留旧值
+	v := reflect.ValueOf(resource).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	if err := json.Unmarshal(data, resource); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	return nil
+}
+
+// patchPath 解析后的属性路径
+type patchPath struct {
+	attr   string
+	filter Filter
+	sub    string
+}
+
+// parsePath 解析路径；扩展 schema 中的属性返回 nil（忽略）
+func parsePath(path string) (*patchPath, error) {
+	path = strings.TrimSpace(path)
+	lower := strings.ToLower(path)
+	for _, schema := range []string{SchemaUser, SchemaGroup} {
+		if prefix := strings.ToLower(schema) + ":"; strings.HasPrefix(lower, prefix) {
+			path = path[len(prefix):]
+			lower = lower[len(prefix):]
+			break
+		}
+	}
+	if strings.HasPrefix(lower, "urn:") {
+		return nil, nil
+	}
+	p := &patchPath{}
+	rest := ""
+	if open := strings.IndexByte(path, '['); open >= 0 {
+		end := strings.LastIndexByte(path, ']')
+		if end < open {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPath, path)
+		}
+		f, err := ParseFilter(path[open+1 : end])
+		if err != nil || len(f) == 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPath, path)
+		}
+		p.attr, p.filter, rest = path[:open], f, path[end+1:]
+		if rest != "" && !strings.HasPrefix(rest, ".") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPath, path)
+		}
+		rest = strings.TrimPrefix(rest, ".")
+	} else if dot := strings.IndexByte(path, '.'); dot >= 0 {
+		p.attr, rest = path[:dot], path[dot+1:]
+	} else {
+		p.attr = path
+	}
+	p.sub = rest
+	if p.attr == "" || strings.ContainsAny(p.sub, ".[]") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPath, path)
+	}
+	return p, nil
+}
+
+func applyOperation(doc map[string]any, op, path string, value any) error {
+	if path == "" {
+		if op == "remove" {
+			return fmt.Errorf("%w: remove requires a path", ErrNoTarget)
+		}
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%w: operation without path requires an object value", ErrInvalidValue)
+		}
+		// 部分 IdP 在无路径操作中使用 name.givenName 这样的键，按路径处理
+		for k, v := range obj {
+			if err := applyOperation(doc, op, k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	p, err := parsePath(path)
+	if err != nil || p == nil {
+		return err
+	}
+	key, exists := lookupKey(doc, p.attr)
+	current := doc[key]
+
+	if p.filter != nil {
+		return applyFiltered(doc, key, current, op, p, value)
+	}
+	if p.sub != "" {
+		if !exists || current == nil {
+			if op == "remove" {
+				return nil
+			}
+			current = map[string]any{}
+		}
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%w: %q is not a complex attribute", ErrInvalidPath, p.attr)
+		}
+		subKey, _ := lookupKey(obj, p.sub)
+		if op == "remove" {
+			delete(obj, subKey)
+		} else {
+			obj[subKey] = value
+		}
+		doc[key] = obj
+		return nil
+	}
+
+	switch op {
+	case "remove":
+		list, isList := current.([]any)
+		if isList && value != nil {
+			// 带 value 的 remove：只移除列出的元素（按 value 子属性比较），用于移除组成员
+			doc[key] = removeItems(list, asList(value))
+			return nil
+		}
+		delete(doc, key)
+	case "add":
+		if list, ok := current.([]any); ok {
+			doc[key] = appendItems(list, asList(value))
+			return nil
+		}
+		if merged, ok := mergeObjects(current, value); ok {
+			doc[key] = merged
+			return nil
+		}
+		doc[key] = value
+	case "replace":
+		if merged, ok := mergeObjects(current, value); ok {
+			doc[key] = merged
+			return nil
+		}
+		doc[key] = value
+	}
+	return nil
+}
+
+// applyFiltered 处理 attr[filter] 与 attr[filter].sub
+func applyFiltered(doc map[string]any, key string, current any, op string, p *patchPath, value any) error {
+	var list []any
+	if current != nil {
+		l, ok := current.([]any)
+		if !ok {
+			return fmt.Errorf("%w: %q is not multi-valued", ErrInvalidPath, p.attr)
+		}
+		list = l
+	}
+	var out []any
+	matched := false
+	for _, item := range list {
+		obj, ok := item.(map[string]any)
+		if !ok || !p.filter.Match(obj) {
+			out = append(out, item)
+			continue
+		}
+		matched = true
+		switch {
+		case op == "remove" && p.sub == "":
+			continue
+		case op == "remove":
+			subKey, _ := lookupKey(obj, p.sub)
+			delete(obj, subKey)
+		case p.sub != "":
+			subKey, _ := lookupKey(obj, p.sub)
+			obj[subKey] = value
+		default:
+			merged, ok := mergeObjects(obj, value)
+			if !ok {
+				return fmt.Errorf("%w: filtered replace requires an object value", ErrInvalidValue)
+			}
+			item = merged
+		}
+		out = append(out, item)
+	}
+	if !matched && op != "remove" {
+		if p.sub == "" {
+			return fmt.Errorf("%w: no values match the filter", ErrNoTarget)
+		}
+		// 如 emails[type eq "work"].value 且尚无 work 邮箱：按过滤条件新建一项
+		obj := map[string]any{p.sub: value}
+		for _, c := range p.filter {
+			if !strings.Contains(c.Attr, ".") {
+				obj[c.Attr] = c.Value
+			}
+		}
+		out = append(out, obj)
+	}
+	if out == nil {
+		delete(doc, key)
+		return nil
+	}
+	doc[key] = out
+	return nil
+}
+
+func asList(v any) []any {
+	if l, ok := v.([]any); ok {
+		return l
+	}
+	if v == nil {
+		return nil
+	}
+	return []any{v}
+}
+
+// appendItems 追加元素，value 子属性相同的元素视为重复
+func appendItems(list, items []any) []any {
+	for _, item := range items {
+		if !containsItem(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func removeItems(list, items []any) []any {
+	out := list[:0]
+	for _, item := range list {
+		if !containsItem(items, item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func containsItem(list []any, item any) bool {
+	for _, v := range list {
+		if itemValue(v) != nil && valuesEqual(itemValue(v), itemValue(item)) {
+			return true
+		}
+	}
+	return false
+}
+
+func itemValue(v any) any {
+	if obj, ok := v.(map[string]any); ok {
+		key, _ := lookupKey(obj, "value")
+		return obj[key]
+	}
+	return v
+}
+
+// mergeObjects 两者均为对象时将 value 的属性合并到 current
+func mergeObjects(current, value any) (map[string]any, bool) {
+	cur, ok1 := current.(map[string]any)
+	val, ok2 := value.(map[string]any)
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	for k, v := range val {
+		key, _ := lookupKey(cur, k)
+		cur[key] = v
+	}
+	return cur, true
+}
+
+// normalizeBooleans 部分 IdP 以字符串 "True" / "False" 发送布尔属性，解码前转换
+func normalizeBooleans(doc map[string]any) {
+	if key, ok := lookupKey(doc, "active"); ok {
+		if b, ok := asBool(doc[key]); ok {
+			doc[key] = b
+		}
+	}
+	for _, v := range doc {
+		list, ok := v.([]any)
+		if !ok {
+			continue
+		}
+		for _, item := range list {
+			if obj, ok := item.(map[string]any); ok {
+				if key, ok := lookupKey(obj, "primary"); ok {
+					if b, ok := asBool(obj[key]); ok {
+						obj[key] = b
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func newPatchUser() *User {
+	active := true
+	return &User{
+		Schemas:  []string{SchemaUser},
+		UserName: "alice",
+		Name:     &Name{GivenName: "Alice", FamilyName: "Smith"},
+		Emails: []MultiValue{
+			{Value: "alice@example.com", Type: "work", Primary: true},
+			{Value: "alice@home.example", Type: "home"},
+		},
+		Active: &active,
+	}
+}
+
+func op(name, path, value string) PatchOperation {
+	o := PatchOperation{Op: name, Path: path}
+	if value != "" {
+		o.Value = json.RawMessage(value)
+	}
+	return o
+}
+
+func TestApplyPatchUser(t *testing.T) {
+	tests := []struct {
+		name  string
+		ops   []PatchOperation
+		check func(t *testing.T, u *User)
+	}{
+		{"replace value path sub attribute", []PatchOperation{op("replace", `emails[type eq "work"].value`, `"alice@corp.example"`)}, func(t *testing.T, u *User) {
+			want := []MultiValue{{Value: "alice@corp.example", Type: "work", Primary: true}, {Value: "alice@home.example", Type: "home"}}
+			if !reflect.DeepEqual(u.Emails, want) {
+				t.Fatalf("emails = %+v", u.Emails)
+			}
+		}},
+		{"value path filter is case insensitive", []PatchOperation{op("Replace", `Emails[Type EQ "WORK"].Value`, `"alice@corp.example"`)}, func(t *testing.T, u *User) {
+			if u.Emails[0].Value != "alice@corp.example" {
+				t.Fatalf("emails = %+v", u.Emails)
+			}
+		}},
+		{"add value path creates missing item", []PatchOperation{op("add", `phoneNumbers[type eq "mobile"].value`, `"+8613800000000"`)}, func(t *testing.T, u *User) {
+			want := []MultiValue{{Value: "+8613800000000", Type: "mobile"}}
+			if !reflect.DeepEqual(u.PhoneNumbers, want) {
+				t.Fatalf("phoneNumbers = %+v", u.PhoneNumbers)
+			}
+		}},
+		{"replace value path whole item", []PatchOperation{op("replace", `emails[type eq "home"]`, `{"value":"a@new.example","primary":"False"}`)}, func(t *testing.T, u *User) {
+			if got := u.Emails[1]; got.Value != "a@new.example" || got.Type != "home" || got.Primary {
+				t.Fatalf("emails[1] = %+v", got)
+			}
+		}},
+		{"remove value path item", []PatchOperation{op("remove", `emails[type eq "home"]`, "")}, func(t *testing.T, u *User) {
+			if len(u.Emails) != 1 || u.Emails[0].Type != "work" {
+				t.Fatalf("emails = %+v", u.Emails)
+			}
+		}},
+		{"remove value path sub attribute", []PatchOperation{op("remove", `emails[type eq "work"].primary`, "")}, func(t *testing.T, u *User) {
+			if u.Emails[0].Primary || u.Emails[0].Value != "alice@example.com" {
+				t.Fatalf("emails = %+v", u.Emails)
+			}
+		}},
+		{"remove unmatched value path is a no-op", []PatchOperation{op("remove", `emails[type eq "other"]`, "")}, func(t *testing.T, u *User) {
+			if len(u.Emails) != 2 {
+				t.Fatalf("emails = %+v", u.Emails)
+			}
+		}},
+		{"replace sub attribute", []PatchOperation{op("replace", "name.givenName", `"Alicia"`)}, func(t *testing.T, u *User) {
+			if u.Name.GivenName != "Alicia" || u.Name.FamilyName != "Smith" {
+				t.Fatalf("name = %+v", u.Name)
+			}
+		}},
+		{"no path with dotted keys and string boolean", []PatchOperation{op("replace", "", `{"name.familyName":"Jones","active":"False"}`)}, func(t *testing.T, u *User) {
+			if u.Name.FamilyName != "Jones" || u.Active == nil || *u.Active {
+				t.Fatalf("name = %+v active = %v", u.Name, u.Active)
+			}
+		}},
+		{"remove attribute", []PatchOperation{op("remove", "name", "")}, func(t *testing.T, u *User) {
+			if u.Name != nil {
+				t.Fatalf("name = %+v", u.Name)
+			}
+		}},
+		{"extension attributes ignored", []PatchOperation{op("replace", "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department", `"R&D"`)}, func(t *testing.T, u *User) {
+			if u.UserName != "alice" {
+				t.Fatalf("user = %+v", u)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newPatchUser()
+			if err := ApplyPatch(u, tt.ops); err != nil {
+				t.Fatalf("ApplyPatch: %v", err)
+			}
+			tt.check(t, u)
+		})
+	}
+}
+
+func TestApplyPatchErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      PatchOperation
+		wantErr error
+	}{
+		{"unsupported op", op("move", "userName", `"bob"`), ErrInvalidSyntax},
+		{"invalid value json", PatchOperation{Op: "replace", Path: "userName", Value: json.RawMessage(`{`)}, ErrInvalidSyntax},
+		{"remove without path", op("remove", "", ""), ErrNoTarget},
+		{"no path with non-object value", op("replace", "", `"bob"`), ErrInvalidValue},
+		{"unterminated filter", op("replace", `emails[type eq "work].value`, `"x"`), ErrInvalidPath},
+		{"unsupported filter operator", op("replace", `emails[type sw "w"].value`, `"x"`), ErrInvalidPath},
+		{"missing closing bracket", op("replace", `emails[type eq "work".value`, `"x"`), ErrInvalidPath},
+		{"text after filter", op("replace", `emails[type eq "work"]value`, `"x"`), ErrInvalidPath},
+		{"nested sub attribute", op("replace", "name.givenName.first", `"x"`), ErrInvalidPath},
+		{"filter on single-valued attribute", op("replace", `userName[value eq "alice"].value`, `"x"`), ErrInvalidPath},
+		{"sub attribute of simple attribute", op("replace", "userName.first", `"x"`), ErrInvalidPath},
+		{"replace unmatched item without sub attribute", op("replace", `emails[type eq "other"]`, `{"value":"x"}`), ErrNoTarget},
+		{"filtered replace with non-object value", op("replace", `emails[type eq "work"]`, `"x"`), ErrInvalidValue},
+		{"wrong value type", op("replace", "userName", `42`), ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newPatchUser()
+			if err := ApplyPatch(u, []PatchOperation{tt.op}); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyPatchGroupMembers(t *testing.T) {
+	g := &Group{Schemas: []string{SchemaGroup}, DisplayName: "eng", Members: []Reference{{Value: "1"}, {Value: "2"}}}
+	ops := []PatchOperation{
+		// 重复成员不会重复添加
+		op("add", "members", `[{"value":"2"},{"value":"3"}]`),
+		// 带 value 的 remove 只移除列出的成员
+		op("remove", "members", `[{"value":"1"}]`),
+		op("remove", `members[value eq "3"]`, ""),
+	}
+	if err := ApplyPatch(g, ops); err != nil {
+		t.Fatalf("ApplyPatch: %v", err)
+	}
+	if want := []Reference{{Value: "2"}}; !reflect.DeepEqual(g.Members, want) {
+		t.Fatalf("members = %+v, want %+v", g.Members, want)
+	}
+}
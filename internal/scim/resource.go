@@ -0,0 +1,165 @@
+// Package scim 实现 SCIM 2.0（RFC 7643 / 7644）资源表示、过滤表达式与 PATCH 操作，供用户与组的自动化同步使用
+package scim
+
+import (
+	"errors"
+	"time"
+)
+
+// 资源与消息的 schema URN
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// MediaType SCIM 请求与响应的内容类型
+const MediaType = "application/scim+json"
+
+// 请求错误，对应 RFC 7644 §3.12 的 scimType
+var (
+	ErrInvalidFilter = errors.New("scim: invalid filter")
+	ErrInvalidPath   = errors.New("scim: invalid path")
+	ErrInvalidValue  = errors.New("scim: invalid value")
+	ErrInvalidSyntax = errors.New("scim: invalid syntax")
+	ErrNoTarget      = errors.New("scim: no target")
+)
+
+// Meta 资源元数据
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location,omitempty"`
+}
+
+// Name 用户姓名
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+}
+
+// MultiValue 多值属性（emails、phoneNumbers）中的一项
+type MultiValue struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+// Reference 指向其他资源的引用（用户的 groups、组的 members）
+type Reference struct {
+	Value   string `json:"value"`
+	Ref     string `json:"$ref,omitempty"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+}
+
+// User 用户资源；未列出的属性（含企业扩展）读取时忽略
+type User struct {
+	Schemas      []string     `json:"schemas"`
+	ID           string       `json:"id,omitempty"`
+	ExternalID   string       `json:"externalId,omitempty"`
+	UserName     string       `json:"userName"`
+	Name         *Name        `json:"name,omitempty"`
+	DisplayName  string       `json:"displayName,omitempty"`
+	Emails       []MultiValue `json:"emails,omitempty"`
+	PhoneNumbers []MultiValue `json:"phoneNumbers,omitempty"`
+	// Active nil 表示请求未携带（不修改状态）
+	Active *bool `json:"active,omitempty"`
+	// Password 只写属性，响应中始终为空
+	Password string      `json:"password,omitempty"`
+	Groups   []Reference `json:"groups,omitempty"`
+	Meta     *Meta       `json:"meta,omitempty"`
+}
+
+// PrimaryEmail 返回 primary 邮箱，没有时返回第一个
+func (u *User) PrimaryEmail() string {
+	return primaryValue(u.Emails)
+}
+
+// PrimaryPhoneNumber 返回 primary 手机号，没有时返回第一个
+func (u *User) PrimaryPhoneNumber() string {
+	return primaryValue(u.PhoneNumbers)
+}
+
+func primaryValue(values []MultiValue) string {
+	for _, v := range values {
+		if v.Primary {
+			return v.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+// Group 组资源
+type Group struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	ExternalID  string      `json:"externalId,omitempty"`
+	DisplayName string      `json:"displayName"`
+	Members     []Reference `json:"members,omitempty"`
+	Meta        *Meta       `json:"meta,omitempty"`
+}
+
+// ListResponse 查询结果，StartIndex 从 1 开始
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int64    `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+// NewListResponse 构造查询结果
+func NewListResponse(total int64, startIndex int, resources []any) *ListResponse {
+	if resources == nil {
+		resources = []any{}
+	}
+	return &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+// PatchRequest PATCH 请求体
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// Error 错误响应体；Status 按规范为字符串
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// ErrorType 返回请求错误对应的 scimType，非请求错误返回空字符串
+func ErrorType(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidFilter):
+		return "invalidFilter"
+	case errors.Is(err, ErrInvalidPath):
+		return "invalidPath"
+	case errors.Is(err, ErrInvalidValue):
+		return "invalidValue"
+	case errors.Is(err, ErrInvalidSyntax):
+		return "invalidSyntax"
+	case errors.Is(err, ErrNoTarget):
+		return "noTarget"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,92 @@
+// Package sms 提供短信发送抽象：生产环境接入短信服务商时实现 Sender，文件/日志实现用于本地开发与测试。
+package sms
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message 一条纯文本短信
+type Message struct {
+	To   string
+	Body string
+}
+
+// Sender 短信发送接口
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// validateRecipient 防止收件号码中携带换行等控制字符
+func validateRecipient(to string) error {
+	if to == "" || strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid sms recipient %q", to)
+	}
+	return nil
+}
+
+// FileSender 将短信写入本地目录（每条一个 .txt 文件），用于本地开发与测试
+type FileSender struct {
+	dir string
+}
+
+// NewFileSender 创建文件短信发送器，目录不存在时自动创建
+func NewFileSender(dir string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create sms dir: %w", err)
+	}
+	return &FileSender{dir: dir}, nil
+}
+
+func (s *FileSender) Send(ctx context.Context, msg Message) error {
+	if err := validateRecipient(msg.To); err != nil {
+		return err
+	}
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s_%s.txt", time.Now().Format("20060102T150405.000"), hex.EncodeToString(b))
+	content := fmt.Sprintf("To: %s\nDate: %s\n\n%s\n", msg.To, time.Now().Format(time.RFC1123Z), msg.Body)
+	if err := os.WriteFile(filepath.Join(s.dir, name), []byte(content), 0644); err != nil {
+		return fmt.Errorf("write sms file: %w", err)
+	}
+	return nil
+}
+
+// LogSender 仅将短信内容打印到日志，并保留在内存中供测试读取
+type LogSender struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+// NewLogSender 创建日志短信发送器
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	if err := validateRecipient(msg.To); err != nil {
+		return err
+	}
+	log.Printf("[SMS] to=%s\n%s", msg.To, msg.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+// Sent 返回已发送短信的副本
+func (s *LogSender) Sent() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.sent...)
+}
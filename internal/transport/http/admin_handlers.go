@@ -0,0 +1,65 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"monai-auth/internal/domain"
+)
+
+// requireAdmin 校验当前用户为管理员，否则写入 401/403 错误响应
+func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request) (*domain.User, bool) {
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return nil, false
+	}
+	if user.Role != domain.RoleAdmin {
+		writeError(w, "FORBIDDEN", "Admin role required", http.StatusForbidden,
+			fmt.Sprintf("admin access denied user_id=%d path=%s", user.ID, r.URL.Path))
+		return nil, false
+	}
+	return user, true
+}
+
+// pathUserID 解析路由中的 {id} 用户 ID
+func pathUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, "INVALID_REQUEST", "Invalid user id", http.StatusBadRequest, "")
+		return 0, false
+	}
+	return id, true
+}
+
+// AdminUnlockUserHandler 管理员解除账号的登录失败锁定
+// POST /api/v1/admin/users/{id}/unlock
+func (h *Handler) AdminUnlockUserHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	userID, ok := pathUserID(w, r)
+	if !ok {
+		return
+	}
+	err := h.AuthService.UnlockUser(r.Context(), userID)
+	h.audit(r, domain.AuditEvent{Type: domain.AuditAdminUnlockUser, Outcome: auditOutcome(err), UserID: userID, ActorID: admin.ID, Reason: auditReason(err)})
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			writeError(w, "USER_NOT_FOUND", "User not found", http.StatusNotFound, "")
+			return
+		}
+		writeError(w, "INTERNAL_ERROR", "Unlock failed", http.StatusInternalServerError,
+			fmt.Sprintf("admin unlock failed admin_id=%d user_id=%d err=%v", admin.ID, userID, err))
+		return
+	}
+	log.Printf("[AUTH] admin unlock admin_id=%d user_id=%d", admin.ID, userID)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
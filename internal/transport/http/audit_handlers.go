@@ -0,0 +1,274 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"monai-auth/internal/auth"
+	"monai-auth/internal/domain"
+	"monai-auth/internal/scim"
+)
+
+// AuditEventResponse 审计事件
+type AuditEventResponse struct {
+	ID        int64  `json:"id"`
+	Type      string `json:"type"`
+	Outcome   string `json:"outcome"`
+	UserID    int64  `json:"user_id,omitempty"`
+	ActorID   int64  `json:"actor_id,omitempty"`
+	Email     string `json:"email,omitempty"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	ClientID  string `json:"client_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// AuditEventListResponse 审计事件分页列表；next_before_id 非 0 时可作为 before_id 继续查询下一页
+type AuditEventListResponse struct {
+	Events       []AuditEventResponse `json:"events"`
+	NextBeforeID int64                `json:"next_before_id,omitempty"`
+}
+
+func newAuditEventResponse(e *domain.AuditEvent) AuditEventResponse {
+	return AuditEventResponse{
+		ID:        e.ID,
+		Type:      e.Type,
+		Outcome:   e.Outcome,
+		UserID:    e.UserID,
+		ActorID:   e.ActorID,
+		Email:     e.Email,
+		IP:        e.IP,
+		UserAgent: e.UserAgent,
+		ClientID:  e.ClientID,
+		Reason:    e.Reason,
+		CreatedAt: e.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// audit 补全请求来源（IP、User-Agent）后记录审计事件；未启用审计时忽略
+func (h *Handler) audit(r *http.Request, event domain.AuditEvent) {
+	if h.AuditService == nil {
+		return
+	}
+	event.IP = ClientIP(r, h.TrustProxyHeaders)
+	event.UserAgent = r.UserAgent()
+	h.AuditService.Record(r.Context(), &event)
+}
+
+// auditOutcome 根据 err 返回审计结果
+func auditOutcome(err error) string {
+	if err != nil {
+		return domain.AuditOutcomeFailure
+	}
+	return domain.AuditOutcomeSuccess
+}
+
+// auditReason 将业务错误映射为审计记录中的失败原因（与错误码对应的小写形式）
+func auditReason(err error) string {
+	var locked *domain.AccountLockedError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		return "invalid_credentials"
+	case errors.Is(err, domain.ErrEmailNotVerified):
+		return "email_not_verified"
+	case errors.As(err, &locked):
+		return "account_locked"
+	case errors.Is(err, domain.ErrIncorrectPassword):
+		return "incorrect_password"
+	case errors.Is(err, domain.ErrPasswordUnchanged):
+		return "password_unchanged"
+	case domain.IsPasswordPolicyError(err):
+		return "password_policy"
+	case errors.Is(err, domain.ErrInvalidResetToken):
+		return "invalid_reset_token"
+	case errors.Is(err, domain.ErrEmailExists):
+		return "email_exists"
+	case errors.Is(err, domain.ErrUserExists):
+		return "username_exists"
+	case errors.Is(err, domain.ErrInvalidEmail):
+		return "invalid_email"
+	case errors.Is(err, domain.ErrInvalidUsername):
+		return "invalid_username"
+	case errors.Is(err, domain.ErrInvalidMFACode):
+		return "invalid_mfa_code"
+	case errors.Is(err, domain.ErrInvalidMFAToken):
+		return "invalid_mfa_token"
+	case errors.Is(err, domain.ErrInvalidWebAuthnSession):
+		return "invalid_webauthn_session"
+	case errors.Is(err, domain.ErrWebAuthnVerification):
+		return "webauthn_verification_failed"
+	case errors.Is(err, domain.ErrInvalidMagicLink):
+		return "invalid_magic_link"
+	case errors.Is(err, domain.ErrInvalidOTP):
+		return "invalid_otp"
+	case errors.Is(err, domain.ErrInvalidPhone):
+		return "invalid_phone"
+	case errors.Is(err, domain.ErrPhoneExists):
+		return "phone_exists"
+	case errors.Is(err, domain.ErrInvalidFederationState):
+		return "invalid_federation_state"
+	case errors.Is(err, domain.ErrInvalidFederationTicket):
+		return "invalid_federation_ticket"
+	case errors.Is(err, domain.ErrFederationFailed):
+		return "federation_failed"
+	case errors.Is(err, domain.ErrFederatedEmailUnverified):
+		return "federated_email_unverified"
+	case errors.Is(err, domain.ErrFederatedAccountNotFound):
+		return "federated_account_not_found"
+	case errors.Is(err, domain.ErrIdentityExists):
+		return "identity_exists"
+	case errors.Is(err, domain.ErrIdentityNotFound):
+		return "identity_not_found"
+	case errors.Is(err, domain.ErrDirectoryUnavailable):
+		return "directory_unavailable"
+	case errors.Is(err, domain.ErrUnknownServiceProvider):
+		return "unknown_service_provider"
+	case errors.Is(err, domain.ErrInvalidSAMLRequest):
+		return "invalid_saml_request"
+	case errors.Is(err, domain.ErrInvalidSAMLState):
+		return "invalid_saml_state"
+	case errors.Is(err, domain.ErrUserNotFound):
+		return "user_not_found"
+	case errors.Is(err, domain.ErrInvalidProfile):
+		return "invalid_profile"
+	case errors.Is(err, domain.ErrGroupNotFound):
+		return "group_not_found"
+	case errors.Is(err, domain.ErrGroupExists):
+		return "group_exists"
+	case errors.Is(err, domain.ErrWebhookDeliveryNotFound):
+		return "webhook_delivery_not_found"
+	case scim.ErrorType(err) != "":
+		return "invalid_scim_request"
+	default:
+		return "internal_error"
+	}
+}
+
+// requireAuditService 审计未启用时写入错误响应
+func (h *Handler) requireAuditService(w http.ResponseWriter) bool {
+	if h.AuditService == nil {
+		writeError(w, "INTERNAL_ERROR", "Audit log not configured", http.StatusInternalServerError, "")
+		return false
+	}
+	return true
+}
+
+// parsePage 解析分页参数 limit（缺省为 defaultLimit，不超过 auth.MaxAuditPageSize）、before_id
+func parsePage(w http.ResponseWriter, r *http.Request, defaultLimit int) (limit int, beforeID int64, ok bool) {
+	q := r.URL.Query()
+	limit = defaultLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, "INVALID_REQUEST", "Invalid limit", http.StatusBadRequest, "")
+			return 0, 0, false
+		}
+		limit = min(n, auth.MaxAuditPageSize)
+	}
+	if v := q.Get("before_id"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			writeError(w, "INVALID_REQUEST", "Invalid before_id", http.StatusBadRequest, "")
+			return 0, 0, false
+		}
+		beforeID = n
+	}
+	return limit, beforeID, true
+}
+
+// writeAuditEvents 写入事件列表；本页条数达到 limit 时返回下一页游标
+func writeAuditEvents(w http.ResponseWriter, events []*domain.AuditEvent, limit int, hideEmail bool) {
+	resp := AuditEventListResponse{Events: make([]AuditEventResponse, 0, len(events))}
+	for _, e := range events {
+		item := newAuditEventResponse(e)
+		if hideEmail {
+			item.Email = ""
+		}
+		resp.Events = append(resp.Events, item)
+	}
+	if len(events) > 0 && len(events) >= limit {
+		resp.NextBeforeID = events[len(events)-1].ID
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// MyActivityHandler 当前用户的近期安全活动（登录、密码修改、MFA 变更等），便于发现异常访问
+// GET /api/v1/auth/me/activity?limit=&before_id=
+func (h *Handler) MyActivityHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAuditService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	filter := domain.AuditEventFilter{UserID: user.ID}
+	if filter.Limit, filter.BeforeID, ok = parsePage(w, r, 20); !ok {
+		return
+	}
+	events, err := h.AuditService.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, "INTERNAL_ERROR", "Failed to load activity", http.StatusInternalServerError,
+			fmt.Sprintf("list activity failed user_id=%d err=%v", user.ID, err))
+		return
+	}
+	// 失败尝试中提交的邮箱可能属于他人（如输错邮箱），不向用户展示
+	writeAuditEvents(w, events, filter.Limit, true)
+}
+
+// AdminAuditEventsHandler 管理员按条件查询审计事件
+// GET /api/v1/admin/audit-events?user_id=&type=&outcome=&ip=&client_id=&since=&until=&limit=&before_id=
+// since / until 为 RFC3339 时间
+func (h *Handler) AdminAuditEventsHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAuditService(w) {
+		return
+	}
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+	q := r.URL.Query()
+	filter := domain.AuditEventFilter{
+		Type:     strings.TrimSpace(q.Get("type")),
+		Outcome:  strings.TrimSpace(q.Get("outcome")),
+		IP:       strings.TrimSpace(q.Get("ip")),
+		ClientID: strings.TrimSpace(q.Get("client_id")),
+	}
+	if v := q.Get("user_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			writeError(w, "INVALID_REQUEST", "Invalid user_id", http.StatusBadRequest, "")
+			return
+		}
+		filter.UserID = id
+	}
+	for name, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, "INVALID_REQUEST", "Invalid "+name+", expected RFC3339", http.StatusBadRequest, "")
+				return
+			}
+			*dst = t
+		}
+	}
+	var ok bool
+	if filter.Limit, filter.BeforeID, ok = parsePage(w, r, auth.DefaultAuditPageSize); !ok {
+		return
+	}
+	events, err := h.AuditService.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, "INTERNAL_ERROR", "Failed to query audit events", http.StatusInternalServerError,
+			fmt.Sprintf("list audit events failed err=%v", err))
+		return
+	}
+	writeAuditEvents(w, events, filter.Limit, false)
+}
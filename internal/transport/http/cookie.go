@@ -0,0 +1,85 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// hostCookiePrefix 浏览器仅接受 Secure、Path=/ 且不带 Domain 的 __Host- Cookie，防止子域覆盖
+const hostCookiePrefix = "__Host-"
+
+// CookieOptions 服务端下发 Cookie（auth_token、csrf_token、device_id）的共用属性
+type CookieOptions struct {
+	// Secure 生产 HTTPS 时 true，Cookie 仅通过 HTTPS 发送
+	Secure bool
+	// Domain 跨子域 SSO 时设置（如 example.com），为空表示仅当前主机
+	Domain string
+	// SameSite 缺省为 Lax
+	SameSite http.SameSite
+	// HostPrefix Cookie 名加 __Host- 前缀，要求 Secure 且不能设置 Domain
+	HostPrefix bool
+}
+
+// ParseSameSite 解析配置中的 SameSite 取值：lax（缺省）/ strict / none
+func ParseSameSite(s string) (http.SameSite, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("invalid same_site %q, expected lax, strict or none", s)
+	}
+}
+
+// Validate 校验属性组合是否会被浏览器接受
+func (o CookieOptions) Validate() error {
+	if o.HostPrefix && !o.Secure {
+		return errors.New("__Host- cookies require secure")
+	}
+	if o.HostPrefix && o.Domain != "" {
+		return errors.New("__Host- cookies cannot set domain")
+	}
+	if o.SameSite == http.SameSiteNoneMode && !o.Secure {
+		return errors.New("SameSite=None cookies require secure")
+	}
+	return nil
+}
+
+// name 返回实际 Cookie 名
+func (o CookieOptions) name(base string) string {
+	if o.HostPrefix {
+		return hostCookiePrefix + base
+	}
+	return base
+}
+
+// cookie 构造 HttpOnly Cookie；maxAge 为 0 表示会话 Cookie，小于 0 表示删除
+func (o CookieOptions) cookie(base, value string, maxAge int) *http.Cookie {
+	sameSite := o.SameSite
+	if sameSite == 0 {
+		sameSite = http.SameSiteLaxMode
+	}
+	return &http.Cookie{
+		Name:     o.name(base),
+		Value:    value,
+		Path:     "/",
+		Domain:   o.Domain,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   o.Secure,
+		SameSite: sameSite,
+	}
+}
+
+// read 读取 Cookie 值，不存在时返回空字符串
+func (o CookieOptions) read(r *http.Request, base string) string {
+	if c, err := r.Cookie(o.name(base)); err == nil {
+		return c.Value
+	}
+	return ""
+}
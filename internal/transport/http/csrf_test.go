@@ -0,0 +1,101 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// issueCSRFToken 通过 TokenHandler 取得一个有效令牌
+func issueCSRFToken(t *testing.T, c *CSRFProtection) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.TokenHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/auth/csrf", nil))
+	var body CSRFTokenResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode csrf response: %v", err)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != csrfCookieName || cookies[0].Value != body.CSRFToken {
+		t.Fatalf("csrf cookie = %v, body token = %q", cookies, body.CSRFToken)
+	}
+	return body.CSRFToken
+}
+
+func TestCSRFMiddleware(t *testing.T) {
+	csrf := NewCSRFProtection("test-secret", CookieOptions{})
+	token := issueCSRFToken(t, csrf)
+	other := issueCSRFToken(t, csrf)
+	foreign := issueCSRFToken(t, NewCSRFProtection("other-secret", CookieOptions{}))
+
+	tests := []struct {
+		name       string
+		method     string
+		authCookie bool
+		bearer     bool
+		cookie     string
+		header     string
+		wantStatus int
+	}{
+		{"cookie auth without header", http.MethodPost, true, false, token, "", http.StatusForbidden},
+		{"cookie auth without csrf cookie", http.MethodPost, true, false, "", token, http.StatusForbidden},
+		{"mismatched header", http.MethodPost, true, false, token, other, http.StatusForbidden},
+		{"forged unsigned cookie", http.MethodPost, true, false, "attacker-nonce", "attacker-nonce", http.StatusForbidden},
+		{"forged bad signature", http.MethodDelete, true, false, "attacker-nonce.c2ln", "attacker-nonce.c2ln", http.StatusForbidden},
+		{"signed with another secret", http.MethodPatch, true, false, foreign, foreign, http.StatusForbidden},
+		{"valid token", http.MethodPost, true, false, token, token, http.StatusOK},
+		{"bearer without token", http.MethodPost, true, true, "", "", http.StatusOK},
+		{"get without token", http.MethodGet, true, false, "", "", http.StatusOK},
+		{"no auth cookie", http.MethodPost, false, false, "", "", http.StatusOK},
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/api/v1/auth/password", nil)
+			if tt.authCookie {
+				r.AddCookie(&http.Cookie{Name: authTokenCookieName, Value: "jwt"})
+			}
+			if tt.bearer {
+				r.Header.Set("Authorization", "Bearer jwt")
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: csrfCookieName, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				r.Header.Set(csrfHeaderName, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			csrf.Middleware(next).ServeHTTP(rec, r)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCSRFTokenHandlerReusesValidCookie(t *testing.T) {
+	csrf := NewCSRFProtection("test-secret", CookieOptions{})
+	token := issueCSRFToken(t, csrf)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/auth/csrf", nil)
+	r.AddCookie(&http.Cookie{Name: csrfCookieName, Value: token})
+	rec := httptest.NewRecorder()
+	csrf.TokenHandler(rec, r)
+	var body CSRFTokenResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.CSRFToken != token || len(rec.Result().Cookies()) != 0 {
+		t.Fatalf("token = %q cookies = %v, want existing token reused", body.CSRFToken, rec.Result().Cookies())
+	}
+
+	// 伪造的 Cookie 被替换为新签发的令牌
+	r = httptest.NewRequest(http.MethodGet, "/api/v1/auth/csrf", nil)
+	r.AddCookie(&http.Cookie{Name: csrfCookieName, Value: "attacker-nonce"})
+	rec = httptest.NewRecorder()
+	csrf.TokenHandler(rec, r)
+	if cookies := rec.Result().Cookies(); len(cookies) != 1 || !csrf.valid(cookies[0].Value) {
+		t.Fatalf("cookies = %v, want a freshly signed token", cookies)
+	}
+}
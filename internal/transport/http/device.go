@@ -0,0 +1,35 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+
+	"monai-auth/internal/auth"
+)
+
+// deviceCookieName 长期设备 Cookie，用于识别已登录过的设备（新设备登录提醒）
+const deviceCookieName = "device_id"
+
+// deviceCookieMaxAge 设备 Cookie 有效期：约 2 年
+const deviceCookieMaxAge = 2 * 365 * 24 * 3600
+
+// withClientInfo 确保请求带有设备 Cookie（没有则生成并写入响应），
+// 并将客户端 IP、User-Agent、设备 ID 放入 context 供登录风险评估使用
+func (h *Handler) withClientInfo(w http.ResponseWriter, r *http.Request) *http.Request {
+	deviceID := ""
+	if v := h.Cookies.read(r, deviceCookieName); len(v) >= 16 && len(v) <= 64 {
+		deviceID = v
+	} else {
+		b := make([]byte, 24)
+		if _, err := rand.Read(b); err == nil {
+			deviceID = base64.RawURLEncoding.EncodeToString(b)
+			http.SetCookie(w, h.Cookies.cookie(deviceCookieName, deviceID, deviceCookieMaxAge))
+		}
+	}
+	return r.WithContext(auth.WithClientInfo(r.Context(), auth.ClientInfo{
+		IP:        ClientIP(r, h.TrustProxyHeaders),
+		UserAgent: r.UserAgent(),
+		DeviceID:  deviceID,
+	}))
+}
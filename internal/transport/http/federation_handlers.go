@@ -0,0 +1,236 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"monai-auth/internal/auth"
+	"monai-auth/internal/domain"
+)
+
+// federationStateCookieName 将第三方登录的 state 绑定到发起登录的浏览器，防止攻击者把自己的授权回调塞给受害者（登录 CSRF）
+const federationStateCookieName = "federation_state"
+
+// federationStateMaxAge 与流程参数有效期一致
+const federationStateMaxAge = 10 * 60
+
+// FederatedIdentityResponse 已绑定的第三方账号（不返回提供方内的用户标识）
+type FederatedIdentityResponse struct {
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// ConsumeFederationTicketRequest 使用第三方登录票据完成登录 DTO
+type ConsumeFederationTicketRequest struct {
+	Ticket string `json:"ticket"`
+}
+
+// requireFederationService 第三方登录未启用时写入错误响应
+func (h *Handler) requireFederationService(w http.ResponseWriter) bool {
+	if h.FederationService == nil {
+		writeError(w, "INTERNAL_ERROR", "Federated login not configured", http.StatusInternalServerError, "")
+		return false
+	}
+	return true
+}
+
+// federationErrorCode 将第三方登录错误映射为错误码、提示与状态码
+func federationErrorCode(err error) (string, string, int) {
+	switch {
+	case errors.Is(err, domain.ErrUnknownProvider):
+		return "UNKNOWN_PROVIDER", "Unknown identity provider", http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidFederationState):
+		return "INVALID_FEDERATION_STATE", "Invalid or expired sign-in request", http.StatusBadRequest
+	case errors.Is(err, domain.ErrInvalidFederationTicket):
+		return "INVALID_FEDERATION_TICKET", "Invalid or expired sign-in ticket", http.StatusBadRequest
+	case errors.Is(err, domain.ErrFederatedEmailUnverified):
+		return "FEDERATED_EMAIL_UNVERIFIED", "Identity provider did not return a verified email", http.StatusForbidden
+	case errors.Is(err, domain.ErrFederatedAccountNotFound):
+		return "FEDERATED_ACCOUNT_NOT_FOUND", "No account matches the identity provider email", http.StatusForbidden
+	case errors.Is(err, domain.ErrIdentityExists):
+		return "IDENTITY_EXISTS", "Another account from this provider is already linked", http.StatusConflict
+	case errors.Is(err, domain.ErrIdentityNotFound):
+		return "IDENTITY_NOT_FOUND", "Linked identity not found", http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		return "INVALID_CREDENTIALS", "Invalid credentials", http.StatusUnauthorized
+	case errors.Is(err, domain.ErrEmailNotVerified):
+		return "EMAIL_NOT_VERIFIED", "Email address not verified", http.StatusForbidden
+	case errors.Is(err, domain.ErrFederationFailed):
+		return "FEDERATION_FAILED", "Identity provider authentication failed", http.StatusBadGateway
+	default:
+		return "INTERNAL_ERROR", "Server error", http.StatusInternalServerError
+	}
+}
+
+// federationStateCookie 构造 state 绑定 Cookie。提供方回调是跨站顶层跳转，SameSite=Strict 的 Cookie 不会被携带，此处至少放宽为 Lax
+func (h *Handler) federationStateCookie(value string, maxAge int) *http.Cookie {
+	c := h.Cookies.cookie(federationStateCookieName, value, maxAge)
+	if c.SameSite == http.SameSiteStrictMode {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	return c
+}
+
+// redirectFederationLanding 重定向到前端落地页，附带 ticket 或 error
+func (h *Handler) redirectFederationLanding(w http.ResponseWriter, r *http.Request, key, value string) {
+	u, err := url.Parse(h.FederationLandingURL)
+	if err != nil {
+		writeError(w, "INTERNAL_ERROR", "Server error", http.StatusInternalServerError,
+			"parse federation landing url failed err="+err.Error())
+		return
+	}
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// FederationProvidersHandler 列出可用的第三方登录提供方，供登录页渲染按钮
+// GET /api/v1/auth/federation/providers
+func (h *Handler) FederationProvidersHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireFederationService(w) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"providers": h.FederationService.Providers()})
+}
+
+// FederationLoginHandler 发起第三方登录：浏览器直接导航到本接口，302 跳转到提供方授权页
+// GET /api/v1/auth/federation/{provider}/login?server_state=xxx（SSO 时带上登录页 URL 中的 state）
+func (h *Handler) FederationLoginHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireFederationService(w) {
+		return
+	}
+	provider := chi.URLParam(r, "provider")
+	authURL, state, err := h.FederationService.Begin(r.Context(), provider, r.URL.Query().Get("server_state"))
+	if err != nil {
+		code, _, _ := federationErrorCode(err)
+		log.Printf("[AUTH] federation begin failed provider=%s err=%v", provider, err)
+		h.redirectFederationLanding(w, r, "error", code)
+		return
+	}
+	http.SetCookie(w, h.federationStateCookie(state, federationStateMaxAge))
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// FederationCallbackHandler 提供方授权后的回调：校验 state、换取上游身份并解析本地用户，
+// 成功后重定向到前端落地页 ?ticket=xxx，失败时 ?error=错误码
+// GET /api/v1/auth/federation/{provider}/callback?code=xxx&state=xxx
+func (h *Handler) FederationCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireFederationService(w) {
+		return
+	}
+	provider := chi.URLParam(r, "provider")
+	q := r.URL.Query()
+	state := q.Get("state")
+	bound := h.Cookies.read(r, federationStateCookieName)
+	http.SetCookie(w, h.federationStateCookie("", -1))
+
+	if upstreamErr := q.Get("error"); upstreamErr != "" {
+		// 用户在提供方页面拒绝授权等
+		h.audit(r, domain.AuditEvent{Type: domain.AuditLogin, Outcome: domain.AuditOutcomeFailure, Reason: "federation_cancelled provider=" + provider + " error=" + upstreamErr})
+		h.redirectFederationLanding(w, r, "error", "FEDERATION_CANCELLED")
+		return
+	}
+	var ticket string
+	var err error
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(bound)) != 1 {
+		err = domain.ErrInvalidFederationState
+	} else {
+		ticket, err = h.FederationService.Callback(r.Context(), provider, state, q.Get("code"))
+	}
+	if err != nil {
+		h.audit(r, domain.AuditEvent{Type: domain.AuditLogin, Outcome: domain.AuditOutcomeFailure, Reason: auditReason(err) + " provider=" + provider})
+		code, _, _ := federationErrorCode(err)
+		log.Printf("[AUTH] federation callback failed provider=%s code=%s err=%v", provider, code, err)
+		h.redirectFederationLanding(w, r, "error", code)
+		return
+	}
+	h.redirectFederationLanding(w, r, "ticket", ticket)
+}
+
+// FederationConsumeHandler 前端落地页用回调得到的票据完成登录；成功响应与 /login 一致（SSO 返回 redirect_url，否则写 Cookie）
+// POST /api/v1/auth/federation/consume，Body: {"ticket"}
+func (h *Handler) FederationConsumeHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireFederationService(w) {
+		return
+	}
+	var req ConsumeFederationTicketRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	grant, err := h.FederationService.Consume(r.Context(), req.Ticket)
+	if err != nil {
+		h.audit(r, domain.AuditEvent{Type: domain.AuditLogin, Outcome: domain.AuditOutcomeFailure, Reason: auditReason(err)})
+		code, message, status := federationErrorCode(err)
+		writeError(w, code, message, status, "")
+		return
+	}
+	r = h.withClientInfo(w, r)
+	result, err := h.AuthService.CompleteLogin(r.Context(), grant.UserID, []string{auth.AMRFederated})
+	if err != nil {
+		h.audit(r, domain.AuditEvent{Type: domain.AuditLogin, Outcome: domain.AuditOutcomeFailure, UserID: grant.UserID, ActorID: grant.UserID, Reason: auditReason(err)})
+		code, message, status := federationErrorCode(err)
+		writeError(w, code, message, status, fmt.Sprintf("federated login failed user_id=%d provider=%s err=%v", grant.UserID, grant.Provider, err))
+		return
+	}
+	h.writeLoginResult(w, r, result, grant.ServerState)
+}
+
+// MyIdentitiesHandler 列出当前用户已绑定的第三方账号
+// GET /api/v1/auth/me/identities
+func (h *Handler) MyIdentitiesHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireFederationService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	identities, err := h.FederationService.ListIdentities(r.Context(), user.ID)
+	if err != nil {
+		writeError(w, "INTERNAL_ERROR", "Server error", http.StatusInternalServerError,
+			fmt.Sprintf("list identities failed user_id=%d err=%v", user.ID, err))
+		return
+	}
+	out := make([]FederatedIdentityResponse, 0, len(identities))
+	for _, i := range identities {
+		out = append(out, FederatedIdentityResponse{Provider: i.Provider, Email: i.Email, CreatedAt: i.CreatedAt, LastLoginAt: i.LastLoginAt})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"identities": out})
+}
+
+// UnlinkIdentityHandler 解除当前用户在指定提供方的绑定；之后再用该提供方登录时会按已验证邮箱重新绑定
+// DELETE /api/v1/auth/me/identities/{provider}
+func (h *Handler) UnlinkIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireFederationService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	err := h.FederationService.Unlink(r.Context(), user.ID, chi.URLParam(r, "provider"))
+	h.audit(r, domain.AuditEvent{Type: domain.AuditIdentityUnlink, Outcome: auditOutcome(err), UserID: user.ID, ActorID: user.ID, Reason: auditReason(err)})
+	if err != nil {
+		code, message, status := federationErrorCode(err)
+		writeError(w, code, message, status, fmt.Sprintf("unlink identity failed user_id=%d err=%v", user.ID, err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
@@ -39,25 +39,64 @@ type Handler struct {
 	StateStore           auth.StateStore
 	CodeStore            auth.CodeStore
 	UserAssetRepository  domain.UserAssetRepository // 上传资源写入 user_assets 表，可为 nil 则仅落盘
-	CookieSecure         bool   // 生产 HTTPS 时 true，Cookie 仅通过 HTTPS 发送
+	Cookies              CookieOptions              // Cookie 属性（Secure、Domain、SameSite、__Host- 前缀）
 	LoginPagePath        string
 	AuthBaseURL          string // 认证中心对外 base URL，用于拼完整登录页地址
 	AllowedRedirectURIs  []string
 	Clients              []Client
 	AccessTokenExpireSec int
+	PasswordResetService auth.PasswordResetService // 忘记密码流程，可为 nil 表示未启用
+	// EmailVerificationService 注册邮箱验证，可为 nil 表示注册后直接激活
+	EmailVerificationService auth.EmailVerificationService
+	MFAService               auth.MFAService     // TOTP 多因素认证，可为 nil 表示未启用
+	PasskeyService           auth.PasskeyService // WebAuthn / Passkey 登录，可为 nil 表示未启用
+	AuditService             auth.AuditService   // 安全审计，可为 nil 表示不记录
+	// LoginHistoryService 登录成功时更新 last_login_at 并写入登录历史，可为 nil 表示不记录
+	LoginHistoryService auth.LoginHistoryService
+	// TrustProxyHeaders 为 true 时审计记录的客户端 IP 取自 X-Forwarded-For / X-Real-IP
+	TrustProxyHeaders bool
+	// MagicLinkService 邮件链接无密码登录，可为 nil 表示未启用
+	MagicLinkService auth.MagicLinkService
+	// PhoneOTPService 短信验证码登录与手机号验证，可为 nil 表示未启用
+	PhoneOTPService auth.PhoneOTPService
+	// FederationService 第三方（GitHub / Google / OIDC）登录，可为 nil 表示未启用
+	FederationService auth.FederationService
+	// FederationLandingURL 第三方登录回调后重定向到的前端落地页
+	FederationLandingURL string
+	// SAMLService SAML 2.0 IdP，可为 nil 表示未启用
+	SAMLService auth.SAMLService
+	// SCIMService SCIM 2.0 用户与组同步，可为 nil 表示未启用
+	SCIMService auth.SCIMService
+	// WebhookService 用户生命周期 Webhook，可为 nil 表示未启用
+	WebhookService auth.WebhookService
 }
 
 // HandlerOpts 可选配置
 type HandlerOpts struct {
-	StateStore           auth.StateStore
-	CodeStore            auth.CodeStore
-	UserAssetRepository  domain.UserAssetRepository
-	CookieSecure         bool // 生产 HTTPS 时 true
-	LoginPagePath        string
-	AuthBaseURL          string
-	AllowedRedirectURIs  []string
-	Clients              []Client
-	AccessTokenExpireSec int
+	StateStore               auth.StateStore
+	CodeStore                auth.CodeStore
+	UserAssetRepository      domain.UserAssetRepository
+	Cookies                  CookieOptions
+	LoginPagePath            string
+	AuthBaseURL              string
+	AllowedRedirectURIs      []string
+	Clients                  []Client
+	AccessTokenExpireSec     int
+	PasswordResetService     auth.PasswordResetService
+	EmailVerificationService auth.EmailVerificationService
+	MFAService               auth.MFAService
+	PasskeyService           auth.PasskeyService
+	AuditService             auth.AuditService
+	LoginHistoryService      auth.LoginHistoryService
+	TrustProxyHeaders        bool
+	MagicLinkService         auth.MagicLinkService
+	PhoneOTPService          auth.PhoneOTPService
+	FederationService        auth.FederationService
+	// FederationLandingURL 第三方登录回调后重定向到的前端落地页，附带 ?ticket= 或 ?error=
+	FederationLandingURL string
+	SAMLService          auth.SAMLService
+	SCIMService          auth.SCIMService
+	WebhookService       auth.WebhookService
 }
 
 // UserInfoResponse 验证接口返回的用户信息
@@ -66,13 +105,21 @@ type UserInfoResponse struct {
 	Role string `json:"role"`
 }
 
-// CurrentUserResponse 当前用户基本信息（/me）
+// CurrentUserResponse 当前用户资料（/me）
 type CurrentUserResponse struct {
-	ID        int64  `json:"id"`
-	Username  string `json:"username"`
-	Email     string `json:"email"`
-	Role      string `json:"role"`
-	CreatedAt string `json:"created_at"`
+	ID          int64  `json:"id"`
+	Username    string `json:"username"`
+	Email       string `json:"email"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	AvatarURL   string `json:"avatar_url"`
+	PhoneNumber string `json:"phone_number"`
+	// PhoneVerified 手机号已通过短信验证码验证，可用于短信登录
+	PhoneVerified bool   `json:"phone_verified"`
+	Role          string `json:"role"`
+	Status        string `json:"status"`
+	CreatedAt     string `json:"created_at"`
+	LastLoginAt   string `json:"last_login_at,omitempty"`
 }
 
 // ErrorResponse 统一错误响应格式
@@ -87,7 +134,7 @@ func NewHandler(authService auth.Service, opts *HandlerOpts) *Handler {
 		h.StateStore = opts.StateStore
 		h.CodeStore = opts.CodeStore
 		h.UserAssetRepository = opts.UserAssetRepository
-		h.CookieSecure = opts.CookieSecure
+		h.Cookies = opts.Cookies
 		if opts.LoginPagePath != "" {
 			h.LoginPagePath = opts.LoginPagePath
 		} else {
@@ -97,6 +144,20 @@ func NewHandler(authService auth.Service, opts *HandlerOpts) *Handler {
 		h.AllowedRedirectURIs = opts.AllowedRedirectURIs
 		h.Clients = opts.Clients
 		h.AccessTokenExpireSec = opts.AccessTokenExpireSec
+		h.PasswordResetService = opts.PasswordResetService
+		h.EmailVerificationService = opts.EmailVerificationService
+		h.MFAService = opts.MFAService
+		h.PasskeyService = opts.PasskeyService
+		h.AuditService = opts.AuditService
+		h.LoginHistoryService = opts.LoginHistoryService
+		h.TrustProxyHeaders = opts.TrustProxyHeaders
+		h.MagicLinkService = opts.MagicLinkService
+		h.PhoneOTPService = opts.PhoneOTPService
+		h.FederationService = opts.FederationService
+		h.FederationLandingURL = opts.FederationLandingURL
+		h.SAMLService = opts.SAMLService
+		h.SCIMService = opts.SCIMService
+		h.WebhookService = opts.WebhookService
 		if h.AccessTokenExpireSec <= 0 {
 			h.AccessTokenExpireSec = 86400 // 24h
 		}
@@ -125,51 +186,118 @@ func (h *Handler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.AuthService.Login(r.Context(), req)
+	r = h.withClientInfo(w, r)
+	identifier := req.LoginIdentifier()
+	result, err := h.AuthService.Login(r.Context(), req)
 	if err != nil {
+		h.audit(r, domain.AuditEvent{Type: domain.AuditLogin, Outcome: domain.AuditOutcomeFailure, Email: identifier, Reason: auditReason(err)})
 		if errors.Is(err, domain.ErrInvalidCredentials) {
 			writeError(w, "INVALID_CREDENTIALS", "Invalid credentials", http.StatusUnauthorized,
-				"login failed email="+req.Email+" reason=invalid_credentials")
+				"login failed identifier="+identifier+" reason=invalid_credentials")
+			return
+		}
+		if errors.Is(err, domain.ErrEmailNotVerified) {
+			writeError(w, "EMAIL_NOT_VERIFIED", "Email address not verified", http.StatusForbidden,
+				"login failed identifier="+identifier+" reason=email_not_verified")
+			return
+		}
+		var locked *domain.AccountLockedError
+		if errors.As(err, &locked) {
+			writeTooManyRequests(w, "ACCOUNT_LOCKED", "Too many failed login attempts, please try again later",
+				locked.RetryAfter, "login failed identifier="+identifier+" reason=account_locked")
+			return
+		}
+		if errors.Is(err, domain.ErrDirectoryUnavailable) {
+			writeError(w, "DIRECTORY_UNAVAILABLE", "Directory service unavailable, please try again later", http.StatusServiceUnavailable,
+				fmt.Sprintf("login failed identifier=%s reason=directory_unavailable err=%v", identifier, err))
 			return
 		}
 		writeError(w, "INTERNAL_ERROR", "Server error", http.StatusInternalServerError,
-			"login failed email="+req.Email+" reason=internal")
+			"login failed identifier="+identifier+" reason=internal")
+		return
+	}
+	h.writeLoginResult(w, r, result, req.ServerState)
+}
+
+// MFARequiredResponse 已启用 MFA 的用户密码校验通过后返回的挑战
+type MFARequiredResponse struct {
+	Status   string   `json:"status"`
+	MFAToken string   `json:"mfa_token"`
+	Methods  []string `json:"methods"`
+	// ServerState SSO 流程中本次登录绑定的 server_state，第二步提交时原样带上（邮件链接登录时前端无从得知）
+	ServerState string `json:"server_state,omitempty"`
+}
+
+// writeLoginResult 写入登录成功响应：
+// 需要 MFA 时返回挑战令牌（此时不消费 server_state，第二步提交时再带上）；
+// 带 server_state（SSO）时生成授权码并返回回调地址；否则写入 Cookie
+func (h *Handler) writeLoginResult(w http.ResponseWriter, r *http.Request, result *auth.LoginResult, serverState string) {
+	event := domain.AuditEvent{
+		Type:    domain.AuditLogin,
+		Outcome: domain.AuditOutcomeSuccess,
+		UserID:  result.UserID,
+		ActorID: result.UserID,
+		Reason:  "amr=" + strings.Join(result.AMR, ","),
+	}
+	if result.MFAToken != "" {
+		event.Type = domain.AuditLoginMFAChallenge
+		event.Reason = ""
+		h.audit(r, event)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(MFARequiredResponse{
+			Status:      "mfa_required",
+			MFAToken:    result.MFAToken,
+			Methods:     []string{"totp", "recovery_code"},
+			ServerState: serverState,
+		})
 		return
 	}
 
+	// SAML SP 发起的登录：server_state 为待完成的 SAML 请求时记录登录用户并写 Cookie，
+	// 返回 continue 地址，由浏览器导航过去完成断言投递
+	if serverState != "" && h.SAMLService != nil {
+		if pending, err := h.SAMLService.MarkAuthenticated(serverState, result.UserID, result.AMR); err == nil {
+			event.ClientID = pending.ServiceProvider
+			h.audit(r, event)
+			h.recordLogin(r, result, pending.ServiceProvider)
+			h.setAuthCookie(w, result.Token)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]string{"redirect_url": h.samlContinueURL(serverState)})
+			return
+		}
+	}
+
 	// SSO 授权码流程：带 server_state 时用其取回 client_id/redirect_uri，生成 code 并返回完整回调 URL 字符串（不 302）
-	if req.ServerState != "" && h.StateStore != nil && h.CodeStore != nil {
-		clientID, redirectURI, _, ok := h.StateStore.GetAndConsume(req.ServerState)
+	if serverState != "" && h.StateStore != nil && h.CodeStore != nil {
+		clientID, redirectURI, _, ok := h.StateStore.GetAndConsume(serverState)
 		if ok && redirectURI != "" {
-			user, errUser := h.AuthService.Validate(r.Context(), token)
-			if errUser == nil {
-				code, errCode := h.CodeStore.Save(user.ID, clientID, redirectURI)
-				if errCode == nil {
-					u, _ := url.Parse(redirectURI)
-					if u != nil {
-						q := u.Query()
-						q.Set("code", code)
-						// q.Set("state", req.ServerState)
-						u.RawQuery = q.Encode()
-						w.Header().Set("Content-Type", "application/json")
-						fmt.Println("redirect_url", u.String())
-						_ = json.NewEncoder(w).Encode(map[string]string{"redirect_url": u.String()})
-						return
-					}
+			code, errCode := h.CodeStore.Save(auth.CodeGrant{
+				UserID:      result.UserID,
+				ClientID:    clientID,
+				RedirectURI: redirectURI,
+				AMR:         result.AMR,
+			})
+			if errCode == nil {
+				u, _ := url.Parse(redirectURI)
+				if u != nil {
+					q := u.Query()
+					q.Set("code", code)
+					u.RawQuery = q.Encode()
+					event.ClientID = clientID
+					h.audit(r, event)
+					h.recordLogin(r, result, clientID)
+					w.Header().Set("Content-Type", "application/json")
+					_ = json.NewEncoder(w).Encode(map[string]string{"redirect_url": u.String()})
+					return
 				}
 			}
 		}
 	}
 
 	// 非 SSO：将 token 写入 HttpOnly Cookie 并返回 JSON
-	http.SetCookie(w, &http.Cookie{
-		Name:     authTokenCookieName,
-		Value:    token,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   h.CookieSecure,
-		SameSite: http.SameSiteLaxMode,
-	})
+	h.audit(r, event)
+	h.recordLogin(r, result, "")
+	h.setAuthCookie(w, result.Token)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
@@ -177,6 +305,11 @@ func (h *Handler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 // authTokenCookieName 与登录时设置的 Cookie 名称一致
 const authTokenCookieName = "auth_token"
 
+// setAuthCookie 将 token 写入 HttpOnly Cookie
+func (h *Handler) setAuthCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, h.Cookies.cookie(authTokenCookieName, token, 0))
+}
+
 // RequestLoginResponse 请求登录接口返回的登录页地址
 type RequestLoginResponse struct {
 	LoginURL string `json:"login_url"`
@@ -214,22 +347,22 @@ func (h *Handler) SSORequestLoginHandler(w http.ResponseWriter, r *http.Request)
 
 // LogoutHandler 处理登出：清除服务端下发的 auth_token Cookie，客户端无需再持有 token
 func (h *Handler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{
-		Name:     authTokenCookieName,
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: true,
-		Secure:   h.CookieSecure,
-		SameSite: http.SameSiteLaxMode,
-	})
+	if h.AuditService != nil {
+		// 仅用于记录审计：token 无效时同样清除 Cookie
+		if token := h.tokenFromRequest(r); token != "" {
+			if user, err := h.AuthService.Validate(r.Context(), token); err == nil {
+				h.audit(r, domain.AuditEvent{Type: domain.AuditLogout, Outcome: domain.AuditOutcomeSuccess, UserID: user.ID, ActorID: user.ID})
+			}
+		}
+	}
+	http.SetCookie(w, h.Cookies.cookie(authTokenCookieName, "", -1))
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
 
 // ValidateHandler 处理 /validate 请求 (用于其他服务验证JWT)
 func (h *Handler) ValidateHandler(w http.ResponseWriter, r *http.Request) {
-	token := getTokenFromRequest(r)
+	token := h.tokenFromRequest(r)
 	if token == "" {
 		writeError(w, "UNAUTHORIZED", "Missing or invalid token", http.StatusUnauthorized, "")
 		return
@@ -248,53 +381,231 @@ func (h *Handler) ValidateHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// getTokenFromRequest 从 Cookie 或 Authorization 头获取 token
-func getTokenFromRequest(r *http.Request) string {
-	if c, err := r.Cookie(authTokenCookieName); err == nil && c.Value != "" {
-		return c.Value
-	}
+// tokenFromRequest 从 Authorization 头或 Cookie 获取 token；显式携带 Bearer 时优先使用，
+// 与 CSRF 校验对 Bearer 请求的豁免保持一致
+func (h *Handler) tokenFromRequest(r *http.Request) string {
 	authHeader := r.Header.Get("Authorization")
 	if strings.HasPrefix(authHeader, "Bearer ") {
 		return strings.TrimPrefix(authHeader, "Bearer ")
 	}
-	return ""
+	return h.Cookies.read(r, authTokenCookieName)
 }
 
-// MeHandler 获取当前登录用户基本信息
-// GET /api/v1/auth/me，鉴权方式同 validate（Cookie 或 Authorization: Bearer）
-func (h *Handler) MeHandler(w http.ResponseWriter, r *http.Request) {
-	token := getTokenFromRequest(r)
+// authenticate 从请求中取出 token 并校验，失败时写入 401 响应并返回 false
+func (h *Handler) authenticate(w http.ResponseWriter, r *http.Request) (*domain.User, bool) {
+	token := h.tokenFromRequest(r)
 	if token == "" {
 		writeError(w, "UNAUTHORIZED", "Missing or invalid token", http.StatusUnauthorized, "")
-		return
+		return nil, false
 	}
 	user, err := h.AuthService.Validate(r.Context(), token)
 	if err != nil {
 		writeError(w, "INVALID_TOKEN", "Token validation failed", http.StatusUnauthorized,
-			"me failed reason=invalid_token")
-		return
+			"authenticate failed path="+r.URL.Path+" reason=invalid_token")
+		return nil, false
 	}
+	return user, true
+}
+
+// newCurrentUserResponse 将领域用户转换为 /me 响应
+func newCurrentUserResponse(user *domain.User) CurrentUserResponse {
 	createdAt := ""
 	if !user.CreatedAt.IsZero() {
 		createdAt = user.CreatedAt.Format(time.RFC3339)
 	}
+	lastLoginAt := ""
+	if user.LastLoginAt != nil {
+		lastLoginAt = user.LastLoginAt.Format(time.RFC3339)
+	}
+	return CurrentUserResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		AvatarURL:     user.AvatarURL,
+		PhoneNumber:   user.PhoneNumber,
+		PhoneVerified: user.PhoneNumber != "" && user.PhoneVerifiedAt != nil,
+		Role:          user.Role,
+		Status:        user.Status,
+		CreatedAt:     createdAt,
+		LastLoginAt:   lastLoginAt,
+	}
+}
+
+// MeHandler 获取当前登录用户资料
+// GET /api/v1/auth/me，鉴权方式同 validate（Cookie 或 Authorization: Bearer）
+func (h *Handler) MeHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(CurrentUserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		CreatedAt: createdAt,
+	_ = json.NewEncoder(w).Encode(newCurrentUserResponse(user))
+}
+
+// UpdateMeHandler 更新当前登录用户资料（PATCH 语义，仅修改请求体中出现的字段）
+// PATCH /api/v1/auth/me，Body: {"username","first_name","last_name","avatar_url","phone_number"}
+func (h *Handler) UpdateMeHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	var req domain.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	updated, err := h.AuthService.UpdateProfile(r.Context(), user.ID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidUsername):
+			writeError(w, "INVALID_USERNAME", "Invalid username", http.StatusBadRequest, "")
+		case errors.Is(err, domain.ErrInvalidPhone):
+			writeError(w, "INVALID_PHONE", "Invalid phone number format", http.StatusBadRequest, "")
+		case errors.Is(err, domain.ErrInvalidProfile):
+			writeError(w, "INVALID_REQUEST", "Profile field too long", http.StatusBadRequest, "")
+		case errors.Is(err, domain.ErrUserExists):
+			writeError(w, "USERNAME_EXISTS", "Username already taken", http.StatusConflict, "")
+		case errors.Is(err, domain.ErrPhoneExists):
+			writeError(w, "PHONE_EXISTS", "Phone number already bound", http.StatusConflict, "")
+		default:
+			writeError(w, "INTERNAL_ERROR", "Profile update failed", http.StatusInternalServerError,
+				fmt.Sprintf("update profile failed user_id=%d err=%v", user.ID, err))
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(newCurrentUserResponse(updated))
+}
+
+// ChangePasswordHandler 修改当前用户密码，成功后该用户其他会话/token 全部失效
+// POST /api/v1/auth/password，Body: {"current_password","new_password"}
+// 通过 Cookie 鉴权时刷新 auth_token Cookie；通过 Bearer 鉴权时在响应体中返回新的 access_token
+func (h *Handler) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	var req domain.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	token, err := h.AuthService.ChangePassword(r.Context(), h.tokenFromRequest(r), req)
+	h.audit(r, domain.AuditEvent{Type: domain.AuditPasswordChange, Outcome: auditOutcome(err), UserID: user.ID, ActorID: user.ID, Reason: auditReason(err)})
+	if err != nil {
+		var locked *domain.AccountLockedError
+		switch {
+		case errors.As(err, &locked):
+			writeTooManyRequests(w, "ACCOUNT_LOCKED", "Too many failed password attempts, please try again later",
+				locked.RetryAfter, fmt.Sprintf("change password failed user_id=%d reason=account_locked", user.ID))
+		case errors.Is(err, domain.ErrTokenRevoked), errors.Is(err, domain.ErrUserNotFound):
+			writeError(w, "INVALID_TOKEN", "Token validation failed", http.StatusUnauthorized, "")
+		case errors.Is(err, domain.ErrIncorrectPassword):
+			writeError(w, "INCORRECT_PASSWORD", "Current password is incorrect", http.StatusForbidden,
+				fmt.Sprintf("change password failed user_id=%d reason=incorrect_password", user.ID))
+		case domain.IsPasswordPolicyError(err):
+			writePasswordPolicyError(w, err)
+		case errors.Is(err, domain.ErrPasswordUnchanged):
+			writeError(w, "PASSWORD_UNCHANGED", "New password must differ from current password", http.StatusBadRequest, "")
+		default:
+			writeError(w, "INTERNAL_ERROR", "Password change failed", http.StatusInternalServerError,
+				fmt.Sprintf("change password failed user_id=%d err=%v", user.ID, err))
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if h.Cookies.read(r, authTokenCookieName) != "" {
+		h.setAuthCookie(w, token)
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   h.AccessTokenExpireSec,
+		UserID:      user.ID,
 	})
 }
 
+// writePasswordPolicyError 将密码策略错误映射为具体错误码，便于前端提示用户
+func writePasswordPolicyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrPasswordTooShort):
+		writeError(w, "PASSWORD_TOO_SHORT", "Password too short", http.StatusBadRequest, "")
+	case errors.Is(err, domain.ErrPasswordTooLong):
+		writeError(w, "PASSWORD_TOO_LONG", "Password too long", http.StatusBadRequest, "")
+	case errors.Is(err, domain.ErrPasswordTooWeak):
+		writeError(w, "PASSWORD_TOO_WEAK", "Password does not meet character requirements", http.StatusBadRequest, "")
+	case errors.Is(err, domain.ErrPasswordContainsUserInfo):
+		writeError(w, "PASSWORD_CONTAINS_USER_INFO", "Password must not contain your email or username", http.StatusBadRequest, "")
+	case errors.Is(err, domain.ErrPasswordReused):
+		writeError(w, "PASSWORD_REUSED", "Password was used recently", http.StatusBadRequest, "")
+	case errors.Is(err, domain.ErrPasswordBreached):
+		writeError(w, "PASSWORD_BREACHED", "Password appears in a known data breach, please choose another", http.StatusBadRequest, "")
+	}
+}
+
+// ForgotPasswordHandler 申请重置密码：向邮箱发送一次性重置链接
+// POST /api/v1/auth/password/forgot，Body: {"email"}
+// 无论邮箱是否注册均返回相同响应，避免枚举用户
+func (h *Handler) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if h.PasswordResetService == nil {
+		writeError(w, "INTERNAL_ERROR", "Password reset not configured", http.StatusInternalServerError, "")
+		return
+	}
+	var req domain.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	if err := h.PasswordResetService.RequestReset(r.Context(), req.Email); err != nil {
+		// 仅记录日志，响应与成功时一致
+		log.Printf("[AUTH] forgot password failed email=%s err=%v", req.Email, err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// ResetPasswordHandler 使用邮件中的重置令牌设置新密码，成功后该用户所有会话/token 失效
+// POST /api/v1/auth/password/reset，Body: {"token","new_password"}
+func (h *Handler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if h.PasswordResetService == nil {
+		writeError(w, "INTERNAL_ERROR", "Password reset not configured", http.StatusInternalServerError, "")
+		return
+	}
+	var req domain.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	userID, err := h.PasswordResetService.ConfirmReset(r.Context(), req)
+	h.audit(r, domain.AuditEvent{Type: domain.AuditPasswordReset, Outcome: auditOutcome(err), UserID: userID, ActorID: userID, Reason: auditReason(err)})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidResetToken):
+			writeError(w, "INVALID_RESET_TOKEN", "Invalid or expired reset token", http.StatusBadRequest, "")
+		case domain.IsPasswordPolicyError(err):
+			writePasswordPolicyError(w, err)
+		default:
+			writeError(w, "INTERNAL_ERROR", "Password reset failed", http.StatusInternalServerError,
+				fmt.Sprintf("reset password failed err=%v", err))
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
 // 用于生成用户目录名的安全字符（仅保留字母数字、下划线、横线、点）
 var safeUsernameRe = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
 
 // UploadHandler 上传静态资源：multipart form 字段 fileName、file；通过 token 鉴权，保存至 uploads/<用户名>/<文件名>
 // POST /api/v1/auth/upload，Content-Type: multipart/form-data
 func (h *Handler) UploadHandler(w http.ResponseWriter, r *http.Request) {
-	token := getTokenFromRequest(r)
+	token := h.tokenFromRequest(r)
 	if token == "" {
 		writeError(w, "UNAUTHORIZED", "Missing or invalid token", http.StatusUnauthorized, "")
 		return
@@ -421,6 +732,7 @@ func (h *Handler) TokenHandler(w http.ResponseWriter, r *http.Request) {
 		writeError(w, "INVALID_REQUEST", "code, client_id, client_secret are required", http.StatusBadRequest, "")
 		return
 	}
+	exchange := domain.AuditEvent{Type: domain.AuditCodeExchange, Outcome: domain.AuditOutcomeFailure, ClientID: clientID}
 	var client *Client
 	for i := range h.Clients {
 		if h.Clients[i].ClientID == clientID {
@@ -429,27 +741,41 @@ func (h *Handler) TokenHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	if client == nil || client.ClientSecret != clientSecret {
+		exchange.Reason = "invalid_client"
+		h.audit(r, exchange)
 		writeError(w, "INVALID_CLIENT", "invalid client_id or client_secret", http.StatusUnauthorized, "")
 		return
 	}
-	userID, codeClientID, codeRedirectURI, ok := h.CodeStore.GetAndConsume(code)
+	grant, ok := h.CodeStore.GetAndConsume(code)
 	if !ok {
+		exchange.Reason = "invalid_grant"
+		h.audit(r, exchange)
 		writeError(w, "INVALID_GRANT", "invalid or expired code", http.StatusBadRequest, "")
 		return
 	}
-	if codeClientID != clientID {
+	exchange.UserID, exchange.ActorID = grant.UserID, grant.UserID
+	if grant.ClientID != clientID {
+		exchange.Reason = "client_mismatch"
+		h.audit(r, exchange)
 		writeError(w, "INVALID_GRANT", "code was issued for another client", http.StatusBadRequest, "")
 		return
 	}
-	if redirectURI != "" && redirectURI != codeRedirectURI {
+	if redirectURI != "" && redirectURI != grant.RedirectURI {
+		exchange.Reason = "redirect_uri_mismatch"
+		h.audit(r, exchange)
 		writeError(w, "INVALID_GRANT", "redirect_uri does not match", http.StatusBadRequest, "")
 		return
 	}
-	accessToken, err := h.AuthService.IssueToken(r.Context(), userID)
+	userID := grant.UserID
+	accessToken, err := h.AuthService.IssueToken(r.Context(), userID, grant.AMR...)
 	if err != nil {
+		exchange.Reason = "internal_error"
+		h.audit(r, exchange)
 		writeError(w, "INTERNAL_ERROR", "Failed to issue token", http.StatusInternalServerError, "")
 		return
 	}
+	exchange.Outcome = domain.AuditOutcomeSuccess
+	h.audit(r, exchange)
 	expiresIn := h.AccessTokenExpireSec
 	if expiresIn <= 0 {
 		expiresIn = 86400
@@ -488,29 +814,32 @@ func (h *Handler) TokenByCodeHandler(w http.ResponseWriter, r *http.Request) {
 		writeError(w, "INVALID_REQUEST", "client_id and code are required", http.StatusBadRequest, "")
 		return
 	}
-	userID, codeClientID, _, ok := h.CodeStore.GetAndConsume(code)
+	exchange := domain.AuditEvent{Type: domain.AuditCodeExchange, Outcome: domain.AuditOutcomeFailure, ClientID: clientID}
+	grant, ok := h.CodeStore.GetAndConsume(code)
 	if !ok {
+		exchange.Reason = "invalid_grant"
+		h.audit(r, exchange)
 		writeError(w, "INVALID_GRANT", "invalid or expired code", http.StatusBadRequest, "")
 		return
 	}
-	if codeClientID != clientID {
+	exchange.UserID, exchange.ActorID = grant.UserID, grant.UserID
+	if grant.ClientID != clientID {
+		exchange.Reason = "client_mismatch"
+		h.audit(r, exchange)
 		writeError(w, "INVALID_GRANT", "code was not issued for this client_id", http.StatusBadRequest, "")
 		return
 	}
-	accessToken, err := h.AuthService.IssueToken(r.Context(), userID)
+	accessToken, err := h.AuthService.IssueToken(r.Context(), grant.UserID, grant.AMR...)
 	if err != nil {
+		exchange.Reason = "internal_error"
+		h.audit(r, exchange)
 		writeError(w, "INTERNAL_ERROR", "Failed to issue token", http.StatusInternalServerError, "")
 		return
 	}
+	exchange.Outcome = domain.AuditOutcomeSuccess
+	h.audit(r, exchange)
 	// Cookie 落在认证中心域；前端若与认证中心同源可直接带 Cookie 访问 /me、/validate；若跨域且需 user_id 可再调 GET /me（带 credentials）
-	http.SetCookie(w, &http.Cookie{
-		Name:     authTokenCookieName,
-		Value:    accessToken,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   h.CookieSecure,
-		SameSite: http.SameSiteLaxMode,
-	})
+	h.setAuthCookie(w, accessToken)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
@@ -522,18 +851,26 @@ func (h *Handler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
 		return
 	}
-	_, err := h.AuthService.Register(r.Context(), req)
+	userID, err := h.AuthService.Register(r.Context(), req)
+	h.audit(r, domain.AuditEvent{Type: domain.AuditRegister, Outcome: auditOutcome(err), UserID: userID, ActorID: userID, Email: req.Email, Reason: auditReason(err)})
 	if err != nil {
 		switch {
-		case errors.Is(err, domain.ErrEmailExists), errors.Is(err, domain.ErrUserExists):
+		case errors.Is(err, domain.ErrEmailExists):
 			writeError(w, "EMAIL_EXISTS", "Email already registered", http.StatusConflict,
 				"register failed email="+req.Email+" reason=email_exists")
 			return
+		case errors.Is(err, domain.ErrUserExists):
+			writeError(w, "USERNAME_EXISTS", "Username already taken", http.StatusConflict,
+				"register failed email="+req.Email+" reason=username_exists")
+			return
 		case errors.Is(err, domain.ErrInvalidEmail):
 			writeError(w, "INVALID_EMAIL", "Invalid email format", http.StatusBadRequest, "")
 			return
-		case errors.Is(err, domain.ErrPasswordTooShort):
-			writeError(w, "PASSWORD_TOO_SHORT", "Password too short", http.StatusBadRequest, "")
+		case errors.Is(err, domain.ErrInvalidUsername):
+			writeError(w, "INVALID_USERNAME", "Invalid username", http.StatusBadRequest, "")
+			return
+		case domain.IsPasswordPolicyError(err):
+			writePasswordPolicyError(w, err)
 			return
 		default:
 			writeError(w, "INTERNAL_ERROR", "User registration failed", http.StatusInternalServerError,
@@ -541,5 +878,63 @@ func (h *Handler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if h.EmailVerificationService != nil {
+		// 需邮箱验证：告知前端提示用户查收验证邮件
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": domain.UserStatusPending})
+		return
+	}
 	w.WriteHeader(http.StatusCreated)
 }
+
+// VerifyEmailHandler 使用验证邮件中的令牌激活账号，返回设置密码用的 reset_token
+// POST /api/v1/auth/verify-email，Body: {"token"}
+func (h *Handler) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	if h.EmailVerificationService == nil {
+		writeError(w, "INTERNAL_ERROR", "Email verification not configured", http.StatusInternalServerError, "")
+		return
+	}
+	var req domain.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	resetToken, err := h.EmailVerificationService.Verify(r.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidVerifyToken) {
+			writeError(w, "INVALID_VERIFY_TOKEN", "Invalid or expired verification token", http.StatusBadRequest, "")
+			return
+		}
+		writeError(w, "INTERNAL_ERROR", "Email verification failed", http.StatusInternalServerError,
+			fmt.Sprintf("verify email failed err=%v", err))
+		return
+	}
+	resp := map[string]string{"status": "ok"}
+	if resetToken != "" {
+		resp["reset_token"] = resetToken
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// ResendVerificationHandler 重新发送验证邮件（同一账号有发送间隔限制）
+// POST /api/v1/auth/verify-email/resend，Body: {"email"}
+// 无论邮箱是否存在、是否已验证均返回相同响应，避免枚举用户
+func (h *Handler) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	if h.EmailVerificationService == nil {
+		writeError(w, "INTERNAL_ERROR", "Email verification not configured", http.StatusInternalServerError, "")
+		return
+	}
+	var req domain.ResendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	if err := h.EmailVerificationService.Resend(r.Context(), req.Email); err != nil {
+		log.Printf("[AUTH] resend verification failed email=%s err=%v", req.Email, err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
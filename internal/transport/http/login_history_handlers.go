@@ -0,0 +1,88 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"monai-auth/internal/auth"
+	"monai-auth/internal/domain"
+)
+
+// LoginRecordResponse 一次登录记录
+type LoginRecordResponse struct {
+	ID        int64  `json:"id"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	ClientID  string `json:"client_id,omitempty"`
+	Method    string `json:"method"`
+	Country   string `json:"country,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// LoginRecordListResponse 登录记录分页列表；next_before_id 非 0 时可作为 before_id 继续查询下一页
+type LoginRecordListResponse struct {
+	Logins       []LoginRecordResponse `json:"logins"`
+	NextBeforeID int64                 `json:"next_before_id,omitempty"`
+}
+
+// recordLogin 登录成功（签发 token 或授权码）时记录登录历史；未启用时忽略
+func (h *Handler) recordLogin(r *http.Request, result *auth.LoginResult, clientID string) {
+	if h.LoginHistoryService == nil {
+		return
+	}
+	record := &domain.LoginRecord{
+		UserID:    result.UserID,
+		IP:        ClientIP(r, h.TrustProxyHeaders),
+		UserAgent: r.UserAgent(),
+		ClientID:  clientID,
+		Method:    auth.LoginMethod(result.AMR),
+	}
+	if result.Risk != nil && result.Risk.Location != nil {
+		record.Country = result.Risk.Location.Country
+		record.Latitude = result.Risk.Location.Latitude
+		record.Longitude = result.Risk.Location.Longitude
+	}
+	h.LoginHistoryService.Record(r.Context(), record)
+}
+
+// MyLoginsHandler 当前用户的登录历史（时间、IP、设备、子应用、登录方式）
+// GET /api/v1/auth/me/logins?limit=&before_id=
+func (h *Handler) MyLoginsHandler(w http.ResponseWriter, r *http.Request) {
+	if h.LoginHistoryService == nil {
+		writeError(w, "INTERNAL_ERROR", "Login history not configured", http.StatusInternalServerError, "")
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	limit, beforeID, ok := parsePage(w, r, 20)
+	if !ok {
+		return
+	}
+	records, err := h.LoginHistoryService.List(r.Context(), user.ID, beforeID, limit)
+	if err != nil {
+		writeError(w, "INTERNAL_ERROR", "Failed to load login history", http.StatusInternalServerError,
+			fmt.Sprintf("list login history failed user_id=%d err=%v", user.ID, err))
+		return
+	}
+	resp := LoginRecordListResponse{Logins: make([]LoginRecordResponse, 0, len(records))}
+	for _, rec := range records {
+		resp.Logins = append(resp.Logins, LoginRecordResponse{
+			ID:        rec.ID,
+			IP:        rec.IP,
+			UserAgent: rec.UserAgent,
+			ClientID:  rec.ClientID,
+			Method:    rec.Method,
+			Country:   rec.Country,
+			CreatedAt: rec.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	if len(records) > 0 && len(records) >= limit {
+		resp.NextBeforeID = records[len(records)-1].ID
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
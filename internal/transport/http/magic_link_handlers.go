@@ -0,0 +1,82 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"monai-auth/internal/auth"
+	"monai-auth/internal/domain"
+)
+
+// requireMagicLinkService 邮件登录未启用时写入错误响应
+func (h *Handler) requireMagicLinkService(w http.ResponseWriter) bool {
+	if h.MagicLinkService == nil {
+		writeError(w, "INTERNAL_ERROR", "Magic link login not configured", http.StatusInternalServerError, "")
+		return false
+	}
+	return true
+}
+
+// MagicLinkRequestHandler 申请邮件登录链接
+// POST /api/v1/auth/magic-link，Body: {"email","server_state"}
+// 无论邮箱是否注册均返回相同响应，避免枚举用户
+func (h *Handler) MagicLinkRequestHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireMagicLinkService(w) {
+		return
+	}
+	var req domain.MagicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	if err := h.MagicLinkService.Request(r.Context(), req); err != nil {
+		// 仅记录日志，响应与成功时一致
+		log.Printf("[AUTH] magic link request failed email=%s err=%v", req.Email, err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// MagicLinkConsumeHandler 使用邮件中的链接令牌登录；成功响应与 /login 一致（SSO 返回 redirect_url，否则写 Cookie）。
+// 仅接受 POST：邮件中的链接指向前端落地页，由用户点击后再调用本接口，邮件网关预取链接不会消耗令牌
+// POST /api/v1/auth/magic-link/consume，Body: {"token"}
+func (h *Handler) MagicLinkConsumeHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireMagicLinkService(w) {
+		return
+	}
+	var req domain.ConsumeMagicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	grant, err := h.MagicLinkService.Consume(r.Context(), req.Token)
+	if err != nil {
+		h.audit(r, domain.AuditEvent{Type: domain.AuditLogin, Outcome: domain.AuditOutcomeFailure, Reason: auditReason(err)})
+		if errors.Is(err, domain.ErrInvalidMagicLink) {
+			writeError(w, "INVALID_MAGIC_LINK", "Invalid or expired sign-in link", http.StatusBadRequest, "")
+			return
+		}
+		writeError(w, "INTERNAL_ERROR", "Server error", http.StatusInternalServerError, "consume magic link failed err="+err.Error())
+		return
+	}
+	r = h.withClientInfo(w, r)
+	result, err := h.AuthService.CompleteLogin(r.Context(), grant.UserID, []string{auth.AMREmail})
+	if err != nil {
+		h.audit(r, domain.AuditEvent{Type: domain.AuditLogin, Outcome: domain.AuditOutcomeFailure, UserID: grant.UserID, ActorID: grant.UserID, Reason: auditReason(err)})
+		switch {
+		case errors.Is(err, domain.ErrInvalidCredentials):
+			writeError(w, "INVALID_MAGIC_LINK", "Invalid or expired sign-in link", http.StatusBadRequest, "")
+		case errors.Is(err, domain.ErrEmailNotVerified):
+			writeError(w, "EMAIL_NOT_VERIFIED", "Email address not verified", http.StatusForbidden, "")
+		default:
+			writeError(w, "INTERNAL_ERROR", "Server error", http.StatusInternalServerError,
+				fmt.Sprintf("magic link login failed user_id=%d err=%v", grant.UserID, err))
+		}
+		return
+	}
+	h.writeLoginResult(w, r, result, grant.ServerState)
+}
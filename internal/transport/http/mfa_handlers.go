@@ -0,0 +1,179 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"monai-auth/internal/domain"
+)
+
+// RecoveryCodesResponse 返回一次性恢复码（明文仅在生成时返回一次）
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// writeMFAError 将 MFA 相关错误映射为统一错误响应
+func writeMFAError(w http.ResponseWriter, err error, userID int64) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidMFACode):
+		writeError(w, "INVALID_MFA_CODE", "Invalid verification code", http.StatusUnauthorized,
+			fmt.Sprintf("mfa verify failed user_id=%d reason=invalid_code", userID))
+	case errors.Is(err, domain.ErrInvalidMFAToken):
+		writeError(w, "INVALID_MFA_TOKEN", "Invalid or expired MFA token", http.StatusUnauthorized, "")
+	case errors.Is(err, domain.ErrMFANotEnrolled):
+		writeError(w, "MFA_NOT_ENROLLED", "MFA is not enrolled", http.StatusBadRequest, "")
+	case errors.Is(err, domain.ErrMFAAlreadyEnabled):
+		writeError(w, "MFA_ALREADY_ENABLED", "MFA is already enabled", http.StatusConflict, "")
+	case errors.Is(err, domain.ErrMFANotConfigured):
+		writeError(w, "INTERNAL_ERROR", "MFA not configured", http.StatusInternalServerError, "")
+	default:
+		writeError(w, "INTERNAL_ERROR", "MFA operation failed", http.StatusInternalServerError,
+			fmt.Sprintf("mfa failed user_id=%d err=%v", userID, err))
+	}
+}
+
+// LoginMFAHandler 登录第二步：提交登录接口返回的 mfa_token 与 TOTP 验证码（或恢复码）
+// POST /api/v1/auth/login/mfa，Body: {"mfa_token","code","server_state"}；成功响应与 /login 一致
+func (h *Handler) LoginMFAHandler(w http.ResponseWriter, r *http.Request) {
+	var req domain.MFAVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	if req.MFAToken == "" || req.Code == "" {
+		writeError(w, "INVALID_REQUEST", "mfa_token and code are required", http.StatusBadRequest, "")
+		return
+	}
+	r = h.withClientInfo(w, r)
+	result, err := h.AuthService.VerifyMFA(r.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		h.audit(r, domain.AuditEvent{Type: domain.AuditLogin, Outcome: domain.AuditOutcomeFailure, Reason: auditReason(err)})
+		writeMFAError(w, err, 0)
+		return
+	}
+	h.writeLoginResult(w, r, result, req.ServerState)
+}
+
+// requireMFAService MFA 未配置时写入错误响应
+func (h *Handler) requireMFAService(w http.ResponseWriter) bool {
+	if h.MFAService == nil {
+		writeError(w, "INTERNAL_ERROR", "MFA not configured", http.StatusInternalServerError, "")
+		return false
+	}
+	return true
+}
+
+// MFAStatusHandler 查询当前用户 MFA 状态
+// GET /api/v1/auth/mfa
+func (h *Handler) MFAStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireMFAService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	status, err := h.MFAService.Status(r.Context(), user.ID)
+	if err != nil {
+		writeMFAError(w, err, user.ID)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(status)
+}
+
+// EnrollTOTPHandler 开始绑定 TOTP：返回密钥与 otpauth URI（前端渲染为二维码），需调用 confirm 接口后生效
+// POST /api/v1/auth/mfa/totp/enroll
+func (h *Handler) EnrollTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireMFAService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	enrollment, err := h.MFAService.BeginTOTPEnrollment(r.Context(), user)
+	if err != nil {
+		writeMFAError(w, err, user.ID)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(enrollment)
+}
+
+// ConfirmTOTPHandler 提交验证器 App 中的验证码确认绑定，成功后返回恢复码
+// POST /api/v1/auth/mfa/totp/confirm，Body: {"code"}
+func (h *Handler) ConfirmTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireMFAService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	var req domain.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	codes, err := h.MFAService.ConfirmTOTPEnrollment(r.Context(), user.ID, req.Code)
+	h.audit(r, domain.AuditEvent{Type: domain.AuditMFAEnable, Outcome: auditOutcome(err), UserID: user.ID, ActorID: user.ID, Reason: auditReason(err)})
+	if err != nil {
+		writeMFAError(w, err, user.ID)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTPHandler 提交当前验证码（或恢复码）关闭 MFA
+// POST /api/v1/auth/mfa/totp/disable，Body: {"code"}
+func (h *Handler) DisableTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireMFAService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	var req domain.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	err := h.MFAService.DisableTOTP(r.Context(), user.ID, req.Code)
+	h.audit(r, domain.AuditEvent{Type: domain.AuditMFADisable, Outcome: auditOutcome(err), UserID: user.ID, ActorID: user.ID, Reason: auditReason(err)})
+	if err != nil {
+		writeMFAError(w, err, user.ID)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// RegenerateRecoveryCodesHandler 提交当前验证码后重新生成恢复码，旧恢复码全部作废
+// POST /api/v1/auth/mfa/recovery-codes，Body: {"code"}
+func (h *Handler) RegenerateRecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireMFAService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	var req domain.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	codes, err := h.MFAService.RegenerateRecoveryCodes(r.Context(), user.ID, req.Code)
+	h.audit(r, domain.AuditEvent{Type: domain.AuditMFARecoveryCodes, Outcome: auditOutcome(err), UserID: user.ID, ActorID: user.ID, Reason: auditReason(err)})
+	if err != nil {
+		writeMFAError(w, err, user.ID)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(RecoveryCodesResponse{RecoveryCodes: codes})
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -68,8 +69,9 @@ func CORSMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
 				w.Header().Set("Vary", "Origin")
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-CSRF-Token")
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Expose-Headers", "Retry-After")
 			}
 
 			// 预检请求直接返回
@@ -91,3 +93,47 @@ func isOriginAllowed(origin string, allowed []string) bool {
 	}
 	return false
 }
+
+// SecurityHeadersConfig 安全响应头配置，字符串为空表示不发送对应响应头
+type SecurityHeadersConfig struct {
+	// HSTSMaxAge Strict-Transport-Security 的 max-age（秒），0 表示不发送；仅在全站 HTTPS 时开启
+	HSTSMaxAge            int
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	ContentSecurityPolicy string
+	ReferrerPolicy        string
+	FrameOptions          string
+}
+
+// SecurityHeadersMiddleware 为所有响应添加安全响应头；X-Content-Type-Options: nosniff 始终发送
+func SecurityHeadersMiddleware(cfg SecurityHeadersConfig) func(http.Handler) http.Handler {
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(cfg.HSTSMaxAge)
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cfg.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			if hsts != "" {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+			if cfg.ContentSecurityPolicy != "" {
+				h.Set("Content-Security-Policy", cfg.ContentSecurityPolicy)
+			}
+			if cfg.ReferrerPolicy != "" {
+				h.Set("Referrer-Policy", cfg.ReferrerPolicy)
+			}
+			if cfg.FrameOptions != "" {
+				h.Set("X-Frame-Options", cfg.FrameOptions)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
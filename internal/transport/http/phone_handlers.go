@@ -0,0 +1,156 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"monai-auth/internal/auth"
+	"monai-auth/internal/domain"
+)
+
+// requirePhoneOTPService 短信验证码未启用时写入错误响应
+func (h *Handler) requirePhoneOTPService(w http.ResponseWriter) bool {
+	if h.PhoneOTPService == nil {
+		writeError(w, "INTERNAL_ERROR", "SMS verification not configured", http.StatusInternalServerError, "")
+		return false
+	}
+	return true
+}
+
+// writePhoneOTPError 将短信验证码相关错误映射为 HTTP 响应
+func writePhoneOTPError(w http.ResponseWriter, err error, logMsg string) {
+	var limited *domain.RateLimitedError
+	switch {
+	case errors.As(err, &limited):
+		writeTooManyRequests(w, "RATE_LIMITED", "Verification code was sent recently, please try again later", limited.RetryAfter, "")
+	case errors.Is(err, domain.ErrInvalidPhone):
+		writeError(w, "INVALID_PHONE", "Invalid phone number format", http.StatusBadRequest, "")
+	case errors.Is(err, domain.ErrInvalidOTP):
+		writeError(w, "INVALID_OTP", "Invalid or expired verification code", http.StatusBadRequest, "")
+	case errors.Is(err, domain.ErrPhoneExists):
+		writeError(w, "PHONE_EXISTS", "Phone number already bound", http.StatusConflict, "")
+	case errors.Is(err, domain.ErrEmailNotVerified):
+		writeError(w, "EMAIL_NOT_VERIFIED", "Email address not verified", http.StatusForbidden, "")
+	default:
+		writeError(w, "INTERNAL_ERROR", "Server error", http.StatusInternalServerError, fmt.Sprintf("%s err=%v", logMsg, err))
+	}
+}
+
+// PhoneLoginSendHandler 向已验证的手机号发送登录验证码
+// POST /api/v1/auth/phone/login/send，Body: {"phone_number"}
+// 手机号未注册或未验证时同样返回 202，避免枚举用户
+func (h *Handler) PhoneLoginSendHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePhoneOTPService(w) {
+		return
+	}
+	var req domain.PhoneCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	if err := h.PhoneOTPService.SendLoginCode(r.Context(), req.PhoneNumber); err != nil {
+		writePhoneOTPError(w, err, "send sms login code failed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// PhoneLoginHandler 使用短信验证码登录；成功响应与 /login 一致（SSO 返回 redirect_url，否则写 Cookie）
+// POST /api/v1/auth/phone/login，Body: {"phone_number","code","server_state"}
+func (h *Handler) PhoneLoginHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePhoneOTPService(w) {
+		return
+	}
+	var req domain.PhoneLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	userID, err := h.PhoneOTPService.VerifyLoginCode(r.Context(), req.PhoneNumber, req.Code)
+	if err != nil {
+		h.audit(r, domain.AuditEvent{Type: domain.AuditLogin, Outcome: domain.AuditOutcomeFailure, Reason: auditReason(err)})
+		writePhoneOTPError(w, err, "sms login failed")
+		return
+	}
+	r = h.withClientInfo(w, r)
+	result, err := h.AuthService.CompleteLogin(r.Context(), userID, []string{auth.AMRSMS})
+	if err != nil {
+		h.audit(r, domain.AuditEvent{Type: domain.AuditLogin, Outcome: domain.AuditOutcomeFailure, UserID: userID, ActorID: userID, Reason: auditReason(err)})
+		writePhoneOTPError(w, err, fmt.Sprintf("sms login failed user_id=%d", userID))
+		return
+	}
+	h.writeLoginResult(w, r, result, req.ServerState)
+}
+
+// SendPhoneVerificationHandler 向待绑定的手机号发送验证码
+// POST /api/v1/auth/me/phone/send，Body: {"phone_number"}
+func (h *Handler) SendPhoneVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePhoneOTPService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	var req domain.PhoneCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	if err := h.PhoneOTPService.SendVerificationCode(r.Context(), user.ID, req.PhoneNumber); err != nil {
+		writePhoneOTPError(w, err, fmt.Sprintf("send phone verification failed user_id=%d", user.ID))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// VerifyPhoneHandler 校验验证码并将手机号绑定为已验证，返回更新后的用户资料
+// POST /api/v1/auth/me/phone/verify，Body: {"phone_number","code"}
+func (h *Handler) VerifyPhoneHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePhoneOTPService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	var req domain.VerifyPhoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	updated, err := h.PhoneOTPService.VerifyPhone(r.Context(), user.ID, req.PhoneNumber, req.Code)
+	h.audit(r, domain.AuditEvent{Type: domain.AuditPhoneVerify, Outcome: auditOutcome(err), UserID: user.ID, ActorID: user.ID, Reason: auditReason(err)})
+	if err != nil {
+		writePhoneOTPError(w, err, fmt.Sprintf("verify phone failed user_id=%d", user.ID))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(newCurrentUserResponse(updated))
+}
+
+// RemovePhoneHandler 解绑当前手机号，返回更新后的用户资料
+// DELETE /api/v1/auth/me/phone
+func (h *Handler) RemovePhoneHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePhoneOTPService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	updated, err := h.PhoneOTPService.RemovePhone(r.Context(), user.ID)
+	h.audit(r, domain.AuditEvent{Type: domain.AuditPhoneRemove, Outcome: auditOutcome(err), UserID: user.ID, ActorID: user.ID, Reason: auditReason(err)})
+	if err != nil {
+		writePhoneOTPError(w, err, fmt.Sprintf("remove phone failed user_id=%d", user.ID))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(newCurrentUserResponse(updated))
+}
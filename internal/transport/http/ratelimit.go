@@ -0,0 +1,154 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"monai-auth/internal/auth"
+	"monai-auth/internal/domain"
+)
+
+// maxRateLimitBodyBytes 提取请求体字段作为限流 key 时最多读取的字节数
+const maxRateLimitBodyBytes = 1 << 20
+
+// RateLimitMiddleware 按 keyFunc 提取的 key 限流，超限返回 429 与 Retry-After。
+// key 为空时不限流；限流器出错时放行并记录日志，避免存储故障导致无法登录。
+func RateLimitMiddleware(limiter auth.RateLimiter, scope string, keyFunc func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := keyFunc(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			allowed, retryAfter, err := limiter.Allow(r.Context(), scope+":"+key)
+			if err != nil {
+				log.Printf("[AUTH] rate limit check failed scope=%s err=%v", scope, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !allowed {
+				writeTooManyRequests(w, "RATE_LIMITED", "Too many requests, please try again later", retryAfter,
+					fmt.Sprintf("rate limited scope=%s key=%s", scope, key))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeTooManyRequests 写入 429 响应，Retry-After 向上取整到秒
+func writeTooManyRequests(w http.ResponseWriter, code, message string, retryAfter time.Duration, logMsg string) {
+	secs := int(math.Ceil(retryAfter.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	writeError(w, code, message, http.StatusTooManyRequests, logMsg)
+}
+
+// ClientIPKey 以客户端 IP 作为限流 key
+func ClientIPKey(trustProxyHeaders bool) func(*http.Request) string {
+	return func(r *http.Request) string {
+		return ClientIP(r, trustProxyHeaders)
+	}
+}
+
+// JSONFieldKey 以 JSON 请求体中的字段（如 email）作为限流 key，统一去空格并转小写；读取后还原请求体。
+// 传入多个字段时取第一个非空字段。字段名按 encoding/json 解码结构体的规则匹配（不区分大小写、重复时取最后一个），
+// 且不要求 Content-Type 为 JSON，与处理函数实际解码得到的值一致，避免改写字段名大小写或 Content-Type 绕过限流
+func JSONFieldKey(fields ...string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		body := readRateLimitBody(r)
+		values := make([]string, len(fields))
+		dec := json.NewDecoder(bytes.NewReader(body))
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+			return ""
+		}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return ""
+			}
+			name, _ := tok.(string)
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return ""
+			}
+			for i, field := range fields {
+				if strings.EqualFold(name, field) {
+					var v string
+					if json.Unmarshal(raw, &v) == nil {
+						values[i] = v
+					}
+				}
+			}
+		}
+		for _, v := range values {
+			if v = strings.TrimSpace(v); v != "" {
+				return strings.ToLower(v)
+			}
+		}
+		return ""
+	}
+}
+
+// LoginIdentifierKey 以登录请求的登录标识作为限流 key：与 LoginHandler 一样解码为 domain.LoginRequest，
+// 按登录查找用户的规则规范化（手机号去除分隔符，其余转小写），同一账号的不同写法共用一个计数
+func LoginIdentifierKey() func(*http.Request) string {
+	return func(r *http.Request) string {
+		var req domain.LoginRequest
+		if json.NewDecoder(bytes.NewReader(readRateLimitBody(r))).Decode(&req) != nil {
+			return ""
+		}
+		identifier := req.LoginIdentifier()
+		if phone, err := domain.NormalizePhoneNumber(identifier); err == nil {
+			return phone
+		}
+		return strings.ToLower(identifier)
+	}
+}
+
+// readRateLimitBody 读取请求体（最多 maxRateLimitBodyBytes）并还原，供后续处理函数再次读取
+func readRateLimitBody(r *http.Request) []byte {
+	if r.Body == nil {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(r.Body, maxRateLimitBodyBytes))
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body
+}
+
+// ClientIP 获取客户端 IP；仅在部署于可信反向代理之后时才信任 X-Forwarded-For / X-Real-IP。
+// X-Forwarded-For 取最右一项：该项由可信代理追加，左侧各项来自客户端，可任意伪造
+func ClientIP(r *http.Request, trustProxyHeaders bool) string {
+	if trustProxyHeaders {
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			last := values[len(values)-1]
+			if i := strings.LastIndex(last, ","); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := net.ParseIP(strings.TrimSpace(last)); ip != nil {
+				return ip.String()
+			}
+		}
+		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+			return ip.String()
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
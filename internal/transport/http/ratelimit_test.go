@@ -0,0 +1,142 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		xff        []string
+		realIP     string
+		want       string
+	}{
+		{"no proxy", false, nil, "", "203.0.113.9"},
+		{"headers ignored without trusted proxy", false, []string{"198.51.100.1"}, "198.51.100.2", "203.0.113.9"},
+		{"single hop", true, []string{"198.51.100.1"}, "", "198.51.100.1"},
+		// 客户端自带的 X-Forwarded-For 在左侧，代理追加的真实地址在最右
+		{"spoofed leftmost", true, []string{"1.2.3.4, 198.51.100.1"}, "", "198.51.100.1"},
+		{"multiple header lines", true, []string{"1.2.3.4", "5.6.7.8, 198.51.100.1"}, "", "198.51.100.1"},
+		{"ipv6", true, []string{"2001:db8::1"}, "", "2001:db8::1"},
+		{"invalid entry falls back", true, []string{"1.2.3.4, not-an-ip"}, "", "203.0.113.9"},
+		{"x-real-ip", true, nil, "198.51.100.2", "198.51.100.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = "203.0.113.9:51234"
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := ClientIP(r, tt.trustProxy); got != tt.want {
+				t.Fatalf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func newBodyRequest(contentType, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+func TestJSONFieldKey(t *testing.T) {
+	key := JSONFieldKey("email")
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"json", "application/json", `{"email":" Alice@Example.com "}`, "alice@example.com"},
+		// 处理函数按 encoding/json 解码结构体：字段名不区分大小写、不检查 Content-Type、重复字段取最后一个
+		{"upper-case field name", "application/json", `{"EMAIL":"alice@example.com"}`, "alice@example.com"},
+		{"text/plain", "text/plain", `{"email":"alice@example.com"}`, "alice@example.com"},
+		{"no content type", "", `{"email":"alice@example.com"}`, "alice@example.com"},
+		{"duplicate field", "application/json", `{"email":"decoy@example.com","Email":"alice@example.com"}`, "alice@example.com"},
+		{"trailing data", "application/json", `{"email":"alice@example.com"} trailing`, "alice@example.com"},
+		{"missing", "application/json", `{"other":"x"}`, ""},
+		{"not json", "application/json", `email=alice@example.com`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newBodyRequest(tt.contentType, tt.body)
+			if got := key(r); got != tt.want {
+				t.Fatalf("key = %q, want %q", got, tt.want)
+			}
+			// 请求体需还原给处理函数
+			if body, _ := io.ReadAll(r.Body); string(body) != tt.body {
+				t.Fatalf("body not restored: %q", body)
+			}
+		})
+	}
+}
+
+func TestLoginIdentifierKey(t *testing.T) {
+	key := LoginIdentifierKey()
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"identifier", `{"identifier":"Alice@Example.com","password":"x"}`, "alice@example.com"},
+		{"email fallback", `{"email":"alice@example.com"}`, "alice@example.com"},
+		{"username fallback", `{"username":"Alice"}`, "alice"},
+		{"field case", `{"Identifier":"alice"}`, "alice"},
+		{"identifier wins", `{"identifier":"alice","email":"bob@example.com"}`, "alice"},
+		// 同一手机号的不同写法共用计数
+		{"phone", `{"identifier":"+86 138-0013-8000"}`, "+8613800138000"},
+		{"empty", `{"password":"x"}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := key(newBodyRequest("text/plain", tt.body)); got != tt.want {
+				t.Fatalf("key = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// countingLimiter 每个 key 允许 limit 次
+type countingLimiter struct {
+	limit int
+	seen  map[string]int
+}
+
+func (l *countingLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	l.seen[key]++
+	return l.seen[key] <= l.limit, 1500 * time.Millisecond, nil
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter := &countingLimiter{limit: 2, seen: map[string]int{}}
+	handler := RateLimitMiddleware(limiter, "login_email", LoginIdentifierKey())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	// 改写大小写与 Content-Type 不能绕过同一账号的计数
+	bodies := []string{`{"identifier":"alice@example.com"}`, `{"EMAIL":"ALICE@example.com"}`, `{"Identifier":"Alice@Example.com"}`}
+	want := []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests}
+	for i, body := range bodies {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newBodyRequest("text/plain", body))
+		if w.Code != want[i] {
+			t.Fatalf("request %d: status = %d, want %d", i, w.Code, want[i])
+		}
+	}
+	if limiter.seen["login_email:alice@example.com"] != 3 {
+		t.Fatalf("unexpected keys %v", limiter.seen)
+	}
+}
@@ -0,0 +1,217 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"monai-auth/internal/auth"
+	"monai-auth/internal/domain"
+	"monai-auth/internal/saml"
+)
+
+// maxSAMLFormBytes HTTP-POST 绑定请求体上限
+const maxSAMLFormBytes = 256 << 10
+
+// samlContinuePath 浏览器完成登录（或已有会话）后投递断言的地址
+const samlContinuePath = "/api/v1/auth/saml/continue"
+
+// samlHopPage 经本站页面再导航一次：SP 的跨站 POST 不会携带 SameSite Cookie，由本站发起的导航才能读到登录会话
+var samlHopPage = template.Must(template.New("saml_hop").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta http-equiv="refresh" content="0;url={{.}}"><title>Signing in</title></head>
+<body><p><a href="{{.}}">Continue</a></p></body></html>
+`))
+
+// samlPostPage HTTP-POST 绑定：自动提交到 SP 的 ACS 地址
+var samlPostPage = template.Must(template.New("saml_post").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Signing in</title></head>
+<body><form method="post" action="{{.Post.ACSURL}}">
+<input type="hidden" name="SAMLResponse" value="{{.Post.SAMLResponse}}">
+{{- if .Post.RelayState}}
+<input type="hidden" name="RelayState" value="{{.Post.RelayState}}">
+{{- end}}
+<noscript><button type="submit">Continue</button></noscript>
+</form>
+<script nonce="{{.Nonce}}">document.forms[0].submit();</script>
+</body></html>
+`))
+
+// requireSAMLService SAML IdP 未启用时写入错误响应
+func (h *Handler) requireSAMLService(w http.ResponseWriter) bool {
+	if h.SAMLService == nil {
+		writeError(w, "INTERNAL_ERROR", "SAML not configured", http.StatusInternalServerError, "")
+		return false
+	}
+	return true
+}
+
+// samlErrorCode 将 SAML 错误映射为错误码、提示与状态码
+func samlErrorCode(err error) (string, string, int) {
+	switch {
+	case errors.Is(err, domain.ErrUnknownServiceProvider):
+		return "UNKNOWN_SERVICE_PROVIDER", "Unknown SAML service provider", http.StatusBadRequest
+	case errors.Is(err, domain.ErrInvalidSAMLRequest):
+		return "INVALID_SAML_REQUEST", "Invalid SAML request", http.StatusBadRequest
+	case errors.Is(err, domain.ErrInvalidSAMLState):
+		return "INVALID_SAML_STATE", "Invalid or expired SAML sign-in request", http.StatusBadRequest
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		return "INVALID_CREDENTIALS", "Invalid credentials", http.StatusUnauthorized
+	default:
+		return "INTERNAL_ERROR", "Server error", http.StatusInternalServerError
+	}
+}
+
+func (h *Handler) samlContinueURL(state string) string {
+	return strings.TrimSuffix(h.AuthBaseURL, "/") + samlContinuePath + "?state=" + url.QueryEscape(state)
+}
+
+// SAMLMetadataHandler IdP 元数据，供 SP 管理员导入
+// GET /api/v1/auth/saml/metadata
+func (h *Handler) SAMLMetadataHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSAMLService(w) {
+		return
+	}
+	md, err := h.SAMLService.Metadata()
+	if err != nil {
+		writeError(w, "INTERNAL_ERROR", "Server error", http.StatusInternalServerError, "build saml metadata failed err="+err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	_, _ = w.Write(md)
+}
+
+// SAMLSSOHandler 接收 SP 的 AuthnRequest（HTTP-Redirect 或 HTTP-POST 绑定），保存为待完成请求后经本站页面导航到 continue
+// GET /api/v1/auth/saml/sso?SAMLRequest=xxx&RelayState=xxx
+// POST /api/v1/auth/saml/sso，表单字段 SAMLRequest、RelayState
+func (h *Handler) SAMLSSOHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSAMLService(w) {
+		return
+	}
+	var req *saml.AuthnRequest
+	var relayState string
+	var err error
+	if r.Method == http.MethodPost {
+		r.Body = http.MaxBytesReader(w, r.Body, maxSAMLFormBytes)
+		if err := r.ParseForm(); err != nil {
+			writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+			return
+		}
+		req, err = saml.ParsePostRequest(r.PostForm.Get("SAMLRequest"))
+		relayState = r.PostForm.Get("RelayState")
+	} else {
+		q := r.URL.Query()
+		req, err = saml.ParseRedirectRequest(q.Get("SAMLRequest"))
+		relayState = q.Get("RelayState")
+	}
+	var state string
+	if err != nil {
+		err = fmt.Errorf("%w: %v", domain.ErrInvalidSAMLRequest, err)
+	} else {
+		state, err = h.SAMLService.Begin(req, relayState)
+	}
+	if err != nil {
+		issuer := ""
+		if req != nil {
+			issuer = req.Issuer
+		}
+		h.audit(r, domain.AuditEvent{Type: domain.AuditSAMLAssertion, Outcome: domain.AuditOutcomeFailure, ClientID: issuer, Reason: auditReason(err)})
+		code, message, status := samlErrorCode(err)
+		writeError(w, code, message, status, fmt.Sprintf("saml sso rejected issuer=%q err=%v", issuer, err))
+		return
+	}
+	h.writeSAMLHTML(w, samlHopPage, h.samlContinueURL(state), "")
+}
+
+// SAMLContinueHandler 为待完成的 SAML 请求签发断言并自动 POST 到 SP：
+// 已有登录会话（且 SP 未要求 ForceAuthn）时直接签发；否则跳转登录页 ?client_id=<SP 实体标识>&state=xxx，
+// 登录页把 state 作为 server_state 提交，登录成功后回到本接口；IsPassive 请求且未登录时向 SP 返回 NoPassive
+// GET /api/v1/auth/saml/continue?state=xxx
+func (h *Handler) SAMLContinueHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSAMLService(w) {
+		return
+	}
+	state := r.URL.Query().Get("state")
+	pending, ok := h.SAMLService.Pending(state)
+	if !ok {
+		code, message, status := samlErrorCode(domain.ErrInvalidSAMLState)
+		writeError(w, code, message, status, "")
+		return
+	}
+	var user *domain.User
+	if token := h.tokenFromRequest(r); token != "" {
+		if u, err := h.AuthService.Validate(r.Context(), token); err == nil {
+			user = u
+		}
+	}
+	// 已在登录页为本请求登录时会话用户必须一致；否则复用已有会话，ForceAuthn 要求重新登录
+	authenticated := user != nil && !pending.ForceAuthn
+	if pending.UserID != 0 {
+		authenticated = user != nil && user.ID == pending.UserID
+	}
+
+	if !authenticated {
+		if pending.IsPassive {
+			post, _, err := h.SAMLService.Fail(state, saml.StatusNoPassive)
+			h.audit(r, domain.AuditEvent{Type: domain.AuditSAMLAssertion, Outcome: domain.AuditOutcomeFailure, ClientID: pending.ServiceProvider, Reason: "no_passive"})
+			if err != nil {
+				code, message, status := samlErrorCode(err)
+				writeError(w, code, message, status, "saml passive response failed err="+err.Error())
+				return
+			}
+			h.writeSAMLPost(w, post)
+			return
+		}
+		base := strings.TrimSuffix(h.AuthBaseURL, "/")
+		path := strings.TrimPrefix(h.LoginPagePath, "/")
+		w.Header().Set("Cache-Control", "no-store")
+		http.Redirect(w, r, base+"/"+path+"?client_id="+url.QueryEscape(pending.ServiceProvider)+"&state="+url.QueryEscape(state), http.StatusFound)
+		return
+	}
+
+	post, _, err := h.SAMLService.Complete(r.Context(), state, user.ID)
+	h.audit(r, domain.AuditEvent{Type: domain.AuditSAMLAssertion, Outcome: auditOutcome(err), UserID: user.ID, ActorID: user.ID, ClientID: pending.ServiceProvider, Reason: auditReason(err)})
+	if err != nil {
+		code, message, status := samlErrorCode(err)
+		writeError(w, code, message, status, fmt.Sprintf("saml assertion failed user_id=%d sp=%s err=%v", user.ID, pending.ServiceProvider, err))
+		return
+	}
+	log.Printf("[AUTH] saml assertion issued user_id=%d sp=%s", user.ID, pending.ServiceProvider)
+	h.writeSAMLPost(w, post)
+}
+
+// writeSAMLPost 渲染自动提交到 ACS 的表单；CSP 仅放行本页脚本与向 ACS 所在源提交
+func (h *Handler) writeSAMLPost(w http.ResponseWriter, post auth.SAMLPost) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		writeError(w, "INTERNAL_ERROR", "Server error", http.StatusInternalServerError, "generate csp nonce failed")
+		return
+	}
+	nonce := base64.StdEncoding.EncodeToString(b)
+	u, err := url.Parse(post.ACSURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		writeError(w, "INTERNAL_ERROR", "Server error", http.StatusInternalServerError, "invalid acs url "+post.ACSURL)
+		return
+	}
+	csp := "default-src 'none'; script-src 'nonce-" + nonce + "'; form-action " + u.Scheme + "://" + u.Host + "; frame-ancestors 'none'; base-uri 'none'"
+	h.writeSAMLHTML(w, samlPostPage, struct {
+		Post  auth.SAMLPost
+		Nonce string
+	}{post, nonce}, csp)
+}
+
+func (h *Handler) writeSAMLHTML(w http.ResponseWriter, tmpl *template.Template, data any, csp string) {
+	if csp != "" {
+		w.Header().Set("Content-Security-Policy", csp)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := tmpl.Execute(w, data); err != nil {
+		log.Printf("[AUTH] render saml page failed err=%v", err)
+	}
+}
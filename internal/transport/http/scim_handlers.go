@@ -0,0 +1,410 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"monai-auth/internal/auth"
+	"monai-auth/internal/domain"
+	"monai-auth/internal/scim"
+)
+
+// maxSCIMBodyBytes SCIM 请求体上限（大组的全量成员替换也在此范围内）
+const maxSCIMBodyBytes = 1 << 20
+
+// SCIMClient 持有 bearer token 的同步客户端（HR 系统、IdP 等）
+type SCIMClient struct {
+	Name  string
+	Token string
+}
+
+// SCIMAuth 校验 SCIM 请求的 Authorization: Bearer <token>，通过后将客户端名称写入请求上下文
+type SCIMAuth struct {
+	names   []string
+	digests [][32]byte
+}
+
+// NewSCIMAuth 创建 SCIM 鉴权；比较 token 的 SHA-256 摘要，耗时与 token 内容、长度无关
+func NewSCIMAuth(clients []SCIMClient) *SCIMAuth {
+	a := &SCIMAuth{}
+	for _, c := range clients {
+		a.names = append(a.names, c.Name)
+		a.digests = append(a.digests, sha256.Sum256([]byte(c.Token)))
+	}
+	return a
+}
+
+type scimClientKey struct{}
+
+// Middleware 拒绝未携带或携带无效 token 的请求（401，SCIM 错误格式）
+func (a *SCIMAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		client := ""
+		if ok && token != "" {
+			digest := sha256.Sum256([]byte(token))
+			for i, d := range a.digests {
+				if subtle.ConstantTimeCompare(digest[:], d[:]) == 1 {
+					client = a.names[i]
+				}
+			}
+		}
+		if client == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+			writeSCIMError(w, http.StatusUnauthorized, "", "Invalid or missing bearer token")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), scimClientKey{}, client)))
+	})
+}
+
+// scimClient 返回当前请求的同步客户端名称
+func scimClient(r *http.Request) string {
+	name, _ := r.Context().Value(scimClientKey{}).(string)
+	return name
+}
+
+// scimServiceProviderConfig 服务能力声明（RFC 7643 §5）
+var scimServiceProviderConfig = map[string]any{
+	"schemas":        []string{scim.SchemaServiceProviderConfig},
+	"patch":          map[string]any{"supported": true},
+	"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+	"filter":         map[string]any{"supported": true, "maxResults": auth.MaxSCIMPageSize},
+	"changePassword": map[string]any{"supported": true},
+	"sort":           map[string]any{"supported": false},
+	"etag":           map[string]any{"supported": false},
+	"authenticationSchemes": []map[string]any{{
+		"type":        "oauthbearertoken",
+		"name":        "Bearer Token",
+		"description": "Static bearer token issued to the provisioning client",
+		"primary":     true,
+	}},
+}
+
+// requireSCIMService SCIM 未启用时写入错误响应
+func (h *Handler) requireSCIMService(w http.ResponseWriter) bool {
+	if h.SCIMService == nil {
+		writeSCIMError(w, http.StatusInternalServerError, "", "SCIM not configured")
+		return false
+	}
+	return true
+}
+
+// writeSCIM 以 application/scim+json 写入响应
+func writeSCIM(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", scim.MediaType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeSCIMError(w http.ResponseWriter, status int, scimType, detail string) {
+	writeSCIM(w, status, scim.Error{
+		Schemas:  []string{scim.SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+// writeSCIMServiceError 将服务错误映射为 SCIM 错误响应
+func writeSCIMServiceError(w http.ResponseWriter, err error, logMsg string) {
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound), errors.Is(err, domain.ErrGroupNotFound):
+		writeSCIMError(w, http.StatusNotFound, "", "Resource not found")
+	case errors.Is(err, domain.ErrUserExists), errors.Is(err, domain.ErrEmailExists), errors.Is(err, domain.ErrPhoneExists),
+		errors.Is(err, domain.ErrGroupExists), errors.Is(err, domain.ErrIdentityExists):
+		writeSCIMError(w, http.StatusConflict, "uniqueness", err.Error())
+	case scim.ErrorType(err) != "":
+		writeSCIMError(w, http.StatusBadRequest, scim.ErrorType(err), err.Error())
+	case errors.Is(err, domain.ErrInvalidEmail), errors.Is(err, domain.ErrInvalidUsername), errors.Is(err, domain.ErrInvalidPhone),
+		errors.Is(err, domain.ErrInvalidProfile), domain.IsPasswordPolicyError(err):
+		writeSCIMError(w, http.StatusBadRequest, "invalidValue", err.Error())
+	default:
+		log.Printf("[SCIM] %s err=%v", logMsg, err)
+		writeSCIMError(w, http.StatusInternalServerError, "", "Server error")
+	}
+}
+
+// decodeSCIM 读取请求体，失败时写入 invalidSyntax 错误
+func decodeSCIM(w http.ResponseWriter, r *http.Request, v any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxSCIMBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalidSyntax", "Invalid request body")
+		return false
+	}
+	return true
+}
+
+// decodeSCIMPatch 读取 PATCH 请求体，至少需要一个操作
+func decodeSCIMPatch(w http.ResponseWriter, r *http.Request) ([]scim.PatchOperation, bool) {
+	var req scim.PatchRequest
+	if !decodeSCIM(w, r, &req) {
+		return nil, false
+	}
+	if len(req.Operations) == 0 {
+		writeSCIMError(w, http.StatusBadRequest, "invalidSyntax", "Operations is required")
+		return nil, false
+	}
+	return req.Operations, true
+}
+
+// scimListQuery 解析 filter、startIndex、count 查询参数；非法的分页参数按未指定处理
+func scimListQuery(r *http.Request) auth.SCIMListQuery {
+	q := r.URL.Query()
+	query := auth.SCIMListQuery{Filter: q.Get("filter"), StartIndex: 1, Count: -1}
+	if v, err := strconv.Atoi(q.Get("startIndex")); err == nil {
+		query.StartIndex = v
+	}
+	if v, err := strconv.Atoi(q.Get("count")); err == nil && v >= 0 {
+		query.Count = v
+	}
+	return query
+}
+
+// SCIMServiceProviderConfigHandler 服务能力声明
+// GET /scim/v2/ServiceProviderConfig
+func (h *Handler) SCIMServiceProviderConfigHandler(w http.ResponseWriter, r *http.Request) {
+	writeSCIM(w, http.StatusOK, scimServiceProviderConfig)
+}
+
+// SCIMListUsersHandler 查询用户，filter 支持 userName、emails(.value)、externalId、id、active 的 eq 条件（以 and 连接）
+// GET /scim/v2/Users?filter=userName eq "alice"&startIndex=1&count=100
+func (h *Handler) SCIMListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSCIMService(w) {
+		return
+	}
+	list, err := h.SCIMService.ListUsers(r.Context(), scimListQuery(r))
+	if err != nil {
+		writeSCIMServiceError(w, err, "list users failed")
+		return
+	}
+	writeSCIM(w, http.StatusOK, list)
+}
+
+// SCIMGetUserHandler 查询单个用户
+// GET /scim/v2/Users/{id}
+func (h *Handler) SCIMGetUserHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSCIMService(w) {
+		return
+	}
+	user, err := h.SCIMService.GetUser(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeSCIMServiceError(w, err, "get user failed")
+		return
+	}
+	writeSCIM(w, http.StatusOK, user)
+}
+
+// SCIMCreateUserHandler 创建用户
+// POST /scim/v2/Users
+func (h *Handler) SCIMCreateUserHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSCIMService(w) {
+		return
+	}
+	var req scim.User
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+	user, err := h.SCIMService.CreateUser(r.Context(), &req)
+	var userID int64
+	if err == nil {
+		userID, _ = strconv.ParseInt(user.ID, 10, 64)
+	}
+	h.audit(r, domain.AuditEvent{Type: domain.AuditSCIMUserCreate, Outcome: auditOutcome(err), UserID: userID, ClientID: scimClient(r), Reason: auditReason(err)})
+	if err != nil {
+		writeSCIMServiceError(w, err, fmt.Sprintf("create user failed client=%s", scimClient(r)))
+		return
+	}
+	log.Printf("[SCIM] user created user_id=%d client=%s", userID, scimClient(r))
+	w.Header().Set("Location", user.Meta.Location)
+	writeSCIM(w, http.StatusCreated, user)
+}
+
+// SCIMReplaceUserHandler 整体替换用户属性；active 未携带时不修改状态，password 为空时不修改密码
+// PUT /scim/v2/Users/{id}
+func (h *Handler) SCIMReplaceUserHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSCIMService(w) {
+		return
+	}
+	var req scim.User
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	user, err := h.SCIMService.ReplaceUser(r.Context(), id, &req)
+	h.auditSCIMUser(r, domain.AuditSCIMUserUpdate, id, err)
+	if err != nil {
+		writeSCIMServiceError(w, err, fmt.Sprintf("replace user failed id=%s client=%s", id, scimClient(r)))
+		return
+	}
+	writeSCIM(w, http.StatusOK, user)
+}
+
+// SCIMPatchUserHandler 按 PatchOp 修改用户，如 {"op":"replace","path":"active","value":false} 停用账号
+// PATCH /scim/v2/Users/{id}
+func (h *Handler) SCIMPatchUserHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSCIMService(w) {
+		return
+	}
+	ops, ok := decodeSCIMPatch(w, r)
+	if !ok {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	user, err := h.SCIMService.PatchUser(r.Context(), id, ops)
+	h.auditSCIMUser(r, domain.AuditSCIMUserUpdate, id, err)
+	if err != nil {
+		writeSCIMServiceError(w, err, fmt.Sprintf("patch user failed id=%s client=%s", id, scimClient(r)))
+		return
+	}
+	writeSCIM(w, http.StatusOK, user)
+}
+
+// SCIMDeleteUserHandler 删除用户（软删除，设置 deleted_at），同时移出所有组
+// DELETE /scim/v2/Users/{id}
+func (h *Handler) SCIMDeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSCIMService(w) {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	err := h.SCIMService.DeleteUser(r.Context(), id)
+	h.auditSCIMUser(r, domain.AuditSCIMUserDelete, id, err)
+	if err != nil {
+		writeSCIMServiceError(w, err, fmt.Sprintf("delete user failed id=%s client=%s", id, scimClient(r)))
+		return
+	}
+	log.Printf("[SCIM] user deleted user_id=%s client=%s", id, scimClient(r))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) auditSCIMUser(r *http.Request, eventType, id string, err error) {
+	userID, _ := strconv.ParseInt(id, 10, 64)
+	h.audit(r, domain.AuditEvent{Type: eventType, Outcome: auditOutcome(err), UserID: userID, ClientID: scimClient(r), Reason: auditReason(err)})
+}
+
+// SCIMListGroupsHandler 查询组，filter 支持 displayName、externalId、id 的 eq 条件
+// GET /scim/v2/Groups?filter=displayName eq "Engineering"
+func (h *Handler) SCIMListGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSCIMService(w) {
+		return
+	}
+	list, err := h.SCIMService.ListGroups(r.Context(), scimListQuery(r))
+	if err != nil {
+		writeSCIMServiceError(w, err, "list groups failed")
+		return
+	}
+	writeSCIM(w, http.StatusOK, list)
+}
+
+// SCIMGetGroupHandler 查询单个组（含成员）
+// GET /scim/v2/Groups/{id}
+func (h *Handler) SCIMGetGroupHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSCIMService(w) {
+		return
+	}
+	group, err := h.SCIMService.GetGroup(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeSCIMServiceError(w, err, "get group failed")
+		return
+	}
+	writeSCIM(w, http.StatusOK, group)
+}
+
+// SCIMCreateGroupHandler 创建组，成员必须是已存在的用户
+// POST /scim/v2/Groups
+func (h *Handler) SCIMCreateGroupHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSCIMService(w) {
+		return
+	}
+	var req scim.Group
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+	group, err := h.SCIMService.CreateGroup(r.Context(), &req)
+	reason := auditReason(err)
+	if err == nil {
+		reason = "group_id=" + group.ID
+	}
+	h.audit(r, domain.AuditEvent{Type: domain.AuditSCIMGroupCreate, Outcome: auditOutcome(err), ClientID: scimClient(r), Reason: reason})
+	if err != nil {
+		writeSCIMServiceError(w, err, fmt.Sprintf("create group failed client=%s", scimClient(r)))
+		return
+	}
+	w.Header().Set("Location", group.Meta.Location)
+	writeSCIM(w, http.StatusCreated, group)
+}
+
+// SCIMReplaceGroupHandler 整体替换组名称与成员
+// PUT /scim/v2/Groups/{id}
+func (h *Handler) SCIMReplaceGroupHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSCIMService(w) {
+		return
+	}
+	var req scim.Group
+	if !decodeSCIM(w, r, &req) {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	group, err := h.SCIMService.ReplaceGroup(r.Context(), id, &req)
+	h.auditSCIMGroup(r, domain.AuditSCIMGroupUpdate, id, err)
+	if err != nil {
+		writeSCIMServiceError(w, err, fmt.Sprintf("replace group failed id=%s client=%s", id, scimClient(r)))
+		return
+	}
+	writeSCIM(w, http.StatusOK, group)
+}
+
+// SCIMPatchGroupHandler 按 PatchOp 修改组，如 {"op":"add","path":"members","value":[{"value":"42"}]}
+// PATCH /scim/v2/Groups/{id}
+func (h *Handler) SCIMPatchGroupHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSCIMService(w) {
+		return
+	}
+	ops, ok := decodeSCIMPatch(w, r)
+	if !ok {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	group, err := h.SCIMService.PatchGroup(r.Context(), id, ops)
+	h.auditSCIMGroup(r, domain.AuditSCIMGroupUpdate, id, err)
+	if err != nil {
+		writeSCIMServiceError(w, err, fmt.Sprintf("patch group failed id=%s client=%s", id, scimClient(r)))
+		return
+	}
+	writeSCIM(w, http.StatusOK, group)
+}
+
+// SCIMDeleteGroupHandler 删除组及其成员关系（不影响成员用户）
+// DELETE /scim/v2/Groups/{id}
+func (h *Handler) SCIMDeleteGroupHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireSCIMService(w) {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	err := h.SCIMService.DeleteGroup(r.Context(), id)
+	h.auditSCIMGroup(r, domain.AuditSCIMGroupDelete, id, err)
+	if err != nil {
+		writeSCIMServiceError(w, err, fmt.Sprintf("delete group failed id=%s client=%s", id, scimClient(r)))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// auditSCIMGroup 组事件没有对应用户，成功时 Reason 记录组 ID
+func (h *Handler) auditSCIMGroup(r *http.Request, eventType, id string, err error) {
+	reason := auditReason(err)
+	if err == nil {
+		reason = "group_id=" + id
+	}
+	h.audit(r, domain.AuditEvent{Type: eventType, Outcome: auditOutcome(err), ClientID: scimClient(r), Reason: reason})
+}
@@ -0,0 +1,240 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-webauthn/webauthn/protocol"
+
+	"monai-auth/internal/domain"
+)
+
+// WebAuthnBeginRegistrationResponse 注册选项：publicKey 直接传给 navigator.credentials.create
+type WebAuthnBeginRegistrationResponse struct {
+	SessionID string                       `json:"session_id"`
+	Options   *protocol.CredentialCreation `json:"options"`
+}
+
+// WebAuthnBeginLoginResponse 登录选项：publicKey 直接传给 navigator.credentials.get
+type WebAuthnBeginLoginResponse struct {
+	SessionID string                        `json:"session_id"`
+	Options   *protocol.CredentialAssertion `json:"options"`
+}
+
+// WebAuthnFinishRegistrationRequest 注册完成请求，credential 为浏览器返回的 PublicKeyCredential JSON
+type WebAuthnFinishRegistrationRequest struct {
+	SessionID  string          `json:"session_id"`
+	Name       string          `json:"name"`
+	Credential json.RawMessage `json:"credential"`
+}
+
+// WebAuthnFinishLoginRequest 登录完成请求；SSO 流程中携带 server_state
+type WebAuthnFinishLoginRequest struct {
+	SessionID   string          `json:"session_id"`
+	Credential  json.RawMessage `json:"credential"`
+	ServerState string          `json:"server_state"`
+}
+
+// WebAuthnCredentialResponse 凭据列表项（不返回公钥）
+type WebAuthnCredentialResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Transports []string   `json:"transports"`
+	Synced     bool       `json:"synced"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func newWebAuthnCredentialResponse(c *domain.WebAuthnCredential) WebAuthnCredentialResponse {
+	transports := c.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+	return WebAuthnCredentialResponse{
+		ID:         c.ID,
+		Name:       c.Name,
+		Transports: transports,
+		Synced:     c.BackupState,
+		CreatedAt:  c.CreatedAt,
+		LastUsedAt: c.LastUsedAt,
+	}
+}
+
+// writeWebAuthnError 将 WebAuthn 相关错误映射为统一错误响应
+func writeWebAuthnError(w http.ResponseWriter, err error, userID int64) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidWebAuthnSession):
+		writeError(w, "INVALID_WEBAUTHN_SESSION", "Invalid or expired WebAuthn session", http.StatusBadRequest, "")
+	case errors.Is(err, domain.ErrWebAuthnVerification):
+		writeError(w, "WEBAUTHN_VERIFICATION_FAILED", "WebAuthn verification failed", http.StatusUnauthorized,
+			fmt.Sprintf("webauthn verify failed user_id=%d err=%v", userID, err))
+	case errors.Is(err, domain.ErrWebAuthnCredentialNotFound):
+		writeError(w, "WEBAUTHN_CREDENTIAL_NOT_FOUND", "Credential not found", http.StatusNotFound, "")
+	case errors.Is(err, domain.ErrWebAuthnCredentialExists):
+		writeError(w, "WEBAUTHN_CREDENTIAL_EXISTS", "Credential already registered", http.StatusConflict, "")
+	case errors.Is(err, domain.ErrEmailNotVerified):
+		writeError(w, "EMAIL_NOT_VERIFIED", "Email address not verified", http.StatusForbidden, "")
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		writeError(w, "INVALID_CREDENTIALS", "Invalid credentials", http.StatusUnauthorized, "")
+	default:
+		writeError(w, "INTERNAL_ERROR", "WebAuthn operation failed", http.StatusInternalServerError,
+			fmt.Sprintf("webauthn failed user_id=%d err=%v", userID, err))
+	}
+}
+
+// requirePasskeyService Passkey 未配置时写入错误响应
+func (h *Handler) requirePasskeyService(w http.ResponseWriter) bool {
+	if h.PasskeyService == nil {
+		writeError(w, "INTERNAL_ERROR", "WebAuthn not configured", http.StatusInternalServerError, "")
+		return false
+	}
+	return true
+}
+
+// WebAuthnBeginRegistrationHandler 已登录用户开始注册 Passkey
+// POST /api/v1/auth/webauthn/register/begin
+func (h *Handler) WebAuthnBeginRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePasskeyService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	options, sessionID, err := h.PasskeyService.BeginRegistration(r.Context(), user)
+	if err != nil {
+		writeWebAuthnError(w, err, user.ID)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(WebAuthnBeginRegistrationResponse{SessionID: sessionID, Options: options})
+}
+
+// WebAuthnFinishRegistrationHandler 提交认证器返回的注册结果，校验通过后保存凭据
+// POST /api/v1/auth/webauthn/register/finish，Body: {"session_id","name","credential"}
+func (h *Handler) WebAuthnFinishRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePasskeyService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	var req WebAuthnFinishRegistrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	if req.SessionID == "" || len(req.Credential) == 0 {
+		writeError(w, "INVALID_REQUEST", "session_id and credential are required", http.StatusBadRequest, "")
+		return
+	}
+	cred, err := h.PasskeyService.FinishRegistration(r.Context(), user, req.SessionID, req.Name, req.Credential)
+	h.audit(r, domain.AuditEvent{Type: domain.AuditPasskeyRegister, Outcome: auditOutcome(err), UserID: user.ID, ActorID: user.ID, Reason: auditReason(err)})
+	if err != nil {
+		writeWebAuthnError(w, err, user.ID)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(newWebAuthnCredentialResponse(cred))
+}
+
+// WebAuthnListCredentialsHandler 列出当前用户已注册的 Passkey
+// GET /api/v1/auth/webauthn/credentials
+func (h *Handler) WebAuthnListCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePasskeyService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	creds, err := h.PasskeyService.ListCredentials(r.Context(), user.ID)
+	if err != nil {
+		writeWebAuthnError(w, err, user.ID)
+		return
+	}
+	out := make([]WebAuthnCredentialResponse, 0, len(creds))
+	for _, c := range creds {
+		out = append(out, newWebAuthnCredentialResponse(c))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"credentials": out})
+}
+
+// WebAuthnDeleteCredentialHandler 删除当前用户的指定 Passkey
+// DELETE /api/v1/auth/webauthn/credentials/{id}
+func (h *Handler) WebAuthnDeleteCredentialHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePasskeyService(w) {
+		return
+	}
+	user, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, "INVALID_REQUEST", "Invalid credential id", http.StatusBadRequest, "")
+		return
+	}
+	err = h.PasskeyService.DeleteCredential(r.Context(), user.ID, id)
+	h.audit(r, domain.AuditEvent{Type: domain.AuditPasskeyDelete, Outcome: auditOutcome(err), UserID: user.ID, ActorID: user.ID, Reason: auditReason(err)})
+	if err != nil {
+		writeWebAuthnError(w, err, user.ID)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// WebAuthnBeginLoginHandler 开始无密码登录（可发现凭据，无需输入用户名）
+// POST /api/v1/auth/webauthn/login/begin
+func (h *Handler) WebAuthnBeginLoginHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePasskeyService(w) {
+		return
+	}
+	options, sessionID, err := h.PasskeyService.BeginLogin(r.Context())
+	if err != nil {
+		writeWebAuthnError(w, err, 0)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(WebAuthnBeginLoginResponse{SessionID: sessionID, Options: options})
+}
+
+// WebAuthnFinishLoginHandler 提交认证器签名完成登录；成功响应与 /login 一致（SSO 返回 redirect_url，否则写 Cookie）
+// POST /api/v1/auth/webauthn/login/finish，Body: {"session_id","credential","server_state"}
+func (h *Handler) WebAuthnFinishLoginHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePasskeyService(w) {
+		return
+	}
+	var req WebAuthnFinishLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest, "")
+		return
+	}
+	if req.SessionID == "" || len(req.Credential) == 0 {
+		writeError(w, "INVALID_REQUEST", "session_id and credential are required", http.StatusBadRequest, "")
+		return
+	}
+	userID, amr, err := h.PasskeyService.FinishLogin(r.Context(), req.SessionID, req.Credential)
+	if err != nil {
+		h.audit(r, domain.AuditEvent{Type: domain.AuditLogin, Outcome: domain.AuditOutcomeFailure, Reason: auditReason(err)})
+		writeWebAuthnError(w, err, 0)
+		return
+	}
+	r = h.withClientInfo(w, r)
+	result, err := h.AuthService.CompleteLogin(r.Context(), userID, amr)
+	if err != nil {
+		h.audit(r, domain.AuditEvent{Type: domain.AuditLogin, Outcome: domain.AuditOutcomeFailure, UserID: userID, ActorID: userID, Reason: auditReason(err)})
+		writeWebAuthnError(w, err, userID)
+		return
+	}
+	h.writeLoginResult(w, r, result, req.ServerState)
+}
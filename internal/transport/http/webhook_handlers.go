@@ -0,0 +1,151 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"monai-auth/internal/auth"
+	"monai-auth/internal/domain"
+)
+
+// WebhookDeliveryResponse Webhook 投递记录
+type WebhookDeliveryResponse struct {
+	ID             int64           `json:"id"`
+	Subscriber     string          `json:"subscriber"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	UserID         int64           `json:"user_id,omitempty"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  string          `json:"next_attempt_at,omitempty"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	DeliveredAt    string          `json:"delivered_at,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+// WebhookDeliveryListResponse 投递记录分页列表；next_before_id 非 0 时可作为 before_id 继续查询下一页
+type WebhookDeliveryListResponse struct {
+	Deliveries   []WebhookDeliveryResponse `json:"deliveries"`
+	NextBeforeID int64                     `json:"next_before_id,omitempty"`
+}
+
+func newWebhookDeliveryResponse(d *domain.WebhookDelivery) WebhookDeliveryResponse {
+	resp := WebhookDeliveryResponse{
+		ID:             d.ID,
+		Subscriber:     d.Subscriber,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		UserID:         d.UserID,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+		Payload:        json.RawMessage(d.Payload),
+	}
+	if d.Status == domain.WebhookDeliveryPending {
+		resp.NextAttemptAt = d.NextAttemptAt.Format(time.RFC3339)
+	}
+	if d.DeliveredAt != nil {
+		resp.DeliveredAt = d.DeliveredAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+func (h *Handler) requireWebhookService(w http.ResponseWriter) bool {
+	if h.WebhookService == nil {
+		writeError(w, "INTERNAL_ERROR", "Webhooks not configured", http.StatusInternalServerError, "")
+		return false
+	}
+	return true
+}
+
+// AdminWebhookDeliveriesHandler 管理员查询 Webhook 投递日志
+// GET /api/v1/admin/webhooks/deliveries?subscriber=&event_type=&status=&user_id=&limit=&before_id=
+func (h *Handler) AdminWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireWebhookService(w) {
+		return
+	}
+	if _, ok := h.requireAdmin(w, r); !ok {
+		return
+	}
+	q := r.URL.Query()
+	filter := domain.WebhookDeliveryFilter{
+		Subscriber: strings.TrimSpace(q.Get("subscriber")),
+		EventType:  strings.TrimSpace(q.Get("event_type")),
+		Status:     strings.TrimSpace(q.Get("status")),
+	}
+	if v := q.Get("user_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			writeError(w, "INVALID_REQUEST", "Invalid user_id", http.StatusBadRequest, "")
+			return
+		}
+		filter.UserID = id
+	}
+	var ok bool
+	if filter.Limit, filter.BeforeID, ok = parsePage(w, r, auth.DefaultWebhookPageSize); !ok {
+		return
+	}
+	deliveries, err := h.WebhookService.ListDeliveries(r.Context(), filter)
+	if err != nil {
+		writeError(w, "INTERNAL_ERROR", "Failed to query webhook deliveries", http.StatusInternalServerError,
+			fmt.Sprintf("list webhook deliveries failed err=%v", err))
+		return
+	}
+	resp := WebhookDeliveryListResponse{Deliveries: make([]WebhookDeliveryResponse, 0, len(deliveries))}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, newWebhookDeliveryResponse(d))
+	}
+	if len(deliveries) > 0 && len(deliveries) >= filter.Limit {
+		resp.NextBeforeID = deliveries[len(deliveries)-1].ID
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// AdminReplayWebhookDeliveryHandler 管理员重放投递记录：以原事件 ID 与请求体重新投递，尝试次数清零
+// POST /api/v1/admin/webhooks/deliveries/{id}/replay
+func (h *Handler) AdminReplayWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireWebhookService(w) {
+		return
+	}
+	admin, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, "INVALID_REQUEST", "Invalid delivery id", http.StatusBadRequest, "")
+		return
+	}
+	delivery, err := h.WebhookService.Replay(r.Context(), id)
+	event := domain.AuditEvent{Type: domain.AuditAdminWebhookReplay, Outcome: auditOutcome(err), ActorID: admin.ID, Reason: auditReason(err)}
+	if err == nil {
+		event.UserID = delivery.UserID
+		event.Reason = fmt.Sprintf("delivery_id=%d", delivery.ID)
+	}
+	h.audit(r, event)
+	if err != nil {
+		if errors.Is(err, domain.ErrWebhookDeliveryNotFound) {
+			writeError(w, "WEBHOOK_DELIVERY_NOT_FOUND", "Webhook delivery not found", http.StatusNotFound, "")
+			return
+		}
+		writeError(w, "INTERNAL_ERROR", "Replay failed", http.StatusInternalServerError,
+			fmt.Sprintf("webhook replay failed admin_id=%d delivery_id=%d err=%v", admin.ID, id, err))
+		return
+	}
+	log.Printf("[AUTH] admin webhook replay admin_id=%d delivery_id=%d subscriber=%s", admin.ID, id, delivery.Subscriber)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(newWebhookDeliveryResponse(delivery))
+}
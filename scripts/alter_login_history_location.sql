@@ -0,0 +1,7 @@
+-- 为已存在的 login_history 表增加 GeoIP 位置列（新国家 / 不可能旅行检测）
+-- 使用方式: mysql -u root -p <database_name> < scripts/alter_login_history_location.sql
+
+ALTER TABLE login_history
+    ADD COLUMN country   VARCHAR(8) NOT NULL DEFAULT '' COMMENT 'GeoIP 国家代码' AFTER method,
+    ADD COLUMN latitude  DOUBLE     NULL                COMMENT 'GeoIP 纬度' AFTER country,
+    ADD COLUMN longitude DOUBLE     NULL                COMMENT 'GeoIP 经度' AFTER latitude;
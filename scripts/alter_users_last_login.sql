@@ -0,0 +1,8 @@
+-- last_login_at 改为可空：此前仅在创建用户时写入，现于每次登录成功时更新，从未登录的用户为 NULL
+-- 使用方式: mysql -u root -p <database_name> < scripts/alter_users_last_login.sql
+
+ALTER TABLE users
+    MODIFY COLUMN last_login_at TIMESTAMP NULL DEFAULT NULL COMMENT '最近一次登录成功时间，从未登录为 NULL。';
+
+-- 旧数据中的 last_login_at 实为创建时间，并非真实登录时间
+UPDATE users SET last_login_at = NULL WHERE last_login_at = created_at;
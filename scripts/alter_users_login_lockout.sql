@@ -0,0 +1,7 @@
+-- 为已存在的 users 表增加角色与登录失败锁定相关列
+-- 使用方式: mysql -u root -p <database_name> < scripts/alter_users_login_lockout.sql
+
+ALTER TABLE users
+    ADD COLUMN role               VARCHAR(32) DEFAULT 'standard' NOT NULL COMMENT '角色：standard / admin。' AFTER token_version,
+    ADD COLUMN failed_login_count INT         DEFAULT 0          NOT NULL COMMENT '连续登录失败次数。' AFTER role,
+    ADD COLUMN locked_until       TIMESTAMP   NULL                        COMMENT '临时锁定截止时间。' AFTER failed_login_count;
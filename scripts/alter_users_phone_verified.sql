@@ -0,0 +1,5 @@
+-- 为已存在的 users 表增加手机号验证时间（短信验证码登录仅对已验证手机号开放）
+-- 使用方式: mysql -u root -p <database_name> < scripts/alter_users_phone_verified.sql
+
+ALTER TABLE users
+    ADD COLUMN phone_verified_at TIMESTAMP NULL DEFAULT NULL COMMENT '手机号通过短信验证码验证的时间，NULL 表示未验证。' AFTER last_login_at;
@@ -0,0 +1,5 @@
+-- 为已存在的 users 表增加 token_version 列（修改密码时递增以吊销已签发的 token）
+-- 使用方式: mysql -u root -p <database_name> < scripts/alter_users_token_version.sql
+
+ALTER TABLE users
+    ADD COLUMN token_version BIGINT DEFAULT 0 NOT NULL COMMENT '令牌版本号，修改密码时递增以吊销已签发的 token。' AFTER password_hash;
@@ -0,0 +1,20 @@
+-- 安全审计事件表（audit.enabled 为 true 时使用）
+-- 使用方式: mysql -u root -p identity_db < scripts/create_audit_events.sql
+
+CREATE TABLE IF NOT EXISTS `audit_events` (
+  `id`         BIGINT NOT NULL AUTO_INCREMENT,
+  `event_type` VARCHAR(64) NOT NULL COMMENT '事件类型，如 login、code.exchange、password.change',
+  `outcome`    VARCHAR(16) NOT NULL COMMENT 'success / failure',
+  `user_id`    BIGINT NOT NULL DEFAULT 0 COMMENT '事件所属用户，0 表示未知',
+  `actor_id`   BIGINT NOT NULL DEFAULT 0 COMMENT '执行操作的用户（管理员操作时为管理员）',
+  `email`      VARCHAR(255) NOT NULL DEFAULT '' COMMENT '请求中提交的登录邮箱',
+  `ip`         VARCHAR(64) NOT NULL DEFAULT '' COMMENT '客户端 IP',
+  `user_agent` VARCHAR(512) NOT NULL DEFAULT '' COMMENT '客户端 User-Agent',
+  `client_id`  VARCHAR(128) NOT NULL DEFAULT '' COMMENT 'SSO 子应用 client_id',
+  `reason`     VARCHAR(255) NOT NULL DEFAULT '' COMMENT '失败原因或补充信息',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_audit_events_user_id` (`user_id`),
+  KEY `idx_audit_events_event_type` (`event_type`),
+  KEY `idx_audit_events_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='安全审计事件';
@@ -0,0 +1,14 @@
+-- 邮箱验证令牌表（仅存令牌 SHA-256，一次性使用）
+-- 使用方式: mysql -u root -p identity_db < scripts/create_email_verification_tokens.sql
+
+CREATE TABLE IF NOT EXISTS `email_verification_tokens` (
+  `id`         BIGINT NOT NULL AUTO_INCREMENT,
+  `user_id`    BIGINT NOT NULL COMMENT '所属用户',
+  `token_hash` CHAR(64) NOT NULL COMMENT '令牌 SHA-256（十六进制）',
+  `expires_at` DATETIME NOT NULL COMMENT '过期时间',
+  `used_at`    DATETIME DEFAULT NULL COMMENT '使用时间，非空表示已使用',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_email_verification_tokens_token_hash` (`token_hash`),
+  KEY `idx_email_verification_tokens_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='邮箱验证令牌';
@@ -0,0 +1,15 @@
+-- 用户已知设备表（login_risk.enabled 为 true 时使用，用于新设备登录提醒）
+-- 使用方式: mysql -u root -p identity_db < scripts/create_known_devices.sql
+
+CREATE TABLE IF NOT EXISTS `known_devices` (
+  `id`            BIGINT NOT NULL AUTO_INCREMENT,
+  `user_id`       BIGINT NOT NULL COMMENT '所属用户',
+  `fingerprint`   CHAR(64) NOT NULL COMMENT '设备指纹：SHA-256(设备 Cookie + 去版本号的 User-Agent)',
+  `user_agent`    VARCHAR(512) NOT NULL DEFAULT '' COMMENT '最近一次登录的 User-Agent',
+  `last_ip`       VARCHAR(64) NOT NULL DEFAULT '' COMMENT '最近一次登录 IP',
+  `last_country`  VARCHAR(8) NOT NULL DEFAULT '' COMMENT '最近一次登录国家代码',
+  `first_seen_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `last_seen_at`  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_known_devices_user_fp` (`user_id`, `fingerprint`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户已知设备';
@@ -0,0 +1,17 @@
+-- 登录历史表（每次登录成功一条，供 GET /api/v1/auth/me/logins 查询）
+-- 使用方式: mysql -u root -p identity_db < scripts/create_login_history.sql
+
+CREATE TABLE IF NOT EXISTS `login_history` (
+  `id`         BIGINT NOT NULL AUTO_INCREMENT,
+  `user_id`    BIGINT NOT NULL COMMENT '登录用户',
+  `ip`         VARCHAR(64) NOT NULL DEFAULT '' COMMENT '客户端 IP',
+  `user_agent` VARCHAR(512) NOT NULL DEFAULT '' COMMENT '客户端 User-Agent',
+  `client_id`  VARCHAR(128) NOT NULL DEFAULT '' COMMENT 'SSO 子应用 client_id，直接登录为空',
+  `method`     VARCHAR(64) NOT NULL DEFAULT '' COMMENT '登录方式：password / password+totp / password+recovery_code / passkey',
+  `country`    VARCHAR(8) NOT NULL DEFAULT '' COMMENT 'GeoIP 国家代码',
+  `latitude`   DOUBLE NULL COMMENT 'GeoIP 纬度',
+  `longitude`  DOUBLE NULL COMMENT 'GeoIP 经度',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_login_history_user_id` (`user_id`, `id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='登录历史';
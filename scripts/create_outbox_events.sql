@@ -0,0 +1,21 @@
+-- 领域事件发件箱表（events.enabled 为 true 时使用）
+-- 使用方式: mysql -u root -p identity_db < scripts/create_outbox_events.sql
+
+CREATE TABLE IF NOT EXISTS `outbox_events` (
+  `id`              BIGINT NOT NULL AUTO_INCREMENT,
+  `event_id`        VARCHAR(64) NOT NULL COMMENT '事件标识，消费方据此去重',
+  `event_type`      VARCHAR(64) NOT NULL COMMENT '事件类型，如 user.registered、user.logged_in、token.issued',
+  `user_id`         BIGINT NOT NULL DEFAULT 0 COMMENT '事件所属用户',
+  `payload`         MEDIUMTEXT NOT NULL COMMENT '编码后的事件 JSON',
+  `attempts`        INT NOT NULL DEFAULT 0 COMMENT '投递失败次数',
+  `next_attempt_at` DATETIME NOT NULL COMMENT '下次投递时间（未投递时有效）',
+  `last_error`      VARCHAR(512) NOT NULL DEFAULT '' COMMENT '最近一次投递失败原因',
+  `published_at`    DATETIME NULL DEFAULT NULL COMMENT '投递到全部 sink 的时间，NULL 表示未投递',
+  `failed_at`       DATETIME NULL DEFAULT NULL COMMENT '重试次数用尽、放弃投递的时间',
+  `created_at`      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_outbox_events_event_id` (`event_id`),
+  KEY `idx_outbox_events_due` (`published_at`, `failed_at`, `next_attempt_at`),
+  KEY `idx_outbox_events_user_id` (`user_id`),
+  KEY `idx_outbox_events_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='领域事件发件箱';
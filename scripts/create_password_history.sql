@@ -0,0 +1,11 @@
+-- 历史密码表（password_policy.history_size > 0 时使用，禁止重复使用近期密码）
+-- 使用方式: mysql -u root -p identity_db < scripts/create_password_history.sql
+
+CREATE TABLE IF NOT EXISTS `password_history` (
+  `id`            BIGINT NOT NULL AUTO_INCREMENT,
+  `user_id`       BIGINT NOT NULL COMMENT '所属用户',
+  `password_hash` VARCHAR(255) NOT NULL COMMENT '历史密码哈希',
+  `created_at`    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_password_history_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户历史密码';
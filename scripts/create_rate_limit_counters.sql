@@ -0,0 +1,10 @@
+-- 限流计数表（rate_limit.store 为 mysql 时使用，多实例共享固定窗口计数）
+-- 使用方式: mysql -u root -p identity_db < scripts/create_rate_limit_counters.sql
+
+CREATE TABLE IF NOT EXISTS `rate_limit_counters` (
+  `bucket_key` VARCHAR(191) NOT NULL COMMENT '规则:IP 或 规则:邮箱',
+  `hits`       BIGINT NOT NULL DEFAULT 0 COMMENT '当前窗口内请求次数',
+  `window_end` DATETIME(3) NOT NULL COMMENT '当前窗口结束时间',
+  PRIMARY KEY (`bucket_key`),
+  KEY `idx_rate_limit_counters_window_end` (`window_end`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='接口限流计数';
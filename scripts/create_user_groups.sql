@@ -0,0 +1,21 @@
+-- 用户组与成员关系表（SCIM 同步的组织结构）
+-- 使用方式: mysql -u root -p identity_db < scripts/create_user_groups.sql
+
+CREATE TABLE IF NOT EXISTS `user_groups` (
+  `id`           BIGINT NOT NULL AUTO_INCREMENT,
+  `display_name` VARCHAR(255) NOT NULL COMMENT '组显示名',
+  `external_id`  VARCHAR(255) NOT NULL DEFAULT '' COMMENT '外部系统（HR / IdP）中的组标识',
+  `created_at`   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at`   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_user_groups_display_name` (`display_name`),
+  KEY `idx_user_groups_external_id` (`external_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户组';
+
+CREATE TABLE IF NOT EXISTS `user_group_members` (
+  `group_id`   BIGINT NOT NULL,
+  `user_id`    BIGINT NOT NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`group_id`, `user_id`),
+  KEY `idx_user_group_members_user` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户组成员';
@@ -0,0 +1,15 @@
+-- 第三方登录（GitHub / Google 等上游身份提供方）身份绑定表
+-- 使用方式: mysql -u root -p identity_db < scripts/create_user_identities.sql
+
+CREATE TABLE IF NOT EXISTS `user_identities` (
+  `id`            BIGINT NOT NULL AUTO_INCREMENT,
+  `user_id`       BIGINT NOT NULL COMMENT '本地用户',
+  `provider`      VARCHAR(64) NOT NULL COMMENT '提供方名称（配置中的 federation.providers[].name）',
+  `subject`       VARCHAR(255) NOT NULL COMMENT '提供方内的用户唯一标识（OIDC sub / GitHub 用户 ID）',
+  `email`         VARCHAR(255) NOT NULL DEFAULT '' COMMENT '提供方返回的邮箱，仅用于展示',
+  `last_login_at` DATETIME DEFAULT NULL COMMENT '最近一次通过该提供方登录的时间',
+  `created_at`    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_user_identities_provider_subject` (`provider`, `subject`),
+  UNIQUE KEY `uk_user_identities_user_provider` (`user_id`, `provider`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='第三方登录身份绑定';
@@ -0,0 +1,23 @@
+-- 多因素认证表：TOTP 凭据 + 一次性恢复码
+-- 使用方式: mysql -u root -p identity_db < scripts/create_user_mfa.sql
+
+CREATE TABLE IF NOT EXISTS `user_mfa_totp` (
+  `user_id`        BIGINT NOT NULL COMMENT '所属用户',
+  `secret_enc`     VARCHAR(255) NOT NULL COMMENT 'AES-GCM 加密后的 TOTP 密钥（base64）',
+  `enabled`        TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否已确认启用',
+  `last_used_step` BIGINT NOT NULL DEFAULT 0 COMMENT '最近一次验证通过的时间步，防重放',
+  `confirmed_at`   DATETIME DEFAULT NULL COMMENT '确认启用时间',
+  `created_at`     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at`     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户 TOTP 凭据';
+
+CREATE TABLE IF NOT EXISTS `user_mfa_recovery_codes` (
+  `id`         BIGINT NOT NULL AUTO_INCREMENT,
+  `user_id`    BIGINT NOT NULL COMMENT '所属用户',
+  `code_hash`  CHAR(64) NOT NULL COMMENT '恢复码 SHA-256（十六进制）',
+  `used_at`    DATETIME DEFAULT NULL COMMENT '使用时间，非空表示已使用',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_mfa_recovery_codes_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='MFA 一次性恢复码';
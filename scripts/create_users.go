@@ -23,7 +23,12 @@ const createUsersTableSQL = `CREATE TABLE IF NOT EXISTS users
     email         VARCHAR(255)                                not null comment '主要联系方式和登录凭证。',
     password_hash VARCHAR(255)                                not null comment '存储加密后的密码',
     status        ENUM ('active', 'inactive', 'suspended', 'pending') default 'active'                 null comment '账户状态：1: active (活动), 2: inactive (非活动), 3: suspended (封禁), 4: pending (待验证)。',
-    last_login_at TIMESTAMP         default CURRENT_TIMESTAMP not null comment '最后一次登录时间。',
+    token_version BIGINT            default 0                 not null comment '令牌版本号，修改密码时递增以吊销已签发的 token。',
+    role          VARCHAR(32)       default 'standard'        not null comment '角色：standard / admin。',
+    failed_login_count INT          default 0                 not null comment '连续登录失败次数。',
+    locked_until  TIMESTAMP                                   null comment '临时锁定截止时间。',
+    last_login_at TIMESTAMP                                   null comment '最近一次登录成功时间，从未登录为 NULL。',
+    phone_verified_at TIMESTAMP                               null comment '手机号通过短信验证码验证的时间，NULL 表示未验证。',
     created_at    TIMESTAMP         default CURRENT_TIMESTAMP not null comment '记录创建时间（默认当前时间）。',
     updated_at    TIMESTAMP         default CURRENT_TIMESTAMP not null comment '记录更新时间。',
     deleted_at    TIMESTAMP                                   null comment '软删除机制。非空则表示用户已被"删除"（实际只是隐藏）。',
@@ -0,0 +1,21 @@
+-- WebAuthn / Passkey 凭据表
+-- 使用方式: mysql -u root -p identity_db < scripts/create_webauthn_credentials.sql
+
+CREATE TABLE IF NOT EXISTS `webauthn_credentials` (
+  `id`               BIGINT NOT NULL AUTO_INCREMENT,
+  `user_id`          BIGINT NOT NULL COMMENT '所属用户',
+  `credential_id`    VARBINARY(1023) NOT NULL COMMENT '认证器生成的凭据 ID',
+  `public_key`       BLOB NOT NULL COMMENT 'COSE 编码的凭据公钥',
+  `attestation_type` VARCHAR(32) NOT NULL DEFAULT '' COMMENT '注册时的证明类型',
+  `aaguid`           VARBINARY(16) DEFAULT NULL COMMENT '认证器型号标识',
+  `sign_count`       INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '签名计数，用于检测克隆',
+  `transports`       VARCHAR(255) NOT NULL DEFAULT '' COMMENT '传输方式，逗号分隔',
+  `backup_eligible`  TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否可同步备份',
+  `backup_state`     TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否已同步备份',
+  `name`             VARCHAR(100) NOT NULL DEFAULT '' COMMENT '用户自定义名称',
+  `last_used_at`     DATETIME DEFAULT NULL COMMENT '最近登录使用时间',
+  `created_at`       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_webauthn_credentials_credential_id` (`credential_id`),
+  KEY `idx_webauthn_credentials_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='WebAuthn 凭据';
@@ -0,0 +1,25 @@
+-- Webhook 发件箱与投递日志表（webhooks.enabled 为 true 时使用）
+-- 使用方式: mysql -u root -p identity_db < scripts/create_webhook_deliveries.sql
+
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (
+  `id`               BIGINT NOT NULL AUTO_INCREMENT,
+  `subscriber`       VARCHAR(64) NOT NULL COMMENT '订阅方名称（webhooks.subscribers[].name）',
+  `event_id`         VARCHAR(64) NOT NULL COMMENT '事件标识，同一事件投递到各订阅方时相同',
+  `event_type`       VARCHAR(64) NOT NULL COMMENT '事件类型，如 user.registered、user.deleted',
+  `user_id`          BIGINT NOT NULL DEFAULT 0 COMMENT '事件所属用户',
+  `payload`          MEDIUMTEXT NOT NULL COMMENT '请求体 JSON，重试与重放时原样发送',
+  `status`           VARCHAR(16) NOT NULL COMMENT 'pending / delivered / failed',
+  `attempts`         INT NOT NULL DEFAULT 0 COMMENT '已尝试次数',
+  `next_attempt_at`  DATETIME NOT NULL COMMENT '下次尝试时间（pending 时有效）',
+  `last_status_code` INT NOT NULL DEFAULT 0 COMMENT '最近一次响应的 HTTP 状态码，0 表示未收到响应',
+  `last_error`       VARCHAR(512) NOT NULL DEFAULT '' COMMENT '最近一次失败原因',
+  `delivered_at`     DATETIME NULL DEFAULT NULL,
+  `created_at`       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at`       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_webhook_deliveries_due` (`status`, `next_attempt_at`),
+  KEY `idx_webhook_deliveries_subscriber` (`subscriber`),
+  KEY `idx_webhook_deliveries_event_id` (`event_id`),
+  KEY `idx_webhook_deliveries_user_id` (`user_id`),
+  KEY `idx_webhook_deliveries_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Webhook 发件箱与投递日志';
@@ -0,0 +1,26 @@
+-- 安全审计事件表（PostgreSQL，audit.enabled 为 true 时使用）
+-- 使用方式: psql -U postgres -d identity_db -f scripts/postgres/create_audit_events.sql
+
+CREATE TABLE IF NOT EXISTS audit_events
+(
+    id         BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(64)  NOT NULL,
+    outcome    VARCHAR(16)  NOT NULL,
+    user_id    BIGINT       NOT NULL DEFAULT 0,
+    actor_id   BIGINT       NOT NULL DEFAULT 0,
+    email      VARCHAR(255) NOT NULL DEFAULT '',
+    ip         VARCHAR(64)  NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    client_id  VARCHAR(128) NOT NULL DEFAULT '',
+    reason     VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_user_id ON audit_events (user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_event_type ON audit_events (event_type);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
+
+COMMENT ON TABLE audit_events IS '安全审计事件';
+COMMENT ON COLUMN audit_events.event_type IS '事件类型，如 login、code.exchange、password.change';
+COMMENT ON COLUMN audit_events.outcome IS 'success / failure';
+COMMENT ON COLUMN audit_events.actor_id IS '执行操作的用户（管理员操作时为管理员）';
@@ -0,0 +1,18 @@
+-- 用户已知设备表（PostgreSQL，login_risk.enabled 为 true 时使用）
+-- 使用方式: psql -U postgres -d identity_db -f scripts/postgres/create_known_devices.sql
+
+CREATE TABLE IF NOT EXISTS known_devices
+(
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT       NOT NULL,
+    fingerprint   CHAR(64)     NOT NULL,
+    user_agent    VARCHAR(512) NOT NULL DEFAULT '',
+    last_ip       VARCHAR(64)  NOT NULL DEFAULT '',
+    last_country  VARCHAR(8)   NOT NULL DEFAULT '',
+    first_seen_at TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at  TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_known_devices_user_fp UNIQUE (user_id, fingerprint)
+);
+
+COMMENT ON TABLE known_devices IS '用户已知设备';
+COMMENT ON COLUMN known_devices.fingerprint IS '设备指纹：SHA-256(设备 Cookie + 去版本号的 User-Agent)';
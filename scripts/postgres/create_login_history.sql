@@ -0,0 +1,22 @@
+-- 登录历史表（PostgreSQL，每次登录成功一条）
+-- 使用方式: psql -U postgres -d identity_db -f scripts/postgres/create_login_history.sql
+
+CREATE TABLE IF NOT EXISTS login_history
+(
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT           NOT NULL,
+    ip         VARCHAR(64)      NOT NULL DEFAULT '',
+    user_agent VARCHAR(512)     NOT NULL DEFAULT '',
+    client_id  VARCHAR(128)     NOT NULL DEFAULT '',
+    method     VARCHAR(64)      NOT NULL DEFAULT '',
+    country    VARCHAR(8)       NOT NULL DEFAULT '',
+    latitude   DOUBLE PRECISION NULL,
+    longitude  DOUBLE PRECISION NULL,
+    created_at TIMESTAMPTZ      NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_history_user_id ON login_history (user_id, id);
+
+COMMENT ON TABLE login_history IS '登录历史';
+COMMENT ON COLUMN login_history.method IS '登录方式：password / password+totp / password+recovery_code / passkey';
+COMMENT ON COLUMN login_history.country IS 'GeoIP 国家代码';
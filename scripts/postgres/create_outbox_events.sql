@@ -0,0 +1,27 @@
+-- 领域事件发件箱表（PostgreSQL，events.enabled 为 true 时使用）
+-- 使用方式: psql -U postgres -d identity_db -f scripts/postgres/create_outbox_events.sql
+
+CREATE TABLE IF NOT EXISTS outbox_events
+(
+    id              BIGSERIAL PRIMARY KEY,
+    event_id        VARCHAR(64)  NOT NULL,
+    event_type      VARCHAR(64)  NOT NULL,
+    user_id         BIGINT       NOT NULL DEFAULT 0,
+    payload         TEXT         NOT NULL,
+    attempts        INT          NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ  NOT NULL,
+    last_error      VARCHAR(512) NOT NULL DEFAULT '',
+    published_at    TIMESTAMPTZ  NULL,
+    failed_at       TIMESTAMPTZ  NULL,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_outbox_events_event_id UNIQUE (event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_due ON outbox_events (published_at, failed_at, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_user_id ON outbox_events (user_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_created_at ON outbox_events (created_at);
+
+COMMENT ON TABLE outbox_events IS '领域事件发件箱';
+COMMENT ON COLUMN outbox_events.event_id IS '事件标识，消费方据此去重';
+COMMENT ON COLUMN outbox_events.published_at IS '投递到全部 sink 的时间，NULL 表示未投递';
+COMMENT ON COLUMN outbox_events.failed_at IS '重试次数用尽、放弃投递的时间';
@@ -0,0 +1,14 @@
+-- 历史密码表（PostgreSQL，password_policy.history_size > 0 时使用）
+-- 使用方式: psql -U postgres -d identity_db -f scripts/postgres/create_password_history.sql
+
+CREATE TABLE IF NOT EXISTS password_history
+(
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT       NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user_id ON password_history (user_id);
+
+COMMENT ON TABLE password_history IS '用户历史密码';
@@ -0,0 +1,18 @@
+-- 密码重置令牌表（PostgreSQL，仅存令牌 SHA-256，一次性使用）
+-- 使用方式: psql -U postgres -d identity_db -f scripts/postgres/create_password_reset_tokens.sql
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens
+(
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL,
+    token_hash CHAR(64)    NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_password_reset_tokens_token_hash UNIQUE (token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+
+COMMENT ON TABLE password_reset_tokens IS '密码重置令牌';
+COMMENT ON COLUMN password_reset_tokens.token_hash IS '令牌 SHA-256（十六进制）';
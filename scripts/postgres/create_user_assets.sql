@@ -0,0 +1,20 @@
+-- 用户资源表（PostgreSQL，database.driver 为 postgres 时使用）
+-- 使用方式: psql -U postgres -d identity_db -f scripts/postgres/create_user_assets.sql
+
+CREATE TABLE IF NOT EXISTS user_assets
+(
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT       NOT NULL,
+    file_path     VARCHAR(512) NOT NULL,
+    file_type     VARCHAR(32)  NOT NULL DEFAULT 'avatar',
+    original_name VARCHAR(255) NULL,
+    size          INT          NULL CHECK (size >= 0),
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_assets_user_id ON user_assets (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_assets_file_type ON user_assets (file_type);
+
+COMMENT ON TABLE user_assets IS '用户上传资源（图片等）';
+COMMENT ON COLUMN user_assets.file_path IS '相对路径，如 uploads/avatars/123_xxx.jpg';
+COMMENT ON COLUMN user_assets.file_type IS '类型: avatar/cover/gallery';
@@ -0,0 +1,30 @@
+-- 用户组与成员关系表（PostgreSQL，SCIM 同步的组织结构）
+-- 使用方式: psql -U postgres -d identity_db -f scripts/postgres/create_user_groups.sql
+
+CREATE TABLE IF NOT EXISTS user_groups
+(
+    id           BIGSERIAL PRIMARY KEY,
+    display_name VARCHAR(255) NOT NULL,
+    external_id  VARCHAR(255) NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 显示名大小写不敏感唯一（与 MySQL _ci 排序规则一致）
+CREATE UNIQUE INDEX IF NOT EXISTS uk_user_groups_display_name ON user_groups (lower(display_name));
+CREATE INDEX IF NOT EXISTS idx_user_groups_external_id ON user_groups (external_id);
+
+COMMENT ON TABLE user_groups IS '用户组';
+COMMENT ON COLUMN user_groups.external_id IS '外部系统（HR / IdP）中的组标识';
+
+CREATE TABLE IF NOT EXISTS user_group_members
+(
+    group_id   BIGINT      NOT NULL,
+    user_id    BIGINT      NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_group_members_user ON user_group_members (user_id);
+
+COMMENT ON TABLE user_group_members IS '用户组成员';
@@ -0,0 +1,19 @@
+-- 第三方登录 / LDAP 目录身份绑定表（PostgreSQL）
+-- 使用方式: psql -U postgres -d identity_db -f scripts/postgres/create_user_identities.sql
+
+CREATE TABLE IF NOT EXISTS user_identities
+(
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT       NOT NULL,
+    provider      VARCHAR(64)  NOT NULL,
+    subject       VARCHAR(255) NOT NULL,
+    email         VARCHAR(255) NOT NULL DEFAULT '',
+    last_login_at TIMESTAMPTZ  NULL,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_user_identities_provider_subject UNIQUE (provider, subject),
+    CONSTRAINT uk_user_identities_user_provider UNIQUE (user_id, provider)
+);
+
+COMMENT ON TABLE user_identities IS '第三方登录身份绑定';
+COMMENT ON COLUMN user_identities.provider IS '提供方名称（配置中的 federation.providers[].name）';
+COMMENT ON COLUMN user_identities.subject IS '提供方内的用户唯一标识（OIDC sub / GitHub 用户 ID）';
@@ -0,0 +1,31 @@
+-- 多因素认证表（PostgreSQL）：TOTP 凭据 + 一次性恢复码
+-- 使用方式: psql -U postgres -d identity_db -f scripts/postgres/create_user_mfa.sql
+
+CREATE TABLE IF NOT EXISTS user_mfa_totp
+(
+    user_id        BIGINT PRIMARY KEY,
+    secret_enc     VARCHAR(255) NOT NULL,
+    enabled        BOOLEAN      NOT NULL DEFAULT FALSE,
+    last_used_step BIGINT       NOT NULL DEFAULT 0,
+    confirmed_at   TIMESTAMPTZ  NULL,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE user_mfa_totp IS '用户 TOTP 凭据';
+COMMENT ON COLUMN user_mfa_totp.secret_enc IS 'AES-GCM 加密后的 TOTP 密钥（base64）';
+COMMENT ON COLUMN user_mfa_totp.last_used_step IS '最近一次验证通过的时间步，防重放';
+
+CREATE TABLE IF NOT EXISTS user_mfa_recovery_codes
+(
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL,
+    code_hash  CHAR(64)    NOT NULL,
+    used_at    TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_mfa_recovery_codes_user_id ON user_mfa_recovery_codes (user_id);
+
+COMMENT ON TABLE user_mfa_recovery_codes IS 'MFA 一次性恢复码';
+COMMENT ON COLUMN user_mfa_recovery_codes.code_hash IS '恢复码 SHA-256（十六进制）';
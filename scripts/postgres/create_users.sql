@@ -0,0 +1,41 @@
+-- 用户表（PostgreSQL，database.driver 为 postgres 时使用）
+-- 使用方式: psql -U postgres -d identity_db -f scripts/postgres/create_users.sql
+
+CREATE TABLE IF NOT EXISTS users
+(
+    id                 BIGSERIAL PRIMARY KEY,
+    username           VARCHAR(100) NOT NULL,
+    first_name         VARCHAR(100) NOT NULL DEFAULT '',
+    last_name          VARCHAR(100) NOT NULL DEFAULT '',
+    avatar_url         VARCHAR(512) NOT NULL DEFAULT '',
+    phone_number       VARCHAR(20)  NULL,
+    email              VARCHAR(255) NOT NULL,
+    password_hash      VARCHAR(255) NOT NULL,
+    status             VARCHAR(16)  NOT NULL DEFAULT 'active'
+        CONSTRAINT users_status_check CHECK (status IN ('active', 'inactive', 'suspended', 'pending')),
+    token_version      BIGINT       NOT NULL DEFAULT 0,
+    role               VARCHAR(32)  NOT NULL DEFAULT 'standard',
+    failed_login_count INT          NOT NULL DEFAULT 0,
+    locked_until       TIMESTAMPTZ  NULL,
+    last_login_at      TIMESTAMPTZ  NULL,
+    phone_verified_at  TIMESTAMPTZ  NULL,
+    created_at         TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at         TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at         TIMESTAMPTZ  NULL
+);
+
+-- 用户名、邮箱大小写不敏感唯一（与 MySQL _ci 排序规则一致）；索引名用于把唯一约束冲突映射为领域错误
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_key ON users (lower(username));
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (lower(email));
+CREATE UNIQUE INDEX IF NOT EXISTS users_phone_number_key ON users (phone_number);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+COMMENT ON TABLE users IS '用户表';
+COMMENT ON COLUMN users.username IS '公开显示的用户名，可用于登录。';
+COMMENT ON COLUMN users.phone_number IS '电话号码，NULL 表示未绑定';
+COMMENT ON COLUMN users.email IS '主要联系方式和登录凭证。';
+COMMENT ON COLUMN users.password_hash IS '存储加密后的密码';
+COMMENT ON COLUMN users.status IS '账户状态：active / inactive / suspended / pending。';
+COMMENT ON COLUMN users.token_version IS '令牌版本号，修改密码时递增以吊销已签发的 token。';
+COMMENT ON COLUMN users.role IS '角色：standard / admin。';
+COMMENT ON COLUMN users.deleted_at IS '软删除时间，非空表示用户已被删除。';
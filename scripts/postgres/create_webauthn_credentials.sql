@@ -0,0 +1,26 @@
+-- WebAuthn / Passkey 凭据表（PostgreSQL）
+-- 使用方式: psql -U postgres -d identity_db -f scripts/postgres/create_webauthn_credentials.sql
+
+CREATE TABLE IF NOT EXISTS webauthn_credentials
+(
+    id               BIGSERIAL PRIMARY KEY,
+    user_id          BIGINT       NOT NULL,
+    credential_id    BYTEA        NOT NULL CHECK (octet_length(credential_id) <= 1023),
+    public_key       BYTEA        NOT NULL,
+    attestation_type VARCHAR(32)  NOT NULL DEFAULT '',
+    aaguid           BYTEA        NULL,
+    sign_count       BIGINT       NOT NULL DEFAULT 0 CHECK (sign_count >= 0),
+    transports       VARCHAR(255) NOT NULL DEFAULT '',
+    backup_eligible  BOOLEAN      NOT NULL DEFAULT FALSE,
+    backup_state     BOOLEAN      NOT NULL DEFAULT FALSE,
+    name             VARCHAR(100) NOT NULL DEFAULT '',
+    last_used_at     TIMESTAMPTZ  NULL,
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_webauthn_credentials_credential_id UNIQUE (credential_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user_id ON webauthn_credentials (user_id);
+
+COMMENT ON TABLE webauthn_credentials IS 'WebAuthn 凭据';
+COMMENT ON COLUMN webauthn_credentials.public_key IS 'COSE 编码的凭据公钥';
+COMMENT ON COLUMN webauthn_credentials.sign_count IS '签名计数，用于检测克隆';
@@ -0,0 +1,30 @@
+-- Webhook 发件箱与投递日志表（PostgreSQL，webhooks.enabled 为 true 时使用）
+-- 使用方式: psql -U postgres -d identity_db -f scripts/postgres/create_webhook_deliveries.sql
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id               BIGSERIAL PRIMARY KEY,
+    subscriber       VARCHAR(64)  NOT NULL,
+    event_id         VARCHAR(64)  NOT NULL,
+    event_type       VARCHAR(64)  NOT NULL,
+    user_id          BIGINT       NOT NULL DEFAULT 0,
+    payload          TEXT         NOT NULL,
+    status           VARCHAR(16)  NOT NULL,
+    attempts         INT          NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ  NOT NULL,
+    last_status_code INT          NOT NULL DEFAULT 0,
+    last_error       VARCHAR(512) NOT NULL DEFAULT '',
+    delivered_at     TIMESTAMPTZ  NULL,
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscriber ON webhook_deliveries (subscriber);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries (event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_user_id ON webhook_deliveries (user_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at ON webhook_deliveries (created_at);
+
+COMMENT ON TABLE webhook_deliveries IS 'Webhook 发件箱与投递日志';
+COMMENT ON COLUMN webhook_deliveries.payload IS '请求体 JSON，重试与重放时原样发送';
+COMMENT ON COLUMN webhook_deliveries.status IS 'pending / delivered / failed';